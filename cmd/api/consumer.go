@@ -0,0 +1,93 @@
+package api
+
+import (
+	"assist-tix/config"
+	"assist-tix/internal/consumer"
+	"assist-tix/internal/infra/nats"
+	"context"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type Consumer struct {
+	Env        *config.EnvironmentVariable
+	Subscriber *nats.Subscriber
+
+	AsyncOrderHandler    consumer.AsyncOrderHandler
+	AsyncCallbackHandler consumer.AsyncCallbackHandler
+}
+
+func NewConsumer(
+	env *config.EnvironmentVariable,
+	js jetstream.JetStream,
+	service Service,
+) *Consumer {
+	return &Consumer{
+		Env: env,
+		Subscriber: nats.NewSubscriber(js, nats.SubscriberConfig{
+			DeadLetterSubject: env.Nats.Subjects.DeadLetter,
+			MaxDeliver:        env.Nats.Consumer.MaxDeliver,
+			AckWait:           env.Nats.Consumer.AckWait,
+			NakDelay:          env.Nats.Consumer.NakDelay,
+		}),
+		AsyncOrderHandler:    consumer.NewAsyncOrderHandler(service.EventTransactionService),
+		AsyncCallbackHandler: consumer.NewAsyncCallbackHandler(service.EventTransactionService),
+	}
+}
+
+// seatmapStreamConfig is ensured by both consumer and api, seatmap changes are only read by ephemeral consumers of seatmap stream viewers
+func seatmapStreamConfig(env *config.EnvironmentVariable) jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:       env.Nats.Streams.Seatmap,
+		Subjects:   []string{env.Nats.Subjects.SeatmapChange + ".>"},
+		Storage:    jetstream.FileStorage,
+		Retention:  jetstream.LimitsPolicy,
+		MaxAge:     env.SeatmapStream.MaxAge,
+		Duplicates: 10 * time.Minute, // outbox relay republish with the same message id
+	}
+}
+
+func (c *Consumer) Start() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = c.Subscriber.EnsureStream(ctx, jetstream.StreamConfig{
+		Name:       c.Env.Nats.Streams.Async,
+		Subjects:   []string{c.Env.Nats.Subjects.AsyncOrder, c.Env.Nats.Subjects.AsyncCallback},
+		Storage:    jetstream.FileStorage,
+		Retention:  jetstream.LimitsPolicy,
+		MaxAge:     24 * time.Hour,
+		Duplicates: 10 * time.Minute, // outbox relay republish with the same message id
+	})
+	if err != nil {
+		return
+	}
+
+	err = c.Subscriber.EnsureStream(ctx, jetstream.StreamConfig{
+		Name:      c.Env.Nats.Streams.DeadLetter,
+		Subjects:  []string{c.Env.Nats.Subjects.DeadLetter},
+		Storage:   jetstream.FileStorage,
+		Retention: jetstream.LimitsPolicy,
+		MaxAge:    14 * 24 * time.Hour,
+	})
+	if err != nil {
+		return
+	}
+
+	err = c.Subscriber.EnsureStream(ctx, seatmapStreamConfig(c.Env))
+	if err != nil {
+		return
+	}
+
+	err = c.Subscriber.Subscribe(ctx, c.Env.Nats.Streams.Async, c.Env.Nats.Consumer.AsyncOrderDurable, c.Env.Nats.Subjects.AsyncOrder, c.AsyncOrderHandler.Handle)
+	if err != nil {
+		return
+	}
+
+	return c.Subscriber.Subscribe(ctx, c.Env.Nats.Streams.Async, c.Env.Nats.Consumer.AsyncCallbackDurable, c.Env.Nats.Subjects.AsyncCallback, c.AsyncCallbackHandler.Handle)
+}
+
+func (c *Consumer) Shutdown() {
+	c.Subscriber.Stop()
+}
@@ -14,6 +14,11 @@ type Handler struct {
 	EventHandler               handler.EventHandler
 	EventTicketCategoryHandler handler.EventTicketCategoryHandler
 	EventTransactionHandler    handler.EventTransactionHandler
+	RefundHandler              handler.RefundHandler
+	VoucherHandler             handler.VoucherHandler
+	WaitingRoomHandler         handler.WaitingRoomHandler
+	SeatHoldHandler            handler.SeatHoldHandler
+	SeatmapHandler             handler.SeatmapHandler
 }
 
 func Newhandler(
@@ -28,5 +33,10 @@ func Newhandler(
 		EventHandler:               handler.NewEventHandler(env, s.EventService, validator),
 		EventTicketCategoryHandler: handler.NewEventTicketCategoryHandler(env, s.EventTicketCategoryService, validator),
 		EventTransactionHandler:    handler.NewEventTransactionHandler(env, s.EventTransactionService, s.PaymentLogsService, validator),
+		RefundHandler:              handler.NewRefundHandler(env, s.RefundService, validator),
+		VoucherHandler:             handler.NewVoucherHandler(env, s.VoucherService, validator),
+		WaitingRoomHandler:         handler.NewWaitingRoomHandler(env, s.WaitingRoomService),
+		SeatHoldHandler:            handler.NewSeatHoldHandler(env, s.SeatHoldService),
+		SeatmapHandler:             handler.NewSeatmapHandler(env, s.SeatmapService),
 	}
 }
@@ -23,6 +23,8 @@ import (
 
 type Setup struct {
 	Router     *gin.Engine
+	Worker     *Worker
+	Consumer   *Consumer
 	Service    Service
 	Repository Repository
 	WrapDB     *database.WrapDB
@@ -46,7 +48,8 @@ func Init(env *config.EnvironmentVariable) (*Setup, error) {
 	custValidator.InitCustomValidator(validate)
 
 	// Init asynq
-	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: env.Redis.Host, Username: env.Redis.Username, Password: env.Redis.Password})
+	asynqRedisOpt := asynq.RedisClientOpt{Addr: env.Redis.Host, Username: env.Redis.Username, Password: env.Redis.Password}
+	asynqClient := asynq.NewClient(asynqRedisOpt)
 	err = asynqClient.Ping()
 	if err != nil {
 		log.Fatal().Err(err).Msg("asynq didn't respond")
@@ -79,14 +82,26 @@ func Init(env *config.EnvironmentVariable) (*Setup, error) {
 
 	// Publisher
 	natsPublisher := nats.NewPublisher(natsClient, js)
-	useCase := NewUseCase(env, natsPublisher)
-	job := NewJob(env, asynqClient)
+	seatmapWatcher := nats.NewSeatmapWatcher(js, env.Nats.Streams.Seatmap, env.Nats.Subjects.SeatmapChange)
+	// api can start before consumer, seatmap stream must exist for outbox relay and seatmap viewers
+	streamCtx, streamCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer streamCancel()
+	err = nats.EnsureStream(streamCtx, js, seatmapStreamConfig(env))
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to ensure seatmap stream")
+	}
 	redisRepo := repository.NewRedisRepository(redisClient)
-	repository := Newrepository(wrapDB, env, gcsClient, redisRepo)
-	service := Newservice(env, repository, wrapDB, job, useCase)
+	repository := Newrepository(wrapDB, env, gcsClient, redisRepo, redisClient)
+	useCase := NewUseCase(env, natsPublisher, repository.OutboxRepo)
+	job := NewJob(env, asynqClient)
+	paymentGateways, err := NewPaymentGateways(env)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to init payment gateways")
+	}
+	service := Newservice(env, repository, wrapDB, job, useCase, natsPublisher, paymentGateways, seatmapWatcher)
 	handler := Newhandler(env, service, validate)
 
-	middleware := middleware.NewMiddleware(env)
+	middleware := middleware.NewMiddleware(env, repository.IdempotencyKeyRepo, repository.EventSettingRepo, repository.WaitingRoomRepo)
 
 	r := router.Handler{
 		Env:                        env,
@@ -96,13 +111,25 @@ func Init(env *config.EnvironmentVariable) (*Setup, error) {
 		EventHandler:               handler.EventHandler,
 		EventTicketCategoryHandler: handler.EventTicketCategoryHandler,
 		EventTransaction:           handler.EventTransactionHandler,
+		Refund:                     handler.RefundHandler,
+		Voucher:                    handler.VoucherHandler,
+		WaitingRoom:                handler.WaitingRoomHandler,
+		SeatHold:                   handler.SeatHoldHandler,
+		Seatmap:                    handler.SeatmapHandler,
 		Middleware:                 middleware,
 	}
 
 	routes := router.NewRouter(r)
+	// failed callback is replayed through the same router which serve the callback
+	service.PaymentLogsService.SetCallbackDispatcher(routes)
+
+	worker := NewWorker(env, asynqRedisOpt, service)
+	consumer := NewConsumer(env, js, service)
 
 	return &Setup{
 		Router:     routes,
+		Worker:     worker,
+		Consumer:   consumer,
 		Repository: repository,
 		Service:    service,
 		WrapDB:     wrapDB,
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"assist-tix/config"
+	"assist-tix/internal/domain"
+	"assist-tix/internal/infra/paylabs"
+	"assist-tix/internal/infra/payment"
+	"assist-tix/lib"
+
+	"github.com/rs/zerolog/log"
+)
+
+// NewPaymentGateways fail on misconfigured gateway when payment is active, otherwise the gateway is left out
+func NewPaymentGateways(
+	env *config.EnvironmentVariable,
+) (gateways domain.PaymentGateways, err error) {
+	gateways = make(domain.PaymentGateways)
+
+	paylabsClient, err := paylabs.NewClient(env)
+	if err != nil {
+		if env.Paylabs.ActivePayment {
+			return nil, err
+		}
+		log.Warn().Err(err).Msg("paylabs is not configured, its payment channel is disabled")
+		return gateways, nil
+	}
+	gateways[lib.PaymentChannelPaylabs] = payment.NewPaylabsGateway(env, paylabsClient)
+
+	return gateways, nil
+}
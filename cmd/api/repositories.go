@@ -6,23 +6,34 @@ import (
 	"assist-tix/repository"
 
 	"cloud.google.com/go/storage"
+	"github.com/redis/go-redis/v9"
 )
 
 type Repository struct {
-	OrganizerRepo                 repository.OrganizerRepository
-	VenueRepo                     repository.VenueRepository
-	VenueSectorRepo               repository.VenueSectorRepository
-	EventRepo                     repository.EventRepository
-	EventSettingRepo              repository.EventSettingsRepository
-	EventTicketCategoryRepo       repository.EventTicketCategoryRepository
-	EventTransactionRepo          repository.EventTransactionRepository
-	EventTransactionItemRepo      repository.EventTransactionItemRepository
-	EventSeatmapBookRepo          repository.EventSeatmapBookRepository
-	EventTransactionGarudaIDRepo  repository.EventTransactionGarudaIDRepository
-	EventOrderInformationBookRepo repository.EventOrderInformationBookRepository
-	EventTicketRepo               repository.EventTicketRepository
-	PaymentMethodRepository       repository.PaymentMethodRepository
-	PaymentLogsRepository         repository.PaymentLogRepository
+	OrganizerRepo                     repository.OrganizerRepository
+	VenueRepo                         repository.VenueRepository
+	VenueSectorRepo                   repository.VenueSectorRepository
+	EventRepo                         repository.EventRepository
+	EventSettingRepo                  repository.EventSettingsRepository
+	EventTicketCategoryRepo           repository.EventTicketCategoryRepository
+	EventTransactionRepo              repository.EventTransactionRepository
+	EventTransactionStatusHistoryRepo repository.EventTransactionStatusHistoryRepository
+	EventTransactionRefundRepo        repository.EventTransactionRefundRepository
+	EventTransactionItemRepo          repository.EventTransactionItemRepository
+	EventSeatmapBookRepo              repository.EventSeatmapBookRepository
+	EventTransactionGarudaIDRepo      repository.EventTransactionGarudaIDRepository
+	EventOrderInformationBookRepo     repository.EventOrderInformationBookRepository
+	EventTicketRepo                   repository.EventTicketRepository
+	PaymentMethodRepository           repository.PaymentMethodRepository
+	PaymentLogsRepository             repository.PaymentLogRepository
+	PaymentReconciliationRepository   repository.PaymentReconciliationRepository
+	OutboxRepo                        repository.OutboxRepository
+	IdempotencyKeyRepo                repository.IdempotencyKeyRepository
+	VoucherRepo                       repository.VoucherRepository
+	PriceTierRepo                     repository.EventTicketCategoryPriceTierRepository
+	WaitingRoomRepo                   repository.WaitingRoomRepository
+	SeatHoldRepo                      repository.SeatHoldRepository
+	VenueSectorSeatmapRepo            repository.VenueSectorSeatmapRepository
 	// Storage Section
 	GcsStorageRepository repository.GCSStorageRepository
 }
@@ -32,22 +43,33 @@ func Newrepository(
 	env *config.EnvironmentVariable,
 	gcsClient *storage.Client,
 	redisRepo repository.RedisRepository,
+	redisClient *redis.Client,
 ) Repository {
 	return Repository{
-		OrganizerRepo:                 repository.NewOrganizerRepository(wrapDB, env),
-		VenueRepo:                     repository.NewVenueRepository(wrapDB, env),
-		VenueSectorRepo:               repository.NewVenueSectorRepository(wrapDB, redisRepo, env),
-		EventRepo:                     repository.NewEventRepository(wrapDB, redisRepo, env),
-		EventSettingRepo:              repository.NewEventSettingsRepository(wrapDB, redisRepo, env),
-		EventTicketCategoryRepo:       repository.NewEventTicketCategoryRepository(wrapDB, env),
-		EventTransactionRepo:          repository.NewEventTransactionRepository(wrapDB, env),
-		EventTransactionItemRepo:      repository.NewEventTransactionItemRepository(wrapDB, env),
-		EventSeatmapBookRepo:          repository.NewEventSeatmapBookRepository(wrapDB, env),
-		EventTransactionGarudaIDRepo:  repository.NewEventTransactionGarudaIDRepository(wrapDB, env),
-		EventOrderInformationBookRepo: repository.NewEventOrderInformationBookRepository(wrapDB, env),
-		EventTicketRepo:               repository.NewEventTicketRepository(wrapDB, env),
-		PaymentMethodRepository:       repository.NewPaymentMethodRepository(wrapDB, redisRepo, env),
-		GcsStorageRepository:          repository.NewGCSFileRepositoryImpl(gcsClient, env),
-		PaymentLogsRepository:         repository.NewPaymentLogRepository(wrapDB, env),
+		OrganizerRepo:                     repository.NewOrganizerRepository(wrapDB, env),
+		VenueRepo:                         repository.NewVenueRepository(wrapDB, env),
+		VenueSectorRepo:                   repository.NewVenueSectorRepository(wrapDB, redisRepo, env),
+		EventRepo:                         repository.NewEventRepository(wrapDB, redisRepo, env),
+		EventSettingRepo:                  repository.NewEventSettingsRepository(wrapDB, redisRepo, env),
+		EventTicketCategoryRepo:           repository.NewEventTicketCategoryRepository(wrapDB, env),
+		EventTransactionRepo:              repository.NewEventTransactionRepository(wrapDB, env),
+		EventTransactionStatusHistoryRepo: repository.NewEventTransactionStatusHistoryRepository(wrapDB, env),
+		EventTransactionRefundRepo:        repository.NewEventTransactionRefundRepository(wrapDB, env),
+		EventTransactionItemRepo:          repository.NewEventTransactionItemRepository(wrapDB, env),
+		EventSeatmapBookRepo:              repository.NewEventSeatmapBookRepository(wrapDB, env),
+		EventTransactionGarudaIDRepo:      repository.NewEventTransactionGarudaIDRepository(wrapDB, env),
+		EventOrderInformationBookRepo:     repository.NewEventOrderInformationBookRepository(wrapDB, env),
+		EventTicketRepo:                   repository.NewEventTicketRepository(wrapDB, env),
+		PaymentMethodRepository:           repository.NewPaymentMethodRepository(wrapDB, redisRepo, env),
+		GcsStorageRepository:              repository.NewGCSFileRepositoryImpl(gcsClient, env),
+		PaymentLogsRepository:             repository.NewPaymentLogRepository(wrapDB, env),
+		PaymentReconciliationRepository:   repository.NewPaymentReconciliationRepository(wrapDB, env),
+		OutboxRepo:                        repository.NewOutboxRepository(wrapDB, env),
+		IdempotencyKeyRepo:                repository.NewIdempotencyKeyRepository(wrapDB, env),
+		VoucherRepo:                       repository.NewVoucherRepository(wrapDB, env),
+		PriceTierRepo:                     repository.NewEventTicketCategoryPriceTierRepository(wrapDB, env),
+		WaitingRoomRepo:                   repository.NewWaitingRoomRepository(redisClient, env),
+		SeatHoldRepo:                      repository.NewSeatHoldRepository(redisClient, env),
+		VenueSectorSeatmapRepo:            repository.NewVenueSectorSeatmapRepository(wrapDB, env),
 	}
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"assist-tix/config"
 	"assist-tix/database"
+	"assist-tix/internal/domain"
 	"assist-tix/service"
 )
 
@@ -13,6 +14,12 @@ type Service struct {
 	EventTicketCategoryService service.EventTicketCategoryService
 	EventTransactionService    service.EventTransactionService
 	PaymentLogsService         service.PaymentLogsService
+	RefundService              service.RefundService
+	VoucherService             service.VoucherService
+	WaitingRoomService         service.WaitingRoomService
+	SeatHoldService            service.SeatHoldService
+	SeatmapService             service.SeatmapService
+	OutboxRelay                service.OutboxRelay
 }
 
 func Newservice(
@@ -21,12 +28,17 @@ func Newservice(
 	db *database.WrapDB,
 	job Job,
 	useCase UseCase,
+	publisher domain.EventPublisher,
+	paymentGateways domain.PaymentGateways,
+	seatmapWatcher domain.SeatmapWatcher,
 ) Service {
 	organizerService := service.NewOrganizerService(db, env, r.OrganizerRepo)
 	venueService := service.NewVenueService(db, env, r.VenueRepo, r.VenueSectorRepo)
 	eventService := service.NewEventService(db, env, r.EventRepo, r.EventSettingRepo, r.EventTicketCategoryRepo, r.OrganizerRepo, r.VenueRepo, r.EventTransactionGarudaIDRepo, r.GcsStorageRepository)
-	eventTicketCategoryService := service.NewEventTicketCategoryService(db, env, r.VenueRepo, r.VenueSectorRepo, r.EventRepo, r.EventTicketCategoryRepo, r.EventSeatmapBookRepo, r.GcsStorageRepository)
+	eventTicketCategoryService := service.NewEventTicketCategoryService(db, env, r.VenueRepo, r.VenueSectorRepo, r.EventRepo, r.EventTicketCategoryRepo, r.EventSeatmapBookRepo, r.GcsStorageRepository, r.PriceTierRepo, seatmapWatcher)
 	paymentLogsService := service.NewPaymentLogsService(db, env, r.PaymentLogsRepository)
+	outboxRelay := service.NewOutboxRelay(db, env, r.OutboxRepo, publisher)
+	transactionLifecycle := service.NewTransactionLifecycle(db, env, r.EventTransactionRepo, r.EventTransactionStatusHistoryRepo)
 	eventTransactionService := service.NewEventTransactionService(
 		db,
 		env,
@@ -43,8 +55,36 @@ func Newservice(
 		r.PaymentMethodRepository,
 		job.CheckStatusTransactionJob,
 		r.PaymentLogsRepository,
+		r.PaymentReconciliationRepository,
 		useCase.TransactionUseCase,
+		useCase.SeatmapUseCase,
+		outboxRelay,
+		paymentGateways,
+		transactionLifecycle,
+		r.VoucherRepo,
+		r.PriceTierRepo,
+		r.SeatHoldRepo,
 	)
+	refundService := service.NewRefundService(
+		db,
+		env,
+		r.EventRepo,
+		r.EventTicketCategoryRepo,
+		r.EventTransactionRepo,
+		r.EventTransactionItemRepo,
+		r.EventTransactionRefundRepo,
+		r.EventSeatmapBookRepo,
+		r.EventTransactionGarudaIDRepo,
+		r.EventOrderInformationBookRepo,
+		r.EventTicketRepo,
+		useCase.SeatmapUseCase,
+		outboxRelay,
+		paymentGateways,
+	)
+	voucherService := service.NewVoucherService(db, env, r.EventRepo, r.EventTicketCategoryRepo, r.VoucherRepo, r.PriceTierRepo)
+	waitingRoomService := service.NewWaitingRoomService(db, env, r.EventRepo, r.EventSettingRepo, r.WaitingRoomRepo)
+	seatmapService := service.NewSeatmapService(db, env, r.EventRepo, r.EventTicketCategoryRepo, r.VenueSectorRepo, r.VenueSectorSeatmapRepo, r.EventSeatmapBookRepo, r.EventTicketRepo, r.GcsStorageRepository, useCase.SeatmapUseCase, outboxRelay)
+	seatHoldService := service.NewSeatHoldService(db, env, r.EventRepo, r.EventSettingRepo, r.EventTicketCategoryRepo, r.EventTransactionRepo, r.VenueSectorRepo, r.EventSeatmapBookRepo, r.SeatHoldRepo, useCase.SeatmapUseCase, outboxRelay)
 
 	return Service{
 		OrganizerService:           organizerService,
@@ -53,5 +93,11 @@ func Newservice(
 		EventTicketCategoryService: eventTicketCategoryService,
 		EventTransactionService:    eventTransactionService,
 		PaymentLogsService:         paymentLogsService,
+		RefundService:              refundService,
+		VoucherService:             voucherService,
+		WaitingRoomService:         waitingRoomService,
+		SeatHoldService:            seatHoldService,
+		SeatmapService:             seatmapService,
+		OutboxRelay:                outboxRelay,
 	}
 }
@@ -8,13 +8,16 @@ import (
 
 type UseCase struct {
 	TransactionUseCase usecase.TransactionUsecase
+	SeatmapUseCase     usecase.SeatmapUsecase
 }
 
 func NewUseCase(
 	env *config.EnvironmentVariable,
 	publisher domain.EventPublisher,
+	outbox domain.OutboxWriter,
 ) UseCase {
 	return UseCase{
-		TransactionUseCase: usecase.NewTransactionUsecase(env, publisher),
+		TransactionUseCase: usecase.NewTransactionUsecase(env, publisher, outbox),
+		SeatmapUseCase:     usecase.NewSeatmapUsecase(env, outbox),
 	}
 }
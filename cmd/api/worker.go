@@ -0,0 +1,81 @@
+package api
+
+import (
+	"assist-tix/config"
+	"assist-tix/internal/job"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+type Worker struct {
+	Server    *asynq.Server
+	Mux       *asynq.ServeMux
+	Scheduler *asynq.Scheduler
+}
+
+func NewWorker(
+	env *config.EnvironmentVariable,
+	redisOpt asynq.RedisClientOpt,
+	service Service,
+) *Worker {
+	server := asynq.NewServer(redisOpt, asynq.Config{
+		Concurrency: env.Asynq.Concurrency,
+	})
+
+	checkStatusTransactionHandler := job.NewCheckStatusTransactionHandler(service.EventTransactionService)
+	reconcileTransactionHandler := job.NewReconcileTransactionHandler(service.EventTransactionService)
+	relayOutboxHandler := job.NewRelayOutboxHandler(service.OutboxRelay)
+	releaseSeatHoldHandler := job.NewReleaseSeatHoldHandler(service.SeatHoldService)
+
+	mux := asynq.NewServeMux()
+	mux.HandleFunc(job.QueueTypeCheckStatusTransaction, checkStatusTransactionHandler.ProcessTask)
+	mux.HandleFunc(job.QueueTypeReconcileTransaction, reconcileTransactionHandler.ProcessTask)
+	mux.HandleFunc(job.QueueTypeRelayOutbox, relayOutboxHandler.ProcessTask)
+	mux.HandleFunc(job.QueueTypeReleaseSeatHold, releaseSeatHoldHandler.ProcessTask)
+
+	worker := &Worker{
+		Server:    server,
+		Mux:       mux,
+		Scheduler: asynq.NewScheduler(redisOpt, nil),
+	}
+
+	_, err := worker.Scheduler.Register(env.Outbox.Cron, job.NewRelayOutboxTask(env.Outbox.Timeout))
+	if err != nil {
+		log.Fatal().Err(err).Str("cron", env.Outbox.Cron).Msg("failed to register outbox relay schedule")
+	}
+
+	_, err = worker.Scheduler.Register(env.SeatHold.Cron, job.NewReleaseSeatHoldTask(env.SeatHold.Timeout))
+	if err != nil {
+		log.Fatal().Err(err).Str("cron", env.SeatHold.Cron).Msg("failed to register seat hold release schedule")
+	}
+
+	if env.Reconciliation.Enable {
+		_, err = worker.Scheduler.Register(env.Reconciliation.Cron, job.NewReconcileTransactionTask(env.Reconciliation.Timeout))
+		if err != nil {
+			log.Fatal().Err(err).Str("cron", env.Reconciliation.Cron).Msg("failed to register reconciliation schedule")
+		}
+	}
+
+	return worker
+}
+
+func (w *Worker) Start() error {
+	err := w.Server.Start(w.Mux)
+	if err != nil {
+		return err
+	}
+
+	if w.Scheduler != nil {
+		return w.Scheduler.Start()
+	}
+
+	return nil
+}
+
+func (w *Worker) Shutdown() {
+	if w.Scheduler != nil {
+		w.Scheduler.Shutdown()
+	}
+	w.Server.Shutdown()
+}
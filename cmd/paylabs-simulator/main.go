@@ -0,0 +1,85 @@
+// Command paylabs-simulator run a fake paylabs server for local development.
+//
+// Point the api to it with PAYLABS.BASE_URL=http://localhost:4010 and PAYLABS.PUBLIC_KEY set to the
+// public key written by the simulator, then pay, fail or expire payments through /simulator endpoints:
+//
+//	GET  /simulator/payments
+//	GET  /simulator/payments/:merchantTradeNo
+//	POST /simulator/payments/:merchantTradeNo/pay       {"amount": 10000} optional, to simulate amount mismatch
+//	POST /simulator/payments/:merchantTradeNo/fail
+//	POST /simulator/payments/:merchantTradeNo/expire
+//	POST /simulator/payments/:merchantTradeNo/callback  resend the last callback, to simulate paylabs retry
+//	POST /simulator/faults                              {"path": "/payment/v2/qris/create", "status": 503, "count": 2}
+package main
+
+import (
+	"assist-tix/helper"
+	"assist-tix/internal/infra/paylabs/simulator"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	addr := flag.String("addr", ":4010", "listen address")
+	accountID := flag.String("account-id", "", "paylabs account id used by api (PAYLABS.ACCOUNT_ID), merchant id is its last 6 characters")
+	vaCallbackURL := flag.String("va-callback-url", "http://localhost:3000/api/v1/external/paylabs/va-snap/callback", "url to send VA callback to")
+	privateKeyPath := flag.String("private-key", "", "simulator private key, a new key is generated when empty")
+	publicKeyOut := flag.String("public-key-out", "paylabs-simulator.pub", "where generated public key is written, use it as PAYLABS.PUBLIC_KEY of api")
+	clientPublicKeyPath := flag.String("client-public-key", "", "api public key to verify request signature, verification is skipped when empty")
+	scenario := flag.String("scenario", simulator.ScenarioNone, "run for every new payment: none, pay, fail or expire")
+	autoDelay := flag.Duration("auto-delay", 5*time.Second, "delay before pay or fail scenario is run")
+	flag.Parse()
+
+	// api send paylabs time in Asia/Jakarta, see main.go
+	var err error
+	time.Local, err = time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load time zone")
+	}
+
+	if len(*accountID) < 6 {
+		log.Fatal().Msg("account-id is required")
+	}
+
+	var privateKeyPEM, clientKeyPEM string
+	if *privateKeyPath != "" {
+		privateKeyPEM = helper.GetKeyFileString(*privateKeyPath)
+	} else {
+		var publicKeyPEM string
+		privateKeyPEM, publicKeyPEM, err = simulator.GenerateKey()
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to generate key")
+		}
+
+		err = os.WriteFile(*publicKeyOut, []byte(publicKeyPEM), 0644)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to write public key")
+		}
+		log.Info().Str("path", *publicKeyOut).Msg("public key written, set it as PAYLABS.PUBLIC_KEY of api")
+	}
+
+	if *clientPublicKeyPath != "" {
+		clientKeyPEM = helper.GetKeyFileString(*clientPublicKeyPath)
+	}
+
+	sim, err := simulator.New(simulator.Config{
+		MerchantID:    (*accountID)[len(*accountID)-6:],
+		VACallbackURL: *vaCallbackURL,
+		PrivateKeyPEM: privateKeyPEM,
+		ClientKeyPEM:  clientKeyPEM,
+		Scenario:      *scenario,
+		AutoDelay:     *autoDelay,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create simulator")
+	}
+
+	log.Info().Str("addr", *addr).Str("scenario", *scenario).Msg("paylabs simulator is running")
+	err = sim.Router().Run(*addr)
+	if err != nil {
+		log.Fatal().Err(err).Msg("paylabs simulator stopped")
+	}
+}
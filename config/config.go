@@ -71,8 +71,60 @@ func SetDefaultConfig(v *viper.Viper) {
 	v.SetDefault("DATABASE.TIMEOUT.READ", "5s")
 	v.SetDefault("DATABASE.TIMEOUT.WRITE", "5s")
 
+	v.SetDefault("APP.ROLE", "api")
+
 	v.SetDefault("ASYNQ.PROCESS_TIMEOUT", "30s")
 	v.SetDefault("ASYNQ.MAX_RETRY", 5)
+	v.SetDefault("ASYNQ.CONCURRENCY", 10)
+
+	v.SetDefault("PAYLABS.TIMEOUT", "15s")
+	v.SetDefault("PAYLABS.MAX_RETRY", 2)
+	v.SetDefault("PAYLABS.RETRY_BACKOFF", "500ms")
+
+	v.SetDefault("TRANSACTION.CHARGE_CLAIM_TIMEOUT", "1m")
+
+	v.SetDefault("RECONCILIATION.ENABLE", false)
+	v.SetDefault("RECONCILIATION.CRON", "@every 10m")
+	v.SetDefault("RECONCILIATION.PENDING_AGE", "5m")
+	v.SetDefault("RECONCILIATION.EXPIRED_LOOKBACK", "6h")
+	v.SetDefault("RECONCILIATION.BATCH_SIZE", 100)
+	v.SetDefault("RECONCILIATION.TIMEOUT", "5m")
+	v.SetDefault("NATS.SUBJECTS.DEAD_LETTER", "DEAD_LETTER.ASYNC")
+	v.SetDefault("NATS.STREAMS.ASYNC", "ASYNC")
+	v.SetDefault("NATS.STREAMS.DEAD_LETTER", "ASYNC_DEAD_LETTER")
+	v.SetDefault("NATS.SUBJECTS.SEATMAP_CHANGE", "SEATMAP.CHANGE")
+	v.SetDefault("NATS.STREAMS.SEATMAP", "SEATMAP")
+	v.SetDefault("NATS.CONSUMER.ASYNC_ORDER_DURABLE", "assist-tix-async-order")
+	v.SetDefault("NATS.CONSUMER.ASYNC_CALLBACK_DURABLE", "assist-tix-async-callback")
+	v.SetDefault("NATS.CONSUMER.MAX_DELIVER", 5)
+	v.SetDefault("NATS.CONSUMER.ACK_WAIT", "2m")
+	v.SetDefault("NATS.CONSUMER.NAK_DELAY", "10s")
+	v.SetDefault("OUTBOX.CRON", "@every 10s")
+	v.SetDefault("OUTBOX.BATCH_SIZE", 100)
+	v.SetDefault("OUTBOX.MAX_ATTEMPTS", 20)
+	v.SetDefault("OUTBOX.RETRY_INTERVAL", "5s")
+	v.SetDefault("OUTBOX.MAX_RETRY_INTERVAL", "10m")
+	v.SetDefault("OUTBOX.PUBLISH_TIMEOUT", "5s")
+	v.SetDefault("OUTBOX.TIMEOUT", "1m")
+
+	v.SetDefault("IDEMPOTENCY.TTL", "24h")
+	v.SetDefault("IDEMPOTENCY.LOCK_TIMEOUT", "2m")
+
+	v.SetDefault("WAITING_ROOM.QUEUE_TOKEN_TTL", "6h")
+	v.SetDefault("WAITING_ROOM.ADMISSION_TTL", "15m")
+	v.SetDefault("WAITING_ROOM.MAX_ORDERS_PER_ADMISSION", 3)
+
+	v.SetDefault("SEAT_HOLD.TTL", "5m")
+	v.SetDefault("SEAT_HOLD.CRON", "@every 1m")
+	v.SetDefault("SEAT_HOLD.TIMEOUT", "1m")
+
+	v.SetDefault("SEATMAP_STREAM.MAX_AGE", "1h")
+	v.SetDefault("SEATMAP_STREAM.HEARTBEAT", "15s")
+	v.SetDefault("SEATMAP_IMAGE.SEAT_SIZE", 24)
+	v.SetDefault("SEATMAP_IMAGE.CACHE_MAX_AGE", "30s")
+	v.SetDefault("TICKET_BARCODE.MODULE_SIZE", 8)
+
+	v.SetDefault("REFUND.STALE_AFTER", "15m")
 }
 
 type EnvironmentVariable struct {
@@ -80,6 +132,7 @@ type EnvironmentVariable struct {
 		Host  string `mapstucture:"HOST"`
 		Port  int    `mapstructure:"PORT"`
 		Mode  string `mapstructure:"MODE"`
+		Role  string `mapstructure:"ROLE"` // api / worker / consumer / all
 		Debug bool   `mapstructure:"DEBUG"`
 
 		AutoAssignSeat bool `mapstructure:"AUTO_ASSIGN_SEAT"` // It will disable validation seat
@@ -90,10 +143,16 @@ type EnvironmentVariable struct {
 		Url              string `mapstructure:"URL"`
 		AllowOrigin      bool   `mapstructure:"ALLOW_ORIGIN"`
 		OriginMiddleware bool   `mapstructure:"ORIGIN_MIDDLEWARE"` // Enable or disable origin middleware
+
+		TrustedProxies  []string `mapstructure:"TRUSTED_PROXIES"`  // IPs or CIDRs of proxies whose X-Forwarded-For is trusted for client ip, none is trusted when empty
+		TrustedPlatform string   `mapstructure:"TRUSTED_PLATFORM"` // header set by the platform with client ip, ex: CF-Connecting-IP, it takes precedence over X-Forwarded-For
 	} `mapstructure:"API"`
 	AccessToken struct {
 		SecretKey string `mapstructure:"SECRET_KEY"`
 	} `mapstructure:"ACCESS_TOKEN"`
+	Admin struct {
+		ApiKey string `mapstructure:"API_KEY"` // X-API-Key for admin endpoints, admin endpoints are rejected when empty
+	} `mapstructure:"ADMIN"`
 	Redis struct {
 		Address  string `mapstructure:"ADDRESS"`
 		Port     string `mapstructure:"PORT"`
@@ -129,7 +188,21 @@ type EnvironmentVariable struct {
 			SendETicket   string `mapstructure:"SEND_ETICKET"`
 			AsyncOrder    string `mapstructure:"ASYNC_ORDER"`
 			AsyncCallback string `mapstructure:"ASYNC_CALLBACK"`
+			DeadLetter    string `mapstructure:"DEAD_LETTER"`    // async message which failed on every delivery
+			SeatmapChange string `mapstructure:"SEATMAP_CHANGE"` // prefix of seat status change subject, followed by event id and sector id
 		} `mapstructure:"SUBJECTS"`
+		Streams struct {
+			Async      string `mapstructure:"ASYNC"`       // stream of async order and async callback, created by consumer when missing
+			DeadLetter string `mapstructure:"DEAD_LETTER"` // stream of dead letter subject, created by consumer when missing
+			Seatmap    string `mapstructure:"SEATMAP"`     // stream of seatmap change subjects, created by consumer when missing
+		} `mapstructure:"STREAMS"`
+		Consumer struct {
+			AsyncOrderDurable    string        `mapstructure:"ASYNC_ORDER_DURABLE"`
+			AsyncCallbackDurable string        `mapstructure:"ASYNC_CALLBACK_DURABLE"`
+			MaxDeliver           int           `mapstructure:"MAX_DELIVER"` // message is sent to dead letter when its last delivery fails
+			AckWait              time.Duration `mapstructure:"ACK_WAIT"`    // max duration of handling a message
+			NakDelay             time.Duration `mapstructure:"NAK_DELAY"`   // redelivery delay, multiplied by delivery count
+		} `mapstructure:"CONSUMER"`
 	} `mapstructure:"NATS"`
 	Mailer struct {
 		AssetsPath string `mapstructure:"ASSETS_PATH"`
@@ -145,6 +218,7 @@ type EnvironmentVariable struct {
 	Transaction struct {
 		UseV2              bool          `mapstructure:"USE_V2"` // Use V2 transaction flow
 		ExpirationDuration time.Duration `mapstructure:"EXPIRATION_DURATION"`
+		ChargeClaimTimeout time.Duration `mapstructure:"CHARGE_CLAIM_TIMEOUT"` // charge claimed by async order longer than this is checked on gateway and taken over
 	} `mapstructure:"TRANSACTION"`
 	Paylabs struct {
 		BaseUrl         string `mapstructure:"BASE_URL"`
@@ -153,6 +227,10 @@ type EnvironmentVariable struct {
 		PrivateKey      string `mapstructure:"PRIVATE_KEY"`      // our private key in PEM format
 		PaymentDuration int    `mapstructure:"PAYMENT_DURATION"` // Duration in seconds
 		ActivePayment   bool   `mapstructure:"ACTIVE_PAYMENT"`   // Enable or disable payment
+
+		Timeout      time.Duration `mapstructure:"TIMEOUT"`       // timeout for each request attempt
+		MaxRetry     int           `mapstructure:"MAX_RETRY"`     // retry on 5xx or network error
+		RetryBackoff time.Duration `mapstructure:"RETRY_BACKOFF"` // initial backoff, doubled on each retry
 	} `mapstructure:"PAYLABS"`
 	Storage struct {
 		Type string `mapstructure:"TYPE"`
@@ -175,7 +253,54 @@ type EnvironmentVariable struct {
 	Asynq struct {
 		ProcessTimeout time.Duration `mapstructure:"PROCESS_TIMEOUT"`
 		MaxRetry       int           `mapstructure:"MAX_RETRY"`
+		Concurrency    int           `mapstructure:"CONCURRENCY"` // Worker concurrency
 	} `mapstructure:"ASYNQ"`
+	Reconciliation struct {
+		Enable          bool          `mapstructure:"ENABLE"`
+		Cron            string        `mapstructure:"CRON"`             // asynq scheduler cron spec, ex: @every 10m
+		PendingAge      time.Duration `mapstructure:"PENDING_AGE"`      // only pending transaction older than this is checked, give callback time to arrive
+		ExpiredLookback time.Duration `mapstructure:"EXPIRED_LOOKBACK"` // expired transaction in this window is checked for late payment
+		BatchSize       int           `mapstructure:"BATCH_SIZE"`       // max pending and max expired transaction checked on each run
+		Timeout         time.Duration `mapstructure:"TIMEOUT"`          // max duration of each run
+	} `mapstructure:"RECONCILIATION"`
+	Outbox struct {
+		Cron             string        `mapstructure:"CRON"`               // asynq scheduler cron spec of relay, message is also relayed right after its commit
+		BatchSize        int           `mapstructure:"BATCH_SIZE"`         // max message relayed on each run
+		MaxAttempts      int           `mapstructure:"MAX_ATTEMPTS"`       // message is marked FAILED after this many failed publish
+		RetryInterval    time.Duration `mapstructure:"RETRY_INTERVAL"`     // first retry delay, doubled on each failed publish
+		MaxRetryInterval time.Duration `mapstructure:"MAX_RETRY_INTERVAL"` // cap of retry delay
+		PublishTimeout   time.Duration `mapstructure:"PUBLISH_TIMEOUT"`    // max duration of each publish
+		Timeout          time.Duration `mapstructure:"TIMEOUT"`            // max duration of each run
+	} `mapstructure:"OUTBOX"`
+	Idempotency struct {
+		TTL         time.Duration `mapstructure:"TTL"`          // stored response is replayed until it expires, then the key can be reused
+		LockTimeout time.Duration `mapstructure:"LOCK_TIMEOUT"` // in progress key older than this is taken over by retry of the same request
+	} `mapstructure:"IDEMPOTENCY"`
+	WaitingRoom struct {
+		SecretKey             string        `mapstructure:"SECRET_KEY"`               // signs queue and admission token, ACCESS_TOKEN.SECRET_KEY is used when empty
+		QueueTokenTTL         time.Duration `mapstructure:"QUEUE_TOKEN_TTL"`          // buyer who waits longer has to join again
+		AdmissionTTL          time.Duration `mapstructure:"ADMISSION_TTL"`            // time to place the order once admitted
+		MaxOrdersPerAdmission int           `mapstructure:"MAX_ORDERS_PER_ADMISSION"` // order attempts allowed with one admission token
+	} `mapstructure:"WAITING_ROOM"`
+	SeatHold struct {
+		TTL     time.Duration `mapstructure:"TTL"`     // seats are reserved this long before checkout
+		Cron    string        `mapstructure:"CRON"`    // release of expired holds
+		Timeout time.Duration `mapstructure:"TIMEOUT"` // timeout of release job
+	} `mapstructure:"SEAT_HOLD"`
+	SeatmapStream struct {
+		MaxAge    time.Duration `mapstructure:"MAX_AGE"`   // seatmap changes are kept this long, older resync token must refetch the seatmap
+		Heartbeat time.Duration `mapstructure:"HEARTBEAT"` // keep alive comment of idle stream connection
+	} `mapstructure:"SEATMAP_STREAM"`
+	SeatmapImage struct {
+		SeatSize    int           `mapstructure:"SEAT_SIZE"`     // pixel size of one seat, shrunk for big sector
+		CacheMaxAge time.Duration `mapstructure:"CACHE_MAX_AGE"` // max age of rendered seatmap in client cache
+	} `mapstructure:"SEATMAP_IMAGE"`
+	TicketBarcode struct {
+		ModuleSize int `mapstructure:"MODULE_SIZE"` // pixel size of the narrowest bar or qr module
+	} `mapstructure:"TICKET_BARCODE"`
+	Refund struct {
+		StaleAfter time.Duration `mapstructure:"STALE_AFTER"` // processing refund older than this can be resolved by admin
+	} `mapstructure:"REFUND"`
 }
 
 func (e *EnvironmentVariable) GetDBDSN() string {
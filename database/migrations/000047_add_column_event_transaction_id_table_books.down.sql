@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_event_order_information_books_transaction_id;
+DROP INDEX IF EXISTS idx_event_transaction_garuda_id_books_transaction_id;
+DROP INDEX IF EXISTS idx_event_seatmap_books_transaction_id;
+
+ALTER TABLE event_transaction_garuda_id_books DROP COLUMN event_transaction_id;
+ALTER TABLE event_seatmap_books DROP COLUMN event_transaction_id;
+
+ALTER TABLE event_transactions DROP COLUMN ticket_quantity;
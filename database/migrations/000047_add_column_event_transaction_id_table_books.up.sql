@@ -0,0 +1,8 @@
+ALTER TABLE event_transactions ADD COLUMN ticket_quantity int NOT NULL DEFAULT 0;
+
+ALTER TABLE event_seatmap_books ADD COLUMN event_transaction_id uuid REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE event_transaction_garuda_id_books ADD COLUMN event_transaction_id uuid REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_event_seatmap_books_transaction_id ON event_seatmap_books (event_transaction_id);
+CREATE INDEX IF NOT EXISTS idx_event_transaction_garuda_id_books_transaction_id ON event_transaction_garuda_id_books (event_transaction_id);
+CREATE INDEX IF NOT EXISTS idx_event_order_information_books_transaction_id ON event_order_information_books (event_transaction_id);
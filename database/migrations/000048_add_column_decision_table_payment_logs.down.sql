@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_payment_logs_payment_reference;
+DROP INDEX IF EXISTS idx_payment_logs_transaction_id;
+
+ALTER TABLE payment_logs DROP COLUMN decision;
+ALTER TABLE payment_logs DROP COLUMN payment_reference;
+ALTER TABLE payment_logs DROP COLUMN event_transaction_id;
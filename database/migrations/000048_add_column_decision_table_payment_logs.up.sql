@@ -0,0 +1,6 @@
+ALTER TABLE payment_logs ADD COLUMN event_transaction_id uuid;
+ALTER TABLE payment_logs ADD COLUMN payment_reference text;
+ALTER TABLE payment_logs ADD COLUMN decision varchar(50);
+
+CREATE INDEX IF NOT EXISTS idx_payment_logs_transaction_id ON payment_logs (event_transaction_id);
+CREATE INDEX IF NOT EXISTS idx_payment_logs_payment_reference ON payment_logs (payment_reference);
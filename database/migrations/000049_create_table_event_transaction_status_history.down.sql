@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_transaction_status_history_transaction_id;
+DROP TABLE IF EXISTS event_transaction_status_history;
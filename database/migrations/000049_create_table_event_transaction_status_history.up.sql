@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS event_transaction_status_history (
+    id serial primary key,
+    event_transaction_id uuid not null REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    from_status varchar(50) not null,
+    to_status varchar(50) not null,
+    actor varchar(50) not null,
+    reason text,
+    created_at timestamp with time zone default now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_transaction_status_history_transaction_id ON event_transaction_status_history (event_transaction_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_reconciliations_transaction_id;
+DROP INDEX IF EXISTS idx_payment_reconciliations_created_at;
+DROP TABLE IF EXISTS payment_reconciliations;
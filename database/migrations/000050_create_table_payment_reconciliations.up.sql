@@ -0,0 +1,18 @@
+-- Discrepancies found by payment reconciliation job, one row per transaction checked and not in sync
+CREATE TABLE IF NOT EXISTS payment_reconciliations (
+    id serial primary key,
+    event_transaction_id uuid not null REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    order_number varchar(50) not null,
+    payment_method varchar(255) not null,
+    local_status varchar(50) not null,
+    gateway_status varchar(50) not null,
+    gateway_raw_status varchar(50),
+    grand_total int not null default 0,
+    paid_amount int not null default 0,
+    result varchar(50) not null,
+    message text,
+    created_at timestamp with time zone default now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_reconciliations_created_at ON payment_reconciliations (created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_reconciliations_transaction_id ON payment_reconciliations (event_transaction_id);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_event_transaction_refund_items_item_id;
+DROP TABLE IF EXISTS event_transaction_refund_items;
+
+DROP INDEX IF EXISTS idx_event_transaction_refunds_transaction_id;
+DROP TABLE IF EXISTS event_transaction_refunds;
+
+ALTER TABLE event_tickets DROP COLUMN IF EXISTS invalidation_reason;
+ALTER TABLE event_tickets DROP COLUMN IF EXISTS invalidated_at;
+
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS refund_status;
+
+ALTER TABLE events DROP COLUMN IF EXISTS status;
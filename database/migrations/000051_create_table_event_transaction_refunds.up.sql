@@ -0,0 +1,36 @@
+-- Only CANCELED / POSTPONED is stored, other event status is derived from event_time
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status varchar(50);
+
+-- Refund status is tracked separately from transaction_status (payment status)
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS refund_status varchar(50) NOT NULL DEFAULT 'NONE';
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS refunded_amount int NOT NULL DEFAULT 0;
+
+ALTER TABLE event_tickets ADD COLUMN IF NOT EXISTS invalidated_at timestamptz;
+ALTER TABLE event_tickets ADD COLUMN IF NOT EXISTS invalidation_reason text;
+
+CREATE TABLE IF NOT EXISTS event_transaction_refunds (
+    id serial primary key,
+    event_transaction_id uuid not null REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    refund_number varchar(100) not null unique,
+    refund_type varchar(50) not null, -- FULL / ITEM
+    amount int not null,
+    reason text not null,
+    status varchar(50) not null, -- PROCESSING / SUCCESS / MANUAL_REQUIRED / FAILED
+    pg_refund_id varchar(100),
+    failure_message text,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_transaction_refunds_transaction_id ON event_transaction_refunds (event_transaction_id);
+
+-- Item can only be refunded once, rows are removed when the refund failed
+CREATE TABLE IF NOT EXISTS event_transaction_refund_items (
+    id serial primary key,
+    refund_id int not null REFERENCES event_transaction_refunds(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    event_transaction_item_id int not null REFERENCES event_transaction_items(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    amount int not null,
+    created_at timestamp with time zone default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_transaction_refund_items_item_id ON event_transaction_refund_items (event_transaction_item_id);
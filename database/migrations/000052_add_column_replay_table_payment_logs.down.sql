@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payment_logs_failed_created_at;
+DROP INDEX IF EXISTS idx_payment_logs_replay_of_id;
+
+ALTER TABLE payment_logs DROP COLUMN IF EXISTS signature_skipped;
+ALTER TABLE payment_logs DROP COLUMN IF EXISTS replay_of_id;
@@ -0,0 +1,6 @@
+-- Replayed callback is logged as a new row pointing to the log it replays
+ALTER TABLE payment_logs ADD COLUMN IF NOT EXISTS replay_of_id int REFERENCES payment_logs(id) ON DELETE SET NULL;
+ALTER TABLE payment_logs ADD COLUMN IF NOT EXISTS signature_skipped boolean NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_payment_logs_replay_of_id ON payment_logs (replay_of_id);
+CREATE INDEX IF NOT EXISTS idx_payment_logs_failed_created_at ON payment_logs (created_at) WHERE error_code IS NOT NULL AND error_code <> '';
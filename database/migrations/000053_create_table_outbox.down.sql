@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_pending_next_attempt_at;
+DROP INDEX IF EXISTS idx_outbox_message_id;
+DROP TABLE IF EXISTS outbox;
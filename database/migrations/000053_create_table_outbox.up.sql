@@ -0,0 +1,16 @@
+-- Message written in the same transaction as the change which produce it, published to nats by outbox relay
+CREATE TABLE IF NOT EXISTS outbox (
+    id bigserial primary key,
+    subject varchar(255) not null,
+    message_id varchar(255) not null, -- nats msg id, jetstream drop duplicate publish in its duplicate window
+    payload text not null,
+    status varchar(50) not null default 'PENDING', -- PENDING / PUBLISHED / FAILED
+    attempts int not null default 0,
+    last_error text,
+    next_attempt_at timestamp with time zone not null default now(),
+    published_at timestamp with time zone,
+    created_at timestamp with time zone default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_message_id ON outbox (message_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_next_attempt_at ON outbox (next_attempt_at) WHERE status = 'PENDING';
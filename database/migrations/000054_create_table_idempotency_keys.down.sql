@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_idempotency_keys_idempotency_key;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,15 @@
+-- Response of request sent with Idempotency-Key header, replayed when the same request is repeated
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id bigserial primary key,
+    idempotency_key varchar(255) not null,
+    request_hash varchar(64) not null, -- sha256 of method, path and body
+    status varchar(50) not null default 'IN_PROGRESS', -- IN_PROGRESS / COMPLETED
+    response_status int,
+    response_body text,
+    locked_at timestamp with time zone not null default now(),
+    expires_at timestamp with time zone not null,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_idempotency_key ON idempotency_keys (idempotency_key);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_transaction_items_event_ticket_category_id;
+ALTER TABLE event_transaction_items DROP COLUMN IF EXISTS event_ticket_category_id;
@@ -0,0 +1,9 @@
+-- Item of cart order has its own ticket category, event_transactions.event_ticket_category_id is its first category
+ALTER TABLE event_transaction_items ADD COLUMN IF NOT EXISTS event_ticket_category_id uuid REFERENCES event_ticket_categories(id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+UPDATE event_transaction_items eti
+SET event_ticket_category_id = et.event_ticket_category_id
+FROM event_transactions et
+WHERE eti.transaction_id = et.id AND eti.event_ticket_category_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_event_transaction_items_event_ticket_category_id ON event_transaction_items (event_ticket_category_id);
@@ -0,0 +1,10 @@
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS voucher_code;
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS voucher_id;
+
+DROP INDEX IF EXISTS idx_voucher_usages_voucher_id_email;
+DROP INDEX IF EXISTS idx_voucher_usages_transaction_id;
+DROP TABLE IF EXISTS voucher_usages;
+
+DROP INDEX IF EXISTS idx_vouchers_event_id_code;
+DROP TABLE IF EXISTS vouchers;
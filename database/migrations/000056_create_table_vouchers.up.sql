@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS vouchers (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id uuid not null REFERENCES events(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    event_ticket_category_id uuid REFERENCES event_ticket_categories(id) ON DELETE CASCADE ON UPDATE CASCADE, -- null means every category of the event
+    code varchar(50) not null, -- stored in upper case
+    discount_type varchar(50) not null, -- PERCENTAGE / FIXED
+    discount_value int not null, -- percent for PERCENTAGE, amount for FIXED
+    max_discount int, -- cap of PERCENTAGE discount
+    usage_limit int, -- null means unlimited
+    usage_limit_per_email int,
+    usage_limit_per_garuda_id int,
+    is_active boolean not null default true,
+    start_at timestamp with time zone not null,
+    end_at timestamp with time zone not null,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vouchers_event_id_code ON vouchers (event_id, code) WHERE deleted_at IS NULL;
+
+-- Usage is written with the transaction and removed when the transaction expired, so it counts toward the limits
+CREATE TABLE IF NOT EXISTS voucher_usages (
+    id serial primary key,
+    voucher_id uuid not null REFERENCES vouchers(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    event_transaction_id uuid not null REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    email varchar(255) not null,
+    garuda_ids varchar(20)[] not null default '{}',
+    discount_amount int not null,
+    created_at timestamp with time zone default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_voucher_usages_transaction_id ON voucher_usages (event_transaction_id);
+CREATE INDEX IF NOT EXISTS idx_voucher_usages_voucher_id_email ON voucher_usages (voucher_id, lower(email));
+
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS voucher_id uuid REFERENCES vouchers(id) ON DELETE SET NULL ON UPDATE CASCADE;
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS voucher_code varchar(50);
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS discount_amount int NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_event_transaction_price_tiers_transaction_tier;
+DROP TABLE IF EXISTS event_transaction_price_tiers;
+
+DROP INDEX IF EXISTS idx_event_ticket_category_price_tiers_category_id;
+DROP TABLE IF EXISTS event_ticket_category_price_tiers;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS event_ticket_category_price_tiers (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_ticket_category_id uuid not null REFERENCES event_ticket_categories(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name varchar(255) not null, -- e.g. Presale, Early Bird, Regular, On The Day
+    price int not null,
+    start_at timestamp with time zone not null,
+    end_at timestamp with time zone not null,
+    stock_limit int, -- null means the tier is only limited by the category stock
+    sold int not null default 0,
+    created_at timestamp with time zone default now(),
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    CONSTRAINT event_ticket_category_price_tiers_sold_check CHECK (sold >= 0 AND (stock_limit IS NULL OR sold <= stock_limit))
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_ticket_category_price_tiers_category_id ON event_ticket_category_price_tiers (event_ticket_category_id, start_at) WHERE deleted_at IS NULL;
+
+-- Tier bought by transaction, so its sold count is given back when the transaction expired
+CREATE TABLE IF NOT EXISTS event_transaction_price_tiers (
+    id serial primary key,
+    event_transaction_id uuid not null REFERENCES event_transactions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    price_tier_id uuid not null REFERENCES event_ticket_category_price_tiers(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity int not null,
+    price int not null,
+    created_at timestamp with time zone default now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_transaction_price_tiers_transaction_tier ON event_transaction_price_tiers (event_transaction_id, price_tier_id);
@@ -0,0 +1,3 @@
+DELETE FROM event_settings WHERE setting_id IN ('0b7f3c52-6a1e-4c1f-9d0e-5f3a8e2b7c41', '5d2e9a17-3b84-4f6c-a1d5-8c7e4b0f2a93');
+
+DELETE FROM settings WHERE id IN ('0b7f3c52-6a1e-4c1f-9d0e-5f3a8e2b7c41', '5d2e9a17-3b84-4f6c-a1d5-8c7e4b0f2a93');
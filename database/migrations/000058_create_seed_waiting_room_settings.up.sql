@@ -0,0 +1,17 @@
+-- Waiting room of order endpoint, switched on per event through event_settings
+INSERT INTO settings (
+    id,
+    name,
+    default_value,
+    created_at
+) VALUES (
+    '0b7f3c52-6a1e-4c1f-9d0e-5f3a8e2b7c41',
+    'IS_WAITING_ROOM_ACTIVE',
+    'false',
+    NOW()
+), (
+    '5d2e9a17-3b84-4f6c-a1d5-8c7e4b0f2a93',
+    'WAITING_ROOM_ADMISSION_PER_MINUTE',
+    '100',
+    NOW()
+) ON CONFLICT (name) DO NOTHING;
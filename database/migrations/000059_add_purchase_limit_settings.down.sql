@@ -0,0 +1,8 @@
+DELETE FROM event_settings WHERE setting_id IN ('a3c1e7d4-2f58-4b9a-8e06-7d4b1c9f3e25', 'c8e24f91-5d37-4a6b-b1f0-3e9a7c5d2b48', 'f4b9d2a6-8c13-4e75-9a2d-1b6e0f8c7a39');
+
+DELETE FROM settings WHERE id IN ('a3c1e7d4-2f58-4b9a-8e06-7d4b1c9f3e25', 'c8e24f91-5d37-4a6b-b1f0-3e9a7c5d2b48', 'f4b9d2a6-8c13-4e75-9a2d-1b6e0f8c7a39');
+
+DROP INDEX IF EXISTS idx_event_transactions_event_id_client_ip;
+DROP INDEX IF EXISTS idx_event_transactions_event_id_email;
+
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS client_ip;
@@ -0,0 +1,28 @@
+-- Client ip of buyer, counted by MAX_TICKET_PER_IP
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS client_ip varchar(45);
+
+CREATE INDEX IF NOT EXISTS idx_event_transactions_event_id_email ON event_transactions (event_id, lower(email));
+CREATE INDEX IF NOT EXISTS idx_event_transactions_event_id_client_ip ON event_transactions (event_id, client_ip);
+
+-- Tickets per buyer across all non expired transactions of the event, 0 means unlimited
+INSERT INTO settings (
+    id,
+    name,
+    default_value,
+    created_at
+) VALUES (
+    'a3c1e7d4-2f58-4b9a-8e06-7d4b1c9f3e25',
+    'MAX_TICKET_PER_EMAIL',
+    '0',
+    NOW()
+), (
+    'c8e24f91-5d37-4a6b-b1f0-3e9a7c5d2b48',
+    'MAX_TICKET_PER_GARUDA_ID',
+    '0',
+    NOW()
+), (
+    'f4b9d2a6-8c13-4e75-9a2d-1b6e0f8c7a39',
+    'MAX_TICKET_PER_IP',
+    '0',
+    NOW()
+) ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,7 @@
+DELETE FROM event_seatmap_books WHERE hold_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_event_seatmap_books_hold_expires_at;
+DROP INDEX IF EXISTS idx_event_seatmap_books_hold_id;
+
+ALTER TABLE event_seatmap_books DROP COLUMN IF EXISTS hold_expires_at;
+ALTER TABLE event_seatmap_books DROP COLUMN IF EXISTS hold_id;
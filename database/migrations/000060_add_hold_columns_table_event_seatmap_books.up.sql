@@ -0,0 +1,6 @@
+-- Seat book with hold_id is a seat hold, it becomes a regular book once converted by order
+ALTER TABLE event_seatmap_books ADD COLUMN IF NOT EXISTS hold_id uuid;
+ALTER TABLE event_seatmap_books ADD COLUMN IF NOT EXISTS hold_expires_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_event_seatmap_books_hold_id ON event_seatmap_books (hold_id) WHERE hold_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_event_seatmap_books_hold_expires_at ON event_seatmap_books (hold_expires_at) WHERE hold_id IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS unique_event_venue_sector_seatmap_matrix_seat;
+
+DROP INDEX IF EXISTS unique_venue_sector_seatmap_matrix_seat;
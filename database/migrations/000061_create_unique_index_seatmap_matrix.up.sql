@@ -0,0 +1,5 @@
+CREATE UNIQUE INDEX IF NOT EXISTS unique_venue_sector_seatmap_matrix_seat
+    ON venue_sector_seatmap_matrix (sector_id, seat_row, seat_column);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_event_venue_sector_seatmap_matrix_seat
+    ON event_venue_sector_seatmap_matrix (event_id, sector_id, seat_row, seat_column);
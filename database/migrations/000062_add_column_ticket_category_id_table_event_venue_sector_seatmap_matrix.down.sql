@@ -0,0 +1 @@
+ALTER TABLE event_venue_sector_seatmap_matrix DROP COLUMN IF EXISTS ticket_category_id;
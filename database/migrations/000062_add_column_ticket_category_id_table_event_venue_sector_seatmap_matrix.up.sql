@@ -0,0 +1,2 @@
+-- price zone of seat, null means the seat can be sold by every ticket category of the sector
+ALTER TABLE event_venue_sector_seatmap_matrix ADD COLUMN ticket_category_id uuid REFERENCES event_ticket_categories(id) ON DELETE SET NULL ON UPDATE CASCADE;
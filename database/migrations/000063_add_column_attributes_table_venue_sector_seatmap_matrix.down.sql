@@ -0,0 +1,2 @@
+ALTER TABLE event_venue_sector_seatmap_matrix DROP COLUMN IF EXISTS attributes;
+ALTER TABLE venue_sector_seatmap_matrix DROP COLUMN IF EXISTS attributes;
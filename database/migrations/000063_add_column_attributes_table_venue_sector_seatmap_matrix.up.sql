@@ -0,0 +1,5 @@
+-- attributes of seat e.g. WHEELCHAIR, COMPANION, RESTRICTED_VIEW, AWAY_FANS
+ALTER TABLE venue_sector_seatmap_matrix ADD COLUMN IF NOT EXISTS attributes text[] NOT NULL DEFAULT '{}';
+
+-- null keeps attributes of the venue seat
+ALTER TABLE event_venue_sector_seatmap_matrix ADD COLUMN IF NOT EXISTS attributes text[];
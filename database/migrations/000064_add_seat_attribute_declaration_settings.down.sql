@@ -0,0 +1,5 @@
+DELETE FROM event_settings WHERE setting_id = 'd2f7a9c3-6b14-4e58-8c1d-5a0e9b3f7c62';
+
+DELETE FROM settings WHERE id = 'd2f7a9c3-6b14-4e58-8c1d-5a0e9b3f7c62';
+
+ALTER TABLE event_transaction_items DROP COLUMN IF EXISTS seat_needs;
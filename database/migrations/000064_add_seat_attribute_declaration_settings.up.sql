@@ -0,0 +1,15 @@
+-- needs declared by ticket holder, seat with attribute that requires declaration is only sold to the declared need
+ALTER TABLE event_transaction_items ADD COLUMN IF NOT EXISTS seat_needs text[] NOT NULL DEFAULT '{}';
+
+-- Comma separated seat attributes that require declaration of the need, empty means every seat can be bought
+INSERT INTO settings (
+    id,
+    name,
+    default_value,
+    created_at
+) VALUES (
+    'd2f7a9c3-6b14-4e58-8c1d-5a0e9b3f7c62',
+    'SEAT_ATTRIBUTES_REQUIRE_DECLARATION',
+    'WHEELCHAIR',
+    NOW()
+) ON CONFLICT (name) DO NOTHING;
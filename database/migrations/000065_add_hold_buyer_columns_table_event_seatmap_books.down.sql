@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_event_seatmap_books_hold_event_id;
+
+ALTER TABLE event_seatmap_books DROP COLUMN IF EXISTS hold_client_ip;
+ALTER TABLE event_seatmap_books DROP COLUMN IF EXISTS hold_email;
+ALTER TABLE event_seatmap_books DROP COLUMN IF EXISTS hold_ticket_category_id;
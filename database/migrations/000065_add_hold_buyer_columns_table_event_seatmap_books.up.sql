@@ -0,0 +1,6 @@
+-- Seat hold takes public stock of its ticket category until it's converted or released, and is counted in purchase limit of its buyer
+ALTER TABLE event_seatmap_books ADD COLUMN IF NOT EXISTS hold_ticket_category_id uuid;
+ALTER TABLE event_seatmap_books ADD COLUMN IF NOT EXISTS hold_email varchar(255);
+ALTER TABLE event_seatmap_books ADD COLUMN IF NOT EXISTS hold_client_ip varchar(64);
+
+CREATE INDEX IF NOT EXISTS idx_event_seatmap_books_hold_event_id ON event_seatmap_books (event_id) WHERE hold_id IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS billed_at;
+ALTER TABLE event_transactions DROP COLUMN IF EXISTS charge_claimed_at;
@@ -0,0 +1,6 @@
+-- Async order claims the charge before calling gateway and the bill before sending it, so redelivered order doesn't repeat them
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS charge_claimed_at timestamptz;
+ALTER TABLE event_transactions ADD COLUMN IF NOT EXISTS billed_at timestamptz;
+
+-- Charged transaction is already billed
+UPDATE event_transactions SET billed_at = created_at WHERE billed_at IS NULL AND channel_transaction_id IS NOT NULL AND channel_transaction_id <> '';
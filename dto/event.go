@@ -51,7 +51,13 @@ type DetailEventResponse struct {
 type EventSettingsResponse struct {
 	GarudaIdVerification         bool                         `json:"garuda_id_verification"`
 	MaxAdultTicketPerTransaction int                          `json:"max_adult_ticket_per_transaction"`
+	WaitingRoomActive            bool                         `json:"waiting_room_active"`      // order needs admission token of waiting room
+	MaxTicketPerEmail            int                          `json:"max_ticket_per_email"`     // across transactions of the event, 0 means unlimited
+	MaxTicketPerGarudaID         int                          `json:"max_ticket_per_garuda_id"` // across transactions of the event, 0 means unlimited
+	MaxTicketPerIP               int                          `json:"max_ticket_per_ip"`        // across transactions of the event, 0 means unlimited
 	AdditionalFees               []EventAdditionalFeeResponse `json:"additional_fees"`
+
+	SeatAttributesRequireDeclaration []string `json:"seat_attributes_require_declaration"` // seat with these attributes needs the need declared on order
 }
 
 type EventAdditionalFeeResponse struct {
@@ -62,11 +68,19 @@ type EventAdditionalFeeResponse struct {
 }
 
 type EventSettings struct {
-	GarudaIdVerification         bool    `json:"garuda_id_verification,omitempty"`
-	MaxAdultTicketPerTransaction int     `json:"max_adult_ticket_per_transaction,omitempty"`
-	TaxPercentage                float64 `json:"tax_percentage,omitempty"`
-	AdminFeePercentage           float64 `json:"admin_fee_percentage,omitempty"`
-	AdminFee                     int     `json:"admin_fee,omitempty"`
+	GarudaIdVerification          bool    `json:"garuda_id_verification,omitempty"`
+	MaxAdultTicketPerTransaction  int     `json:"max_adult_ticket_per_transaction,omitempty"`
+	TaxPercentage                 float64 `json:"tax_percentage,omitempty"`
+	AdminFeePercentage            float64 `json:"admin_fee_percentage,omitempty"`
+	AdminFee                      int     `json:"admin_fee,omitempty"`
+	WaitingRoomActive             bool    `json:"waiting_room_active,omitempty"`
+	WaitingRoomAdmissionPerMinute int     `json:"waiting_room_admission_per_minute,omitempty"`
+	MaxTicketPerEmail             int     `json:"max_ticket_per_email,omitempty"` // 0 means unlimited
+	MaxTicketPerGarudaID          int     `json:"max_ticket_per_garuda_id,omitempty"`
+	MaxTicketPerIP                int     `json:"max_ticket_per_ip,omitempty"`
+
+	// seat attributes only sold to order that declares the need, e.g. WHEELCHAIR
+	SeatAttributesRequireDeclaration []string `json:"seat_attributes_require_declaration,omitempty"`
 }
 
 type PaginatedEvents struct {
@@ -134,6 +148,9 @@ type DetailEventPublicTicketCategoryResponse struct {
 
 	PublicStock int `json:"public_stock"`
 
+	PriceTier         *TicketCategoryPriceTierResponse `json:"price_tier"`           // tier of the current price, null means category price
+	NextPriceChangeAt *time.Time                       `json:"next_price_change_at"` // when a tier starts or ends
+
 	Code     string `json:"code"`
 	Entrance string `json:"entrance"`
 }
@@ -153,6 +170,10 @@ type DetailEventTicketCategoryResponse struct {
 	Code     string `json:"code"`
 	Entrance string `json:"entrance"`
 
+	PriceTier         *TicketCategoryPriceTierResponse  `json:"price_tier"`
+	NextPriceChangeAt *time.Time                        `json:"next_price_change_at"`
+	PriceTiers        []TicketCategoryPriceTierResponse `json:"price_tiers"`
+
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 }
@@ -171,6 +192,31 @@ type CreateEventTicketCategoryRequest struct {
 	Entrance             string `json:"entrance" validate:"max=255"`
 }
 
+type TicketCategoryPriceTierResponse struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Price          int       `json:"price"`
+	StartAt        time.Time `json:"start_at"`
+	EndAt          time.Time `json:"end_at"`
+	StockLimit     *int      `json:"stock_limit"`
+	RemainingStock *int      `json:"remaining_stock"`
+	Sold           int       `json:"sold"`
+}
+
+type CreateTicketCategoryPriceTierRequest struct {
+	Name       string    `json:"name" binding:"required,min=1,max=255" example:"Early Bird"`
+	Price      int       `json:"price" binding:"min=0" example:"75000"`
+	StartAt    time.Time `json:"start_at" binding:"required"`
+	EndAt      time.Time `json:"end_at" binding:"required"`
+	StockLimit *int      `json:"stock_limit" binding:"omitempty,min=1"` // empty means only limited by the category stock
+}
+
+type GetTicketCategoryPriceTierByIdParams struct {
+	EventID          string `uri:"eventId" binding:"required,min=1,uuid"`
+	TicketCategoryID string `uri:"ticketCategoryId" binding:"required,min=1,uuid"`
+	PriceTierID      string `uri:"priceTierId" binding:"required,min=1,uuid"`
+}
+
 type GetEventTicketCategoryByIdParams struct {
 	EventID string `uri:"eventId" binding:"required,min=1,uuid"`
 }
@@ -188,3 +234,7 @@ type GetValidateEmailForBookEventParams struct {
 type GetAvailablePaymentMethodParams struct {
 	EventID string `uri:"eventId" binding:"required,min=1,uuid"`
 }
+
+type UpdateEventStatusRequest struct {
+	Status string `json:"status" binding:"omitempty,oneof=CANCELED POSTPONED"` // empty means event follow its event time again
+}
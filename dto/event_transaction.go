@@ -13,6 +13,8 @@ type CreateEventTransaction struct {
 	Items []OrderItemEventTransaction `json:"items" validate:"required,dive"`
 
 	PaymentMethod string `json:"payment_method" validate:"required"`
+	VoucherCode   string `json:"voucher_code" validate:"omitempty,max=50"`
+	SeatHoldID    string `json:"seat_hold_id" validate:"omitempty,uuid"` // seats of items must be the held seats, hold must be made with the same email
 }
 
 type OrderItemEventTransaction struct {
@@ -25,6 +27,26 @@ type OrderItemEventTransaction struct {
 
 	GarudaID              string `json:"garuda_id" validate:"omitempty,max=20"`
 	AdditionalInformation string `json:"additional_information" validate:""`
+
+	// seat attributes needed by ticket holder, required to buy seat whose attribute requires declaration
+	SeatNeeds []string `json:"seat_needs" validate:"omitempty,unique,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"`
+}
+
+// CreateEventTransactionCart is order across ticket categories of the same event, paid as a single transaction
+type CreateEventTransactionCart struct {
+	Fullname string `json:"fullname" validate:"required,alphaunicodespaces,min=3,max=255"`
+	Email    string `json:"email" validate:"required,custom_email,max=255"`
+
+	Items []CartItemEventTransaction `json:"items" validate:"required,dive"`
+
+	PaymentMethod string `json:"payment_method" validate:"required"`
+	VoucherCode   string `json:"voucher_code" validate:"omitempty,max=50"`
+}
+
+type CartItemEventTransaction struct {
+	TicketCategoryID string `json:"ticket_category_id" validate:"required,uuid"`
+
+	OrderItemEventTransaction
 }
 
 type EventTransactionResponse struct {
@@ -32,6 +54,8 @@ type EventTransactionResponse struct {
 	OrderNumber        string  `json:"order_number"`
 	PaymentMethod      string  `json:"payment_method"`
 	TotalPrice         int     `json:"total_price"`
+	VoucherCode        string  `json:"voucher_code,omitempty"`
+	DiscountAmount     int     `json:"discount_amount"`
 	TaxPercentage      float32 `json:"tax_percentage"`
 	TotalTax           int     `json:"total_tax"`
 	AdminFeePercentage float32 `json:"admin_fee_percentage"`
@@ -44,6 +68,24 @@ type EventTransactionResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type EventTransactionCartResponse struct {
+	EventTransactionResponse
+
+	Lines []EventTransactionCartLineResponse `json:"lines"`
+}
+
+// EventTransactionCartLineResponse is price of the items of one ticket category, fixed fee is only counted on the transaction
+type EventTransactionCartLineResponse struct {
+	TicketCategoryID   string `json:"ticket_category_id"`
+	TicketCategoryName string `json:"ticket_category_name"`
+	Quantity           int    `json:"quantity"`
+	Price              int    `json:"price"`
+	TotalPrice         int    `json:"total_price"`
+	DiscountAmount     int    `json:"discount_amount"`
+	TotalTax           int    `json:"total_tax"`
+	TotalAdminFee      int    `json:"total_admin_fee"`
+}
+
 type EventGrouppedPaymentMethodsResponse struct {
 	PaymentGroup string                       `json:"payment_group"`
 	Payments     []EventPaymentMethodResponse `json:"payments"`
@@ -81,9 +123,135 @@ type OrderDetails struct {
 	TotalAdminFee         int                            `json:"total_admin_fee"`         // event_transaction -> transaction -> total admin fee
 	TotalTax              int                            `json:"total_tax"`               // event_transaction -> transaction -> total tax
 	TotalPrice            int                            `json:"total_price"`             // event_transaction -> transaction -> total price
+	VoucherCode           string                         `json:"voucher_code"`            // event_transaction -> transaction -> voucher code
+	DiscountAmount        int                            `json:"discount_amount"`         // event_transaction -> transaction -> discount of voucher
 	TransactionQuantity   int                            `json:"transaction_quantity"`    // event_transaction -> transaction -> item count
 	Country               string                         `json:"country"`                 // event transaction -> user -> country
 	City                  string                         `json:"city"`                    // event transaction -> user -> city
 	AdditionalPayment     []entity.AdditionalPaymentInfo `json:"additional_payment"`      // event transaction -> transaction -> additional payment info
 	PGAdditionalFee       int                            `json:"pg_additional_fee"`       // event transaction -> transaction -> additional fee for payment gateway
 }
+
+type EventTransactionStatusHistoryResponse struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"` // CALLBACK / EXPIRY_JOB / ADMIN / SYSTEM
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ReconcileTransactionRequest struct {
+	OrderNumber string `uri:"orderNumber" binding:"required,min=1"`
+}
+
+type GetPaymentReconciliationsRequest struct {
+	Since time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type PaymentReconciliationResponse struct {
+	TransactionID    string    `json:"transaction_id"`
+	OrderNumber      string    `json:"order_number"`
+	PaymentMethod    string    `json:"payment_method"`
+	LocalStatus      string    `json:"local_status"`   // status before reconciliation
+	GatewayStatus    string    `json:"gateway_status"` // PENDING / SUCCESS / FAILED / UNKNOWN
+	GatewayRawStatus string    `json:"gateway_raw_status"`
+	GrandTotal       int       `json:"grand_total"`
+	PaidAmount       int       `json:"paid_amount"`
+	Result           string    `json:"result"` // MARKED_PAID / MARKED_FAILED / EXPIRED / PAID_AFTER_EXPIRED / AMOUNT_MISMATCH / INQUIRY_FAILED / IN_SYNC
+	Message          string    `json:"message"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type PaymentReconciliationReport struct {
+	Checked       int                             `json:"checked"`
+	Discrepancies []PaymentReconciliationResponse `json:"discrepancies"`
+}
+
+type RefundTransactionRequest struct {
+	ItemIDs []int  `json:"item_ids" binding:"omitempty,dive,min=1"` // empty means full refund of the remaining amount
+	Reason  string `json:"reason" binding:"required,min=1,max=255"`
+}
+
+type EventTransactionRefundResponse struct {
+	ID             int                                  `json:"id"`
+	TransactionID  string                               `json:"transaction_id"`
+	RefundNumber   string                               `json:"refund_number"`
+	RefundType     string                               `json:"refund_type"` // FULL / ITEM
+	Amount         int                                  `json:"amount"`
+	Reason         string                               `json:"reason"`
+	Status         string                               `json:"status"` // PROCESSING / SUCCESS / MANUAL_REQUIRED / FAILED
+	PGRefundID     string                               `json:"pg_refund_id"`
+	FailureMessage string                               `json:"failure_message"`
+	Items          []EventTransactionRefundItemResponse `json:"items"`
+	CreatedAt      time.Time                            `json:"created_at"`
+}
+
+type EventTransactionRefundItemResponse struct {
+	TransactionItemID int `json:"transaction_item_id"`
+	Amount            int `json:"amount"`
+}
+
+type GetRefundByIdParams struct {
+	RefundID int `uri:"refundId" binding:"required,min=1"`
+}
+
+type ResolveRefundRequest struct {
+	Status         string `json:"status" binding:"required,oneof=SUCCESS MANUAL_REQUIRED FAILED"` // result of the refund on gateway
+	PGRefundID     string `json:"pg_refund_id" binding:"max=100"`                                 // pg_refund_id column is varchar(100)
+	FailureMessage string `json:"failure_message" binding:"max=255"`
+}
+
+type GetFailedPaymentCallbacksRequest struct {
+	Since         time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	TransactionID string    `form:"transaction_id" binding:"omitempty,uuid"`
+	Limit         int       `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+type PaymentLogResponse struct {
+	ID               int       `json:"id"`
+	Path             string    `json:"path"`
+	Header           string    `json:"header"`
+	Body             string    `json:"body"`
+	Response         string    `json:"response"`
+	ErrorCode        string    `json:"error_code"`
+	ErrorResponse    string    `json:"error_response"`
+	TransactionID    string    `json:"transaction_id"`
+	PaymentReference string    `json:"payment_reference"`
+	Decision         string    `json:"decision"`
+	ReplayOfID       *int      `json:"replay_of_id"`
+	SignatureSkipped bool      `json:"signature_skipped"`
+	IsResolved       bool      `json:"is_resolved"` // one of its replay succeed
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ReplayPaymentCallbackParams struct {
+	PaymentLogID int `uri:"paymentLogId" binding:"required,min=1"`
+}
+
+type ReplayPaymentCallbackRequest struct {
+	// explicit admin override, for callback whose signature can't be verified anymore (ex: rotated key)
+	SkipSignatureVerification bool `json:"skip_signature_verification"`
+}
+
+type ReplayPaymentCallbackResponse struct {
+	ReplayOfID       int    `json:"replay_of_id"`
+	StatusCode       int    `json:"status_code"` // status code returned by callback handler
+	Response         string `json:"response"`
+	SignatureSkipped bool   `json:"signature_skipped"`
+}
+
+type GetTicketBarcodeParams struct {
+	TransactionID string `uri:"transactionId" binding:"required,min=1,uuid"`
+	TicketNumber  string `uri:"ticketNumber" binding:"required,min=1,max=255"`
+}
+
+type TicketBarcodeQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=qr code128 pdf417"` // empty means qr
+}
+
+// TicketBarcodeResponse is png of ticket code
+type TicketBarcodeResponse struct {
+	ContentType string
+	Body        []byte
+}
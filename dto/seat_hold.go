@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+type CreateSeatHoldRequest struct {
+	Email     string                `json:"email" binding:"required,email"` // buyer, order using the hold must be placed with the same email
+	Seats     []SeatHoldSeatRequest `json:"seats" binding:"required,min=1,dive"`
+	SeatNeeds []string              `json:"seat_needs" binding:"omitempty,unique,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"` // needs declared for the held seats
+}
+
+type SeatHoldSeatRequest struct {
+	SeatRow    int `json:"seat_row" binding:"required,min=1"`
+	SeatColumn int `json:"seat_column" binding:"required,min=1"`
+}
+
+type SeatHoldResponse struct {
+	HoldID           string                 `json:"hold_id"` // send as seat_hold_id of order
+	EventID          string                 `json:"event_id"`
+	TicketCategoryID string                 `json:"ticket_category_id"`
+	Seats            []SeatHoldSeatResponse `json:"seats"`
+	ExpiresAt        time.Time              `json:"expires_at"`
+}
+
+type SeatHoldSeatResponse struct {
+	SeatRow    int    `json:"seat_row"`
+	SeatColumn int    `json:"seat_column"`
+	SeatLabel  string `json:"seat_label"`
+}
+
+type GetSeatHoldByIdParams struct {
+	EventID string `uri:"eventId" binding:"required,min=1,uuid"`
+	HoldID  string `uri:"holdId" binding:"required,min=1,uuid"`
+}
+
+type ReleaseSeatHoldQuery struct {
+	Email string `form:"email" binding:"required,email"` // buyer of the hold, same as email of CreateSeatHoldRequest
+}
@@ -0,0 +1,110 @@
+package dto
+
+import "time"
+
+type GetVenueSectorByIdParams struct {
+	VenueID  string `uri:"venueId" binding:"required,min=1,uuid"`
+	SectorID string `uri:"sectorId" binding:"required,min=1,uuid"`
+}
+
+type GetEventSectorByIdParams struct {
+	EventID  string `uri:"eventId" binding:"required,min=1,uuid"`
+	SectorID string `uri:"sectorId" binding:"required,min=1,uuid"`
+}
+
+// ReplaceSectorSeatmapRequest is the whole grid of sector, cell without seat is a gap or aisle.
+// Empty seats fill every cell of the grid
+type ReplaceSectorSeatmapRequest struct {
+	Rows    int                        `json:"rows" binding:"required,min=1,max=500"`
+	Columns int                        `json:"columns" binding:"required,min=1,max=500"`
+	Seats   []SectorSeatmapSeatRequest `json:"seats" binding:"omitempty,dive"`
+}
+
+type UpdateSectorSeatsRequest struct {
+	Seats []SectorSeatmapSeatRequest `json:"seats" binding:"required,min=1,dive"`
+}
+
+type SectorSeatmapSeatRequest struct {
+	Row      int    `json:"row" binding:"required,min=1"`
+	Column   int    `json:"column" binding:"required,min=1"`
+	RowLabel int    `json:"row_label" binding:"omitempty,min=0"`
+	Label    string `json:"label" binding:"omitempty,max=50"`
+	Status   string `json:"status" binding:"omitempty,oneof=AVAILABLE DISABLE"` // empty means AVAILABLE
+
+	Attributes []string `json:"attributes" binding:"omitempty,unique,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"`
+}
+
+type UpsertEventSeatmapOverridesRequest struct {
+	Seats []EventSeatmapOverrideRequest `json:"seats" binding:"required,min=1,dive"`
+}
+
+type EventSeatmapOverrideRequest struct {
+	Row      int    `json:"row" binding:"required,min=1"`
+	Column   int    `json:"column" binding:"required,min=1"`
+	RowLabel int    `json:"row_label" binding:"omitempty,min=0"`
+	Label    string `json:"label" binding:"omitempty,max=50"` // empty keeps the venue label
+	Status   string `json:"status" binding:"required,oneof=AVAILABLE UNAVAILABLE PREBOOKED COMPLIMENT DISABLE"`
+
+	// TicketCategoryID is price zone of seat, empty lets every ticket category of the sector sell the seat
+	TicketCategoryID string `json:"ticket_category_id" binding:"omitempty,uuid"`
+	// Attributes replace attributes of the venue seat for the event, omitted keeps the venue attributes
+	Attributes []string `json:"attributes" binding:"omitempty,unique,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"`
+}
+
+type DeleteEventSeatmapOverridesRequest struct {
+	Seats []SeatPositionRequest `json:"seats" binding:"required,min=1,dive"`
+}
+
+type SeatPositionRequest struct {
+	Row    int `json:"row" binding:"required,min=1"`
+	Column int `json:"column" binding:"required,min=1"`
+}
+
+type VenueSectorSeatmapResponse struct {
+	SectorID string                           `json:"sector_id"`
+	Rows     int                              `json:"rows"`
+	Columns  int                              `json:"columns"`
+	Capacity int                              `json:"capacity"`
+	Seats    []SectorSeatmapRowColumnResponse `json:"seats"`
+}
+
+// SeatmapFilterQuery keep seats having any of attributes and none of exclude attributes, attribute is repeated
+// as query param e.g. ?attributes=WHEELCHAIR&attributes=COMPANION
+type SeatmapFilterQuery struct {
+	Attributes        []string `form:"attributes" binding:"omitempty,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"`
+	ExcludeAttributes []string `form:"exclude_attributes" binding:"omitempty,dive,oneof=WHEELCHAIR COMPANION RESTRICTED_VIEW AWAY_FANS"`
+}
+
+// WatchSeatmapQuery resume the stream after resync token, Last-Event-ID header of reconnecting client takes precedence
+type WatchSeatmapQuery struct {
+	ResyncToken string `form:"resync_token" binding:"omitempty,numeric"`
+}
+
+type SeatmapChangeResponse struct {
+	EventID   string                 `json:"event_id"`
+	SectorID  string                 `json:"sector_id"`
+	Status    string                 `json:"status"`
+	Seats     []SeatPositionResponse `json:"seats"`
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+type SeatPositionResponse struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+type SeatmapImageQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=svg png"` // empty means svg
+}
+
+type GetTicketSeatmapImageParams struct {
+	TransactionID string `uri:"transactionId" binding:"required,min=1,uuid"`
+	TicketNumber  string `uri:"ticketNumber" binding:"required,min=1,max=255"`
+}
+
+// SeatmapImageResponse is rendered seatmap, ETag changes whenever the drawing changes
+type SeatmapImageResponse struct {
+	ContentType string
+	ETag        string
+	Body        []byte
+}
@@ -25,14 +25,27 @@ type TicketCategorySectorResponse struct {
 }
 
 type SectorSeatmapResponse struct {
-	Column int    `json:"column"`
-	Label  string `json:"label"`
-	Status string `json:"status"`
+	Column           int      `json:"column"`
+	Label            string   `json:"label"`
+	Status           string   `json:"status"`
+	TicketCategoryID string   `json:"ticket_category_id"`
+	Price            int      `json:"price"`
+	Attributes       []string `json:"attributes"`
 }
 
 type SectorSeatmapRowResponse struct {
-	Row   int                     `json:"row"`
-	Seats []SectorSeatmapResponse `json:"seats"`
+	Row      int                     `json:"row"`
+	RowLabel int                     `json:"row_label"`
+	Seats    []SectorSeatmapResponse `json:"seats"`
+}
+
+// SeatmapTicketCategoryResponse is price zone of sector seatmap
+type SeatmapTicketCategoryResponse struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Code     string `json:"code"`
+	Price    int    `json:"price"`
+	Entrance string `json:"entrance"`
 }
 
 type EventSectorSeatmapResponse struct {
@@ -41,6 +54,11 @@ type EventSectorSeatmapResponse struct {
 	Color    string                     `json:"color"`
 	AreaCode string                     `json:"area_code"`
 	Seatmap  []SectorSeatmapRowResponse `json:"seatmap"`
+
+	TicketCategories []SeatmapTicketCategoryResponse `json:"ticket_categories"`
+
+	// ResyncToken resume seatmap stream from this snapshot, changes after it are streamed
+	ResyncToken string `json:"resync_token"`
 }
 
 type SectorSeatmapRowColumnResponse struct {
@@ -49,4 +67,7 @@ type SectorSeatmapRowColumnResponse struct {
 	RowLabel int    `json:"row_label"`
 	Label    string `json:"label"`
 	Status   string `json:"status"`
+
+	TicketCategoryID string   `json:"ticket_category_id,omitempty"`
+	Attributes       []string `json:"attributes"` // null on event override means attributes of the venue seat
 }
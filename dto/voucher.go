@@ -0,0 +1,71 @@
+package dto
+
+import "time"
+
+type CreateVoucherRequest struct {
+	TicketCategoryID string `json:"ticket_category_id" binding:"omitempty,uuid"` // empty means every category of the event
+	Code             string `json:"code" binding:"required,alphanum,min=3,max=50" example:"EARLYBIRD"`
+	DiscountType     string `json:"discount_type" binding:"required,oneof=PERCENTAGE FIXED"`
+	DiscountValue    int    `json:"discount_value" binding:"required,min=1" example:"10"`
+	MaxDiscount      *int   `json:"max_discount" binding:"omitempty,min=1"` // only for PERCENTAGE
+
+	UsageLimit            *int `json:"usage_limit" binding:"omitempty,min=1"`
+	UsageLimitPerEmail    *int `json:"usage_limit_per_email" binding:"omitempty,min=1"`
+	UsageLimitPerGarudaID *int `json:"usage_limit_per_garuda_id" binding:"omitempty,min=1"`
+
+	IsActive *bool     `json:"is_active"` // default true
+	StartAt  time.Time `json:"start_at" binding:"required"`
+	EndAt    time.Time `json:"end_at" binding:"required"`
+}
+
+type VoucherResponse struct {
+	ID               string `json:"id"`
+	EventID          string `json:"event_id"`
+	TicketCategoryID string `json:"ticket_category_id"`
+	Code             string `json:"code"`
+	DiscountType     string `json:"discount_type"`
+	DiscountValue    int    `json:"discount_value"`
+	MaxDiscount      *int   `json:"max_discount"`
+
+	UsageLimit            *int `json:"usage_limit"`
+	UsageLimitPerEmail    *int `json:"usage_limit_per_email"`
+	UsageLimitPerGarudaID *int `json:"usage_limit_per_garuda_id"`
+
+	IsActive  bool      `json:"is_active"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GetVoucherByIdParams struct {
+	EventID   string `uri:"eventId" binding:"required,min=1,uuid"`
+	VoucherID string `uri:"voucherId" binding:"required,min=1,uuid"`
+}
+
+type PreviewVoucherRequest struct {
+	Code      string   `json:"code" binding:"required,max=50"`
+	Email     string   `json:"email" binding:"omitempty,custom_email,max=255"`
+	GarudaIDs []string `json:"garuda_ids" binding:"omitempty,dive,max=20"`
+
+	Items []PreviewVoucherItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PreviewVoucherItemRequest struct {
+	TicketCategoryID string `json:"ticket_category_id" binding:"required,uuid"`
+	Quantity         int    `json:"quantity" binding:"required,min=1"`
+}
+
+type PreviewVoucherResponse struct {
+	Voucher            VoucherResponse              `json:"voucher"`
+	TotalPrice         int                          `json:"total_price"`
+	DiscountAmount     int                          `json:"discount_amount"`
+	TotalAfterDiscount int                          `json:"total_after_discount"`
+	Lines              []PreviewVoucherLineResponse `json:"lines"`
+}
+
+type PreviewVoucherLineResponse struct {
+	TicketCategoryID string `json:"ticket_category_id"`
+	Quantity         int    `json:"quantity"`
+	TotalPrice       int    `json:"total_price"`
+	DiscountAmount   int    `json:"discount_amount"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type WaitingRoomResponse struct {
+	Status               string     `json:"status" example:"WAITING"`  // WAITING / ADMITTED
+	QueueToken           string     `json:"queue_token"`               // send as X-Queue-Token header to check the status
+	AdmissionToken       string     `json:"admission_token,omitempty"` // send as X-Admission-Token header to order, only when ADMITTED
+	Position             int64      `json:"position"`                  // buyers ahead, 0 when ADMITTED
+	EstimatedWaitSeconds int64      `json:"estimated_wait_seconds"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"` // expiry of admission token
+}
@@ -41,4 +41,7 @@ type EventVenueSector struct {
 	SeatRowLabel int
 	Label        string
 	Status       string
+
+	TicketCategoryID string   // price zone of seat, empty means every ticket category of the sector
+	Attributes       []string // e.g. WHEELCHAIR, RESTRICTED_VIEW
 }
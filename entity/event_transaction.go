@@ -29,6 +29,8 @@ type EventTransaction struct {
 	TotalAdminFee        sql.NullInt32
 	GrandTotal           int
 	PgAdditionalFee      sql.NullInt32
+	VoucherCode          sql.NullString
+	DiscountAmount       int
 
 	Fullname string
 	Email    string
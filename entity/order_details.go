@@ -24,6 +24,8 @@ type OrderDetails struct {
 	Country               string         `json:"country"`              // event transaction -> user -> country
 	City                  string         `json:"city"`                 // event transaction -> user -> city
 	PGAdditionalFee       int            `json:"pg_additional_fee"`    // event transaction -> transaction -> additional fee for payment gateway
+	VoucherCode           string         `json:"voucher_code"`         // event transaction -> transaction -> voucher code
+	DiscountAmount        int            `json:"discount_amount"`      // event transaction -> transaction -> discount of voucher
 } // start from event_transaction
 
 type AdditionalPaymentInfo struct {
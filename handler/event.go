@@ -20,6 +20,7 @@ type EventHandler interface {
 	Delete(ctx *gin.Context)
 	VerifyGarudaID(ctx *gin.Context)
 	GetActiveSettings(ctx *gin.Context)
+	UpdateStatus(ctx *gin.Context)
 }
 
 type EventHandlerImpl struct {
@@ -362,3 +363,55 @@ func (h *EventHandlerImpl) GetActiveSettings(ctx *gin.Context) {
 	}
 	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
 }
+
+// @Summary Update event status
+// @Description Mark event as canceled or postponed so its paid transactions can be refunded, empty status revert it
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param request body dto.UpdateEventStatusRequest true "Event status"
+// @Success 200 {object} lib.APIResponse "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/status [put]
+func (h *EventHandlerImpl) UpdateStatus(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.UpdateEventStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	err := h.EventService.UpdateStatus(ctx, uriParams.EventID, req.Status)
+	if err != nil {
+		log.Error().Err(err).Msg("error update event status")
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.ErrorEventNotFound:
+				lib.RespondError(ctx, http.StatusNotFound, "error", err, lib.ErrorEventNotFound.Code, h.Env.App.Debug)
+			default:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+			}
+		} else {
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", nil)
+}
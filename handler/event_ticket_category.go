@@ -6,8 +6,12 @@ import (
 	"assist-tix/lib"
 	"assist-tix/service"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
@@ -18,6 +22,10 @@ type EventTicketCategoryHandler interface {
 	GetByEventId(ctx *gin.Context)
 	GetById(ctx *gin.Context)
 	GetSeatmap(ctx *gin.Context)
+	WatchSeatmap(ctx *gin.Context)
+	CreatePriceTier(ctx *gin.Context)
+	GetPriceTiers(ctx *gin.Context)
+	DeletePriceTier(ctx *gin.Context)
 }
 
 type EventTicketCategoryHandlerImpl struct {
@@ -235,6 +243,8 @@ func (h *EventTicketCategoryHandlerImpl) GetById(ctx *gin.Context) {
 // @Accept json
 // @Param eventId path string false "Event ID"
 // @Param ticketCategoryId path string false "Ticket Category ID"
+// @Param attributes query []string false "Keep seats having any of attributes" collectionFormat(multi)
+// @Param exclude_attributes query []string false "Leave out seats having any of attributes" collectionFormat(multi)
 // @Success 200 {object} lib.APIResponse{data=dto.EventSectorSeatmapResponse} "Success get seatmap"
 // @Failure 400 {object} lib.HTTPError "Invalid request body"
 // @Failure 404 {object} lib.HTTPError "Not Found"
@@ -264,7 +274,18 @@ func (h *EventTicketCategoryHandlerImpl) GetSeatmap(ctx *gin.Context) {
 		return
 	}
 
-	res, err := h.EventTicketCategoryService.GetSeatmapByTicketCategoryId(ctx, uriParams.EventID, uriParams.TicketCategoryID)
+	var filter dto.SeatmapFilterQuery
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTicketCategoryService.GetSeatmapByTicketCategoryId(ctx, uriParams.EventID, uriParams.TicketCategoryID, filter)
 	if err != nil {
 		log.Error().Err(err).Msg("error get seatmap")
 		var tixErr *lib.TIXError
@@ -285,3 +306,224 @@ func (h *EventTicketCategoryHandlerImpl) GetSeatmap(ctx *gin.Context) {
 
 	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
 }
+
+// @Summary Stream seatmap availability
+// @Description Server sent events of seat status changes of ticket category sector. Each "seat_status" event id is the resync token,
+// @Description reconnecting client resumes with Last-Event-ID header or resync_token query taken from the seatmap snapshot.
+// @Description "resync" event means changes after the token aren't kept anymore, client must refetch the seatmap
+// @Tags events
+// @Produce text/event-stream
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket Category ID"
+// @Param resync_token query string false "Resync token of seatmap snapshot"
+// @Param Last-Event-ID header string false "Last received event id"
+// @Success 200 {object} dto.SeatmapChangeResponse "Stream of seat status changes"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 503 {object} lib.HTTPError "Seatmap stream is unavailable"
+// @Router /events/{eventId}/ticket-categories/{ticketCategoryId}/seatmap/stream [get]
+func (h *EventTicketCategoryHandlerImpl) WatchSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetDetailEventTicketCategoryByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", err, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var query dto.WatchSeatmapQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, lib.ErrorSeatmapResyncTokenInvalid.Error(), err, lib.ErrorSeatmapResyncTokenInvalid.Code, h.Env.App.Debug)
+		return
+	}
+
+	rawToken := query.ResyncToken
+	if lastEventID := ctx.GetHeader("Last-Event-ID"); lastEventID != "" {
+		rawToken = lastEventID
+	}
+	var resyncToken uint64
+	if rawToken != "" {
+		token, err := strconv.ParseUint(rawToken, 10, 64)
+		if err != nil {
+			lib.RespondError(ctx, http.StatusBadRequest, lib.ErrorSeatmapResyncTokenInvalid.Error(), err, lib.ErrorSeatmapResyncTokenInvalid.Code, h.Env.App.Debug)
+			return
+		}
+		resyncToken = token
+	}
+
+	changes, resync, err := h.EventTicketCategoryService.WatchSeatmap(ctx.Request.Context(), uriParams.EventID, uriParams.TicketCategoryID, resyncToken)
+	if err != nil {
+		log.Error().Err(err).Msg("error watch seatmap")
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.ErrorTicketCategoryNotFound, lib.ErrorEventNotFound, lib.ErrorVenueNotFound, lib.ErrorVenueSectorDoesntHaveSeatmap:
+				lib.RespondError(ctx, http.StatusNotFound, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorSeatmapStreamUnavailable:
+				lib.RespondError(ctx, http.StatusServiceUnavailable, "error", err, tixErr.Code, h.Env.App.Debug)
+			default:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+			}
+		} else {
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // nginx must not buffer the stream
+
+	if resync {
+		ctx.Render(-1, sse.Event{Event: "resync", Data: gin.H{"resync_token": strconv.FormatUint(resyncToken, 10)}})
+	}
+
+	heartbeat := time.NewTicker(h.Env.SeatmapStream.Heartbeat)
+	defer heartbeat.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case message, ok := <-changes:
+			if !ok {
+				return false
+			}
+
+			res := dto.SeatmapChangeResponse{
+				EventID:   message.Change.EventID,
+				SectorID:  message.Change.SectorID,
+				Status:    message.Change.Status,
+				Seats:     make([]dto.SeatPositionResponse, 0, len(message.Change.Seats)),
+				ChangedAt: message.Change.ChangedAt,
+			}
+			for _, seat := range message.Change.Seats {
+				res.Seats = append(res.Seats, dto.SeatPositionResponse{Row: seat.Row, Column: seat.Column})
+			}
+
+			ctx.Render(-1, sse.Event{Id: strconv.FormatUint(message.Sequence, 10), Event: "seat_status", Data: res})
+		case <-heartbeat.C:
+			// comment line keeps idle connection open through proxies, it's ignored by EventSource
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			if err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// @Summary Create price tier of ticket category
+// @Description Create price tier which replace the category price between start_at and end_at. When tiers overlap, the earliest tier with stock left is used
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket category ID"
+// @Param request body dto.CreateTicketCategoryPriceTierRequest true "Price tier"
+// @Success 200 {object} lib.APIResponse{data=dto.TicketCategoryPriceTierResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Ticket category not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/ticket-categories/{ticketCategoryId}/price-tiers [post]
+func (h *EventTicketCategoryHandlerImpl) CreatePriceTier(ctx *gin.Context) {
+	var uriParams dto.GetDetailEventTicketCategoryByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.CreateTicketCategoryPriceTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTicketCategoryService.CreatePriceTier(ctx, uriParams.EventID, uriParams.TicketCategoryID, req)
+	if err != nil {
+		log.Error().Err(err).Str("ticketCategoryId", uriParams.TicketCategoryID).Msg("error create price tier")
+		h.respondPriceTierError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get price tiers of ticket category
+// @Description Get price tiers of ticket category ordered by start time
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket category ID"
+// @Success 200 {object} lib.APIResponse{data=[]dto.TicketCategoryPriceTierResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Ticket category not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/ticket-categories/{ticketCategoryId}/price-tiers [get]
+func (h *EventTicketCategoryHandlerImpl) GetPriceTiers(ctx *gin.Context) {
+	var uriParams dto.GetDetailEventTicketCategoryByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTicketCategoryService.GetPriceTiers(ctx, uriParams.EventID, uriParams.TicketCategoryID)
+	if err != nil {
+		h.respondPriceTierError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Delete price tier of ticket category
+// @Description Stop price tier from being used, transactions which already bought it keep their price
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket category ID"
+// @Param priceTierId path string true "Price tier ID"
+// @Success 200 {object} lib.APIResponse "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Ticket category or price tier not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/ticket-categories/{ticketCategoryId}/price-tiers/{priceTierId} [delete]
+func (h *EventTicketCategoryHandlerImpl) DeletePriceTier(ctx *gin.Context) {
+	var uriParams dto.GetTicketCategoryPriceTierByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	err := h.EventTicketCategoryService.DeletePriceTier(ctx, uriParams.EventID, uriParams.TicketCategoryID, uriParams.PriceTierID)
+	if err != nil {
+		h.respondPriceTierError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", nil)
+}
+
+func (h *EventTicketCategoryHandlerImpl) respondPriceTierError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorTicketCategoryNotFound, lib.ErrorPriceTierNotFound:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorPriceTierInvalid:
+			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
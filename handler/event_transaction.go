@@ -9,6 +9,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/gin-gonic/gin"
@@ -24,10 +25,18 @@ type EventTransactionHandler interface {
 	IsEmailAlreadyBook(ctx *gin.Context)
 	GetAvailablePaymentMethods(ctx *gin.Context)
 	GetTransactionDetails(ctx *gin.Context)
+	GetTicketBarcode(ctx *gin.Context)
 	CreateTransactionV2(ctx *gin.Context)
+	CreateCartTransaction(ctx *gin.Context)
 
 	CallbackVASnapV2(ctx *gin.Context)
 	CallbackQRISPaylabsV2(ctx *gin.Context)
+
+	GetStatusHistories(ctx *gin.Context)
+	ReconcileTransaction(ctx *gin.Context)
+	GetPaymentReconciliations(ctx *gin.Context)
+	GetFailedPaymentCallbacks(ctx *gin.Context)
+	ReplayPaymentCallback(ctx *gin.Context)
 }
 
 type EventTransactionHandlerImpl struct {
@@ -59,9 +68,11 @@ func NewEventTransactionHandler(
 // @Param eventId path string true "Event ID"
 // @Param ticketCategoryId path string true "Ticket Category ID"
 // @Param request body dto.CreateEventTransaction true "Create event ticket transaction"
+// @Param Idempotency-Key header string false "Unique key of the order, repeated request with the same key returns the first final response"
 // @Success 200 {object} lib.APIResponse{data=dto.EventTransactionResponse} "Order created"
 // @Failure 400 {object} lib.HTTPError "Invalid request body"
 // @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 409 {object} lib.HTTPError "Idempotency key is reused with different request or still in progress"
 // @Failure 500 {object} lib.HTTPError "Internal server error"
 // @Router /events/{eventId}/ticket-categories/{ticketCategoryId}/order [post]
 func (h *EventTransactionHandlerImpl) CreateTransaction(ctx *gin.Context) {
@@ -128,13 +139,16 @@ func (h *EventTransactionHandlerImpl) CreateTransaction(ctx *gin.Context) {
 			switch *tixErr {
 			case lib.ErrorEventSaleIsPaused, lib.ErrorEventSaleIsNotStartedYet, lib.ErrorEventSaleAlreadyOver:
 				lib.RespondError(ctx, http.StatusForbidden, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorSeatIsAlreadyBooked, lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorOrderInformationIsAlreadyBook, lib.ErrorGarudaIDInvalid, lib.ErrorGarudaIDRejected, lib.ErrorGarudaIDBlacklisted, lib.ErrorGarudaIDAlreadyUsed, lib.ErrorDuplicateGarudaIDPayload, lib.TransactionWithoutAdultError:
+			case lib.ErrorSeatIsAlreadyBooked, lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorPurchaseLimitEmailReached, lib.ErrorPurchaseLimitGarudaIDReached, lib.ErrorPurchaseLimitIPReached, lib.ErrorOrderInformationIsAlreadyBook, lib.ErrorGarudaIDInvalid, lib.ErrorGarudaIDRejected, lib.ErrorGarudaIDBlacklisted, lib.ErrorGarudaIDAlreadyUsed, lib.ErrorDuplicateGarudaIDPayload, lib.TransactionWithoutAdultError,
+				lib.ErrorVoucherInactive, lib.ErrorVoucherNotApplicable, lib.ErrorVoucherUsageLimitReached, lib.ErrorVoucherEmailLimitReached, lib.ErrorVoucherGarudaIDLimitReached, lib.ErrorPriceTierSoldOut:
 				lib.RespondError(ctx, http.StatusConflict, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorEventIdInvalid, lib.ErrorTicketCategoryInvalid, lib.ErrorFailedToBookSeat, lib.ErrorPaymentMethodInvalid, lib.ErrorBadRequest:
+			case lib.ErrorEventIdInvalid, lib.ErrorTicketCategoryInvalid, lib.ErrorFailedToBookSeat, lib.ErrorSeatHoldMismatch, lib.ErrorSeatNotInTicketCategory, lib.ErrorSeatNotEligible, lib.ErrorPaymentMethodInvalid, lib.ErrorPaymentChannelNotSupported, lib.ErrorBadRequest:
 				lib.RespondError(ctx, http.StatusBadRequest, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorBookedSeatNotFound, lib.ErrorGarudaIDNotFound, lib.ErrorVenueSectorNotFound:
+			case lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorBookedSeatNotFound, lib.ErrorSeatHoldNotFound, lib.ErrorGarudaIDNotFound, lib.ErrorVenueSectorNotFound, lib.ErrorVoucherNotFound:
 				lib.RespondError(ctx, http.StatusNotFound, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorGetGarudaID, lib.ErrorTransactionPaylabs:
+			case lib.ErrorPaylabsUnavailable:
+				lib.RespondError(ctx, http.StatusServiceUnavailable, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorGetGarudaID, lib.ErrorTransactionPaylabs, lib.ErrorPaylabsRequestInvalid, lib.ErrorPaylabsTransactionDuplicate, lib.ErrorPaylabsTransactionNotFound, lib.ErrorPaylabsUnauthorized, lib.ErrorPaylabsInvalidResponse, lib.ErrorPaymentGatewayKeyInvalid:
 				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, tixErr.Code, h.Env.App.Debug)
 			default:
 				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
@@ -156,9 +170,11 @@ func (h *EventTransactionHandlerImpl) CreateTransaction(ctx *gin.Context) {
 // @Param eventId path string true "Event ID"
 // @Param ticketCategoryId path string true "Ticket Category ID"
 // @Param request body dto.CreateEventTransaction true "Create event ticket transaction"
+// @Param Idempotency-Key header string false "Unique key of the order, repeated request with the same key returns the first final response"
 // @Success 200 {object} lib.APIResponse{data=dto.EventTransactionResponse} "Order created"
 // @Failure 400 {object} lib.HTTPError "Invalid request body"
 // @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 409 {object} lib.HTTPError "Idempotency key is reused with different request or still in progress"
 // @Failure 500 {object} lib.HTTPError "Internal server error"
 // @Router /events/{eventId}/ticket-categories/{ticketCategoryId}/order/v2 [post]
 func (h *EventTransactionHandlerImpl) CreateTransactionV2(ctx *gin.Context) {
@@ -225,11 +241,12 @@ func (h *EventTransactionHandlerImpl) CreateTransactionV2(ctx *gin.Context) {
 			switch *tixErr {
 			case lib.ErrorEventSaleIsPaused, lib.ErrorEventSaleIsNotStartedYet, lib.ErrorEventSaleAlreadyOver:
 				lib.RespondError(ctx, http.StatusForbidden, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorSeatIsAlreadyBooked, lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorOrderInformationIsAlreadyBook, lib.ErrorGarudaIDInvalid, lib.ErrorGarudaIDRejected, lib.ErrorGarudaIDBlacklisted, lib.ErrorGarudaIDAlreadyUsed, lib.ErrorDuplicateGarudaIDPayload, lib.TransactionWithoutAdultError:
+			case lib.ErrorSeatIsAlreadyBooked, lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorPurchaseLimitEmailReached, lib.ErrorPurchaseLimitGarudaIDReached, lib.ErrorPurchaseLimitIPReached, lib.ErrorOrderInformationIsAlreadyBook, lib.ErrorGarudaIDInvalid, lib.ErrorGarudaIDRejected, lib.ErrorGarudaIDBlacklisted, lib.ErrorGarudaIDAlreadyUsed, lib.ErrorDuplicateGarudaIDPayload, lib.TransactionWithoutAdultError,
+				lib.ErrorVoucherInactive, lib.ErrorVoucherNotApplicable, lib.ErrorVoucherUsageLimitReached, lib.ErrorVoucherEmailLimitReached, lib.ErrorVoucherGarudaIDLimitReached, lib.ErrorPriceTierSoldOut:
 				lib.RespondError(ctx, http.StatusConflict, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorEventIdInvalid, lib.ErrorTicketCategoryInvalid, lib.ErrorFailedToBookSeat, lib.ErrorPaymentMethodInvalid, lib.ErrorBadRequest:
+			case lib.ErrorEventIdInvalid, lib.ErrorTicketCategoryInvalid, lib.ErrorFailedToBookSeat, lib.ErrorSeatHoldMismatch, lib.ErrorSeatNotEligible, lib.ErrorPaymentMethodInvalid, lib.ErrorBadRequest:
 				lib.RespondError(ctx, http.StatusBadRequest, "error", err, tixErr.Code, h.Env.App.Debug)
-			case lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorBookedSeatNotFound, lib.ErrorGarudaIDNotFound, lib.ErrorVenueSectorNotFound:
+			case lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorBookedSeatNotFound, lib.ErrorSeatHoldNotFound, lib.ErrorGarudaIDNotFound, lib.ErrorVenueSectorNotFound, lib.ErrorVoucherNotFound:
 				lib.RespondError(ctx, http.StatusNotFound, "error", err, tixErr.Code, h.Env.App.Debug)
 			case lib.ErrorGetGarudaID, lib.ErrorTransactionPaylabs:
 				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, tixErr.Code, h.Env.App.Debug)
@@ -245,6 +262,94 @@ func (h *EventTransactionHandlerImpl) CreateTransactionV2(ctx *gin.Context) {
 	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
 }
 
+// @Summary Create event ticket cart transaction
+// @Description Create a single transaction of items across ticket categories of the same event
+// @Tags events
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param request body dto.CreateEventTransactionCart true "Create event ticket cart transaction"
+// @Param Idempotency-Key header string false "Unique key of the order, repeated request with the same key returns the first final response"
+// @Success 200 {object} lib.APIResponse{data=dto.EventTransactionCartResponse} "Order created"
+// @Failure 400 {object} lib.HTTPError "Invalid request body"
+// @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 409 {object} lib.HTTPError "Out of stock, limit exceeded or idempotency key conflict"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/orders [post]
+func (h *EventTransactionHandlerImpl) CreateCartTransaction(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+
+			mappedError := lib.MapErrorGetEventByIdParams(fieldErr)
+			if mappedError != nil {
+				var tixErr *lib.TIXError
+				if errors.As(mappedError, &tixErr) {
+					lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+					return
+				}
+			}
+
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var request dto.CreateEventTransactionCart
+
+	if err := ctx.ShouldBind(&request); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, err.Error(), err, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	if len(request.Items) < 1 {
+		lib.RespondError(ctx, http.StatusBadRequest, "transaction items cannot be empty", &lib.ErrorNilTransactionItem, lib.ErrorNilTransactionItem.Code, h.Env.App.Debug)
+		return
+	}
+	if err := h.Validator.Struct(request); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTransactionService.CreateEventTransactionCart(ctx, uriParams.EventID, request)
+	if err != nil {
+		log.Error().Err(err).Msg("error create event cart transaction")
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.ErrorEventSaleIsPaused, lib.ErrorEventSaleIsNotStartedYet, lib.ErrorEventSaleAlreadyOver:
+				lib.RespondError(ctx, http.StatusForbidden, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorPurchaseLimitEmailReached, lib.ErrorPurchaseLimitGarudaIDReached, lib.ErrorPurchaseLimitIPReached, lib.ErrorOrderInformationIsAlreadyBook, lib.ErrorGarudaIDInvalid, lib.ErrorGarudaIDRejected, lib.ErrorGarudaIDBlacklisted, lib.ErrorGarudaIDAlreadyUsed, lib.ErrorDuplicateGarudaIDPayload, lib.TransactionWithoutAdultError,
+				lib.ErrorVoucherInactive, lib.ErrorVoucherNotApplicable, lib.ErrorVoucherUsageLimitReached, lib.ErrorVoucherEmailLimitReached, lib.ErrorVoucherGarudaIDLimitReached, lib.ErrorPriceTierSoldOut:
+				lib.RespondError(ctx, http.StatusConflict, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorEventIdInvalid, lib.ErrorTicketCategoryInvalid, lib.ErrorPaymentMethodInvalid, lib.ErrorBadRequest:
+				lib.RespondError(ctx, http.StatusBadRequest, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorGarudaIDNotFound, lib.ErrorVenueSectorNotFound, lib.ErrorVoucherNotFound:
+				lib.RespondError(ctx, http.StatusNotFound, "error", err, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorGetGarudaID:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, tixErr.Code, h.Env.App.Debug)
+			default:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+			}
+		} else {
+			sentry.CaptureException(err)
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
 // // @Summary Create VA snap for event ticket transaction
 // // @Description Create VA snap for event ticket transaction
 // // @Tags events
@@ -287,9 +392,12 @@ func (h *EventTransactionHandlerImpl) CallbackVASnap(ctx *gin.Context) {
 		case lib.ErrorOrderNotFound:
 			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
-		case lib.ErrorCallbackSignatureInvalid:
+		case lib.ErrorCallbackSignatureInvalid, lib.ErrorCallbackAmountMismatch, lib.ErrorCallbackCurrencyMismatch:
 			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
+		case lib.ErrorTransactionIsNotPending:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
+			// return
 		default:
 			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
 			// return
@@ -302,6 +410,14 @@ func (h *EventTransactionHandlerImpl) CallbackVASnap(ctx *gin.Context) {
 		}
 		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, string(respString), strconv.Itoa(tixErr.Code), tixErr.Error())
 		return
+	} else if err != nil {
+		// callback isn't applied, paylabs has to retry it
+		log.Error().Err(err).Msg("error processing payment callback")
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		headerString := ctx.GetString("headers")
+		bodyString := ctx.GetString("rawPayload")
+		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, "", strconv.Itoa(lib.ErrorInternalServer.Code), err.Error())
+		return
 	}
 	headerString := ctx.GetString("headers")
 	bodyString := ctx.GetString("rawPayload")
@@ -333,9 +449,12 @@ func (h *EventTransactionHandlerImpl) CallbackVASnapV2(ctx *gin.Context) {
 		case lib.ErrorOrderNotFound:
 			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
-		case lib.ErrorCallbackSignatureInvalid:
+		case lib.ErrorCallbackSignatureInvalid, lib.ErrorCallbackAmountMismatch, lib.ErrorCallbackCurrencyMismatch:
 			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
+		case lib.ErrorTransactionIsNotPending:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
+			// return
 		default:
 			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
 			// return
@@ -348,6 +467,14 @@ func (h *EventTransactionHandlerImpl) CallbackVASnapV2(ctx *gin.Context) {
 		}
 		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, string(respString), strconv.Itoa(tixErr.Code), tixErr.Error())
 		return
+	} else if err != nil {
+		// callback isn't applied, paylabs has to retry it
+		log.Error().Err(err).Msg("error processing payment callback")
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		headerString := ctx.GetString("headers")
+		bodyString := ctx.GetString("rawPayload")
+		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, "", strconv.Itoa(lib.ErrorInternalServer.Code), err.Error())
+		return
 	}
 	headerString := ctx.GetString("headers")
 	bodyString := ctx.GetString("rawPayload")
@@ -381,9 +508,12 @@ func (h *EventTransactionHandlerImpl) CallbackQRISPaylabs(ctx *gin.Context) {
 		case lib.ErrorOrderNotFound:
 			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
-		case lib.ErrorCallbackSignatureInvalid:
+		case lib.ErrorCallbackSignatureInvalid, lib.ErrorCallbackAmountMismatch, lib.ErrorCallbackCurrencyMismatch:
 			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
+		case lib.ErrorTransactionIsNotPending:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
+			// return
 		default:
 			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
 			// return
@@ -396,6 +526,14 @@ func (h *EventTransactionHandlerImpl) CallbackQRISPaylabs(ctx *gin.Context) {
 		}
 		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, string(respString), strconv.Itoa(tixErr.Code), tixErr.Error())
 		return
+	} else if err != nil {
+		// callback isn't applied, paylabs has to retry it
+		log.Error().Err(err).Msg("error processing payment callback")
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		headerString := ctx.GetString("headers")
+		bodyString := ctx.GetString("rawPayload")
+		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, "", strconv.Itoa(lib.ErrorInternalServer.Code), err.Error())
+		return
 	}
 	headerString := ctx.GetString("headers")
 	bodyString := ctx.GetString("rawPayload")
@@ -427,9 +565,12 @@ func (h *EventTransactionHandlerImpl) CallbackQRISPaylabsV2(ctx *gin.Context) {
 		case lib.ErrorOrderNotFound:
 			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
-		case lib.ErrorCallbackSignatureInvalid:
+		case lib.ErrorCallbackSignatureInvalid, lib.ErrorCallbackAmountMismatch, lib.ErrorCallbackCurrencyMismatch:
 			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
 			// return
+		case lib.ErrorTransactionIsNotPending:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), err, tixErr.Code, h.Env.App.Debug)
+			// return
 		default:
 			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
 			// return
@@ -442,6 +583,14 @@ func (h *EventTransactionHandlerImpl) CallbackQRISPaylabsV2(ctx *gin.Context) {
 		}
 		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, string(respString), strconv.Itoa(tixErr.Code), tixErr.Error())
 		return
+	} else if err != nil {
+		// callback isn't applied, paylabs has to retry it
+		log.Error().Err(err).Msg("error processing payment callback")
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		headerString := ctx.GetString("headers")
+		bodyString := ctx.GetString("rawPayload")
+		_, _ = h.PaymentLogsService.Create(ctx, bodyString, headerString, "", strconv.Itoa(lib.ErrorInternalServer.Code), err.Error())
+		return
 	}
 	headerString := ctx.GetString("headers")
 	bodyString := ctx.GetString("rawPayload")
@@ -597,3 +746,258 @@ func (h *EventTransactionHandlerImpl) GetTransactionDetails(ctx *gin.Context) {
 	log.Info().Interface("transactionDetails", res).Msg("found transaction details by id")
 	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
 }
+
+// @Summary Get ticket barcode
+// @Description Render ticket code of the ticket as qr, code128 or pdf417 png
+// @Tags events
+// @Produce image/png
+// @Param transactionId path string true "Transaction ID"
+// @Param ticketNumber path string true "Ticket number"
+// @Param format query string false "qr, code128 or pdf417, default qr"
+// @Success 200 {file} file "Barcode image"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 403 {object} lib.HTTPError "Transaction of token doesn't match"
+// @Failure 404 {object} lib.HTTPError "Ticket not found"
+// @Failure 409 {object} lib.HTTPError "Ticket is invalidated"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security BearerAuth
+// @Router /events/transactions/{transactionId}/tickets/{ticketNumber}/barcode [get]
+func (h *EventTransactionHandlerImpl) GetTicketBarcode(ctx *gin.Context) {
+	var uriParams dto.GetTicketBarcodeParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	if ctx.GetString("transaction_id") != uriParams.TransactionID {
+		lib.RespondError(ctx, http.StatusForbidden, "you are not allowed to access this transaction", nil, lib.MissmatchTxIDParameterBearerError.Code, h.Env.App.Debug)
+		return
+	}
+
+	var query dto.TicketBarcodeQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTransactionService.GetTicketBarcode(ctx, uriParams.TransactionID, uriParams.TicketNumber, query.Format)
+	if err != nil {
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.EventTicketNotFound:
+				lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+			case lib.ErrorTicketInvalidated:
+				lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+			default:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+			}
+		} else {
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	// barcode is the entry pass, so it's never kept by shared caches
+	ctx.Header("Cache-Control", "private, no-store")
+	ctx.Data(http.StatusOK, res.ContentType, res.Body)
+}
+
+// @Summary Get transaction status histories
+// @Description Get status timeline of transaction, including who change the status
+// @Tags admin
+// @Produce json
+// @Param transactionId path string true "Transaction ID"
+// @Success 200 {object} lib.APIResponse{data=[]dto.EventTransactionStatusHistoryResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/transactions/{transactionId}/status-histories [get]
+func (h *EventTransactionHandlerImpl) GetStatusHistories(ctx *gin.Context) {
+	var uriParams dto.GetTransactionDetails
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTransactionService.FindStatusHistories(ctx, uriParams.TransactionID)
+	if err != nil {
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Reconcile transaction payment
+// @Description Ask payment gateway for payment status of transaction and settle it when it's not in sync
+// @Tags admin
+// @Produce json
+// @Param orderNumber path string true "Order Number"
+// @Success 200 {object} lib.APIResponse{data=dto.PaymentReconciliationResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Order not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/transactions/orders/{orderNumber}/reconcile [post]
+func (h *EventTransactionHandlerImpl) ReconcileTransaction(ctx *gin.Context) {
+	var uriParams dto.ReconcileTransactionRequest
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.EventTransactionService.ReconcileTransaction(ctx, uriParams.OrderNumber)
+	if err != nil {
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.ErrorOrderNotFound:
+				lib.RespondError(ctx, http.StatusNotFound, "order not found", tixErr, tixErr.Code, h.Env.App.Debug)
+			default:
+				lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+			}
+		} else {
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get payment reconciliation discrepancies
+// @Description Get discrepancies reported by payment reconciliation, newest first
+// @Tags admin
+// @Produce json
+// @Param since query string false "RFC3339 time, default 24 hours ago"
+// @Param limit query int false "Max rows, default 100"
+// @Success 200 {object} lib.APIResponse{data=[]dto.PaymentReconciliationResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/payment-reconciliations [get]
+func (h *EventTransactionHandlerImpl) GetPaymentReconciliations(ctx *gin.Context) {
+	var query dto.GetPaymentReconciliationsRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your query", err, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	if query.Since.IsZero() {
+		query.Since = time.Now().Add(-24 * time.Hour)
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	res, err := h.EventTransactionService.FindPaymentReconciliations(ctx, query.Since, query.Limit)
+	if err != nil {
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get failed payment callbacks
+// @Description Get callbacks logged with error or accepted but not applied, newest first. is_resolved is true when one of its replay succeed
+// @Tags admin
+// @Produce json
+// @Param since query string false "RFC3339 time, default 7 days ago"
+// @Param transaction_id query string false "Transaction ID"
+// @Param limit query int false "Max rows, default 100"
+// @Success 200 {object} lib.APIResponse{data=[]dto.PaymentLogResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/payment-logs/failed [get]
+func (h *EventTransactionHandlerImpl) GetFailedPaymentCallbacks(ctx *gin.Context) {
+	var query dto.GetFailedPaymentCallbacksRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your query", err, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	if query.Since.IsZero() {
+		query.Since = time.Now().Add(-7 * 24 * time.Hour)
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+
+	res, err := h.PaymentLogsService.FindFailedCallbacks(ctx, query.Since, query.TransactionID, query.Limit)
+	if err != nil {
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Replay failed payment callback
+// @Description Re-run logged failed callback through the current callback handler. Signature is verified again unless skip_signature_verification is set
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param paymentLogId path int true "Payment log ID"
+// @Param request body dto.ReplayPaymentCallbackRequest false "Replay option"
+// @Success 200 {object} lib.APIResponse{data=dto.ReplayPaymentCallbackResponse} "Replayed, status_code is the callback handler result"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Payment log not found"
+// @Failure 409 {object} lib.HTTPError "Payment log can't be replayed"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/payment-logs/{paymentLogId}/replay [post]
+func (h *EventTransactionHandlerImpl) ReplayPaymentCallback(ctx *gin.Context) {
+	var uriParams dto.ReplayPaymentCallbackParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.ReplayPaymentCallbackRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+	}
+
+	res, err := h.PaymentLogsService.ReplayCallback(ctx, uriParams.PaymentLogID, req.SkipSignatureVerification)
+	if err != nil {
+		log.Error().Err(err).Int("paymentLogId", uriParams.PaymentLogID).Msg("error replay payment callback")
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) {
+			switch *tixErr {
+			case lib.ErrorPaymentLogNotFound:
+				lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+				return
+			case lib.ErrorPaymentLogNotFailed, lib.ErrorPaymentLogNotReplayable:
+				lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+				return
+			}
+		}
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
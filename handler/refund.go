@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type RefundHandler interface {
+	RequestRefund(ctx *gin.Context)
+	GetRefunds(ctx *gin.Context)
+	CompleteManualRefund(ctx *gin.Context)
+	ResolveRefund(ctx *gin.Context)
+}
+
+type RefundHandlerImpl struct {
+	Env           *config.EnvironmentVariable
+	RefundService service.RefundService
+	Validator     *validator.Validate
+}
+
+func NewRefundHandler(
+	env *config.EnvironmentVariable,
+	refundService service.RefundService,
+	validator *validator.Validate,
+) RefundHandler {
+	return &RefundHandlerImpl{
+		Env:           env,
+		RefundService: refundService,
+		Validator:     validator,
+	}
+}
+
+// @Summary Request transaction refund
+// @Description Refund paid transaction of canceled or postponed event. Empty item_ids refund the whole remaining amount
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param transactionId path string true "Transaction ID"
+// @Param request body dto.RefundTransactionRequest true "Refund request"
+// @Success 200 {object} lib.APIResponse{data=dto.EventTransactionRefundResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Not Found"
+// @Failure 409 {object} lib.HTTPError "Transaction can't be refunded"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/transactions/{transactionId}/refunds [post]
+func (h *RefundHandlerImpl) RequestRefund(ctx *gin.Context) {
+	var uriParams dto.GetTransactionDetails
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.RefundTransactionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.RefundService.RequestRefund(ctx, uriParams.TransactionID, req)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", uriParams.TransactionID).Msg("error request refund")
+		h.respondRefundError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get transaction refunds
+// @Description Get refunds of transaction from the oldest
+// @Tags admin
+// @Produce json
+// @Param transactionId path string true "Transaction ID"
+// @Success 200 {object} lib.APIResponse{data=[]dto.EventTransactionRefundResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/transactions/{transactionId}/refunds [get]
+func (h *RefundHandlerImpl) GetRefunds(ctx *gin.Context) {
+	var uriParams dto.GetTransactionDetails
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.RefundService.FindRefunds(ctx, uriParams.TransactionID)
+	if err != nil {
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Complete manual refund
+// @Description Mark refund which gateway can't process as transferred manually
+// @Tags admin
+// @Produce json
+// @Param refundId path int true "Refund ID"
+// @Success 200 {object} lib.APIResponse{data=dto.EventTransactionRefundResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Refund not found"
+// @Failure 409 {object} lib.HTTPError "Refund doesn't need manual completion"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/refunds/{refundId}/complete [post]
+func (h *RefundHandlerImpl) CompleteManualRefund(ctx *gin.Context) {
+	var uriParams dto.GetRefundByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.RefundService.CompleteManualRefund(ctx, uriParams.RefundID)
+	if err != nil {
+		log.Error().Err(err).Int("refundId", uriParams.RefundID).Msg("error complete manual refund")
+		h.respondRefundError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Resolve stuck refund
+// @Description Finish refund which is stuck in processing, ex: the server died while calling gateway. Status is taken from the gateway dashboard
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param refundId path int true "Refund ID"
+// @Param request body dto.ResolveRefundRequest true "Gateway result"
+// @Success 200 {object} lib.APIResponse{data=dto.EventTransactionRefundResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Refund not found"
+// @Failure 409 {object} lib.HTTPError "Refund is finished or still processed"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/refunds/{refundId}/resolve [post]
+func (h *RefundHandlerImpl) ResolveRefund(ctx *gin.Context) {
+	var uriParams dto.GetRefundByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.ResolveRefundRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			fieldErr := validationErrors[0]
+			lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+			return
+		}
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.RefundService.ResolveRefund(ctx, uriParams.RefundID, req)
+	if err != nil {
+		log.Error().Err(err).Int("refundId", uriParams.RefundID).Msg("error resolve refund")
+		h.respondRefundError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+func (h *RefundHandlerImpl) respondRefundError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorOrderNotFound, lib.ErrorEventNotFound, lib.ErrorRefundItemNotFound, lib.ErrorRefundNotFound:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorRefundTransactionNotPaid,
+			lib.ErrorRefundInProgress,
+			lib.ErrorRefundItemAlreadyRefunded,
+			lib.ErrorRefundEventNotEligible,
+			lib.ErrorRefundAmountExceeded,
+			lib.ErrorRefundNotManual,
+			lib.ErrorRefundNotProcessing,
+			lib.ErrorRefundNotStale:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type SeatHoldHandler interface {
+	Create(ctx *gin.Context)
+	Release(ctx *gin.Context)
+}
+
+type SeatHoldHandlerImpl struct {
+	Env             *config.EnvironmentVariable
+	SeatHoldService service.SeatHoldService
+}
+
+func NewSeatHoldHandler(
+	env *config.EnvironmentVariable,
+	seatHoldService service.SeatHoldService,
+) SeatHoldHandler {
+	return &SeatHoldHandlerImpl{
+		Env:             env,
+		SeatHoldService: seatHoldService,
+	}
+}
+
+// @Summary Hold seats
+// @Description Reserve seats of ticket category while buyer fills the order. Held seats are released when the hold expires unless it's used as seat_hold_id of order with the same email
+// @Param X-Admission-Token header string false "Admission token of waiting room, required while the waiting room is active"
+// @Tags events
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket Category ID"
+// @Param request body dto.CreateSeatHoldRequest true "Seats to hold"
+// @Success 201 {object} lib.APIResponse{data=dto.SeatHoldResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 403 {object} lib.HTTPError "Event is not on sale or waiting room admission is required"
+// @Failure 404 {object} lib.HTTPError "Event, ticket category or seat not found"
+// @Failure 409 {object} lib.HTTPError "Seat is already booked, ticket is out of stock or purchase limit is reached"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/ticket-categories/{ticketCategoryId}/seat-holds [post]
+func (h *SeatHoldHandlerImpl) Create(ctx *gin.Context) {
+	var uriParams dto.GetDetailEventTicketCategoryByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.CreateSeatHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatHoldService.Create(ctx, uriParams.EventID, uriParams.TicketCategoryID, ctx.ClientIP(), req)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", uriParams.EventID).Str("ticketCategoryId", uriParams.TicketCategoryID).Msg("error hold seats")
+		h.respondSeatHoldError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusCreated, "success", res)
+}
+
+// @Summary Release seat hold
+// @Description Give back held seats before the hold expires, only the buyer who made the hold can release it
+// @Tags events
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param holdId path string true "Seat Hold ID"
+// @Param email query string true "Email of the buyer who made the hold"
+// @Success 200 {object} lib.APIResponse "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 404 {object} lib.HTTPError "Seat hold not found or expired"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/seat-holds/{holdId} [delete]
+func (h *SeatHoldHandlerImpl) Release(ctx *gin.Context) {
+	var uriParams dto.GetSeatHoldByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var query dto.ReleaseSeatHoldQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	err := h.SeatHoldService.Release(ctx, uriParams.EventID, uriParams.HoldID, query.Email)
+	if err != nil {
+		h.respondSeatHoldError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", nil)
+}
+
+func (h *SeatHoldHandlerImpl) respondBindError(ctx *gin.Context, err error) {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		fieldErr := validationErrors[0]
+		lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+}
+
+func (h *SeatHoldHandlerImpl) respondSeatHoldError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorSeatHoldNotFound, lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound, lib.ErrorVenueSectorNotFound, lib.ErrorBookedSeatNotFound:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorSeatmapNotAvailable, lib.ErrorSeatNotInTicketCategory, lib.ErrorSeatNotEligible, lib.ErrorBadRequest:
+			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorEventSaleIsPaused, lib.ErrorEventSaleIsNotStartedYet, lib.ErrorEventSaleAlreadyOver:
+			lib.RespondError(ctx, http.StatusForbidden, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorSeatIsAlreadyBooked, lib.ErrorTicketIsOutOfStock, lib.ErrorPurchaseQuantityExceedTheLimit, lib.ErrorPurchaseLimitEmailReached, lib.ErrorPurchaseLimitIPReached:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, tixErr.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
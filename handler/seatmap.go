@@ -0,0 +1,458 @@
+package handler
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/service"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type SeatmapHandler interface {
+	GetSectorSeatmap(ctx *gin.Context)
+	ReplaceSectorSeatmap(ctx *gin.Context)
+	ImportSectorSeatmap(ctx *gin.Context)
+	UpdateSectorSeats(ctx *gin.Context)
+	GetEventSeatmapOverrides(ctx *gin.Context)
+	UpsertEventSeatmapOverrides(ctx *gin.Context)
+	DeleteEventSeatmapOverrides(ctx *gin.Context)
+	RenderSectorSeatmap(ctx *gin.Context)
+	RenderEventSeatmap(ctx *gin.Context)
+	RenderTicketSeatmap(ctx *gin.Context)
+}
+
+type SeatmapHandlerImpl struct {
+	Env            *config.EnvironmentVariable
+	SeatmapService service.SeatmapService
+}
+
+func NewSeatmapHandler(
+	env *config.EnvironmentVariable,
+	seatmapService service.SeatmapService,
+) SeatmapHandler {
+	return &SeatmapHandlerImpl{
+		Env:            env,
+		SeatmapService: seatmapService,
+	}
+}
+
+// @Summary Get sector seatmap
+// @Description Get seat grid of venue sector
+// @Tags admin
+// @Produce json
+// @Param venueId path string true "Venue ID"
+// @Param sectorId path string true "Sector ID"
+// @Success 200 {object} lib.APIResponse{data=dto.VenueSectorSeatmapResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Venue sector not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/venues/{venueId}/sectors/{sectorId}/seatmap [get]
+func (h *SeatmapHandlerImpl) GetSectorSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetVenueSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.SeatmapService.GetSectorSeatmap(ctx, uriParams.VenueID, uriParams.SectorID)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Replace sector seatmap
+// @Description Replace seat grid of venue sector. Cell without seat is a gap or aisle, empty seats fill the whole grid. Rejected once any seat of the sector is booked
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param venueId path string true "Venue ID"
+// @Param sectorId path string true "Sector ID"
+// @Param request body dto.ReplaceSectorSeatmapRequest true "Seat grid"
+// @Success 200 {object} lib.APIResponse{data=dto.VenueSectorSeatmapResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Venue sector not found"
+// @Failure 409 {object} lib.HTTPError "Sector already has booked seats"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/venues/{venueId}/sectors/{sectorId}/seatmap [put]
+func (h *SeatmapHandlerImpl) ReplaceSectorSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetVenueSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.ReplaceSectorSeatmapRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.ReplaceSectorSeatmap(ctx, uriParams.VenueID, uriParams.SectorID, req)
+	if err != nil {
+		log.Warn().Err(err).Str("sectorId", uriParams.SectorID).Msg("error replace sector seatmap")
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Import sector seatmap
+// @Description Replace seat grid of venue sector from csv or xlsx file with header row, column, row_label, label and status
+// @Tags admin
+// @Produce json
+// @Accept multipart/form-data
+// @Param venueId path string true "Venue ID"
+// @Param sectorId path string true "Sector ID"
+// @Param file formData file true "Seatmap csv or xlsx"
+// @Success 200 {object} lib.APIResponse{data=dto.VenueSectorSeatmapResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Venue sector not found"
+// @Failure 409 {object} lib.HTTPError "Sector already has booked seats"
+// @Failure 413 {object} lib.HTTPError "File is too large"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/venues/{venueId}/sectors/{sectorId}/seatmap/import [post]
+func (h *SeatmapHandlerImpl) ImportSectorSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetVenueSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "file is required", err, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	defer file.Close()
+
+	// int64(h.Env.FileUpload.MaxSize)<<20 -> Calculate as MegaBytes
+	if header.Size > int64(h.Env.FileUpload.MaxSize)<<20 {
+		lib.RespondError(ctx, http.StatusRequestEntityTooLarge, lib.ErrorSeatmapFileSizeExceeds.Error(), &lib.ErrorSeatmapFileSizeExceeds, lib.ErrorSeatmapFileSizeExceeds.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.SeatmapService.ImportSectorSeatmap(ctx, uriParams.VenueID, uriParams.SectorID, header.Filename, file)
+	if err != nil {
+		log.Warn().Err(err).Str("sectorId", uriParams.SectorID).Str("fileName", header.Filename).Msg("error import sector seatmap")
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Update sector seats
+// @Description Edit label, row label or status of seats in venue sector, new seat must be inside the grid. Booked or held seats can't be edited
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param venueId path string true "Venue ID"
+// @Param sectorId path string true "Sector ID"
+// @Param request body dto.UpdateSectorSeatsRequest true "Seats"
+// @Success 200 {object} lib.APIResponse{data=dto.VenueSectorSeatmapResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Venue sector not found"
+// @Failure 409 {object} lib.HTTPError "Seats are booked or held"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/venues/{venueId}/sectors/{sectorId}/seatmap/seats [patch]
+func (h *SeatmapHandlerImpl) UpdateSectorSeats(ctx *gin.Context) {
+	var uriParams dto.GetVenueSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.UpdateSectorSeatsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.UpdateSectorSeats(ctx, uriParams.VenueID, uriParams.SectorID, req)
+	if err != nil {
+		log.Warn().Err(err).Str("sectorId", uriParams.SectorID).Msg("error update sector seats")
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get event seatmap overrides
+// @Description Get seats of venue sector which are changed only for the event
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param sectorId path string true "Sector ID"
+// @Success 200 {object} lib.APIResponse{data=[]dto.SectorSeatmapRowColumnResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Event or venue sector not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/sectors/{sectorId}/seatmap-overrides [get]
+func (h *SeatmapHandlerImpl) GetEventSeatmapOverrides(ctx *gin.Context) {
+	var uriParams dto.GetEventSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.SeatmapService.GetEventSeatmapOverrides(ctx, uriParams.EventID, uriParams.SectorID)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Upsert event seatmap overrides
+// @Description Change status or label of seats only for the event, e.g. block seats for camera
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param sectorId path string true "Sector ID"
+// @Param request body dto.UpsertEventSeatmapOverridesRequest true "Seats"
+// @Success 200 {object} lib.APIResponse{data=[]dto.SectorSeatmapRowColumnResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Event, venue sector or seat not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/sectors/{sectorId}/seatmap-overrides [put]
+func (h *SeatmapHandlerImpl) UpsertEventSeatmapOverrides(ctx *gin.Context) {
+	var uriParams dto.GetEventSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.UpsertEventSeatmapOverridesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.UpsertEventSeatmapOverrides(ctx, uriParams.EventID, uriParams.SectorID, req)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", uriParams.EventID).Str("sectorId", uriParams.SectorID).Msg("error upsert event seatmap overrides")
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Delete event seatmap overrides
+// @Description Put seats of the event back to the venue seatmap
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param sectorId path string true "Sector ID"
+// @Param request body dto.DeleteEventSeatmapOverridesRequest true "Seats"
+// @Success 200 {object} lib.APIResponse "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Event or venue sector not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/sectors/{sectorId}/seatmap-overrides [delete]
+func (h *SeatmapHandlerImpl) DeleteEventSeatmapOverrides(ctx *gin.Context) {
+	var uriParams dto.GetEventSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.DeleteEventSeatmapOverridesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	err := h.SeatmapService.DeleteEventSeatmapOverrides(ctx, uriParams.EventID, uriParams.SectorID, req)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", nil)
+}
+
+// @Summary Render sector seatmap
+// @Description Render seat layout of venue sector as svg or png
+// @Tags admin
+// @Produce image/svg+xml,image/png
+// @Param venueId path string true "Venue ID"
+// @Param sectorId path string true "Sector ID"
+// @Param format query string false "svg or png, default svg"
+// @Success 200 {file} file "Seatmap image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Venue sector not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/venues/{venueId}/sectors/{sectorId}/seatmap/image [get]
+func (h *SeatmapHandlerImpl) RenderSectorSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetVenueSectorByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	var query dto.SeatmapImageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.RenderSectorSeatmap(ctx, uriParams.VenueID, uriParams.SectorID, query.Format)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	h.respondSeatmapImage(ctx, res, "public")
+}
+
+// @Summary Render event seatmap
+// @Description Render seat status of ticket category sector as svg or png, booked seats are unavailable
+// @Tags events
+// @Produce image/svg+xml,image/png
+// @Param eventId path string true "Event ID"
+// @Param ticketCategoryId path string true "Ticket Category ID"
+// @Param format query string false "svg or png, default svg"
+// @Success 200 {file} file "Seatmap image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 404 {object} lib.HTTPError "Not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/ticket-categories/{ticketCategoryId}/seatmap/image [get]
+func (h *SeatmapHandlerImpl) RenderEventSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetDetailEventTicketCategoryByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	var query dto.SeatmapImageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.RenderEventSeatmap(ctx, uriParams.EventID, uriParams.TicketCategoryID, query.Format)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	h.respondSeatmapImage(ctx, res, "public")
+}
+
+// @Summary Render ticket seatmap
+// @Description Render sector of the ticket as svg or png with seat of the ticket highlighted
+// @Tags events
+// @Produce image/svg+xml,image/png
+// @Param transactionId path string true "Transaction ID"
+// @Param ticketNumber path string true "Ticket number"
+// @Param format query string false "svg or png, default svg"
+// @Success 200 {file} file "Seatmap image"
+// @Success 304 "Not modified"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 403 {object} lib.HTTPError "Transaction of token doesn't match"
+// @Failure 404 {object} lib.HTTPError "Ticket not found or ticket doesn't have seat"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security BearerAuth
+// @Router /events/transactions/{transactionId}/tickets/{ticketNumber}/seatmap/image [get]
+func (h *SeatmapHandlerImpl) RenderTicketSeatmap(ctx *gin.Context) {
+	var uriParams dto.GetTicketSeatmapImageParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	if ctx.GetString("transaction_id") != uriParams.TransactionID {
+		lib.RespondError(ctx, http.StatusForbidden, "you are not allowed to access this transaction", nil, lib.MissmatchTxIDParameterBearerError.Code, h.Env.App.Debug)
+		return
+	}
+
+	var query dto.SeatmapImageQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.SeatmapService.RenderTicketSeatmap(ctx, uriParams.TransactionID, uriParams.TicketNumber, query.Format)
+	if err != nil {
+		h.respondSeatmapError(ctx, err)
+		return
+	}
+
+	h.respondSeatmapImage(ctx, res, "private")
+}
+
+// respondSeatmapImage write image with its etag, client holding the same etag gets not modified
+func (h *SeatmapHandlerImpl) respondSeatmapImage(ctx *gin.Context, res dto.SeatmapImageResponse, cacheability string) {
+	etag := `"` + res.ETag + `"`
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheability, int(h.Env.SeatmapImage.CacheMaxAge.Seconds())))
+
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	ctx.Data(http.StatusOK, res.ContentType, res.Body)
+}
+
+func (h *SeatmapHandlerImpl) respondBindError(ctx *gin.Context, err error) {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		fieldErr := validationErrors[0]
+		lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+}
+
+func (h *SeatmapHandlerImpl) respondSeatmapError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorVenueNotFound, lib.ErrorVenueSectorNotFound, lib.ErrorEventNotFound, lib.ErrorBookedSeatNotFound,
+			lib.ErrorTicketCategoryNotFound, lib.EventTicketNotFound, lib.ErrorVenueSectorDoesntHaveSeatmap:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorSeatmapInvalid, lib.ErrorSeatmapFileInvalid, lib.ErrorSeatmapTicketCategoryInvalid, lib.ErrorSeatmapGridTooLarge:
+			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorSeatmapHasBookings, lib.ErrorSeatmapSeatsBooked:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type VoucherHandler interface {
+	Create(ctx *gin.Context)
+	GetByEventId(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	Preview(ctx *gin.Context)
+}
+
+type VoucherHandlerImpl struct {
+	Env            *config.EnvironmentVariable
+	VoucherService service.VoucherService
+	Validator      *validator.Validate
+}
+
+func NewVoucherHandler(
+	env *config.EnvironmentVariable,
+	voucherService service.VoucherService,
+	validator *validator.Validate,
+) VoucherHandler {
+	return &VoucherHandlerImpl{
+		Env:            env,
+		VoucherService: voucherService,
+		Validator:      validator,
+	}
+}
+
+// @Summary Create voucher
+// @Description Create voucher code of event, empty ticket_category_id makes it apply to every category
+// @Tags admin
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param request body dto.CreateVoucherRequest true "Voucher"
+// @Success 200 {object} lib.APIResponse{data=dto.VoucherResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Event or ticket category not found"
+// @Failure 409 {object} lib.HTTPError "Voucher code already exists"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/vouchers [post]
+func (h *VoucherHandlerImpl) Create(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.CreateVoucherRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.VoucherService.Create(ctx, uriParams.EventID, req)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", uriParams.EventID).Msg("error create voucher")
+		h.respondVoucherError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get vouchers of event
+// @Description Get vouchers of event from the newest
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Success 200 {object} lib.APIResponse{data=[]dto.VoucherResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/vouchers [get]
+func (h *VoucherHandlerImpl) GetByEventId(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.VoucherService.FindByEventId(ctx, uriParams.EventID)
+	if err != nil {
+		lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Delete voucher
+// @Description Stop voucher from being used, transactions which already use it keep their discount
+// @Tags admin
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param voucherId path string true "Voucher ID"
+// @Success 200 {object} lib.APIResponse "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Unauthorized"
+// @Failure 404 {object} lib.HTTPError "Voucher not found"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Security ApiKeyAuth
+// @Router /admin/events/{eventId}/vouchers/{voucherId} [delete]
+func (h *VoucherHandlerImpl) Delete(ctx *gin.Context) {
+	var uriParams dto.GetVoucherByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	err := h.VoucherService.Delete(ctx, uriParams.EventID, uriParams.VoucherID)
+	if err != nil {
+		h.respondVoucherError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", nil)
+}
+
+// @Summary Preview voucher
+// @Description Calculate discount of voucher on the items without using it
+// @Tags events
+// @Produce json
+// @Accept json
+// @Param eventId path string true "Event ID"
+// @Param request body dto.PreviewVoucherRequest true "Voucher and items"
+// @Success 200 {object} lib.APIResponse{data=dto.PreviewVoucherResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 404 {object} lib.HTTPError "Voucher or ticket category not found"
+// @Failure 409 {object} lib.HTTPError "Voucher can't be used"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/vouchers/preview [post]
+func (h *VoucherHandlerImpl) Preview(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	var req dto.PreviewVoucherRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.respondBindError(ctx, err)
+		return
+	}
+
+	res, err := h.VoucherService.Preview(ctx, uriParams.EventID, req)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", uriParams.EventID).Str("code", req.Code).Msg("error preview voucher")
+		h.respondVoucherError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+func (h *VoucherHandlerImpl) respondBindError(ctx *gin.Context, err error) {
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		fieldErr := validationErrors[0]
+		lib.RespondError(ctx, http.StatusBadRequest, fieldErr.Field()+" is invalid", fieldErr, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+	lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+}
+
+func (h *VoucherHandlerImpl) respondVoucherError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorVoucherNotFound, lib.ErrorEventNotFound, lib.ErrorTicketCategoryNotFound:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorVoucherInvalid:
+			lib.RespondError(ctx, http.StatusBadRequest, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorVoucherCodeAlreadyExists,
+			lib.ErrorVoucherInactive,
+			lib.ErrorVoucherNotApplicable,
+			lib.ErrorVoucherUsageLimitReached,
+			lib.ErrorVoucherEmailLimitReached,
+			lib.ErrorVoucherGarudaIDLimitReached:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
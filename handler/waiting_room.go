@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const queueTokenHeader = "X-Queue-Token"
+
+type WaitingRoomHandler interface {
+	Join(ctx *gin.Context)
+	Status(ctx *gin.Context)
+}
+
+type WaitingRoomHandlerImpl struct {
+	Env                *config.EnvironmentVariable
+	WaitingRoomService service.WaitingRoomService
+}
+
+func NewWaitingRoomHandler(
+	env *config.EnvironmentVariable,
+	waitingRoomService service.WaitingRoomService,
+) WaitingRoomHandler {
+	return &WaitingRoomHandlerImpl{
+		Env:                env,
+		WaitingRoomService: waitingRoomService,
+	}
+}
+
+// @Summary Join waiting room
+// @Description Join waiting room of event and get queue token with position and estimated wait. Admission token is given right away when the queue is empty
+// @Tags events
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Success 200 {object} lib.APIResponse{data=dto.WaitingRoomResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 404 {object} lib.HTTPError "Event not found"
+// @Failure 409 {object} lib.HTTPError "Waiting room is not active"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/waiting-room [post]
+func (h *WaitingRoomHandlerImpl) Join(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.WaitingRoomService.Join(ctx, uriParams.EventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", uriParams.EventID).Msg("error join waiting room")
+		h.respondWaitingRoomError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+// @Summary Get waiting room status
+// @Description Get position of queue token, admission token is given once the position is reached
+// @Tags events
+// @Produce json
+// @Param eventId path string true "Event ID"
+// @Param X-Queue-Token header string true "Queue token from join"
+// @Success 200 {object} lib.APIResponse{data=dto.WaitingRoomResponse} "Success"
+// @Failure 400 {object} lib.HTTPError "Invalid request"
+// @Failure 401 {object} lib.HTTPError "Queue token is invalid or expired"
+// @Failure 404 {object} lib.HTTPError "Event not found"
+// @Failure 409 {object} lib.HTTPError "Waiting room is not active"
+// @Failure 500 {object} lib.HTTPError "Internal server error"
+// @Router /events/{eventId}/waiting-room/status [get]
+func (h *WaitingRoomHandlerImpl) Status(ctx *gin.Context) {
+	var uriParams dto.GetEventByIdParams
+	if err := ctx.ShouldBindUri(&uriParams); err != nil {
+		lib.RespondError(ctx, http.StatusBadRequest, "bad request. check your payload", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	queueToken := ctx.GetHeader(queueTokenHeader)
+	if queueToken == "" {
+		lib.RespondError(ctx, http.StatusBadRequest, queueTokenHeader+" header is required", nil, lib.ErrorBadRequest.Code, h.Env.App.Debug)
+		return
+	}
+
+	res, err := h.WaitingRoomService.Status(ctx, uriParams.EventID, queueToken)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", uriParams.EventID).Msg("error get waiting room status")
+		h.respondWaitingRoomError(ctx, err)
+		return
+	}
+
+	lib.RespondSuccess(ctx, http.StatusOK, "success", res)
+}
+
+func (h *WaitingRoomHandlerImpl) respondWaitingRoomError(ctx *gin.Context, err error) {
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) {
+		switch *tixErr {
+		case lib.ErrorEventNotFound:
+			lib.RespondError(ctx, http.StatusNotFound, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorWaitingRoomTokenInvalid:
+			lib.RespondError(ctx, http.StatusUnauthorized, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		case lib.ErrorWaitingRoomNotActive:
+			lib.RespondError(ctx, http.StatusConflict, tixErr.Error(), tixErr, tixErr.Code, h.Env.App.Debug)
+		default:
+			lib.RespondError(ctx, http.StatusInternalServerError, "error", err, tixErr.Code, h.Env.App.Debug)
+		}
+		return
+	}
+
+	lib.RespondError(ctx, http.StatusInternalServerError, "error", err, lib.ErrorInternalServer.Code, h.Env.App.Debug)
+}
@@ -8,132 +8,90 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog/log"
 )
 
-func IsValidPaylabsRequest(ctx *gin.Context, path, payload, publicKey string) (res bool) {
-	timestamp := ctx.GetHeader("X-TIMESTAMP")
-	signature := ctx.GetHeader("X-SIGNATURE")
-
-	// Decode base64 signature
-	binarySignature, err := base64.StdEncoding.DecodeString(signature)
+// GeneratePaylabsSignature sign `METHOD:path:sha256(body):timestamp` with our private key
+func GeneratePaylabsSignature(method, path string, body []byte, timestamp, privateKeyPEM string) (signature string, err error) {
+	privateKey, err := parsePaylabsPrivateKey(privateKeyPEM)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode base64 signature")
-		return false
+		return
 	}
 
-	// Compute SHA-256 hash of dataToSign
-	hash := sha256.Sum256([]byte(payload))
-	shaJson := fmt.Sprintf("%x", hash)
-	signatureAfter := fmt.Sprintf("POST:%s:%s:%s", path, shaJson, timestamp)
-	fmt.Println(signatureAfter)
-
-	// Parse the public key
-	block, _ := pem.Decode([]byte(publicKey))
-	if block == nil || block.Type != "PUBLIC KEY" {
-		log.Error().Msg("Failed to parse public key PEM block")
-		return false
-	}
-	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	hashed := sha256.Sum256([]byte(paylabsStringToSign(method, path, body, timestamp)))
+	rawSignature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed[:])
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to parse public key")
-		return false
-	}
-	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
-	if !ok {
-		log.Error().Msg("Public key is not of type RSA")
-		return false
+		return
 	}
 
-	// Verify the signature
-	hashed := sha256.Sum256([]byte(signatureAfter))
-	err = rsa.VerifyPKCS1v15(rsaPubKey, crypto.SHA256, hashed[:], binarySignature)
-	if err != nil {
-		log.Error().Err(err).Msg("Signature verification failed")
-		return false
-	}
-	log.Info().Msg("Signature is valid.")
-	return true
+	return base64.StdEncoding.EncodeToString(rawSignature), nil
 }
 
-func GenerateSnapSignature(shaJson [32]byte, date, privateKeyPEM string) string {
-	//  Parse the private key
-	blockPrivate, _ := pem.Decode([]byte(privateKeyPEM))
-	privateKey, err := x509.ParsePKCS1PrivateKey(blockPrivate.Bytes)
+// VerifyPaylabsSignature verify signature sent by paylabs with paylabs public key
+func VerifyPaylabsSignature(method, path string, body []byte, timestamp, signature, publicKeyPEM string) (err error) {
+	binarySignature, err := base64.StdEncoding.DecodeString(signature)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to decode base64 signature: %w", err)
 	}
-	// Generate the signature
-	rawSignature := fmt.Sprintf("POST:/transfer-va/create-va:%x:%s", shaJson, date)
-	h := sha256.New()
-	h.Write([]byte(rawSignature))
-	hashed := h.Sum(nil)
-
-	// Sign the hash
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed)
+
+	publicKey, err := parsePaylabsPublicKey(publicKeyPEM)
 	if err != nil {
-		panic(err)
+		return
 	}
 
-	// Base64 encode the signature
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
+	hashed := sha256.Sum256([]byte(paylabsStringToSign(method, path, body, timestamp)))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], binarySignature)
+}
 
-	return signatureB64
+func paylabsStringToSign(method, path string, body []byte, timestamp string) string {
+	return fmt.Sprintf("%s:%s:%x:%s", method, path, sha256.Sum256(body), timestamp)
 }
 
-func GenerateQRISSignature(shaJson [32]byte, date, privateKeyPEM string) string {
-	//  Parse the private key
-	blockPrivate, _ := pem.Decode([]byte(privateKeyPEM))
-	privateKey, err := x509.ParsePKCS1PrivateKey(blockPrivate.Bytes)
-	if err != nil {
-		panic(err)
+func parsePaylabsPrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, errors.New("failed to parse private key PEM block")
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err == nil {
+		return privateKey, nil
 	}
-	// Generate the signature
-	rawSignature := fmt.Sprintf("POST:/payment/v2/qris/create:%x:%s", shaJson, date)
-	h := sha256.New()
-	h.Write([]byte(rawSignature))
-	hashed := h.Sum(nil)
-
-	// Sign the hash
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed)
+
+	// Fallback to PKCS8 format
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
-	// Base64 encode the signature
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not of type RSA")
+	}
 
-	return signatureB64
+	return rsaKey, nil
 }
 
-func GenerateSignature(shaJson [32]byte, path, date, privateKeyPEM string) string {
-	//  Parse the private key
-	blockPrivate, _ := pem.Decode([]byte(privateKeyPEM))
-	privateKey, err := x509.ParsePKCS1PrivateKey(blockPrivate.Bytes)
-	if err != nil {
-		panic(err)
+func parsePaylabsPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("failed to parse public key PEM block")
 	}
-	// Generate the signature
-	rawSignature := fmt.Sprintf("POST:%s:%x:%s", path, shaJson, date)
-	h := sha256.New()
-	h.Write([]byte(rawSignature))
-	hashed := h.Sum(nil)
-
-	// Sign the hash
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hashed)
+
+	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
 	}
 
-	// Base64 encode the signature
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
+	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not of type RSA")
+	}
 
-	return signatureB64
+	return rsaPubKey, nil
 }
 
 func IsVA(paymentMethod string) bool {
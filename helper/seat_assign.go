@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"assist-tix/entity"
+	"sort"
+)
+
+// seatBlock is contiguous available seats in one row
+type seatBlock struct {
+	Row   int
+	Seats []entity.EventVenueSector
+}
+
+// splitSeatBlocks group available seats sorted by row and column into contiguous blocks.
+// Seat with different row label is another physical row even in the same matrix row
+func splitSeatBlocks(seats []entity.EventVenueSector) (blocks []seatBlock) {
+	for i, seat := range seats {
+		if i > 0 {
+			prev := seats[i-1]
+			if prev.SeatRow == seat.SeatRow && prev.SeatRowLabel == seat.SeatRowLabel && prev.SeatColumn+1 == seat.SeatColumn {
+				last := &blocks[len(blocks)-1]
+				last.Seats = append(last.Seats, seat)
+				continue
+			}
+		}
+		blocks = append(blocks, seatBlock{Row: seat.SeatRow, Seats: []entity.EventVenueSector{seat}})
+	}
+
+	return
+}
+
+// FindAdjacentSeats pick n seats from available seats sorted by row and column.
+// It prefers n contiguous seats in one row nearest the front, otherwise the fewest nearby rows
+// which have n seats, taking the longest blocks of those rows first. Returns nil when there are less than n seats
+func FindAdjacentSeats(seats []entity.EventVenueSector, n int) (res []entity.EventVenueSector) {
+	if n <= 0 || len(seats) < n {
+		return nil
+	}
+
+	blocks := splitSeatBlocks(seats)
+	for _, block := range blocks {
+		if len(block.Seats) >= n {
+			return append(res, block.Seats[:n]...)
+		}
+	}
+
+	// rows in order with index of their first block
+	var rows []int
+	rowStart := make(map[int]int)
+	for i, block := range blocks {
+		if _, ok := rowStart[block.Row]; !ok {
+			rowStart[block.Row] = i
+			rows = append(rows, block.Row)
+		}
+	}
+
+	bestStart, bestEnd := -1, -1
+	for i := range rows {
+		total := 0
+		for j := i; j < len(rows); j++ {
+			end := len(blocks)
+			if j+1 < len(rows) {
+				end = rowStart[rows[j+1]]
+			}
+			for _, block := range blocks[rowStart[rows[j]]:end] {
+				total += len(block.Seats)
+			}
+
+			if total >= n {
+				if bestStart < 0 || rows[j]-rows[i] < rows[bestEnd]-rows[bestStart] {
+					bestStart, bestEnd = i, j
+				}
+				break
+			}
+		}
+	}
+	if bestStart < 0 {
+		return nil
+	}
+
+	end := len(blocks)
+	if bestEnd+1 < len(rows) {
+		end = rowStart[rows[bestEnd+1]]
+	}
+	candidates := append([]seatBlock(nil), blocks[rowStart[rows[bestStart]]:end]...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Seats) > len(candidates[j].Seats)
+	})
+
+	for _, block := range candidates {
+		take := min(n-len(res), len(block.Seats))
+		res = append(res, block.Seats[:take]...)
+		if len(res) == n {
+			break
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].SeatRow != res[j].SeatRow {
+			return res[i].SeatRow < res[j].SeatRow
+		}
+		return res[i].SeatColumn < res[j].SeatColumn
+	})
+
+	return
+}
@@ -0,0 +1,97 @@
+package helper
+
+import (
+	"assist-tix/entity"
+	"reflect"
+	"testing"
+)
+
+// seatPosition is row, column and row label of a seat
+type seatPosition [3]int
+
+func seatsAt(positions ...seatPosition) []entity.EventVenueSector {
+	seats := make([]entity.EventVenueSector, 0, len(positions))
+	for _, position := range positions {
+		seats = append(seats, entity.EventVenueSector{SeatRow: position[0], SeatColumn: position[1], SeatRowLabel: position[2]})
+	}
+	return seats
+}
+
+func positionsOf(seats []entity.EventVenueSector) []seatPosition {
+	if seats == nil {
+		return nil
+	}
+
+	positions := make([]seatPosition, 0, len(seats))
+	for _, seat := range seats {
+		positions = append(positions, seatPosition{seat.SeatRow, seat.SeatColumn, seat.SeatRowLabel})
+	}
+	return positions
+}
+
+func TestFindAdjacentSeats(t *testing.T) {
+	tests := []struct {
+		name  string
+		seats []entity.EventVenueSector
+		n     int
+		want  []seatPosition
+	}{
+		{
+			name:  "contiguous seats in front row",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}, seatPosition{1, 3, 1}, seatPosition{1, 4, 1}, seatPosition{2, 1, 2}),
+			n:     3,
+			want:  []seatPosition{{1, 1, 1}, {1, 2, 1}, {1, 3, 1}},
+		},
+		{
+			name:  "gap in row skips the short block",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}, seatPosition{1, 4, 1}, seatPosition{1, 5, 1}, seatPosition{1, 6, 1}),
+			n:     3,
+			want:  []seatPosition{{1, 4, 1}, {1, 5, 1}, {1, 6, 1}},
+		},
+		{
+			name:  "front row too short takes next row",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}, seatPosition{2, 1, 2}, seatPosition{2, 2, 2}, seatPosition{2, 3, 2}, seatPosition{2, 4, 2}),
+			n:     3,
+			want:  []seatPosition{{2, 1, 2}, {2, 2, 2}, {2, 3, 2}},
+		},
+		{
+			name:  "different row label splits matrix row",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}, seatPosition{1, 3, 2}, seatPosition{1, 4, 2}, seatPosition{2, 1, 3}, seatPosition{2, 2, 3}, seatPosition{2, 3, 3}),
+			n:     3,
+			want:  []seatPosition{{2, 1, 3}, {2, 2, 3}, {2, 3, 3}},
+		},
+		{
+			name:  "multi row fallback picks nearest rows and longest block first",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}, seatPosition{3, 1, 3}, seatPosition{3, 2, 3}, seatPosition{4, 1, 4}, seatPosition{4, 2, 4}, seatPosition{4, 3, 4}),
+			n:     4,
+			want:  []seatPosition{{3, 1, 3}, {4, 1, 4}, {4, 2, 4}, {4, 3, 4}},
+		},
+		{
+			name:  "multi row fallback across gaps",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 3, 1}, seatPosition{2, 1, 2}, seatPosition{2, 3, 2}),
+			n:     3,
+			want:  []seatPosition{{1, 1, 1}, {1, 3, 1}, {2, 1, 2}},
+		},
+		{
+			name:  "n larger than available",
+			seats: seatsAt(seatPosition{1, 1, 1}, seatPosition{1, 2, 1}),
+			n:     3,
+			want:  nil,
+		},
+		{
+			name:  "no seat requested",
+			seats: seatsAt(seatPosition{1, 1, 1}),
+			n:     0,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := positionsOf(FindAdjacentSeats(tt.seats, tt.n))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAdjacentSeats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+const (
+	// seatmap image side is limited so big sector doesn't allocate huge png, seat size is shrunk instead
+	maxSeatmapImageSide = 4096
+	minSeatmapSeatSize  = 6
+
+	seatmapBackground     = "#FFFFFF"
+	seatmapTextColor      = "#424242"
+	seatmapHighlightColor = "#E53935"
+)
+
+// SeatmapImage is sector grid to be rendered, rows and columns start from 1
+type SeatmapImage struct {
+	Title    string
+	Rows     int
+	Columns  int
+	SeatSize int // pixel size of one grid cell
+	Seats    []SeatmapImageSeat
+}
+
+type SeatmapImageSeat struct {
+	Row       int
+	Column    int
+	RowLabel  int
+	Label     string
+	Fill      string // hex color, e.g. #4CAF50
+	Highlight bool
+}
+
+type seatmapLayout struct {
+	cell    int
+	gutter  int // left space of row labels
+	header  int // top space of title
+	width   int
+	height  int
+	radius  float32
+	outline float32
+}
+
+func newSeatmapLayout(img SeatmapImage) (layout seatmapLayout) {
+	columns := max(img.Columns, 1)
+	rows := max(img.Rows, 1)
+
+	cell := max(img.SeatSize, minSeatmapSeatSize)
+	cell = max(min(cell, maxSeatmapImageSide/(columns+3), maxSeatmapImageSide/(rows+2)), minSeatmapSeatSize)
+
+	layout = seatmapLayout{
+		cell:    cell,
+		gutter:  max(cell*2, 32),
+		header:  max(cell, 24),
+		radius:  float32(cell) * 0.38,
+		outline: float32(cell) * 0.5,
+	}
+	layout.width = layout.gutter + columns*cell + cell
+	layout.height = layout.header + rows*cell + cell
+	return
+}
+
+// center of seat in pixel
+func (l seatmapLayout) center(row, column int) (x, y float32) {
+	x = float32(l.gutter+(column-1)*l.cell) + float32(l.cell)/2
+	y = float32(l.header+(row-1)*l.cell) + float32(l.cell)/2
+	return
+}
+
+// seatmapRowLabels take label of every row from its first seat, row without label uses its number
+func seatmapRowLabels(img SeatmapImage) (labels map[int]string) {
+	labels = make(map[int]string)
+	for _, seat := range img.Seats {
+		if _, ok := labels[seat.Row]; ok {
+			continue
+		}
+		label := seat.Row
+		if seat.RowLabel > 0 {
+			label = seat.RowLabel
+		}
+		labels[seat.Row] = strconv.Itoa(label)
+	}
+	return
+}
+
+// RenderSeatmapSVG draw seats as circles, highlighted seats have a ring around them
+func RenderSeatmapSVG(img SeatmapImage) []byte {
+	layout := newSeatmapLayout(img)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, layout.width, layout.height, layout.width, layout.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, seatmapBackground)
+
+	fontSize := max(layout.cell/2, 8)
+	if img.Title != "" {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-family="sans-serif" font-size="%d" fill="%s">%s</text>`,
+			layout.gutter, layout.header*2/3, fontSize+2, seatmapTextColor, html.EscapeString(img.Title))
+	}
+
+	rowLabels := seatmapRowLabels(img)
+	for row := 1; row <= img.Rows; row++ {
+		label, ok := rowLabels[row]
+		if !ok {
+			continue
+		}
+		_, y := layout.center(row, 1)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" font-family="sans-serif" font-size="%d" fill="%s" text-anchor="end" dominant-baseline="central">%s</text>`,
+			layout.gutter-layout.cell/3, y, fontSize, seatmapTextColor, html.EscapeString(label))
+	}
+
+	for _, seat := range img.Seats {
+		x, y := layout.center(seat.Row, seat.Column)
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"`, x, y, layout.radius, html.EscapeString(seat.Fill))
+		if seat.Highlight {
+			fmt.Fprintf(&b, ` stroke="%s" stroke-width="%.1f"`, seatmapHighlightColor, layout.outline-layout.radius)
+		}
+		b.WriteString(`>`)
+		if seat.Label != "" {
+			fmt.Fprintf(&b, `<title>%s</title>`, html.EscapeString(seat.Label))
+		}
+		b.WriteString(`</circle>`)
+	}
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// RenderSeatmapPNG rasterize the same drawing of RenderSeatmapSVG, seat labels are left out since they don't fit the seat
+func RenderSeatmapPNG(img SeatmapImage) ([]byte, error) {
+	layout := newSeatmapLayout(img)
+
+	dst := image.NewRGBA(image.Rect(0, 0, layout.width, layout.height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(parseHexColor(seatmapBackground)), image.Point{}, draw.Src)
+
+	text := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(parseHexColor(seatmapTextColor)),
+		Face: basicfont.Face7x13,
+	}
+	if img.Title != "" {
+		text.Dot = fixed.P(layout.gutter, layout.header*2/3)
+		text.DrawString(img.Title)
+	}
+	rowLabels := seatmapRowLabels(img)
+	for row := 1; row <= img.Rows; row++ {
+		label, ok := rowLabels[row]
+		if !ok {
+			continue
+		}
+		_, y := layout.center(row, 1)
+		text.Dot = fixed.P(layout.gutter-layout.cell/3-text.MeasureString(label).Round(), int(y)+4)
+		text.DrawString(label)
+	}
+
+	// seat is drawn in its own cell, so rasterizer only covers one cell instead of the whole image
+	size := layout.cell
+	z := vector.NewRasterizer(size, size)
+	highlight := image.NewUniform(parseHexColor(seatmapHighlightColor))
+	for _, seat := range img.Seats {
+		x, y := layout.center(seat.Row, seat.Column)
+		cell := image.Rect(int(x)-size/2, int(y)-size/2, int(x)-size/2+size, int(y)-size/2+size)
+		local := float32(size) / 2
+
+		if seat.Highlight {
+			z.Reset(size, size)
+			drawCircle(z, local, local, layout.outline)
+			z.Draw(dst, cell, highlight, image.Point{})
+		}
+
+		z.Reset(size, size)
+		drawCircle(z, local, local, layout.radius)
+		z.Draw(dst, cell, image.NewUniform(parseHexColor(seat.Fill)), image.Point{})
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawCircle approximate circle with four cubic bezier curves
+func drawCircle(z *vector.Rasterizer, cx, cy, r float32) {
+	const k = 0.5522847
+	z.MoveTo(cx+r, cy)
+	z.CubeTo(cx+r, cy+k*r, cx+k*r, cy+r, cx, cy+r)
+	z.CubeTo(cx-k*r, cy+r, cx-r, cy+k*r, cx-r, cy)
+	z.CubeTo(cx-r, cy-k*r, cx-k*r, cy-r, cx, cy-r)
+	z.CubeTo(cx+k*r, cy-r, cx+r, cy-k*r, cx+r, cy)
+	z.ClosePath()
+}
+
+// parseHexColor parse #RGB or #RRGGBB color, invalid color is gray
+func parseHexColor(hex string) color.RGBA {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{R: 0x9E, G: 0x9E, B: 0x9E, A: 0xFF}
+	}
+
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}
+}
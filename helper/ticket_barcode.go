@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	TicketBarcodeQR      = "qr"
+	TicketBarcodeCode128 = "code128"
+	TicketBarcodePDF417  = "pdf417"
+
+	// 50 characters code128 is about 600 modules wide, so its bar is a quarter of module size
+	// and its height is in module size to keep it easy to aim at
+	code128BarRatio     = 4
+	code128Height       = 15
+	pdf417SecurityLevel = 2
+)
+
+// quiet zone around barcode in modules, scanners need it to find the edge of barcode
+var ticketBarcodeQuietZone = map[string]int{
+	TicketBarcodeQR:      4,
+	TicketBarcodeCode128: 10,
+	TicketBarcodePDF417:  2,
+}
+
+var ErrTicketBarcodeFormat = errors.New("ticket barcode format is not supported")
+
+// RenderTicketBarcode encode ticket code as png of the format, every module of barcode is moduleSize pixel
+func RenderTicketBarcode(code, format string, moduleSize int) ([]byte, error) {
+	moduleSize = max(moduleSize, 1)
+
+	var (
+		bc  barcode.Barcode
+		err error
+	)
+	switch format {
+	case TicketBarcodeQR:
+		// ticket code is base32, so it's encoded in alphanumeric mode
+		bc, err = qr.Encode(code, qr.M, qr.Auto)
+	case TicketBarcodeCode128:
+		bc, err = code128.Encode(code)
+	case TicketBarcodePDF417:
+		bc, err = pdf417.Encode(code, pdf417SecurityLevel)
+	default:
+		return nil, ErrTicketBarcodeFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	barSize := moduleSize
+	height := bc.Bounds().Dy() * moduleSize
+	if format == TicketBarcodeCode128 {
+		barSize = max(moduleSize/code128BarRatio, 1)
+		height = code128Height * moduleSize
+	}
+	width := bc.Bounds().Dx() * barSize
+	bc, err = barcode.Scale(bc, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	quietZone := ticketBarcodeQuietZone[format] * barSize
+	dst := image.NewGray(image.Rect(0, 0, width+quietZone*2, height+quietZone*2))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(quietZone, quietZone, quietZone+width, quietZone+height), bc, bc.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"assist-tix/config"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type WaitingRoomClaims struct {
+	EventID string `json:"event_id"`
+	Number  int64  `json:"number"` // queue number, admitted in ascending order
+	Type    string `json:"type"`   // QUEUE / ADMISSION
+	jwt.RegisteredClaims
+}
+
+func waitingRoomSecretKey(env *config.EnvironmentVariable) []byte {
+	if env.WaitingRoom.SecretKey != "" {
+		return []byte(env.WaitingRoom.SecretKey)
+	}
+
+	return []byte(env.AccessToken.SecretKey)
+}
+
+func GenerateWaitingRoomToken(env *config.EnvironmentVariable, tokenType, eventID string, number int64, ttl time.Duration) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(ttl)
+
+	claims := WaitingRoomClaims{
+		EventID: eventID,
+		Number:  number,
+		Type:    tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(waitingRoomSecretKey(env))
+	return
+}
+
+// VerifyWaitingRoomToken check signature, expiry, type and event of token
+func VerifyWaitingRoomToken(env *config.EnvironmentVariable, tokenType, eventID, token string) (claims WaitingRoomClaims, err error) {
+	_, err = jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return waitingRoomSecretKey(env), nil
+	})
+	if err != nil {
+		return
+	}
+
+	if claims.Type != tokenType || claims.EventID != eventID {
+		return claims, errors.New("token type or event doesn't match")
+	}
+
+	return
+}
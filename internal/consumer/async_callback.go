@@ -0,0 +1,33 @@
+package consumer
+
+import (
+	"assist-tix/internal/domain"
+	"assist-tix/internal/domain/async_callback"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type AsyncCallbackProcessor interface {
+	ProcessAsyncCallback(ctx context.Context, callback async_callback.AsyncCallback) (err error)
+}
+
+type AsyncCallbackHandler struct {
+	Processor AsyncCallbackProcessor
+}
+
+func NewAsyncCallbackHandler(processor AsyncCallbackProcessor) AsyncCallbackHandler {
+	return AsyncCallbackHandler{
+		Processor: processor,
+	}
+}
+
+func (h *AsyncCallbackHandler) Handle(ctx context.Context, data []byte) (err error) {
+	var callback async_callback.AsyncCallback
+	err = json.Unmarshal(data, &callback)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrUnprocessableMessage, err)
+	}
+
+	return h.Processor.ProcessAsyncCallback(ctx, callback)
+}
@@ -0,0 +1,33 @@
+package consumer
+
+import (
+	"assist-tix/internal/domain"
+	"assist-tix/internal/domain/async_order"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type AsyncOrderProcessor interface {
+	ProcessAsyncOrder(ctx context.Context, order async_order.AsyncOrder) (err error)
+}
+
+type AsyncOrderHandler struct {
+	Processor AsyncOrderProcessor
+}
+
+func NewAsyncOrderHandler(processor AsyncOrderProcessor) AsyncOrderHandler {
+	return AsyncOrderHandler{
+		Processor: processor,
+	}
+}
+
+func (h *AsyncOrderHandler) Handle(ctx context.Context, data []byte) (err error) {
+	var order async_order.AsyncOrder
+	err = json.Unmarshal(data, &order)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrUnprocessableMessage, err)
+	}
+
+	return h.Processor.ProcessAsyncOrder(ctx, order)
+}
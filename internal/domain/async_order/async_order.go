@@ -18,4 +18,5 @@ type AsyncOrder struct {
 	TicketCategory         model.EventTicketCategory    `json:"ticket_category"`
 	VenueSector            entity.VenueSector           `json:"venue_sector"`
 	EventTransactionItem   []model.EventTransactionItem `json:"event_transaction_item"`
+	ItemsCreated           bool                         `json:"items_created"` // cart order writes its items with the transaction
 }
@@ -8,6 +8,10 @@ type TransactionETicket struct {
 	TicketNumber  string `json:"ticket_number"`
 	TicketCode    string `json:"ticket_code"`
 
+	// png of ticket code in base64, mailers embed them instead of encoding ticket code themselves
+	TicketQRCode  string `json:"ticket_qr_code"`
+	TicketBarcode string `json:"ticket_barcode"` // code128
+
 	TicketSeatRow      int    `json:"ticket_seat_row"`
 	TicketSeatColumn   int    `json:"ticket_seat_column"`
 	TicketSeatLabel    string `json:"ticket_seat_label"`
@@ -7,6 +7,8 @@ type TransactionInvoice struct {
 	OrderNumber       string                       `json:"order_number"`
 	Status            string                       `json:"status"`
 	AdditionalFees    []AdditionalFee              `json:"additional_fees"`
+	VoucherCode       string                       `json:"voucher_code,omitempty"`
+	DiscountAmount    int                          `json:"discount_amount"`
 	Payment           PaymentInformation           `json:"payment"`
 	DetailInformation DetailInformationTransaction `json:"detail_information"`
 	Event             EventInformation             `json:"event"`
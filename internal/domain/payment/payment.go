@@ -0,0 +1,71 @@
+package payment
+
+import "time"
+
+// ChargeRequest is what the order flow knows about a transaction when asking gateway to charge it
+type ChargeRequest struct {
+	TransactionID string `json:"transaction_id"`
+	OrderNumber   string `json:"order_number"`
+	PaymentMethod string `json:"payment_method"` // payment code, ex: MandiriVA, QRIS
+	Amount        int    `json:"amount"`         // In IDR, without decimal
+
+	CustomerName  string `json:"customer_name"`
+	CustomerEmail string `json:"customer_email"`
+	ProductName   string `json:"product_name"`
+	ClientIP      string `json:"client_ip"`
+
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type ChargeResponse struct {
+	PaymentAdditionalInfo string `json:"payment_additional_info"` // VA number or QR string shown to user
+	PGOrderID             string `json:"pg_order_id"`             // Order id on gateway side, for lookup purposes
+}
+
+type InquiryRequest struct {
+	TransactionID string `json:"transaction_id"`
+	OrderNumber   string `json:"order_number"`
+	PaymentMethod string `json:"payment_method"`
+	PGOrderID     string `json:"pg_order_id"`
+}
+
+type InquiryResponse struct {
+	Status     string     `json:"status"` // lib.PaymentStatus*
+	PaidAmount int        `json:"paid_amount"`
+	PaidAt     *time.Time `json:"paid_at"`
+	PGOrderID  string     `json:"pg_order_id"`
+	RawStatus  string     `json:"raw_status"` // Status as returned by gateway
+}
+
+type CancelRequest struct {
+	TransactionID         string `json:"transaction_id"`
+	OrderNumber           string `json:"order_number"`
+	PaymentMethod         string `json:"payment_method"`
+	PGOrderID             string `json:"pg_order_id"`
+	PaymentAdditionalInfo string `json:"payment_additional_info"`
+}
+
+type RefundRequest struct {
+	TransactionID string `json:"transaction_id"`
+	OrderNumber   string `json:"order_number"`
+	RefundNumber  string `json:"refund_number"` // Our unique refund number, used as idempotency key on gateway
+	PaymentMethod string `json:"payment_method"`
+	PGOrderID     string `json:"pg_order_id"`
+	Amount        int    `json:"amount"`        // Amount paid by user
+	RefundAmount  int    `json:"refund_amount"` // Amount to be refunded
+	Reason        string `json:"reason"`
+}
+
+type RefundResponse struct {
+	Status        string `json:"status"` // lib.PaymentStatus*
+	PGRefundID    string `json:"pg_refund_id"`
+	RefundedTotal int    `json:"refunded_total"`
+}
+
+// CallbackRequest is raw callback from gateway, verified before it's trusted
+type CallbackRequest struct {
+	PaymentMethod string            `json:"payment_method"`
+	Path          string            `json:"path"`
+	Headers       map[string]string `json:"headers"`
+	Body          []byte            `json:"body"`
+}
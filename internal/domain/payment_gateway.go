@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"assist-tix/internal/domain/payment"
+	"assist-tix/lib"
+	"context"
+	"strings"
+)
+
+type PaymentGateway interface {
+	CreateCharge(ctx context.Context, req payment.ChargeRequest) (res payment.ChargeResponse, err error)
+	InquireStatus(ctx context.Context, req payment.InquiryRequest) (res payment.InquiryResponse, err error)
+	Cancel(ctx context.Context, req payment.CancelRequest) (err error)
+	Refund(ctx context.Context, req payment.RefundRequest) (res payment.RefundResponse, err error)
+	VerifyCallback(ctx context.Context, req payment.CallbackRequest) (err error)
+	// SignCallbackResponse return headers that must be sent along with callback response
+	SignCallbackResponse(ctx context.Context, path, requestID string, body []byte) (headers map[string]string, err error)
+}
+
+// PaymentGateways keyed by payment channel, ex: PAYLABS
+type PaymentGateways map[string]PaymentGateway
+
+func (g PaymentGateways) Get(paymentChannel string) (gateway PaymentGateway, err error) {
+	gateway, ok := g[strings.ToUpper(paymentChannel)]
+	if !ok {
+		return nil, &lib.ErrorPaymentChannelNotSupported
+	}
+
+	return gateway, nil
+}
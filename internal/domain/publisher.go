@@ -1,9 +1,23 @@
 package domain
 
 import (
+	"assist-tix/model"
 	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
 )
 
 type EventPublisher interface {
 	Publish(ctx context.Context, subject string, data []byte) (err error)
+	// PublishMsg publish with message id, publishing the same message id again in the duplicate window is dropped by jetstream
+	PublishMsg(ctx context.Context, subject, msgID string, data []byte) (err error)
+}
+
+// OutboxWriter store message in the caller transaction, it's published by outbox relay once the transaction is committed
+type OutboxWriter interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.Outbox) (res model.Outbox, err error)
 }
+
+// ErrUnprocessableMessage mark consumed message which will never succeed, it's sent to dead letter without redelivery
+var ErrUnprocessableMessage = errors.New("unprocessable message")
@@ -0,0 +1,22 @@
+package seatmap
+
+import "time"
+
+// SeatmapChange is the new status of seats in event sector
+type SeatmapChange struct {
+	EventID   string    `json:"event_id"`
+	SectorID  string    `json:"sector_id"`
+	Status    string    `json:"status"`
+	Seats     []Seat    `json:"seats"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type Seat struct {
+	Row    int `json:"row"`
+	Column int `json:"column"`
+}
+
+// Subject of seatmap change of event sector, so viewer of a sector only receives its changes
+func Subject(prefix, eventID, sectorID string) string {
+	return prefix + "." + eventID + "." + sectorID
+}
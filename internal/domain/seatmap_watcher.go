@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"assist-tix/internal/domain/seatmap"
+	"context"
+)
+
+// SeatmapChangeMessage is seatmap change read from stream, its sequence is the resync token of the change
+type SeatmapChangeMessage struct {
+	Sequence uint64
+	Change   seatmap.SeatmapChange
+}
+
+type SeatmapWatcher interface {
+	// LastSequence is the resync token of the latest change of every sector
+	LastSequence(ctx context.Context) (seq uint64, err error)
+	// Watch send changes of event sector after seq until ctx is done, zero seq only send new changes.
+	// Resync is true when changes after seq aren't kept anymore, only new changes are sent.
+	// Changes is closed when the viewer falls behind, it resumes with the sequence of its last change
+	Watch(ctx context.Context, eventID, sectorID string, afterSeq uint64) (changes <-chan SeatmapChangeMessage, resync bool, err error)
+}
@@ -26,3 +26,8 @@ func (p *Publisher) Publish(ctx context.Context, subject string, data []byte) (e
 	_, err = p.Jetstream.Publish(ctx, subject, data)
 	return
 }
+
+func (p *Publisher) PublishMsg(ctx context.Context, subject, msgID string, data []byte) (err error) {
+	_, err = p.Jetstream.Publish(ctx, subject, data, jetstream.WithMsgID(msgID))
+	return
+}
@@ -0,0 +1,287 @@
+package nats
+
+import (
+	"assist-tix/internal/domain"
+	"assist-tix/internal/domain/seatmap"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+// seatmapWatchBuffer is pending changes of one viewer, viewer which falls behind is closed and resumes with its resync token
+const seatmapWatchBuffer = 64
+
+// SeatmapWatcher read seatmap changes of event sector with one ordered consumer per process, fanned out to every viewer
+// of the sector. Viewer resuming after resync token replays its missed changes with its own consumer first
+type SeatmapWatcher struct {
+	Jetstream     jetstream.JetStream
+	Stream        string
+	SubjectPrefix string
+
+	mu    sync.Mutex
+	feeds map[string]*seatmapFeed
+}
+
+// seatmapFeed is live changes of one subject, it is stopped once its last viewer leaves
+type seatmapFeed struct {
+	messages jetstream.MessagesContext
+	viewers  map[chan domain.SeatmapChangeMessage]struct{}
+}
+
+func NewSeatmapWatcher(jetStream jetstream.JetStream, stream, subjectPrefix string) *SeatmapWatcher {
+	return &SeatmapWatcher{
+		Jetstream:     jetStream,
+		Stream:        stream,
+		SubjectPrefix: subjectPrefix,
+		feeds:         make(map[string]*seatmapFeed),
+	}
+}
+
+func (w *SeatmapWatcher) LastSequence(ctx context.Context) (seq uint64, err error) {
+	stream, err := w.Jetstream.Stream(ctx, w.Stream)
+	if err != nil {
+		return
+	}
+
+	return stream.CachedInfo().State.LastSeq, nil
+}
+
+func (w *SeatmapWatcher) Watch(ctx context.Context, eventID, sectorID string, afterSeq uint64) (changes <-chan domain.SeatmapChangeMessage, resync bool, err error) {
+	stream, err := w.Jetstream.Stream(ctx, w.Stream)
+	if err != nil {
+		return
+	}
+	state := stream.CachedInfo().State
+	subject := seatmap.Subject(w.SubjectPrefix, eventID, sectorID)
+
+	if afterSeq > 0 {
+		// token before the first kept change or after the last one (stream is recreated) can't be resumed
+		if afterSeq+1 < state.FirstSeq || afterSeq > state.LastSeq {
+			resync = true
+			afterSeq = 0
+		}
+	}
+
+	// live changes are buffered from now on, so nothing is lost between replay and live changes
+	live, err := w.subscribe(ctx, stream, subject)
+	if err != nil {
+		return
+	}
+
+	var replayUntil uint64
+	if afterSeq > 0 {
+		last, errLast := stream.GetLastMsgForSubject(ctx, subject)
+		if errLast != nil && !errors.Is(errLast, jetstream.ErrMsgNotFound) {
+			w.unsubscribe(subject, live)
+			return nil, false, errLast
+		}
+		if last != nil && last.Sequence > afterSeq {
+			replayUntil = last.Sequence
+		}
+	}
+
+	ch := make(chan domain.SeatmapChangeMessage, seatmapWatchBuffer)
+	go func() {
+		defer close(ch)
+		defer w.unsubscribe(subject, live)
+
+		send := func(message domain.SeatmapChangeMessage) bool {
+			select {
+			case ch <- message:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		lastSeq := afterSeq
+		if replayUntil > 0 {
+			errReplay := w.replay(ctx, stream, subject, afterSeq, replayUntil, send)
+			if errReplay != nil {
+				if ctx.Err() == nil {
+					log.Warn().Err(errReplay).Str("subject", subject).Uint64("afterSeq", afterSeq).Msg("failed to replay seatmap changes")
+				}
+				return
+			}
+			lastSeq = replayUntil
+		}
+
+		for {
+			select {
+			case message, ok := <-live:
+				if !ok {
+					return
+				}
+				// change of replay can also be received live
+				if message.Sequence <= lastSeq {
+					continue
+				}
+				if !send(message) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, resync, nil
+}
+
+// subscribe add viewer to live changes of subject, consumer of subject is created for its first viewer
+func (w *SeatmapWatcher) subscribe(ctx context.Context, stream jetstream.Stream, subject string) (live chan domain.SeatmapChangeMessage, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	live = make(chan domain.SeatmapChangeMessage, seatmapWatchBuffer)
+	if feed, ok := w.feeds[subject]; ok {
+		feed.viewers[live] = struct{}{}
+		return
+	}
+
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := consumer.Messages()
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &seatmapFeed{
+		messages: messages,
+		viewers:  map[chan domain.SeatmapChangeMessage]struct{}{live: {}},
+	}
+	w.feeds[subject] = feed
+	go w.fanOut(subject, feed)
+
+	log.Debug().Str("subject", subject).Msg("seatmap feed started")
+	return
+}
+
+// unsubscribe remove viewer, feed without viewer is stopped
+func (w *SeatmapWatcher) unsubscribe(subject string, live chan domain.SeatmapChangeMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	feed, ok := w.feeds[subject]
+	if !ok {
+		return
+	}
+	if _, ok := feed.viewers[live]; ok {
+		delete(feed.viewers, live)
+		close(live)
+	}
+
+	if len(feed.viewers) == 0 {
+		delete(w.feeds, subject)
+		feed.messages.Stop()
+		log.Debug().Str("subject", subject).Msg("seatmap feed stopped")
+	}
+}
+
+// fanOut send every change of feed to its viewers. Viewer whose buffer is full is closed instead of blocking the others
+func (w *SeatmapWatcher) fanOut(subject string, feed *seatmapFeed) {
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		for live := range feed.viewers {
+			delete(feed.viewers, live)
+			close(live)
+		}
+		if w.feeds[subject] == feed {
+			delete(w.feeds, subject)
+		}
+	}()
+
+	for {
+		msg, errNext := feed.messages.Next()
+		if errNext != nil {
+			return
+		}
+
+		message, ok := decodeSeatmapChange(msg)
+		if !ok {
+			continue
+		}
+
+		w.mu.Lock()
+		for live := range feed.viewers {
+			select {
+			case live <- message:
+			default:
+				log.Warn().Str("subject", subject).Msg("seatmap viewer falls behind, close its stream")
+				delete(feed.viewers, live)
+				close(live)
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+// replay send changes of subject after afterSeq until untilSeq with its own consumer
+func (w *SeatmapWatcher) replay(ctx context.Context, stream jetstream.Stream, subject string, afterSeq, untilSeq uint64, send func(domain.SeatmapChangeMessage) bool) (err error) {
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:    afterSeq + 1,
+	})
+	if err != nil {
+		return
+	}
+
+	messages, err := consumer.Messages()
+	if err != nil {
+		return
+	}
+	defer messages.Stop()
+
+	stop := context.AfterFunc(ctx, messages.Stop)
+	defer stop()
+
+	for {
+		msg, errNext := messages.Next()
+		if errNext != nil {
+			return errNext
+		}
+
+		metadata, errMetadata := msg.Metadata()
+		if errMetadata != nil {
+			return errMetadata
+		}
+
+		message, ok := decodeSeatmapChange(msg)
+		if ok && !send(message) {
+			return ctx.Err()
+		}
+		if metadata.Sequence.Stream >= untilSeq {
+			return nil
+		}
+	}
+}
+
+func decodeSeatmapChange(msg jetstream.Msg) (message domain.SeatmapChangeMessage, ok bool) {
+	metadata, err := msg.Metadata()
+	if err != nil {
+		log.Warn().Err(err).Str("subject", msg.Subject()).Msg("failed to read seatmap change metadata")
+		return
+	}
+
+	var change seatmap.SeatmapChange
+	err = json.Unmarshal(msg.Data(), &change)
+	if err != nil {
+		log.Warn().Err(err).Uint64("sequence", metadata.Sequence.Stream).Msg("failed to decode seatmap change")
+		return
+	}
+
+	return domain.SeatmapChangeMessage{Sequence: metadata.Sequence.Stream, Change: change}, true
+}
@@ -0,0 +1,160 @@
+package nats
+
+import (
+	"assist-tix/internal/domain"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog/log"
+)
+
+// Header of message published to dead letter subject
+const (
+	HeaderDeadLetterSubject    = "Tix-Original-Subject"
+	HeaderDeadLetterConsumer   = "Tix-Consumer"
+	HeaderDeadLetterDeliveries = "Tix-Deliveries"
+	HeaderDeadLetterError      = "Tix-Error"
+)
+
+// MessageHandler process consumed message, message is acked when it return nil
+type MessageHandler func(ctx context.Context, data []byte) (err error)
+
+type SubscriberConfig struct {
+	DeadLetterSubject string
+	MaxDeliver        int           // message is sent to dead letter on this delivery if it still fails
+	AckWait           time.Duration // max duration of handler, message is redelivered after it
+	NakDelay          time.Duration // redelivery delay, multiplied by delivery count
+}
+
+// Subscriber run durable pull consumers. Max deliver is enforced here instead of on the consumer,
+// so message which can't be sent to dead letter is redelivered instead of silently dropped by server
+type Subscriber struct {
+	Jetstream jetstream.JetStream
+	Config    SubscriberConfig
+
+	consumeContexts []jetstream.ConsumeContext
+}
+
+func NewSubscriber(jetStream jetstream.JetStream, cfg SubscriberConfig) *Subscriber {
+	return &Subscriber{
+		Jetstream: jetStream,
+		Config:    cfg,
+	}
+}
+
+// EnsureStream create stream when it doesn't exist yet, existing stream is left as it is
+func (s *Subscriber) EnsureStream(ctx context.Context, cfg jetstream.StreamConfig) (err error) {
+	return EnsureStream(ctx, s.Jetstream, cfg)
+}
+
+// EnsureStream create stream when it doesn't exist yet, existing stream is left as it is.
+// Used by process which reads stream without subscriber, ex: api reading seatmap stream
+func EnsureStream(ctx context.Context, jetStream jetstream.JetStream, cfg jetstream.StreamConfig) (err error) {
+	_, err = jetStream.Stream(ctx, cfg.Name)
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, jetstream.ErrStreamNotFound) {
+		return
+	}
+
+	_, err = jetStream.CreateStream(ctx, cfg)
+	if err != nil {
+		return
+	}
+
+	log.Info().Str("stream", cfg.Name).Strs("subjects", cfg.Subjects).Msg("nats stream created")
+	return
+}
+
+func (s *Subscriber) Subscribe(ctx context.Context, stream, durable, subject string, handler MessageHandler) (err error) {
+	consumer, err := s.Jetstream.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       s.Config.AckWait,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return
+	}
+
+	consumeContext, err := consumer.Consume(func(msg jetstream.Msg) {
+		s.handle(durable, msg, handler)
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, errConsume error) {
+		log.Warn().Err(errConsume).Str("consumer", durable).Msg("nats consume error")
+	}))
+	if err != nil {
+		return
+	}
+
+	s.consumeContexts = append(s.consumeContexts, consumeContext)
+	log.Info().Str("stream", stream).Str("consumer", durable).Str("subject", subject).Msg("nats consumer started")
+	return
+}
+
+// Stop stop receiving new message and wait for message being handled
+func (s *Subscriber) Stop() {
+	for _, consumeContext := range s.consumeContexts {
+		consumeContext.Drain()
+		<-consumeContext.Closed()
+	}
+}
+
+func (s *Subscriber) handle(durable string, msg jetstream.Msg, handler MessageHandler) {
+	var deliveries uint64 = 1
+	metadata, err := msg.Metadata()
+	if err == nil {
+		deliveries = metadata.NumDelivered
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.AckWait)
+	err = handler(ctx, msg.Data())
+	cancel()
+
+	if err == nil {
+		err = msg.Ack()
+		if err != nil {
+			log.Warn().Err(err).Str("consumer", durable).Msg("failed to ack message")
+		}
+		return
+	}
+
+	if !errors.Is(err, domain.ErrUnprocessableMessage) && deliveries < uint64(s.Config.MaxDeliver) {
+		log.Warn().Err(err).Str("consumer", durable).Uint64("deliveries", deliveries).Msg("failed to handle message, redeliver later")
+		_ = msg.NakWithDelay(s.Config.NakDelay * time.Duration(deliveries))
+		return
+	}
+
+	log.Error().Err(err).Str("consumer", durable).Str("subject", msg.Subject()).Uint64("deliveries", deliveries).Msg("failed to handle message, send to dead letter")
+	sentry.CaptureException(err)
+
+	errDeadLetter := s.deadLetter(durable, msg, deliveries, err)
+	if errDeadLetter != nil {
+		log.Error().Err(errDeadLetter).Str("consumer", durable).Msg("failed to send message to dead letter, redeliver later")
+		_ = msg.NakWithDelay(s.Config.NakDelay * time.Duration(deliveries))
+		return
+	}
+
+	_ = msg.TermWithReason("sent to dead letter")
+}
+
+func (s *Subscriber) deadLetter(durable string, msg jetstream.Msg, deliveries uint64, cause error) (err error) {
+	deadLetter := nats.NewMsg(s.Config.DeadLetterSubject)
+	deadLetter.Data = msg.Data()
+	deadLetter.Header.Set(HeaderDeadLetterSubject, msg.Subject())
+	deadLetter.Header.Set(HeaderDeadLetterConsumer, durable)
+	deadLetter.Header.Set(HeaderDeadLetterDeliveries, strconv.FormatUint(deliveries, 10))
+	deadLetter.Header.Set(HeaderDeadLetterError, cause.Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.AckWait)
+	defer cancel()
+
+	_, err = s.Jetstream.PublishMsg(ctx, deadLetter)
+	return
+}
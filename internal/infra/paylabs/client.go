@@ -0,0 +1,275 @@
+package paylabs
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	timestampLayout = "2006-01-02T15:04:05.999+07:00"
+
+	snapBasePath = "/api/v1.0"
+
+	PathVASnapCreate   = "/transfer-va/create-va"
+	PathVASnapStatus   = "/transfer-va/status"
+	PathVASnapDelete   = "/transfer-va/delete-va"
+	PathVASnapCallback = "/transfer-va/payment"
+	PathQRISCreate     = "/payment/v2/qris/create"
+	PathQRISQuery      = "/payment/v2/qris/query"
+	PathQRISCancel     = "/payment/v2/qris/cancel"
+	PathQRISRefund     = "/payment/v2/qris/refund"
+
+	// account id holds partner service id in its first 8 characters and merchant id in its last 6 characters
+	accountIDMinLength = 8
+)
+
+// createPaths create a charge on each call, they are not retried once the request may have reached paylabs
+var createPaths = map[string]bool{
+	snapBasePath + PathVASnapCreate: true,
+	PathQRISCreate:                  true,
+}
+
+type Client struct {
+	Env        *config.EnvironmentVariable
+	HttpClient *http.Client
+
+	merchantID       string
+	partnerServiceID string
+}
+
+// NewClient validate paylabs account id, so misconfigured account is rejected at startup instead of on the first charge
+func NewClient(env *config.EnvironmentVariable) (client *Client, err error) {
+	accountID := env.Paylabs.AccountID
+	if len(accountID) < accountIDMinLength {
+		return nil, fmt.Errorf("%w: PAYLABS.ACCOUNT_ID must have at least %d characters", &lib.ErrorPaylabsAccountIDInvalid, accountIDMinLength)
+	}
+
+	return &Client{
+		Env: env,
+		// deadline is set per attempt through context, see send
+		HttpClient:       &http.Client{},
+		merchantID:       accountID[len(accountID)-6:],
+		partnerServiceID: accountID[:8],
+	}, nil
+}
+
+// MerchantID is the last 6 characters of paylabs account id
+func (c *Client) MerchantID() string {
+	return c.merchantID
+}
+
+// PartnerServiceID is the first 8 characters of paylabs account id
+func (c *Client) PartnerServiceID() string {
+	return c.partnerServiceID
+}
+
+func (c *Client) CreateVA(ctx context.Context, req dto.VirtualAccountSnapRequest, externalID, clientIP string) (res VASnapResponse, err error) {
+	err = c.doSnap(ctx, PathVASnapCreate, externalID, clientIP, req, &res)
+	if err != nil {
+		return
+	}
+
+	if res.VirtualAccountData == nil || res.VirtualAccountData.VirtualAccountNo == "" {
+		log.Error().Str("responseCode", res.ResponseCode).Msg("paylabs didn't return virtual account number")
+		return res, &lib.ErrorPaylabsInvalidResponse
+	}
+
+	return
+}
+
+func (c *Client) InquiryVA(ctx context.Context, req VASnapInquiryRequest, externalID string) (res VASnapResponse, err error) {
+	err = c.doSnap(ctx, PathVASnapStatus, externalID, "", req, &res)
+	return
+}
+
+func (c *Client) DeleteVA(ctx context.Context, req VASnapDeleteRequest, externalID string) (res VASnapResponse, err error) {
+	err = c.doSnap(ctx, PathVASnapDelete, externalID, "", req, &res)
+	return
+}
+
+func (c *Client) CreateQRIS(ctx context.Context, req dto.PaylabsQRISRequest) (res QRISResponse, err error) {
+	err = c.doQRIS(ctx, PathQRISCreate, req.RequestID, req, &res)
+	if err != nil {
+		return
+	}
+
+	if res.QRCode == "" {
+		log.Error().Str("errCode", res.ErrCode).Msg("paylabs didn't return qr code")
+		return res, &lib.ErrorPaylabsInvalidResponse
+	}
+
+	return
+}
+
+func (c *Client) QueryQRIS(ctx context.Context, req QRISQueryRequest) (res QRISResponse, err error) {
+	err = c.doQRIS(ctx, PathQRISQuery, req.RequestID, req, &res)
+	return
+}
+
+func (c *Client) CancelQRIS(ctx context.Context, req QRISCancelRequest) (res QRISResponse, err error) {
+	err = c.doQRIS(ctx, PathQRISCancel, req.RequestID, req, &res)
+	return
+}
+
+func (c *Client) RefundQRIS(ctx context.Context, req QRISRefundRequest) (res QRISResponse, err error) {
+	err = c.doQRIS(ctx, PathQRISRefund, req.RequestID, req, &res)
+	return
+}
+
+// doSnap call paylabs SNAP api, signature path doesn't include snap base path
+func (c *Client) doSnap(ctx context.Context, path, externalID, clientIP string, payload interface{}, out *VASnapResponse) (err error) {
+	headers := map[string]string{
+		"X-PARTNER-ID":  c.MerchantID(),
+		"X-EXTERNAL-ID": externalID,
+		"Content-Type":  "application/json",
+	}
+	if clientIP != "" {
+		headers["X-IP-ADDRESS"] = clientIP
+	}
+
+	_, err = c.send(ctx, snapBasePath+path, path, headers, payload, out)
+	if err != nil {
+		return
+	}
+
+	err = mapSnapResponseCode(out.ResponseCode)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Str("responseCode", out.ResponseCode).Str("responseMessage", out.ResponseMessage).Msg("paylabs snap request failed")
+		return
+	}
+
+	return nil
+}
+
+func (c *Client) doQRIS(ctx context.Context, path, requestID string, payload interface{}, out *QRISResponse) (err error) {
+	headers := map[string]string{
+		"X-PARTNER-ID": c.MerchantID(),
+		"X-REQUEST-ID": requestID,
+		"Content-Type": "application/json;charset=utf-8",
+	}
+
+	httpStatus, err := c.send(ctx, path, path, headers, payload, out)
+	if err != nil {
+		return
+	}
+
+	err = mapQRISErrCode(httpStatus, out.ErrCode)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Str("errCode", out.ErrCode).Str("errCodeDes", out.ErrCodeDes).Msg("paylabs qris request failed")
+		return
+	}
+
+	return nil
+}
+
+// send sign and post payload to paylabs, retry with exponential backoff on network error or 5xx.
+// Create call is only retried when it never reached paylabs, otherwise retry could create a second charge.
+// response with status below 500 is decoded to out and left to caller to be mapped
+func (c *Client) send(ctx context.Context, urlPath, signaturePath string, headers map[string]string, payload interface{}, out interface{}) (httpStatus int, err error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode paylabs payload")
+		return 0, &lib.ErrorTransactionPaylabs
+	}
+
+	backoff := c.Env.Paylabs.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		var body []byte
+		var retryable bool
+		httpStatus, body, retryable, err = c.attempt(ctx, urlPath, signaturePath, headers, jsonData, !createPaths[urlPath])
+		if err == nil {
+			return httpStatus, decode(body, out)
+		}
+
+		if !retryable || attempt >= c.Env.Paylabs.MaxRetry {
+			return
+		}
+
+		log.Warn().Err(err).Str("path", urlPath).Int("attempt", attempt+1).Dur("backoff", backoff).Msg("retrying paylabs request")
+		select {
+		case <-ctx.Done():
+			return httpStatus, &lib.ErrorPaylabsUnavailable
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attempt send the request once. Failure of non idempotent request is only retryable when the request wasn't sent
+func (c *Client) attempt(ctx context.Context, urlPath, signaturePath string, headers map[string]string, jsonData []byte, idempotent bool) (httpStatus int, body []byte, retryable bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Env.Paylabs.Timeout)
+	defer cancel()
+
+	date := time.Now().Format(timestampLayout)
+	signature, err := helper.GeneratePaylabsSignature(http.MethodPost, signaturePath, jsonData, date, c.Env.Paylabs.PrivateKey)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sign paylabs request")
+		return 0, nil, false, &lib.ErrorPaymentGatewayKeyInvalid
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Env.Paylabs.BaseUrl+urlPath, bytes.NewReader(jsonData))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create paylabs request")
+		return 0, nil, false, &lib.ErrorTransactionPaylabs
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("X-TIMESTAMP", date)
+	req.Header.Set("X-SIGNATURE", signature)
+
+	log.Info().Str("path", urlPath).RawJSON("payload", jsonData).Msg("send request to paylabs")
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("path", urlPath).Msg("failed to send request to paylabs")
+
+		// request which failed to connect never reached paylabs
+		var opErr *net.OpError
+		if idempotent || (errors.As(err, &opErr) && opErr.Op == "dial") {
+			return 0, nil, true, fmt.Errorf("%w: %v", &lib.ErrorPaylabsUnavailable, err)
+		}
+		return 0, nil, false, fmt.Errorf("%w: %v", &lib.ErrorPaylabsResultUnknown, err)
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error().Err(err).Str("path", urlPath).Msg("failed to read response from paylabs")
+		if !idempotent {
+			return resp.StatusCode, nil, false, fmt.Errorf("%w: %v", &lib.ErrorPaylabsResultUnknown, err)
+		}
+		return resp.StatusCode, nil, true, fmt.Errorf("%w: %v", &lib.ErrorPaylabsUnavailable, err)
+	}
+	log.Info().Str("path", urlPath).Int("status", resp.StatusCode).Bytes("response", body).Msg("response from paylabs")
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		if !idempotent {
+			return resp.StatusCode, body, false, &lib.ErrorPaylabsResultUnknown
+		}
+		return resp.StatusCode, body, true, &lib.ErrorPaylabsUnavailable
+	}
+
+	return resp.StatusCode, body, false, nil
+}
+
+func decode(body []byte, out interface{}) (err error) {
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return fmt.Errorf("%w: %v", &lib.ErrorPaylabsInvalidResponse, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package paylabs
+
+import (
+	"assist-tix/lib"
+	"net/http"
+	"strconv"
+)
+
+// QRIS api use errCode "0" as success, otherwise the http status decide the error
+const qrisErrCodeSuccess = "0"
+
+// mapSnapResponseCode map SNAP responseCode (HTTP status + service code + case code, ex: 4042701) to TIXError.
+// return nil if response code is success
+func mapSnapResponseCode(responseCode string) error {
+	if len(responseCode) != 7 {
+		return &lib.ErrorPaylabsInvalidResponse
+	}
+
+	httpStatus, err := strconv.Atoi(responseCode[:3])
+	if err != nil {
+		return &lib.ErrorPaylabsInvalidResponse
+	}
+
+	return mapHttpStatus(httpStatus)
+}
+
+func mapQRISErrCode(httpStatus int, errCode string) error {
+	if errCode == qrisErrCodeSuccess {
+		return nil
+	}
+
+	err := mapHttpStatus(httpStatus)
+	if err == nil {
+		// paylabs return 200 along with business error
+		return &lib.ErrorPaylabsRequestInvalid
+	}
+
+	return err
+}
+
+func mapHttpStatus(httpStatus int) error {
+	switch {
+	case httpStatus >= 200 && httpStatus < 300:
+		return nil
+	case httpStatus == http.StatusUnauthorized || httpStatus == http.StatusForbidden:
+		return &lib.ErrorPaylabsUnauthorized
+	case httpStatus == http.StatusNotFound:
+		return &lib.ErrorPaylabsTransactionNotFound
+	case httpStatus == http.StatusConflict:
+		return &lib.ErrorPaylabsTransactionDuplicate
+	case httpStatus >= 400 && httpStatus < 500:
+		return &lib.ErrorPaylabsRequestInvalid
+	case httpStatus >= 500:
+		return &lib.ErrorPaylabsUnavailable
+	}
+
+	return &lib.ErrorPaylabsInvalidResponse
+}
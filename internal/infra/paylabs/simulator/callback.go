@@ -0,0 +1,180 @@
+package simulator
+
+import (
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/internal/infra/paylabs"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SendCallback send (or resend, to simulate paylabs retry) callback of the current payment status
+func (s *Simulator) SendCallback(merchantTradeNo string) (payment Payment, err error) {
+	payment, ok := s.findPayment(merchantTradeNo)
+	if !ok {
+		return payment, errPaymentNotFound
+	}
+
+	var (
+		callbackURL   string
+		signaturePath string
+		body          []byte
+		headers       map[string]string
+	)
+
+	if payment.PaymentType == PaymentTypeQRIS {
+		if payment.Status != StatusPaid && payment.Status != StatusFailed {
+			return payment, fmt.Errorf("%w: payment is %s", errCallbackNotAvailable, payment.Status)
+		}
+
+		callbackURL = payment.NotifyURL
+		var parsed *url.URL
+		parsed, err = url.Parse(callbackURL)
+		if err != nil {
+			return
+		}
+		// QRIS callback is signed with path of notify url
+		signaturePath = parsed.Path
+		requestID := s.nextRequestID()
+		body, err = json.Marshal(s.qrisCallback(payment, requestID))
+		headers = map[string]string{
+			"X-PARTNER-ID": s.Config.MerchantID,
+			"X-REQUEST-ID": requestID,
+			"Content-Type": "application/json;charset=utf-8",
+		}
+	} else {
+		if payment.Status != StatusPaid {
+			return payment, fmt.Errorf("%w: payment is %s", errCallbackNotAvailable, payment.Status)
+		}
+
+		callbackURL = s.Config.VACallbackURL
+		// VA callback is signed with snap path, not the path of our callback url
+		signaturePath = paylabs.PathVASnapCallback
+		body, err = json.Marshal(s.vaCallback(payment))
+		headers = map[string]string{
+			"X-PARTNER-ID":  s.Config.MerchantID,
+			"X-EXTERNAL-ID": s.nextRequestID(),
+			"Content-Type":  "application/json",
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	attempt := s.post(callbackURL, signaturePath, headers, body)
+	return s.updatePayment(merchantTradeNo, func(p *Payment) error {
+		p.Callbacks = append(p.Callbacks, attempt)
+		return nil
+	})
+}
+
+func (s *Simulator) nextRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.nextID("REQ")
+}
+
+func (s *Simulator) post(callbackURL, signaturePath string, headers map[string]string, body []byte) (attempt CallbackAttempt) {
+	attempt = CallbackAttempt{URL: callbackURL, SentAt: time.Now()}
+
+	date := time.Now().Format(timestampLayout)
+	signature, err := helper.GeneratePaylabsSignature(http.MethodPost, signaturePath, body, date, s.Config.PrivateKeyPEM)
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("X-TIMESTAMP", date)
+	req.Header.Set("X-SIGNATURE", signature)
+
+	log.Info().Str("url", callbackURL).RawJSON("payload", body).Msg("send callback")
+	resp, err := s.Config.CallbackClient.Do(req)
+	if err != nil {
+		log.Error().Err(err).Str("url", callbackURL).Msg("failed to send callback")
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(respBody)
+	log.Info().Str("url", callbackURL).Int("status", resp.StatusCode).Bytes("response", respBody).Msg("callback response")
+
+	return
+}
+
+func (s *Simulator) qrisCallback(payment Payment, requestID string) dto.QRISCallbackRequest {
+	status := paylabsQRISStatus(payment.Status)
+	amount := payment.Amount
+	successTime := ""
+	if payment.PaidAt != nil {
+		amount = payment.PaidAmount
+		successTime = payment.PaidAt.Format(successTimeLayout)
+	}
+
+	return dto.QRISCallbackRequest{
+		MerchantID:      s.Config.MerchantID,
+		RequestID:       requestID,
+		ErrCode:         "0",
+		PaymentType:     payment.PaymentType,
+		Amount:          formatAmount(amount),
+		CreateTime:      payment.CreatedAt.Format(successTimeLayout),
+		SuccessTime:     successTime,
+		MerchantTradeNo: payment.MerchantTradeNo,
+		PlatformTradeNo: payment.PlatformTradeNo,
+		Status:          status,
+		PaymentMethodInfo: dto.QRISPaymentMethodInfo{
+			NMID:  "ID1020000000001",
+			RRN:   payment.RRN,
+			Payer: "Simulator",
+		},
+		ProductName: payment.ProductName,
+	}
+}
+
+func (s *Simulator) vaCallback(payment Payment) dto.SnapCallbackPaymentRequest {
+	trxID := payment.MerchantTradeNo
+	trxDateTime := payment.PaidAt.Format(time.RFC3339)
+	referenceNo := payment.PlatformTradeNo
+	name := payment.VirtualAccountNm
+	paymentType := payment.PaymentType
+
+	return dto.SnapCallbackPaymentRequest{
+		PartnerServiceId:   payment.PartnerServiceID,
+		CustomerNo:         payment.CustomerNo,
+		VirtualAccountNo:   payment.VirtualAccountNo,
+		VirtualAccountName: &name,
+		TrxId:              &trxID,
+		PaymentRequestId:   payment.PaymentRequestID,
+		PaidAmount: dto.SnapCallbackAmount{
+			Value:    formatAmount(payment.PaidAmount),
+			Currency: "IDR",
+		},
+		TotalAmount: &dto.SnapCallbackAmount{
+			Value:    formatAmount(payment.Amount),
+			Currency: "IDR",
+		},
+		TrxDateTime: &trxDateTime,
+		ReferenceNo: &referenceNo,
+		AdditionalInfo: &dto.SnapCallbackAdditionalInfo{
+			PaymentType: &paymentType,
+		},
+	}
+}
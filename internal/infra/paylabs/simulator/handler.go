@@ -0,0 +1,509 @@
+package simulator
+
+import (
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/internal/infra/paylabs"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const snapBasePath = "/api/v1.0"
+
+// SNAP service code, part of response code (HTTP status + service code + case code)
+const (
+	snapServiceCreateVA = "27"
+	snapServiceStatusVA = "26"
+	snapServiceDeleteVA = "31"
+)
+
+var (
+	errPaymentNotFound      = errors.New("payment not found")
+	errPaymentNotPending    = errors.New("payment is not pending")
+	errCallbackNotAvailable = errors.New("payment has no callback to send")
+)
+
+// Router register paylabs api and simulator control endpoints
+func (s *Simulator) Router() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	snap := router.Group(snapBasePath)
+	snap.POST(paylabs.PathVASnapCreate, s.verifyRequest(paylabs.PathVASnapCreate, snapServiceCreateVA), s.createVA)
+	snap.POST(paylabs.PathVASnapStatus, s.verifyRequest(paylabs.PathVASnapStatus, snapServiceStatusVA), s.inquiryVA)
+	snap.POST(paylabs.PathVASnapDelete, s.verifyRequest(paylabs.PathVASnapDelete, snapServiceDeleteVA), s.deleteVA)
+
+	router.POST(paylabs.PathQRISCreate, s.verifyRequest(paylabs.PathQRISCreate, ""), s.createQRIS)
+	router.POST(paylabs.PathQRISQuery, s.verifyRequest(paylabs.PathQRISQuery, ""), s.queryQRIS)
+	router.POST(paylabs.PathQRISCancel, s.verifyRequest(paylabs.PathQRISCancel, ""), s.cancelQRIS)
+	router.POST(paylabs.PathQRISRefund, s.verifyRequest(paylabs.PathQRISRefund, ""), s.refundQRIS)
+
+	control := router.Group("/simulator")
+	control.GET("/payments", s.getPayments)
+	control.GET("/payments/:merchantTradeNo", s.getPayment)
+	control.POST("/payments/:merchantTradeNo/pay", s.payPayment)
+	control.POST("/payments/:merchantTradeNo/fail", s.failPayment)
+	control.POST("/payments/:merchantTradeNo/expire", s.expirePayment)
+	control.POST("/payments/:merchantTradeNo/callback", s.resendCallback)
+	control.POST("/faults", s.createFault)
+
+	return router
+}
+
+// verifyRequest inject configured fault and verify request signature when api public key is given.
+// serviceCode is empty for QRIS api
+func (s *Simulator) verifyRequest(signaturePath, serviceCode string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if status, ok := s.takeFault(signaturePath); ok {
+			log.Warn().Str("path", signaturePath).Int("status", status).Msg("inject fault")
+			s.abort(ctx, status, serviceCode, "simulated fault")
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			s.abort(ctx, http.StatusBadRequest, serviceCode, "failed to read body")
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if s.Config.ClientKeyPEM == "" {
+			ctx.Next()
+			return
+		}
+
+		err = helper.VerifyPaylabsSignature(http.MethodPost, signaturePath, body, ctx.GetHeader("X-TIMESTAMP"), ctx.GetHeader("X-SIGNATURE"), s.Config.ClientKeyPEM)
+		if err != nil {
+			log.Warn().Err(err).Str("path", signaturePath).Msg("invalid request signature")
+			s.abort(ctx, http.StatusUnauthorized, serviceCode, "invalid signature")
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func (s *Simulator) abort(ctx *gin.Context, status int, serviceCode, message string) {
+	if serviceCode != "" {
+		ctx.AbortWithStatusJSON(status, paylabs.VASnapResponse{
+			ResponseCode:    snapResponseCode(status, serviceCode),
+			ResponseMessage: message,
+		})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(status, paylabs.QRISResponse{
+		MerchantID: s.Config.MerchantID,
+		ErrCode:    strconv.Itoa(status),
+		ErrCodeDes: message,
+	})
+}
+
+func snapResponseCode(status int, serviceCode string) string {
+	caseCode := "00"
+	if status >= http.StatusBadRequest {
+		caseCode = "01"
+	}
+
+	return fmt.Sprintf("%d%s%s", status, serviceCode, caseCode)
+}
+
+func formatAmount(amount int) string {
+	return strconv.Itoa(amount) + ".00"
+}
+
+func parseAmount(amount string) (int, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(value), nil
+}
+
+// paylabsQRISStatus map simulator status to paylabs QRIS status: 01 pending, 02 success, 09 failed
+func paylabsQRISStatus(status string) string {
+	switch status {
+	case StatusPaid:
+		return "02"
+	case StatusPending:
+		return "01"
+	}
+
+	return "09"
+}
+
+// paylabsVAFlagStatus map simulator status to SNAP payment flag status: 00 success, 01 reject, 02 timeout
+func paylabsVAFlagStatus(status string) string {
+	switch status {
+	case StatusPaid:
+		return "00"
+	case StatusFailed, StatusCanceled:
+		return "01"
+	case StatusExpired:
+		return "02"
+	}
+
+	return ""
+}
+
+func (s *Simulator) createVA(ctx *gin.Context) {
+	var req dto.VirtualAccountSnapRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, snapServiceCreateVA, "invalid request body")
+		return
+	}
+
+	amount, err := parseAmount(req.TotalAmount.Value)
+	if err != nil || req.TrxID == "" || req.VirtualAccountNo == "" {
+		s.abort(ctx, http.StatusBadRequest, snapServiceCreateVA, "invalid mandatory field")
+		return
+	}
+
+	expiredAt, err := time.Parse(time.RFC3339, req.ExpiredDate)
+	if err != nil {
+		s.abort(ctx, http.StatusBadRequest, snapServiceCreateVA, "invalid expired date")
+		return
+	}
+
+	s.mu.Lock()
+	platformTradeNo := s.nextID("VA")
+	s.mu.Unlock()
+
+	payment := &Payment{
+		PaymentType:      req.AdditionalInfo.PaymentType,
+		MerchantTradeNo:  req.TrxID,
+		PlatformTradeNo:  platformTradeNo,
+		Amount:           amount,
+		Status:           StatusPending,
+		CreatedAt:        time.Now(),
+		ExpiredAt:        expiredAt,
+		PartnerServiceID: req.PartnerServiceID,
+		CustomerNo:       req.CustomerNo,
+		VirtualAccountNo: req.VirtualAccountNo,
+		VirtualAccountNm: req.VirtualAccountName,
+		Callbacks:        make([]CallbackAttempt, 0),
+	}
+
+	err = s.savePayment(payment)
+	if err != nil {
+		s.abort(ctx, http.StatusConflict, snapServiceCreateVA, err.Error())
+		return
+	}
+	s.scheduleScenario(payment.MerchantTradeNo)
+
+	log.Info().Str("merchantTradeNo", payment.MerchantTradeNo).Str("virtualAccountNo", payment.VirtualAccountNo).Int("amount", amount).Msg("virtual account created")
+	ctx.JSON(http.StatusOK, paylabs.VASnapResponse{
+		ResponseCode:       snapResponseCode(http.StatusOK, snapServiceCreateVA),
+		ResponseMessage:    "Successful",
+		VirtualAccountData: vaData(*payment),
+	})
+}
+
+func (s *Simulator) inquiryVA(ctx *gin.Context) {
+	var req paylabs.VASnapInquiryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, snapServiceStatusVA, "invalid request body")
+		return
+	}
+
+	payment, ok := s.findPaymentByVA(req.VirtualAccountNo)
+	if !ok {
+		s.abort(ctx, http.StatusNotFound, snapServiceStatusVA, "virtual account not found")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paylabs.VASnapResponse{
+		ResponseCode:       snapResponseCode(http.StatusOK, snapServiceStatusVA),
+		ResponseMessage:    "Successful",
+		VirtualAccountData: vaData(payment),
+	})
+}
+
+func (s *Simulator) deleteVA(ctx *gin.Context) {
+	var req paylabs.VASnapDeleteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, snapServiceDeleteVA, "invalid request body")
+		return
+	}
+
+	payment, err := s.updatePayment(req.TrxID, func(p *Payment) error {
+		if p.Status != StatusPending {
+			return errPaymentNotPending
+		}
+
+		p.Status = StatusCanceled
+		return nil
+	})
+	if err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errPaymentNotFound) {
+			status = http.StatusNotFound
+		}
+		s.abort(ctx, status, snapServiceDeleteVA, err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paylabs.VASnapResponse{
+		ResponseCode:       snapResponseCode(http.StatusOK, snapServiceDeleteVA),
+		ResponseMessage:    "Successful",
+		VirtualAccountData: vaData(payment),
+	})
+}
+
+func vaData(payment Payment) *paylabs.VirtualAccountData {
+	data := &paylabs.VirtualAccountData{
+		PartnerServiceID:  payment.PartnerServiceID,
+		CustomerNo:        payment.CustomerNo,
+		VirtualAccountNo:  payment.VirtualAccountNo,
+		TrxID:             payment.MerchantTradeNo,
+		PaymentRequestID:  payment.PaymentRequestID,
+		PaymentFlagStatus: paylabsVAFlagStatus(payment.Status),
+		TotalAmount:       &dto.Amount{Value: formatAmount(payment.Amount), Currency: "IDR"},
+		ExpiredDate:       payment.ExpiredAt.Format(time.RFC3339),
+	}
+	if payment.PaidAt != nil {
+		data.PaidAmount = &dto.Amount{Value: formatAmount(payment.PaidAmount), Currency: "IDR"}
+		data.TrxDateTime = payment.PaidAt.Format(time.RFC3339)
+	}
+
+	return data
+}
+
+func (s *Simulator) createQRIS(ctx *gin.Context) {
+	var req dto.PaylabsQRISRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid request body")
+		return
+	}
+
+	amount, err := parseAmount(req.Amount)
+	if err != nil || req.MerchantTradeNo == "" || req.NotifyURL == "" {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid mandatory field")
+		return
+	}
+
+	s.mu.Lock()
+	platformTradeNo := s.nextID("QR")
+	s.mu.Unlock()
+
+	now := time.Now()
+	payment := &Payment{
+		PaymentType:     PaymentTypeQRIS,
+		MerchantTradeNo: req.MerchantTradeNo,
+		PlatformTradeNo: platformTradeNo,
+		Amount:          amount,
+		Status:          StatusPending,
+		NotifyURL:       req.NotifyURL,
+		ProductName:     req.ProductName,
+		CreatedAt:       now,
+		ExpiredAt:       now.Add(time.Duration(req.Expire) * time.Second),
+		QRCode:          "00020101021226SIMULATOR" + platformTradeNo,
+		Callbacks:       make([]CallbackAttempt, 0),
+	}
+
+	err = s.savePayment(payment)
+	if err != nil {
+		s.abort(ctx, http.StatusConflict, "", err.Error())
+		return
+	}
+	s.scheduleScenario(payment.MerchantTradeNo)
+
+	log.Info().Str("merchantTradeNo", payment.MerchantTradeNo).Int("amount", amount).Msg("qris created")
+	ctx.JSON(http.StatusOK, s.qrisResponse(*payment, req.RequestID))
+}
+
+func (s *Simulator) queryQRIS(ctx *gin.Context) {
+	var req paylabs.QRISQueryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid request body")
+		return
+	}
+
+	payment, ok := s.findPayment(req.MerchantTradeNo)
+	if !ok {
+		s.abort(ctx, http.StatusNotFound, "", errPaymentNotFound.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.qrisResponse(payment, req.RequestID))
+}
+
+func (s *Simulator) cancelQRIS(ctx *gin.Context) {
+	var req paylabs.QRISCancelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid request body")
+		return
+	}
+
+	payment, err := s.updatePayment(req.MerchantTradeNo, func(p *Payment) error {
+		if p.Status != StatusPending {
+			return errPaymentNotPending
+		}
+
+		p.Status = StatusCanceled
+		return nil
+	})
+	if err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, errPaymentNotFound) {
+			status = http.StatusNotFound
+		}
+		s.abort(ctx, status, "", err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.qrisResponse(payment, req.RequestID))
+}
+
+func (s *Simulator) refundQRIS(ctx *gin.Context) {
+	var req paylabs.QRISRefundRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid request body")
+		return
+	}
+
+	refundAmount, err := parseAmount(req.RefundAmount)
+	if err != nil || refundAmount <= 0 {
+		s.abort(ctx, http.StatusBadRequest, "", "invalid refund amount")
+		return
+	}
+
+	payment, err := s.updatePayment(req.MerchantTradeNo, func(p *Payment) error {
+		if p.Status != StatusPaid {
+			return errPaymentNotPending
+		}
+		if p.RefundedAmount+refundAmount > p.PaidAmount {
+			return errors.New("refund amount exceed paid amount")
+		}
+
+		p.RefundedAmount += refundAmount
+		return nil
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errPaymentNotFound) {
+			status = http.StatusNotFound
+		}
+		s.abort(ctx, status, "", err.Error())
+		return
+	}
+
+	res := s.qrisResponse(payment, req.RequestID)
+	res.MerchantRefundNo = req.MerchantRefundNo
+	s.mu.Lock()
+	res.PlatformRefundNo = s.nextID("RF")
+	s.mu.Unlock()
+	res.RefundAmount = formatAmount(refundAmount)
+
+	log.Info().Str("merchantTradeNo", payment.MerchantTradeNo).Int("refundAmount", refundAmount).Msg("qris refunded")
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (s *Simulator) qrisResponse(payment Payment, requestID string) paylabs.QRISResponse {
+	res := paylabs.QRISResponse{
+		MerchantID:      s.Config.MerchantID,
+		RequestID:       requestID,
+		ErrCode:         "0",
+		PaymentType:     payment.PaymentType,
+		MerchantTradeNo: payment.MerchantTradeNo,
+		PlatformTradeNo: payment.PlatformTradeNo,
+		QRCode:          payment.QRCode,
+		Status:          paylabsQRISStatus(payment.Status),
+		Amount:          formatAmount(payment.Amount),
+		CreateTime:      payment.CreatedAt.Format(successTimeLayout),
+		ExpiredTime:     payment.ExpiredAt.Format(successTimeLayout),
+	}
+	if payment.PaidAt != nil {
+		res.Amount = formatAmount(payment.PaidAmount)
+		res.SuccessTime = payment.PaidAt.Format(successTimeLayout)
+		res.PaymentMethodInfo = &dto.QRISPaymentMethodInfo{
+			NMID: "ID1020000000001",
+			RRN:  payment.RRN,
+		}
+	}
+
+	return res
+}
+
+type payRequest struct {
+	Amount int `json:"amount"` // empty means full amount
+}
+
+func (s *Simulator) getPayments(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.listPayments())
+}
+
+func (s *Simulator) getPayment(ctx *gin.Context) {
+	payment, ok := s.findPayment(ctx.Param("merchantTradeNo"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": errPaymentNotFound.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payment)
+}
+
+func (s *Simulator) payPayment(ctx *gin.Context) {
+	var req payRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := json.NewDecoder(ctx.Request.Body).Decode(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "invalid request body"})
+			return
+		}
+	}
+
+	payment, err := s.Pay(ctx.Param("merchantTradeNo"), req.Amount)
+	respondControl(ctx, payment, err)
+}
+
+func (s *Simulator) failPayment(ctx *gin.Context) {
+	payment, err := s.Fail(ctx.Param("merchantTradeNo"))
+	respondControl(ctx, payment, err)
+}
+
+func (s *Simulator) expirePayment(ctx *gin.Context) {
+	payment, err := s.Expire(ctx.Param("merchantTradeNo"))
+	respondControl(ctx, payment, err)
+}
+
+func (s *Simulator) resendCallback(ctx *gin.Context) {
+	payment, err := s.SendCallback(ctx.Param("merchantTradeNo"))
+	respondControl(ctx, payment, err)
+}
+
+func (s *Simulator) createFault(ctx *gin.Context) {
+	var req Fault
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	// fault is matched with signature path, which doesn't include snap base path
+	req.Path = strings.TrimPrefix(req.Path, snapBasePath)
+	s.addFault(req)
+	ctx.JSON(http.StatusOK, req)
+}
+
+func respondControl(ctx *gin.Context, payment Payment, err error) {
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, payment)
+	case errors.Is(err, errPaymentNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+	case errors.Is(err, errPaymentNotPending), errors.Is(err, errCallbackNotAvailable):
+		ctx.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+	}
+}
@@ -0,0 +1,342 @@
+// Package simulator is a fake paylabs server for local development and end-to-end testing.
+// It accepts the same requests as paylabs, keeps payments in memory, and sends signed callbacks
+// to the api either on demand (control endpoints) or automatically after a delay.
+package simulator
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	timestampLayout   = "2006-01-02T15:04:05.999+07:00"
+	successTimeLayout = "20060102150405"
+
+	PaymentTypeQRIS = "QRIS"
+)
+
+// Payment status kept by simulator, mapped to paylabs status on each response
+const (
+	StatusPending  = "PENDING"
+	StatusPaid     = "PAID"
+	StatusFailed   = "FAILED"
+	StatusExpired  = "EXPIRED"
+	StatusCanceled = "CANCELED"
+)
+
+// Scenario run automatically for every new payment
+const (
+	ScenarioNone   = "none"   // wait for control endpoint
+	ScenarioPay    = "pay"    // pay after auto delay
+	ScenarioFail   = "fail"   // fail after auto delay
+	ScenarioExpire = "expire" // never paid, expired at its expired time
+)
+
+type Config struct {
+	MerchantID     string        // last 6 characters of paylabs account id used by api
+	VACallbackURL  string        // paylabs VA callback is configured on dashboard instead of per request
+	PrivateKeyPEM  string        // simulator private key, its public key is PAYLABS.PUBLIC_KEY of api
+	ClientKeyPEM   string        // api public key, request signature isn't verified when empty
+	Scenario       string        // Scenario*
+	AutoDelay      time.Duration // delay before auto scenario is run
+	CallbackClient *http.Client
+}
+
+type Payment struct {
+	PaymentType     string     `json:"payment_type"`
+	MerchantTradeNo string     `json:"merchant_trade_no"`
+	PlatformTradeNo string     `json:"platform_trade_no"`
+	Amount          int        `json:"amount"`
+	Status          string     `json:"status"`
+	NotifyURL       string     `json:"notify_url"`
+	ProductName     string     `json:"product_name"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpiredAt       time.Time  `json:"expired_at"`
+	PaidAt          *time.Time `json:"paid_at"`
+	PaidAmount      int        `json:"paid_amount"`
+	RefundedAmount  int        `json:"refunded_amount"`
+
+	// VA only
+	PartnerServiceID string `json:"partner_service_id,omitempty"`
+	CustomerNo       string `json:"customer_no,omitempty"`
+	VirtualAccountNo string `json:"virtual_account_no,omitempty"`
+	VirtualAccountNm string `json:"virtual_account_name,omitempty"`
+	PaymentRequestID string `json:"payment_request_id,omitempty"`
+
+	// QRIS only
+	QRCode string `json:"qr_code,omitempty"`
+	RRN    string `json:"rrn,omitempty"`
+
+	Callbacks []CallbackAttempt `json:"callbacks"`
+}
+
+type CallbackAttempt struct {
+	URL        string    `json:"url"`
+	StatusCode int       `json:"status_code"`
+	Response   string    `json:"response"`
+	Error      string    `json:"error,omitempty"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// Fault make the next Count requests to Path fail with Status, to simulate paylabs outage
+type Fault struct {
+	Path   string `json:"path" binding:"required"`
+	Status int    `json:"status" binding:"required,min=400,max=599"`
+	Count  int    `json:"count" binding:"required,min=1"`
+}
+
+type Simulator struct {
+	Config Config
+
+	mu       sync.Mutex
+	payments map[string]*Payment // keyed by merchant trade no
+	faults   map[string]*Fault   // keyed by path
+	sequence int
+}
+
+func New(cfg Config) (s *Simulator, err error) {
+	if cfg.PrivateKeyPEM == "" {
+		return nil, fmt.Errorf("simulator private key is required")
+	}
+	if cfg.Scenario == "" {
+		cfg.Scenario = ScenarioNone
+	}
+	if cfg.CallbackClient == nil {
+		cfg.CallbackClient = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	return &Simulator{
+		Config:   cfg,
+		payments: make(map[string]*Payment),
+		faults:   make(map[string]*Fault),
+	}, nil
+}
+
+// GenerateKey generate RSA key pair in PEM format, public key is what api need as PAYLABS.PUBLIC_KEY
+func GenerateKey() (privateKeyPEM, publicKeyPEM string, err error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return
+	}
+
+	privateKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+	publicKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}))
+	return
+}
+
+func (s *Simulator) nextID(prefix string) string {
+	s.sequence++
+	return fmt.Sprintf("%s%s%06d", prefix, time.Now().Format(successTimeLayout), s.sequence)
+}
+
+// takeFault consume one fault registered for path
+func (s *Simulator) takeFault(path string) (status int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fault, ok := s.faults[path]
+	if !ok {
+		return 0, false
+	}
+
+	fault.Count--
+	if fault.Count <= 0 {
+		delete(s.faults, path)
+	}
+
+	return fault.Status, true
+}
+
+func (s *Simulator) addFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[fault.Path] = &fault
+}
+
+func (s *Simulator) savePayment(payment *Payment) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exist := s.payments[payment.MerchantTradeNo]; exist {
+		return fmt.Errorf("merchant trade no %s already exist", payment.MerchantTradeNo)
+	}
+
+	s.payments[payment.MerchantTradeNo] = payment
+	return nil
+}
+
+// findPayment return a copy, so it can be read without holding the lock
+func (s *Simulator) findPayment(merchantTradeNo string) (payment Payment, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[merchantTradeNo]
+	if !ok {
+		return
+	}
+
+	s.expire(p)
+	return *p, true
+}
+
+func (s *Simulator) findPaymentByVA(virtualAccountNo string) (payment Payment, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// VA number is derived from transaction id so it's reused by the same transaction only, latest one wins
+	var found *Payment
+	for _, p := range s.payments {
+		if p.VirtualAccountNo == virtualAccountNo && (found == nil || p.CreatedAt.After(found.CreatedAt)) {
+			found = p
+		}
+	}
+	if found == nil {
+		return
+	}
+
+	s.expire(found)
+	return *found, true
+}
+
+func (s *Simulator) listPayments() (res []Payment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res = make([]Payment, 0, len(s.payments))
+	for _, p := range s.payments {
+		s.expire(p)
+		res = append(res, *p)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.After(res[j].CreatedAt)
+	})
+
+	return
+}
+
+// updatePayment apply fn to payment under lock and return the updated copy
+func (s *Simulator) updatePayment(merchantTradeNo string, fn func(p *Payment) error) (payment Payment, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.payments[merchantTradeNo]
+	if !ok {
+		return payment, errPaymentNotFound
+	}
+
+	s.expire(p)
+	err = fn(p)
+	if err != nil {
+		return *p, err
+	}
+
+	return *p, nil
+}
+
+// expire pending payment lazily, must be called with lock held
+func (s *Simulator) expire(p *Payment) {
+	if p.Status == StatusPending && !p.ExpiredAt.IsZero() && time.Now().After(p.ExpiredAt) {
+		p.Status = StatusExpired
+	}
+}
+
+// scheduleScenario run configured scenario for new payment
+func (s *Simulator) scheduleScenario(merchantTradeNo string) {
+	switch s.Config.Scenario {
+	case ScenarioPay, ScenarioFail:
+	default:
+		return
+	}
+
+	time.AfterFunc(s.Config.AutoDelay, func() {
+		var err error
+		if s.Config.Scenario == ScenarioPay {
+			_, err = s.Pay(merchantTradeNo, 0)
+		} else {
+			_, err = s.Fail(merchantTradeNo)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("merchantTradeNo", merchantTradeNo).Str("scenario", s.Config.Scenario).Msg("auto scenario is skipped")
+		}
+	})
+}
+
+// Pay mark payment as paid and send success callback. amount 0 means the full amount,
+// other amount simulate partial or over payment
+func (s *Simulator) Pay(merchantTradeNo string, amount int) (payment Payment, err error) {
+	payment, err = s.updatePayment(merchantTradeNo, func(p *Payment) error {
+		if p.Status != StatusPending {
+			return fmt.Errorf("%w: payment is %s", errPaymentNotPending, p.Status)
+		}
+
+		now := time.Now()
+		p.Status = StatusPaid
+		p.PaidAt = &now
+		p.PaidAmount = p.Amount
+		if amount > 0 {
+			p.PaidAmount = amount
+		}
+		if p.PaymentType == PaymentTypeQRIS {
+			p.RRN = strconv.FormatInt(now.UnixNano()%1000000000000, 10)
+		} else {
+			p.PaymentRequestID = s.nextID("PR")
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	return s.SendCallback(merchantTradeNo)
+}
+
+// Fail mark payment as failed. Only QRIS send callback on failure, VA is just never paid
+func (s *Simulator) Fail(merchantTradeNo string) (payment Payment, err error) {
+	payment, err = s.updatePayment(merchantTradeNo, func(p *Payment) error {
+		if p.Status != StatusPending {
+			return fmt.Errorf("%w: payment is %s", errPaymentNotPending, p.Status)
+		}
+
+		p.Status = StatusFailed
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if payment.PaymentType != PaymentTypeQRIS {
+		return
+	}
+
+	return s.SendCallback(merchantTradeNo)
+}
+
+// Expire move payment expiry to now, so inquiry report it as expired
+func (s *Simulator) Expire(merchantTradeNo string) (payment Payment, err error) {
+	return s.updatePayment(merchantTradeNo, func(p *Payment) error {
+		if p.Status != StatusPending {
+			return fmt.Errorf("%w: payment is %s", errPaymentNotPending, p.Status)
+		}
+
+		p.ExpiredAt = time.Now()
+		p.Status = StatusExpired
+		return nil
+	})
+}
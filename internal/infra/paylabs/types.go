@@ -0,0 +1,91 @@
+package paylabs
+
+import "assist-tix/dto"
+
+// SNAP virtual account
+
+type VASnapInquiryRequest struct {
+	PartnerServiceID string             `json:"partnerServiceId"`
+	CustomerNo       string             `json:"customerNo"`
+	VirtualAccountNo string             `json:"virtualAccountNo"`
+	InquiryRequestID string             `json:"inquiryRequestId"`
+	AdditionalInfo   dto.AdditionalInfo `json:"additionalInfo"`
+}
+
+type VASnapDeleteRequest struct {
+	PartnerServiceID string             `json:"partnerServiceId"`
+	CustomerNo       string             `json:"customerNo"`
+	VirtualAccountNo string             `json:"virtualAccountNo"`
+	TrxID            string             `json:"trxId"`
+	AdditionalInfo   dto.AdditionalInfo `json:"additionalInfo"`
+}
+
+type VASnapResponse struct {
+	ResponseCode       string              `json:"responseCode"`
+	ResponseMessage    string              `json:"responseMessage"`
+	VirtualAccountData *VirtualAccountData `json:"virtualAccountData"`
+}
+
+type VirtualAccountData struct {
+	PartnerServiceID  string      `json:"partnerServiceId"`
+	CustomerNo        string      `json:"customerNo"`
+	VirtualAccountNo  string      `json:"virtualAccountNo"`
+	TrxID             string      `json:"trxId"`
+	PaymentRequestID  string      `json:"paymentRequestId"`
+	PaymentFlagStatus string      `json:"paymentFlagStatus"` // 00 success, 01 reject, 02 timeout
+	TotalAmount       *dto.Amount `json:"totalAmount"`
+	PaidAmount        *dto.Amount `json:"paidAmount"`
+	TrxDateTime       string      `json:"trxDateTime"`
+	ExpiredDate       string      `json:"expiredDate"`
+}
+
+// QRIS
+
+type QRISQueryRequest struct {
+	MerchantID      string `json:"merchantId"`
+	MerchantTradeNo string `json:"merchantTradeNo"`
+	RequestID       string `json:"requestId"`
+	PaymentType     string `json:"paymentType"`
+}
+
+type QRISCancelRequest struct {
+	MerchantID      string `json:"merchantId"`
+	MerchantTradeNo string `json:"merchantTradeNo"`
+	RequestID       string `json:"requestId"`
+	PlatformTradeNo string `json:"platformTradeNo"`
+	QRCode          string `json:"qrCode"`
+}
+
+type QRISRefundRequest struct {
+	MerchantID       string `json:"merchantId"`
+	RequestID        string `json:"requestId"`
+	MerchantTradeNo  string `json:"merchantTradeNo"`
+	PlatformTradeNo  string `json:"platformTradeNo"`
+	MerchantRefundNo string `json:"merchantRefundNo"`
+	PaymentType      string `json:"paymentType"`
+	Amount           string `json:"amount"`       // Amount with 2 decimal
+	RefundAmount     string `json:"refundAmount"` // Amount with 2 decimal
+	Reason           string `json:"reason"`
+}
+
+type QRISResponse struct {
+	MerchantID       string `json:"merchantId"`
+	RequestID        string `json:"requestId"`
+	ErrCode          string `json:"errCode"` // 0 is success
+	ErrCodeDes       string `json:"errCodeDes"`
+	PaymentType      string `json:"paymentType"`
+	MerchantTradeNo  string `json:"merchantTradeNo"`
+	PlatformTradeNo  string `json:"platformTradeNo"`
+	QRCode           string `json:"qrCode"`
+	QRUrl            string `json:"qrisUrl"`
+	Status           string `json:"status"` // 01 pending, 02 success, 09 failed
+	Amount           string `json:"amount"`
+	CreateTime       string `json:"createTime"`
+	ExpiredTime      string `json:"expiredTime"`
+	SuccessTime      string `json:"successTime"`
+	MerchantRefundNo string `json:"merchantRefundNo"`
+	PlatformRefundNo string `json:"platformRefundNo"`
+	RefundAmount     string `json:"refundAmount"`
+
+	PaymentMethodInfo *dto.QRISPaymentMethodInfo `json:"paymentMethodInfo,omitempty"` // only on paid query response
+}
@@ -0,0 +1,306 @@
+package payment
+
+import (
+	"assist-tix/config"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/internal/domain/payment"
+	"assist-tix/internal/infra/paylabs"
+	"assist-tix/lib"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	paylabsTimestampLayout   = "2006-01-02T15:04:05.999+07:00"
+	paylabsSuccessTimeLayout = "20060102150405"
+
+	paylabsQRISStatusPending = "01"
+	paylabsQRISStatusSuccess = "02"
+	paylabsQRISStatusFailed  = "09"
+)
+
+type PaylabsGateway struct {
+	Env    *config.EnvironmentVariable
+	Client *paylabs.Client
+}
+
+func NewPaylabsGateway(env *config.EnvironmentVariable, client *paylabs.Client) *PaylabsGateway {
+	return &PaylabsGateway{
+		Env:    env,
+		Client: client,
+	}
+}
+
+// Virtual account number is derived from transaction id, so it can be rebuilt for inquiry and delete
+func (g *PaylabsGateway) customerNo(transactionID string) string {
+	return transactionID[:20]
+}
+
+func (g *PaylabsGateway) virtualAccountNo(transactionID string) string {
+	return g.customerNo(transactionID) + g.Client.MerchantID()
+}
+
+func formatPaylabsAmount(amount int) string {
+	return strconv.Itoa(amount) + ".00"
+}
+
+func parsePaylabsAmount(amount string) int {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0
+	}
+
+	return int(value)
+}
+
+func (g *PaylabsGateway) CreateCharge(ctx context.Context, req payment.ChargeRequest) (res payment.ChargeResponse, err error) {
+	switch {
+	case helper.IsVA(req.PaymentMethod):
+		return g.createVASnap(ctx, req)
+	case helper.IsQRIS(req.PaymentMethod):
+		return g.createQRIS(ctx, req)
+	}
+
+	return res, &lib.ErrorPaymentMethodInvalid
+}
+
+func (g *PaylabsGateway) createVASnap(ctx context.Context, req payment.ChargeRequest) (res payment.ChargeResponse, err error) {
+	payload := dto.VirtualAccountSnapRequest{
+		PartnerServiceID:    g.Client.PartnerServiceID(),           // 8 characters
+		CustomerNo:          g.customerNo(req.TransactionID),       // Fixed 20-digit value
+		VirtualAccountNo:    g.virtualAccountNo(req.TransactionID), // 28-digit composite value
+		VirtualAccountName:  req.CustomerName,                      // Payer name
+		VirtualAccountEmail: req.CustomerEmail,                     // Payer email
+		TrxID:               req.OrderNumber,                       // Merchant transaction number
+		TotalAmount: dto.Amount{
+			Value:    formatPaylabsAmount(req.Amount), // Amount with 2 decimal
+			Currency: "IDR",                           // Fixed currency
+		},
+		AdditionalInfo: dto.AdditionalInfo{
+			PaymentType: req.PaymentMethod,
+		},
+		ExpiredDate: req.ExpiredAt.Format("2006-01-02T15:04:05+07:00"), // ISO-8601 formatted expiration
+	}
+
+	resp, err := g.Client.CreateVA(ctx, payload, req.TransactionID, req.ClientIP)
+	if err != nil {
+		return
+	}
+
+	res.PaymentAdditionalInfo = resp.VirtualAccountData.VirtualAccountNo
+	return
+}
+
+func (g *PaylabsGateway) createQRIS(ctx context.Context, req payment.ChargeRequest) (res payment.ChargeResponse, err error) {
+	payload := dto.PaylabsQRISRequest{
+		MerchantID:      g.Client.MerchantID(),
+		MerchantTradeNo: req.OrderNumber,
+		RequestID:       helper.GenerateRequestID(),
+		PaymentType:     "QRIS",
+		Amount:          formatPaylabsAmount(req.Amount),
+		ProductName:     req.ProductName,
+		Expire:          int(time.Until(req.ExpiredAt).Seconds()),
+		NotifyURL:       g.Env.Api.Url + "/api/v1/external/paylabs/qris/callback",
+	}
+
+	resp, err := g.Client.CreateQRIS(ctx, payload)
+	if err != nil {
+		return
+	}
+
+	res.PaymentAdditionalInfo = resp.QRCode
+	res.PGOrderID = resp.PlatformTradeNo
+	return
+}
+
+func (g *PaylabsGateway) InquireStatus(ctx context.Context, req payment.InquiryRequest) (res payment.InquiryResponse, err error) {
+	switch {
+	case helper.IsVA(req.PaymentMethod):
+		return g.inquireVASnap(ctx, req)
+	case helper.IsQRIS(req.PaymentMethod):
+		return g.inquireQRIS(ctx, req)
+	}
+
+	return res, &lib.ErrorPaymentMethodInvalid
+}
+
+func (g *PaylabsGateway) inquireVASnap(ctx context.Context, req payment.InquiryRequest) (res payment.InquiryResponse, err error) {
+	resp, err := g.Client.InquiryVA(ctx, paylabs.VASnapInquiryRequest{
+		PartnerServiceID: g.Client.PartnerServiceID(),
+		CustomerNo:       g.customerNo(req.TransactionID),
+		VirtualAccountNo: g.virtualAccountNo(req.TransactionID),
+		InquiryRequestID: helper.GenerateRequestID(),
+		AdditionalInfo: dto.AdditionalInfo{
+			PaymentType: req.PaymentMethod,
+		},
+	}, req.TransactionID)
+	if err != nil {
+		return
+	}
+
+	res.Status = lib.PaymentStatusPending
+	if resp.VirtualAccountData == nil {
+		return
+	}
+
+	res.RawStatus = resp.VirtualAccountData.PaymentFlagStatus
+	res.PGOrderID = resp.VirtualAccountData.PaymentRequestID
+	if resp.VirtualAccountData.PaidAmount != nil {
+		res.PaidAmount = parsePaylabsAmount(resp.VirtualAccountData.PaidAmount.Value)
+	}
+
+	// SNAP payment flag status: 00 success, 01 reject, 02 timeout
+	switch resp.VirtualAccountData.PaymentFlagStatus {
+	case "00":
+		res.Status = lib.PaymentStatusSuccess
+		paidAt, errParse := time.Parse(time.RFC3339, resp.VirtualAccountData.TrxDateTime)
+		if errParse == nil {
+			res.PaidAt = &paidAt
+		}
+	case "01":
+		res.Status = lib.PaymentStatusFailed
+	}
+
+	return
+}
+
+func (g *PaylabsGateway) inquireQRIS(ctx context.Context, req payment.InquiryRequest) (res payment.InquiryResponse, err error) {
+	resp, err := g.Client.QueryQRIS(ctx, paylabs.QRISQueryRequest{
+		MerchantID:      g.Client.MerchantID(),
+		MerchantTradeNo: req.OrderNumber,
+		RequestID:       helper.GenerateRequestID(),
+		PaymentType:     "QRIS",
+	})
+	if err != nil {
+		return
+	}
+
+	res.RawStatus = resp.Status
+	res.PGOrderID = resp.PlatformTradeNo
+	res.PaidAmount = parsePaylabsAmount(resp.Amount)
+	// callback store RRN as payment reference, keep it the same so later callback is acknowledged as duplicate
+	if resp.PaymentMethodInfo != nil && resp.PaymentMethodInfo.RRN != "" {
+		res.PGOrderID = resp.PaymentMethodInfo.RRN
+	}
+
+	switch resp.Status {
+	case paylabsQRISStatusSuccess:
+		res.Status = lib.PaymentStatusSuccess
+		paidAt, errParse := time.ParseInLocation(paylabsSuccessTimeLayout, resp.SuccessTime, time.Local)
+		if errParse == nil {
+			res.PaidAt = &paidAt
+		}
+	case paylabsQRISStatusFailed:
+		res.Status = lib.PaymentStatusFailed
+	case paylabsQRISStatusPending:
+		res.Status = lib.PaymentStatusPending
+	default:
+		res.Status = lib.PaymentStatusUnknown
+	}
+
+	return
+}
+
+func (g *PaylabsGateway) Cancel(ctx context.Context, req payment.CancelRequest) (err error) {
+	switch {
+	case helper.IsVA(req.PaymentMethod):
+		_, err = g.Client.DeleteVA(ctx, paylabs.VASnapDeleteRequest{
+			PartnerServiceID: g.Client.PartnerServiceID(),
+			CustomerNo:       g.customerNo(req.TransactionID),
+			VirtualAccountNo: g.virtualAccountNo(req.TransactionID),
+			TrxID:            req.OrderNumber,
+			AdditionalInfo: dto.AdditionalInfo{
+				PaymentType: req.PaymentMethod,
+			},
+		}, req.TransactionID)
+		return
+	case helper.IsQRIS(req.PaymentMethod):
+		_, err = g.Client.CancelQRIS(ctx, paylabs.QRISCancelRequest{
+			MerchantID:      g.Client.MerchantID(),
+			MerchantTradeNo: req.OrderNumber,
+			RequestID:       helper.GenerateRequestID(),
+			PlatformTradeNo: req.PGOrderID,
+			QRCode:          req.PaymentAdditionalInfo,
+		})
+		return
+	}
+
+	return &lib.ErrorPaymentMethodInvalid
+}
+
+func (g *PaylabsGateway) Refund(ctx context.Context, req payment.RefundRequest) (res payment.RefundResponse, err error) {
+	// Virtual account is bank transfer, paylabs can't refund it through api
+	if !helper.IsQRIS(req.PaymentMethod) {
+		return res, &lib.ErrorPaymentOperationNotSupported
+	}
+
+	resp, err := g.Client.RefundQRIS(ctx, paylabs.QRISRefundRequest{
+		MerchantID:       g.Client.MerchantID(),
+		RequestID:        helper.GenerateRequestID(),
+		MerchantTradeNo:  req.OrderNumber,
+		PlatformTradeNo:  req.PGOrderID,
+		MerchantRefundNo: req.RefundNumber,
+		PaymentType:      "QRIS",
+		Amount:           formatPaylabsAmount(req.Amount),
+		RefundAmount:     formatPaylabsAmount(req.RefundAmount),
+		Reason:           req.Reason,
+	})
+	if err != nil {
+		return
+	}
+
+	res.Status = lib.PaymentStatusSuccess
+	res.PGRefundID = resp.PlatformRefundNo
+	res.RefundedTotal = parsePaylabsAmount(resp.RefundAmount)
+	return
+}
+
+func (g *PaylabsGateway) VerifyCallback(ctx context.Context, req payment.CallbackRequest) (err error) {
+	// VA snap callback is signed with snap path instead of our callback path
+	path := req.Path
+	if helper.IsVA(req.PaymentMethod) {
+		path = paylabs.PathVASnapCallback
+	}
+
+	var body bytes.Buffer
+	err = json.Compact(&body, req.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to compact callback body")
+		return &lib.ErrorCallbackSignatureInvalid
+	}
+
+	err = helper.VerifyPaylabsSignature(http.MethodPost, path, body.Bytes(), req.Headers["X-TIMESTAMP"], req.Headers["X-SIGNATURE"], g.Env.Paylabs.PublicKey)
+	if err != nil {
+		log.Error().Err(err).Msg("paylabs callback signature verification failed")
+		return &lib.ErrorCallbackSignatureInvalid
+	}
+
+	return nil
+}
+
+func (g *PaylabsGateway) SignCallbackResponse(ctx context.Context, path, requestID string, body []byte) (headers map[string]string, err error) {
+	date := time.Now().Format(paylabsTimestampLayout)
+
+	signature, err := helper.GeneratePaylabsSignature(http.MethodPost, path, body, date, g.Env.Paylabs.PrivateKey)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sign paylabs callback response")
+		return nil, &lib.ErrorPaymentGatewayKeyInvalid
+	}
+
+	headers = map[string]string{
+		"X-PARTNER-ID": g.Env.Paylabs.AccountID,
+		"X-REQUEST-ID": requestID,
+		"X-TIMESTAMP":  date,
+		"X-SIGNATURE":  signature,
+		"Content-Type": "application/json;charset=utf-8",
+	}
+
+	return
+}
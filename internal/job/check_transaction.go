@@ -1,11 +1,15 @@
 package job
 
 import (
+	"assist-tix/lib"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -48,3 +52,41 @@ func (j *CheckStatusTransactionJob) EnqueueCheckTransaction(ctx context.Context,
 	_, err = j.Client.EnqueueContext(ctx, task)
 	return err
 }
+
+type TransactionExpirer interface {
+	ExpireTransaction(ctx context.Context, transactionID string) (err error)
+}
+
+type CheckStatusTransactionHandler struct {
+	Expirer TransactionExpirer
+}
+
+func NewCheckStatusTransactionHandler(expirer TransactionExpirer) CheckStatusTransactionHandler {
+	return CheckStatusTransactionHandler{
+		Expirer: expirer,
+	}
+}
+
+func (h *CheckStatusTransactionHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload CheckStatusTransactionPayload
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal check status transaction payload")
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+
+	log.Info().Str("transactionId", payload.TransactionID).Time("createdAt", payload.CreatedAt).Msg("processing check status transaction")
+
+	err = h.Expirer.ExpireTransaction(ctx, payload.TransactionID)
+	if err != nil {
+		// Order is gone, retrying won't help
+		var tixErr *lib.TIXError
+		if errors.As(err, &tixErr) && *tixErr == lib.ErrorOrderNotFound {
+			return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package job
+
+import (
+	"assist-tix/dto"
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	QueueTypeReconcileTransaction = "transaction:reconcile"
+)
+
+type TransactionReconciler interface {
+	ReconcileTransactions(ctx context.Context) (res dto.PaymentReconciliationReport, err error)
+}
+
+type ReconcileTransactionHandler struct {
+	Reconciler TransactionReconciler
+}
+
+func NewReconcileTransactionHandler(reconciler TransactionReconciler) ReconcileTransactionHandler {
+	return ReconcileTransactionHandler{
+		Reconciler: reconciler,
+	}
+}
+
+// NewReconcileTransactionTask is registered on scheduler, unique so multiple worker only run it once on each tick
+func NewReconcileTransactionTask(timeout time.Duration) *asynq.Task {
+	return asynq.NewTask(
+		QueueTypeReconcileTransaction,
+		nil,
+		asynq.Timeout(timeout),
+		asynq.Unique(timeout),
+		asynq.MaxRetry(0), // next tick will pick it up again
+	)
+}
+
+func (h *ReconcileTransactionHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	report, err := h.Reconciler.ReconcileTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("checked", report.Checked).Interface("discrepancies", report.Discrepancies).Msg("payment reconciliation report")
+	return nil
+}
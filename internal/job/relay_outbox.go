@@ -0,0 +1,50 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	QueueTypeRelayOutbox = "outbox:relay"
+)
+
+type OutboxRelayer interface {
+	Relay(ctx context.Context) (published int, err error)
+}
+
+type RelayOutboxHandler struct {
+	Relayer OutboxRelayer
+}
+
+func NewRelayOutboxHandler(relayer OutboxRelayer) RelayOutboxHandler {
+	return RelayOutboxHandler{
+		Relayer: relayer,
+	}
+}
+
+// NewRelayOutboxTask is registered on scheduler, unique so multiple worker only run it once on each tick
+func NewRelayOutboxTask(timeout time.Duration) *asynq.Task {
+	return asynq.NewTask(
+		QueueTypeRelayOutbox,
+		nil,
+		asynq.Timeout(timeout),
+		asynq.Unique(timeout),
+		asynq.MaxRetry(0), // next tick will pick it up again
+	)
+}
+
+func (h *RelayOutboxHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	published, err := h.Relayer.Relay(ctx)
+	if err != nil {
+		return err
+	}
+
+	if published > 0 {
+		log.Info().Int("published", published).Msg("outbox relay done")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	QueueTypeReleaseSeatHold = "seat-hold:release"
+)
+
+type SeatHoldReleaser interface {
+	ReleaseExpired(ctx context.Context) (released int64, err error)
+}
+
+type ReleaseSeatHoldHandler struct {
+	Releaser SeatHoldReleaser
+}
+
+func NewReleaseSeatHoldHandler(releaser SeatHoldReleaser) ReleaseSeatHoldHandler {
+	return ReleaseSeatHoldHandler{
+		Releaser: releaser,
+	}
+}
+
+// NewReleaseSeatHoldTask is registered on scheduler, unique so multiple worker only run it once on each tick
+func NewReleaseSeatHoldTask(timeout time.Duration) *asynq.Task {
+	return asynq.NewTask(
+		QueueTypeReleaseSeatHold,
+		nil,
+		asynq.Timeout(timeout),
+		asynq.Unique(timeout),
+		asynq.MaxRetry(0), // next tick will pick it up again
+	)
+}
+
+func (h *ReleaseSeatHoldHandler) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	released, err := h.Releaser.ReleaseExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if released > 0 {
+		log.Info().Int64("released", released).Msg("expired seat holds released")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"assist-tix/config"
+	"assist-tix/internal/domain"
+	"assist-tix/internal/domain/seatmap"
+	"assist-tix/model"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type SeatmapUsecase struct {
+	Env    *config.EnvironmentVariable
+	Outbox domain.OutboxWriter
+}
+
+func NewSeatmapUsecase(
+	env *config.EnvironmentVariable,
+	outbox domain.OutboxWriter,
+) SeatmapUsecase {
+	return SeatmapUsecase{
+		Env:    env,
+		Outbox: outbox,
+	}
+}
+
+// SendSeatmapChange write new status of seats to outbox in tx, it's streamed to viewers of the sector once tx is committed
+func (u *SeatmapUsecase) SendSeatmapChange(
+	ctx context.Context,
+	tx pgx.Tx,
+	eventID, sectorID, status string,
+	seats []seatmap.Seat,
+) (outbox model.Outbox, err error) {
+	if len(seats) == 0 {
+		return
+	}
+
+	bytes, err := json.Marshal(seatmap.SeatmapChange{
+		EventID:   eventID,
+		SectorID:  sectorID,
+		Status:    status,
+		Seats:     seats,
+		ChangedAt: time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	// every change is a new message, the same seat can change back and forth
+	outbox, err = u.Outbox.Create(ctx, tx, model.Outbox{
+		Subject:   seatmap.Subject(u.Env.Nats.Subjects.SeatmapChange, eventID, sectorID),
+		MessageID: "seatmap-change:" + uuid.NewString(),
+		Payload:   bytes,
+	})
+	if err != nil {
+		return
+	}
+
+	log.Info().Int64("outboxId", outbox.ID).Str("eventId", eventID).Str("sectorId", sectorID).Str("status", status).Int("seats", len(seats)).Msg("success write seatmap change to outbox")
+
+	return
+}
@@ -3,35 +3,43 @@ package usecase
 import (
 	"assist-tix/config"
 	"assist-tix/entity"
+	"assist-tix/helper"
 	"assist-tix/internal/domain"
 	"assist-tix/internal/domain/async_callback"
 	"assist-tix/internal/domain/async_order"
 	domainEvent "assist-tix/internal/domain/event"
 	"assist-tix/model"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
 type TransactionUsecase struct {
 	Env            *config.EnvironmentVariable
 	EventPublisher domain.EventPublisher
+	Outbox         domain.OutboxWriter
 }
 
 func NewTransactionUsecase(
 	env *config.EnvironmentVariable,
 	publisher domain.EventPublisher,
+	outbox domain.OutboxWriter,
 ) TransactionUsecase {
 	return TransactionUsecase{
 		Env:            env,
 		EventPublisher: publisher,
+		Outbox:         outbox,
 	}
 }
 
+// SendAsyncOrder write async order to outbox in tx, it's published once tx is committed
 func (u *TransactionUsecase) SendAsyncOrder(
 	ctx context.Context,
+	tx pgx.Tx,
 	useGarudaId bool, // dkirim
 	itemCount int, // dikirim
 	trxAccessToken string, // dikirim
@@ -41,9 +49,10 @@ func (u *TransactionUsecase) SendAsyncOrder(
 	ticketCategory model.EventTicketCategory,
 	venueSector entity.VenueSector,
 	eventTransactionItems []model.EventTransactionItem,
+	itemsCreated bool, // items are already written in tx, consumer only create the charge
 	clientIP string,
 	orderInformationBookID int,
-) (err error) {
+) (outbox model.Outbox, err error) {
 	jsonData := async_order.AsyncOrder{
 		UseGarudaId:            useGarudaId,
 		ItemCount:              itemCount,
@@ -54,6 +63,7 @@ func (u *TransactionUsecase) SendAsyncOrder(
 		TicketCategory:         ticketCategory,
 		VenueSector:            venueSector,
 		EventTransactionItem:   eventTransactionItems,
+		ItemsCreated:           itemsCreated,
 		ClientIP:               clientIP,
 		OrderInformationBookID: orderInformationBookID,
 	}
@@ -64,21 +74,27 @@ func (u *TransactionUsecase) SendAsyncOrder(
 		return
 	}
 
-	err = u.EventPublisher.Publish(ctx, u.Env.Nats.Subjects.AsyncOrder, bytes)
+	outbox, err = u.Outbox.Create(ctx, tx, model.Outbox{
+		Subject:   u.Env.Nats.Subjects.AsyncOrder,
+		MessageID: "async-order:" + transaction.ID,
+		Payload:   bytes,
+	})
 	if err != nil {
 		return
 	}
 
-	log.Info().Msg("success send publish async order")
+	log.Info().Int64("outboxId", outbox.ID).Msg("success write async order to outbox")
 
 	return
 }
 
+// SendAsyncCallback write async callback to outbox in tx, it's published once tx is committed
 func (u *TransactionUsecase) SendAsyncCallback(
 	ctx context.Context,
+	tx pgx.Tx,
 	transactionId string,
 	paidAt time.Time,
-) (err error) {
+) (outbox model.Outbox, err error) {
 	jsonData := async_callback.AsyncCallback{
 		TransactionId: transactionId,
 		CallbackTime:  paidAt,
@@ -90,12 +106,17 @@ func (u *TransactionUsecase) SendAsyncCallback(
 		return
 	}
 
-	err = u.EventPublisher.Publish(ctx, u.Env.Nats.Subjects.AsyncCallback, bytes)
+	// transaction is paid once, so its callback is only handed to async callback once
+	outbox, err = u.Outbox.Create(ctx, tx, model.Outbox{
+		Subject:   u.Env.Nats.Subjects.AsyncCallback,
+		MessageID: "async-callback:" + transactionId,
+		Payload:   bytes,
+	})
 	if err != nil {
 		return
 	}
 
-	log.Info().Msg("success send publish async callback")
+	log.Info().Int64("outboxId", outbox.ID).Msg("success write async callback to outbox")
 
 	return
 }
@@ -208,6 +229,8 @@ func (u *TransactionUsecase) SendInvoice(
 		TransactionID:  transactionDetail.ID,
 		OrderNumber:    transactionDetail.OrderNumber,
 		AdditionalFees: invoiceAdditionalFees,
+		VoucherCode:    transactionDetail.VoucherCode.String,
+		DiscountAmount: transactionDetail.DiscountAmount,
 		Payment: domainEvent.PaymentInformation{
 			DisplayName:                  transactionDetail.PaymentMethod.Name,
 			Type:                         transactionDetail.PaymentMethod.PaymentType,
@@ -280,11 +303,22 @@ func (u *TransactionUsecase) SendETicket(
 	transactionDetail entity.EventTransaction,
 ) (err error) {
 	log.Info().Msg("send email eticket")
+	qrCode, err := helper.RenderTicketBarcode(eventTicket.TicketCode, helper.TicketBarcodeQR, u.Env.TicketBarcode.ModuleSize)
+	if err != nil {
+		return
+	}
+	barcode, err := helper.RenderTicketBarcode(eventTicket.TicketCode, helper.TicketBarcodeCode128, u.Env.TicketBarcode.ModuleSize)
+	if err != nil {
+		return
+	}
+
 	var transactionPayload = domainEvent.TransactionETicket{
 		TicketID:      eventTicket.ID,
 		TransactionID: transactionDetail.ID,
 		TicketNumber:  eventTicket.TicketNumber,
 		TicketCode:    eventTicket.TicketCode,
+		TicketQRCode:  base64.StdEncoding.EncodeToString(qrCode),
+		TicketBarcode: base64.StdEncoding.EncodeToString(barcode),
 
 		TicketSeatLabel:    eventTicket.SeatLabel.String,
 		TicketSeatRow:      eventTicket.SeatRow,
@@ -199,6 +199,18 @@ var (
 		Code: 40012,
 		Err:  errors.New("payment method is invalid"),
 	}
+	ErrorPaymentChannelNotSupported = TIXError{
+		Code: 40016,
+		Err:  errors.New("payment channel is not supported"),
+	}
+	ErrorPaymentOperationNotSupported = TIXError{
+		Code: 40017,
+		Err:  errors.New("operation is not supported by the payment method"),
+	}
+	ErrorPaymentGatewayKeyInvalid = TIXError{
+		Code: 50011,
+		Err:  errors.New("payment gateway key is invalid"),
+	}
 )
 
 var (
@@ -274,6 +286,30 @@ var (
 		Code: 50006,
 		Err:  errors.New("failed to update va no, please try again"),
 	}
+	ErrorTransactionIsNotPending = TIXError{
+		Code: 40915,
+		Err:  errors.New("transaction is not in pending status"),
+	}
+	ErrorTransactionStatusTransitionInvalid = TIXError{
+		Code: 40918,
+		Err:  errors.New("transaction status transition is not allowed"),
+	}
+	ErrorCallbackAmountMismatch = TIXError{
+		Code: 40019,
+		Err:  errors.New("paid amount doesn't match transaction grand total"),
+	}
+	ErrorCallbackCurrencyMismatch = TIXError{
+		Code: 40020,
+		Err:  errors.New("paid currency is not supported"),
+	}
+)
+
+// expiration
+var (
+	ErrorTransactionIsNotExpiredYet = TIXError{
+		Code: 40916,
+		Err:  errors.New("transaction payment is not expired yet"),
+	}
 )
 
 // transaction details
@@ -286,6 +322,10 @@ var (
 		Code: 40101,
 		Err:  errors.New("invalid JWT token"),
 	}
+	ErrorAdminUnauthorized = TIXError{
+		Code: 40103,
+		Err:  errors.New("invalid admin api key"),
+	}
 	MissmatchTxIDParameterBearerError = TIXError{
 		Code: 40302,
 		Err:  errors.New("transaction ID in parameter does not match with the one in bearer token"),
@@ -309,6 +349,18 @@ var (
 		Code: 50008,
 		Err:  errors.New("failed to create payment log"),
 	}
+	ErrorPaymentLogNotFound = TIXError{
+		Code: 40418,
+		Err:  errors.New("payment log not found"),
+	}
+	ErrorPaymentLogNotFailed = TIXError{
+		Code: 40925,
+		Err:  errors.New("only failed callback can be replayed"),
+	}
+	ErrorPaymentLogNotReplayable = TIXError{
+		Code: 40926,
+		Err:  errors.New("payment log is not a callback"),
+	}
 )
 
 var (
@@ -328,3 +380,279 @@ var (
 		Err:  errors.New("transaction item is nil"),
 	}
 )
+
+// paylabs
+var (
+	ErrorPaylabsRequestInvalid = TIXError{
+		Code: 40018,
+		Err:  errors.New("request rejected by paylabs"),
+	}
+	ErrorPaylabsTransactionNotFound = TIXError{
+		Code: 40415,
+		Err:  errors.New("transaction not found on paylabs"),
+	}
+	ErrorPaylabsTransactionDuplicate = TIXError{
+		Code: 40917,
+		Err:  errors.New("transaction already exist on paylabs"),
+	}
+	ErrorPaylabsUnauthorized = TIXError{
+		Code: 50012,
+		Err:  errors.New("paylabs rejected our credential or signature"),
+	}
+	ErrorPaylabsUnavailable = TIXError{
+		Code: 50013,
+		Err:  errors.New("paylabs is unavailable, please try again"),
+	}
+	ErrorPaylabsInvalidResponse = TIXError{
+		Code: 50014,
+		Err:  errors.New("invalid response from paylabs"),
+	}
+	ErrorPaylabsResultUnknown = TIXError{
+		Code: 50019,
+		Err:  errors.New("paylabs may have processed the request, check its status before retrying"),
+	}
+	ErrorPaylabsAccountIDInvalid = TIXError{
+		Code: 50020,
+		Err:  errors.New("paylabs account id is invalid"),
+	}
+)
+
+// refund
+var (
+	ErrorRefundTransactionNotPaid = TIXError{
+		Code: 40919,
+		Err:  errors.New("only paid transaction can be refunded"),
+	}
+	ErrorRefundInProgress = TIXError{
+		Code: 40920,
+		Err:  errors.New("another refund of this transaction is still in progress"),
+	}
+	ErrorRefundItemAlreadyRefunded = TIXError{
+		Code: 40921,
+		Err:  errors.New("transaction item is already refunded"),
+	}
+	ErrorRefundEventNotEligible = TIXError{
+		Code: 40922,
+		Err:  errors.New("refund is only available for canceled or postponed event"),
+	}
+	ErrorRefundAmountExceeded = TIXError{
+		Code: 40923,
+		Err:  errors.New("refund amount exceed remaining paid amount"),
+	}
+	ErrorRefundNotManual = TIXError{
+		Code: 40924,
+		Err:  errors.New("refund doesn't need manual completion"),
+	}
+	ErrorRefundNotProcessing = TIXError{
+		Code: 40942,
+		Err:  errors.New("refund is already finished"),
+	}
+	ErrorRefundNotStale = TIXError{
+		Code: 40943,
+		Err:  errors.New("refund is still processed, it can be resolved once it is stale"),
+	}
+	ErrorRefundItemNotFound = TIXError{
+		Code: 40416,
+		Err:  errors.New("transaction item not found"),
+	}
+	ErrorRefundNotFound = TIXError{
+		Code: 40417,
+		Err:  errors.New("refund not found"),
+	}
+)
+
+var (
+	ErrorIdempotencyKeyInvalid = TIXError{
+		Code: 40021,
+		Err:  errors.New("idempotency key must be at most 255 characters"),
+	}
+	ErrorIdempotencyKeyReused = TIXError{
+		Code: 40927,
+		Err:  errors.New("idempotency key is already used with different request"),
+	}
+	ErrorIdempotencyKeyInProgress = TIXError{
+		Code: 40928,
+		Err:  errors.New("request with the same idempotency key is still in progress"),
+	}
+	ErrorIdempotencyKeyStore = TIXError{
+		Code: 50015,
+		Err:  errors.New("failed to store idempotency key"),
+	}
+)
+
+var (
+	ErrorVoucherNotFound = TIXError{
+		Code: 40419,
+		Err:  errors.New("voucher not found"),
+	}
+	ErrorVoucherCodeAlreadyExists = TIXError{
+		Code: 40929,
+		Err:  errors.New("voucher code is already used in this event"),
+	}
+	ErrorVoucherInactive = TIXError{
+		Code: 40930,
+		Err:  errors.New("voucher is not active"),
+	}
+	ErrorVoucherNotApplicable = TIXError{
+		Code: 40931,
+		Err:  errors.New("voucher doesn't apply to the ordered ticket category"),
+	}
+	ErrorVoucherUsageLimitReached = TIXError{
+		Code: 40932,
+		Err:  errors.New("voucher usage limit is reached"),
+	}
+	ErrorVoucherEmailLimitReached = TIXError{
+		Code: 40933,
+		Err:  errors.New("voucher usage limit of this email is reached"),
+	}
+	ErrorVoucherGarudaIDLimitReached = TIXError{
+		Code: 40934,
+		Err:  errors.New("voucher usage limit of garuda id is reached"),
+	}
+	ErrorVoucherInvalid = TIXError{
+		Code: 40022,
+		Err:  errors.New("voucher discount or validity window is invalid"),
+	}
+)
+
+var (
+	ErrorPriceTierNotFound = TIXError{
+		Code: 40420,
+		Err:  errors.New("price tier not found"),
+	}
+	ErrorPriceTierSoldOut = TIXError{
+		Code: 40935,
+		Err:  errors.New("price tier is sold out"),
+	}
+	ErrorPriceTierInvalid = TIXError{
+		Code: 40023,
+		Err:  errors.New("price tier validity window is invalid"),
+	}
+)
+
+var (
+	ErrorWaitingRoomTokenInvalid = TIXError{
+		Code: 40102,
+		Err:  errors.New("waiting room token is invalid or expired"),
+	}
+	ErrorWaitingRoomAdmissionRequired = TIXError{
+		Code: 40306,
+		Err:  errors.New("admission token of waiting room is required"),
+	}
+	ErrorWaitingRoomAdmissionExhausted = TIXError{
+		Code: 40307,
+		Err:  errors.New("admission token is already used, join the waiting room again"),
+	}
+	ErrorWaitingRoomNotActive = TIXError{
+		Code: 40936,
+		Err:  errors.New("waiting room is not active for this event"),
+	}
+	ErrorWaitingRoomStore = TIXError{
+		Code: 50016,
+		Err:  errors.New("failed to access waiting room"),
+	}
+)
+
+var (
+	ErrorPurchaseLimitEmailReached = TIXError{
+		Code: 40937,
+		Err:  errors.New("ticket purchase limit of this email is reached for the event"),
+	}
+	ErrorPurchaseLimitGarudaIDReached = TIXError{
+		Code: 40938,
+		Err:  errors.New("ticket purchase limit of this garuda id is reached for the event"),
+	}
+	ErrorPurchaseLimitIPReached = TIXError{
+		Code: 40939,
+		Err:  errors.New("ticket purchase limit from this network is reached for the event"),
+	}
+)
+
+var (
+	ErrorSeatHoldNotFound = TIXError{
+		Code: 40421,
+		Err:  errors.New("seat hold is not found or already expired"),
+	}
+	ErrorSeatHoldMismatch = TIXError{
+		Code: 40024,
+		Err:  errors.New("seats of order don't match the seat hold"),
+	}
+	ErrorSeatmapNotAvailable = TIXError{
+		Code: 40025,
+		Err:  errors.New("ticket category doesn't have seatmap"),
+	}
+	ErrorSeatHoldStore = TIXError{
+		Code: 50017,
+		Err:  errors.New("failed to access seat hold"),
+	}
+)
+
+var (
+	ErrorSeatmapInvalid = TIXError{
+		Code: 40026,
+		Err:  errors.New("seatmap is invalid, seats must be unique and inside the sector rows and columns"),
+	}
+	ErrorSeatmapFileInvalid = TIXError{
+		Code: 40027,
+		Err:  errors.New("seatmap file must be csv or xlsx with row, column, row_label, label and status columns"),
+	}
+	ErrorSeatmapHasBookings = TIXError{
+		Code: 40940,
+		Err:  errors.New("seatmap of sector already has booked seats"),
+	}
+	ErrorSeatmapSeatsBooked = TIXError{
+		Code: 40944,
+		Err:  errors.New("booked or held seats can't be edited"),
+	}
+	ErrorSeatmapGridTooLarge = TIXError{
+		Code: 40032,
+		Err:  errors.New("seatmap grid can't exceed 500 rows and 500 columns"),
+	}
+	ErrorSeatmapFileSizeExceeds = TIXError{
+		Code: 41303,
+		Err:  errors.New("seatmap file size exceeds the limit"),
+	}
+)
+
+var (
+	ErrorSeatmapResyncTokenInvalid = TIXError{
+		Code: 40028,
+		Err:  errors.New("seatmap resync token is invalid"),
+	}
+	ErrorSeatmapStreamUnavailable = TIXError{
+		Code: 50018,
+		Err:  errors.New("seatmap stream is unavailable"),
+	}
+)
+
+var (
+	ErrorSeatNotInTicketCategory = TIXError{
+		Code: 40029,
+		Err:  errors.New("seat is not sold by the ticket category"),
+	}
+	ErrorSeatmapTicketCategoryInvalid = TIXError{
+		Code: 40030,
+		Err:  errors.New("ticket category of seat must belong to the event and the sector"),
+	}
+)
+
+var (
+	ErrorSeatNotEligible = TIXError{
+		Code: 40031,
+		Err:  errors.New("seat requires a need which is not declared by the order"),
+	}
+)
+
+var (
+	ErrorAsyncOrderChargeInProgress = TIXError{
+		Code: 40945,
+		Err:  errors.New("charge of order is in progress"),
+	}
+)
+
+var (
+	ErrorTicketInvalidated = TIXError{
+		Code: 40941,
+		Err:  errors.New("ticket is invalidated"),
+	}
+)
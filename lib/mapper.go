@@ -111,6 +111,19 @@ func MapEventSettingEntityToEventSettingResponse(
 			}
 			res.MaxAdultTicketPerTransaction = val
 		}
+		if setting.Setting.Name == WaitingRoomActiveSettingsName {
+			res.WaitingRoomActive = setting.SettingValue == SettingsValueBooleanTrue
+		}
+	}
+
+	// purchase limits are parsed the same way they're enforced on order
+	limits := MapEventSettings(eventSettings)
+	res.MaxTicketPerEmail = limits.MaxTicketPerEmail
+	res.MaxTicketPerGarudaID = limits.MaxTicketPerGarudaID
+	res.MaxTicketPerIP = limits.MaxTicketPerIP
+	res.SeatAttributesRequireDeclaration = limits.SeatAttributesRequireDeclaration
+	if res.SeatAttributesRequireDeclaration == nil {
+		res.SeatAttributesRequireDeclaration = make([]string, 0)
 	}
 
 	return res
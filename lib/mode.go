@@ -3,3 +3,8 @@ package lib
 const ModeDev = "dev"
 const ModeStaging = "staging"
 const ModeProd = "prod"
+
+const RoleApi = "api"
+const RoleWorker = "worker"
+const RoleConsumer = "consumer" // nats consumer of async order and async callback
+const RoleAll = "all"
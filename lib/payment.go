@@ -15,3 +15,56 @@ const (
 	PaymentGroupVirtualAccount = "Virtual Account"
 	PaymentGroupOthers         = "Others"
 )
+
+const (
+	PaymentCurrencyIDR = "IDR"
+)
+
+// Decision of payment callback, recorded on payment_logs
+const (
+	CallbackDecisionAccepted          = "ACCEPTED"
+	CallbackDecisionDuplicate         = "DUPLICATE"
+	CallbackDecisionNotFound          = "NOT_FOUND"
+	CallbackDecisionRejectedSignature = "REJECTED_SIGNATURE"
+	CallbackDecisionRejectedStatus    = "REJECTED_STATUS"
+	CallbackDecisionRejectedAmount    = "REJECTED_AMOUNT"
+	CallbackDecisionRejectedCurrency  = "REJECTED_CURRENCY"
+)
+
+// gin context keys to pass callback decision to payment logs
+const (
+	PaymentLogTransactionIDKey = "paymentLogTransactionId"
+	PaymentLogReferenceKey     = "paymentLogReference"
+	PaymentLogDecisionKey      = "paymentLogDecision"
+)
+
+// Result of payment reconciliation, only result other than IN_SYNC is reported
+const (
+	ReconciliationResultInSync           = "IN_SYNC"
+	ReconciliationResultMarkedPaid       = "MARKED_PAID"
+	ReconciliationResultMarkedFailed     = "MARKED_FAILED"
+	ReconciliationResultExpired          = "EXPIRED"
+	ReconciliationResultPaidAfterExpired = "PAID_AFTER_EXPIRED"
+	ReconciliationResultAmountMismatch   = "AMOUNT_MISMATCH"
+	ReconciliationResultInquiryFailed    = "INQUIRY_FAILED"
+)
+
+// Refund type
+const (
+	RefundTypeFull = "FULL"
+	RefundTypeItem = "ITEM"
+)
+
+// Refund status of each refund request
+const (
+	RefundStatusProcessing     = "PROCESSING"
+	RefundStatusSuccess        = "SUCCESS"
+	RefundStatusManualRequired = "MANUAL_REQUIRED" // gateway can't refund it, finance transfer it manually
+	RefundStatusFailed         = "FAILED"
+)
+
+// Voucher discount type
+const (
+	VoucherDiscountTypePercentage = "PERCENTAGE"
+	VoucherDiscountTypeFixed      = "FIXED"
+)
@@ -7,4 +7,6 @@ const (
 	GrouppedPaymentsKeyPrefix   = "GROUPPEDPAYMENTS"
 	EventDataKeyPrefix          = "EVENTDATA-"     // +"-"+ event_id
 	PaymentMethodKeyPrefix      = "PAYMENTMETHOD-" // +"-"+ payment_code
+	WaitingRoomKeyPrefix        = "WAITINGROOM-"   // +"-"+ event_id +":"+ field
+	SeatHoldKeyPrefix           = "SEATHOLD-"      // +"-"+ hold_id
 )
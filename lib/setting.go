@@ -4,6 +4,7 @@ import (
 	"assist-tix/dto"
 	"assist-tix/entity"
 	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -14,11 +15,20 @@ const (
 	EventPurchaseAdultTicketPerTransactionSettingName = "MAX_ADULT_TICKET_PURCHASE_PER_TRANSACTION"
 	TaxPercentageSettingsName                         = "TAX_PERCENTAGE"
 	AdminFeePercentageSettingsName                    = "ADMIN_FEE_PERCENTAGE"
+	WaitingRoomActiveSettingsName                     = "IS_WAITING_ROOM_ACTIVE"
+	WaitingRoomAdmissionPerMinuteSettingsName         = "WAITING_ROOM_ADMISSION_PER_MINUTE"
+	MaxTicketPerEmailSettingsName                     = "MAX_TICKET_PER_EMAIL"
+	MaxTicketPerGarudaIDSettingsName                  = "MAX_TICKET_PER_GARUDA_ID"
+	MaxTicketPerIPSettingsName                        = "MAX_TICKET_PER_IP"
+	SeatAttributesRequireDeclarationSettingsName      = "SEAT_ATTRIBUTES_REQUIRE_DECLARATION"
 
 	// Not implemented yet in phase 1
 	AdminFeePriceSettingsName = "ADMIN_FEE_PRICE"
 )
 
+// WaitingRoomDefaultAdmissionPerMinute is used when event turns on waiting room without admission rate
+const WaitingRoomDefaultAdmissionPerMinute = 100
+
 const (
 	SettingsTypeString  = "STRING"
 	SettingsTypeBoolean = "BOOLEAN"
@@ -73,6 +83,36 @@ func MapEventSettings(settings []entity.EventSetting) dto.EventSettings {
 			} else {
 				res.AdminFeePercentage = adminFeePercentage
 			}
+		case WaitingRoomActiveSettingsName:
+			res.WaitingRoomActive = val.SettingValue == SettingsValueBooleanTrue
+		case WaitingRoomAdmissionPerMinuteSettingsName:
+			admissionPerMinute, err := strconv.Atoi(val.SettingValue)
+			if err != nil || admissionPerMinute <= 0 {
+				log.Warn().Str("Key", WaitingRoomAdmissionPerMinuteSettingsName).Str("Value", val.SettingValue).Msg("failed to cast settings value")
+				admissionPerMinute, _ = strconv.Atoi(val.Setting.DefaultValue)
+			}
+			res.WaitingRoomAdmissionPerMinute = admissionPerMinute
+		case MaxTicketPerEmailSettingsName, MaxTicketPerGarudaIDSettingsName, MaxTicketPerIPSettingsName:
+			limit, err := strconv.Atoi(val.SettingValue)
+			if err != nil || limit < 0 {
+				log.Warn().Str("Key", val.Setting.Name).Str("Value", val.SettingValue).Msg("failed to cast settings value")
+				limit, _ = strconv.Atoi(val.Setting.DefaultValue)
+			}
+			switch val.Setting.Name {
+			case MaxTicketPerEmailSettingsName:
+				res.MaxTicketPerEmail = limit
+			case MaxTicketPerGarudaIDSettingsName:
+				res.MaxTicketPerGarudaID = limit
+			case MaxTicketPerIPSettingsName:
+				res.MaxTicketPerIP = limit
+			}
+		case SeatAttributesRequireDeclarationSettingsName:
+			for _, attribute := range strings.Split(val.SettingValue, ",") {
+				attribute = strings.ToUpper(strings.TrimSpace(attribute))
+				if attribute != "" {
+					res.SeatAttributesRequireDeclaration = append(res.SeatAttributesRequireDeclaration, attribute)
+				}
+			}
 		}
 	}
 
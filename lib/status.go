@@ -14,6 +14,14 @@ const (
 	SeatmapStatusDisable     = "DISABLE"
 )
 
+// Venue seat attributes
+const (
+	SeatAttributeWheelchair     = "WHEELCHAIR"
+	SeatAttributeCompanion      = "COMPANION"
+	SeatAttributeRestrictedView = "RESTRICTED_VIEW"
+	SeatAttributeAwayFans       = "AWAY_FANS"
+)
+
 // Event venue seatmap status
 const (
 	EventVenueSeatmapStatusAvailable   = "AVAILABLE"
@@ -49,6 +57,66 @@ const (
 	EventTransactionStatusFailed           = "FAILED"
 )
 
+// Event transaction refund status, separated from transaction status which is the payment status
+const (
+	EventTransactionRefundStatusNone              = "NONE"
+	EventTransactionRefundStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	EventTransactionRefundStatusRefunded          = "REFUNDED"
+)
+
+// Outbox message status
+const (
+	OutboxStatusPending   = "PENDING"
+	OutboxStatusPublished = "PUBLISHED"
+	OutboxStatusFailed    = "FAILED" // max attempts reached, need manual re-drive
+)
+
+// Idempotency key status
+const (
+	IdempotencyKeyStatusInProgress = "IN_PROGRESS"
+	IdempotencyKeyStatusCompleted  = "COMPLETED" // response is stored and replayed for repeated request
+)
+
+// Waiting room status of queue token
+const (
+	WaitingRoomStatusWaiting  = "WAITING"
+	WaitingRoomStatusAdmitted = "ADMITTED" // admission token is issued
+)
+
+// Actor who change event transaction status, recorded on status history
+const (
+	EventTransactionActorCallback       = "CALLBACK"
+	EventTransactionActorExpiryJob      = "EXPIRY_JOB"
+	EventTransactionActorAdmin          = "ADMIN"
+	EventTransactionActorReconciliation = "RECONCILIATION"
+	EventTransactionActorSystem         = "SYSTEM"
+)
+
+// EventTransactionStatusTransitions list legal next status of each event transaction status.
+// SUCCESS, EXPIRED and FAILED are final
+var EventTransactionStatusTransitions = map[string][]string{
+	EventTransactionStatusPending: {
+		EventTransactionStatusProcessingTicket,
+		EventTransactionStatusSuccess,
+		EventTransactionStatusExpired,
+		EventTransactionStatusFailed,
+	},
+	EventTransactionStatusProcessingTicket: {
+		EventTransactionStatusSuccess,
+		EventTransactionStatusFailed,
+	},
+}
+
+func ValidateEventTransactionStatusTransition(from, to string) (err error) {
+	for _, next := range EventTransactionStatusTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+
+	return &ErrorTransactionStatusTransitionInvalid
+}
+
 type EventTicketStatus string
 
 const (
@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateEventTransactionStatusTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr error
+	}{
+		{
+			name: "pending to processing ticket",
+			from: EventTransactionStatusPending,
+			to:   EventTransactionStatusProcessingTicket,
+		},
+		{
+			name: "pending to expired",
+			from: EventTransactionStatusPending,
+			to:   EventTransactionStatusExpired,
+		},
+		{
+			name: "processing ticket to success",
+			from: EventTransactionStatusProcessingTicket,
+			to:   EventTransactionStatusSuccess,
+		},
+		{
+			name: "processing ticket to failed",
+			from: EventTransactionStatusProcessingTicket,
+			to:   EventTransactionStatusFailed,
+		},
+		{
+			name:    "processing ticket back to pending",
+			from:    EventTransactionStatusProcessingTicket,
+			to:      EventTransactionStatusPending,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+		{
+			name:    "processing ticket to expired",
+			from:    EventTransactionStatusProcessingTicket,
+			to:      EventTransactionStatusExpired,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+		{
+			name:    "success is final",
+			from:    EventTransactionStatusSuccess,
+			to:      EventTransactionStatusFailed,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+		{
+			name:    "expired is final",
+			from:    EventTransactionStatusExpired,
+			to:      EventTransactionStatusSuccess,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+		{
+			name:    "same status",
+			from:    EventTransactionStatusPending,
+			to:      EventTransactionStatusPending,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+		{
+			name:    "unknown status",
+			from:    "UNKNOWN",
+			to:      EventTransactionStatusSuccess,
+			wantErr: &ErrorTransactionStatusTransitionInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEventTransactionStatusTransition(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateEventTransactionStatusTransition() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,3 +5,8 @@ const (
 	StorageTypeS3  = "s3"
 	StorageTypeFS  = "fs"
 )
+
+const (
+	WaitingRoomTokenTypeQueue     = "QUEUE"     // place in waiting room
+	WaitingRoomTokenTypeAdmission = "ADMISSION" // allows order of the event
+)
@@ -3,6 +3,7 @@ package main
 import (
 	"assist-tix/cmd/api"
 	"assist-tix/config"
+	"assist-tix/lib"
 	"fmt"
 	"os"
 	"os/signal"
@@ -20,6 +21,9 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	env, err := config.LoadEnv()
 	if err != nil {
@@ -42,12 +46,34 @@ func main() {
 
 	// defer setup.WrapDB.Postgres.Close()
 
-	go func() {
-		err = setup.Router.Run(env.App.Host)
+	if env.App.Role != lib.RoleWorker {
+		go func() {
+			err = setup.Router.Run(env.App.Host)
+			if err != nil {
+				log.Info().Msg(fmt.Sprintf("Listening on %s", env.App.Host))
+			}
+		}()
+	}
+
+	if env.App.Role == lib.RoleWorker || env.App.Role == lib.RoleAll {
+		err = setup.Worker.Start()
+		if err != nil {
+			log.Panic().Err(err).Msg("Failed to start worker")
+			panic(err)
+		}
+		log.Info().Msg("+=== worker [asynq] started ===+")
+		defer setup.Worker.Shutdown()
+	}
+
+	if env.App.Role == lib.RoleConsumer || env.App.Role == lib.RoleAll {
+		err = setup.Consumer.Start()
 		if err != nil {
-			log.Info().Msg(fmt.Sprintf("Listening on %s", env.App.Host))
+			log.Panic().Err(err).Msg("Failed to start consumer")
+			panic(err)
 		}
-	}()
+		log.Info().Msg("+=== consumer [nats] started ===+")
+		defer setup.Consumer.Shutdown()
+	}
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
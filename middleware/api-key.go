@@ -1,19 +1,31 @@
 package middleware
 
-// func (m *MiddlewareImpl) IsAuthorized() gin.HandlerFunc {
-// 	return func(c *gin.Context) {
-// 		APIKey := c.GetHeader("X-API-Key")
-// 		if APIKey == "" {
-// 			c.AbortWithStatusJSON(http.StatusUnauthorized, "")
-// 			return
-// 		}
-// 		if APIKey == m.Env.Admin.ApiKey {
-// 			c.Next()
-// 			return
-// 		} else {
-// 			c.AbortWithStatusJSON(http.StatusUnauthorized, "unauthorized")
-// 			return
-// 		}
+import (
+	"assist-tix/lib"
+	"crypto/subtle"
+	"net/http"
 
-// 	}
-// }
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// IsAuthorized guard admin endpoints with X-API-Key header
+func (m *MiddlewareImpl) IsAuthorized() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" || m.Env.Admin.ApiKey == "" {
+			lib.RespondError(c, http.StatusUnauthorized, "Unauthorized", nil, lib.ErrorAdminUnauthorized.Code, false)
+			c.Abort()
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(m.Env.Admin.ApiKey)) != 1 {
+			log.Warn().Str("ip", c.ClientIP()).Msg("invalid admin api key")
+			lib.RespondError(c, http.StatusUnauthorized, "Unauthorized", nil, lib.ErrorAdminUnauthorized.Code, false)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
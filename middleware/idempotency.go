@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"assist-tix/lib"
+	"assist-tix/model"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+	idempotencyKeyMaxLength   = 255
+)
+
+// idempotencyResponseWriter keep a copy of response body so it can be stored for replay
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyResponseWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// isFinalIdempotentResponse tell whether response is the outcome of the request. Server error and rejection of
+// auth, waiting room or rate limit may change on retry, so their key is released instead of stored
+func isFinalIdempotentResponse(status int) bool {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return false
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusTooManyRequests:
+		return false
+	}
+
+	return true
+}
+
+// Idempotency make request sent with Idempotency-Key header run only once.
+// Final response of the first request, success or error, is stored and returned as is for repeated request,
+// the same key with different method, path or body is rejected. Request without the header is passed through.
+// Put it before middleware which consumes quota, like waiting room, so replay doesn't use it again
+func (m *MiddlewareImpl) Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			lib.RespondError(c, http.StatusBadRequest, lib.ErrorIdempotencyKeyInvalid.Error(), &lib.ErrorIdempotencyKeyInvalid, lib.ErrorIdempotencyKeyInvalid.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to read request body")
+			lib.RespondError(c, http.StatusBadRequest, "failed to read request body", err, lib.ErrorBadRequest.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		idempotencyKey, acquired, err := m.IdempotencyKeyRepo.Acquire(c, nil, model.IdempotencyKey{
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(m.Env.Idempotency.TTL),
+		}, time.Now().Add(-m.Env.Idempotency.LockTimeout))
+		if err != nil {
+			log.Error().Err(err).Str("idempotencyKey", key).Msg("failed to acquire idempotency key")
+			lib.RespondError(c, http.StatusInternalServerError, "internal server error", err, lib.ErrorIdempotencyKeyStore.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			idempotencyKey, err = m.IdempotencyKeyRepo.FindByKey(c, nil, key)
+			if err != nil {
+				log.Error().Err(err).Str("idempotencyKey", key).Msg("failed to find idempotency key")
+				lib.RespondError(c, http.StatusInternalServerError, "internal server error", err, lib.ErrorIdempotencyKeyStore.Code, m.Env.App.Debug)
+				c.Abort()
+				return
+			}
+
+			switch {
+			case idempotencyKey.RequestHash != requestHash:
+				lib.RespondError(c, http.StatusConflict, lib.ErrorIdempotencyKeyReused.Error(), &lib.ErrorIdempotencyKeyReused, lib.ErrorIdempotencyKeyReused.Code, m.Env.App.Debug)
+			case idempotencyKey.Status != lib.IdempotencyKeyStatusCompleted:
+				lib.RespondError(c, http.StatusConflict, lib.ErrorIdempotencyKeyInProgress.Error(), &lib.ErrorIdempotencyKeyInProgress, lib.ErrorIdempotencyKeyInProgress.Code, m.Env.App.Debug)
+			default:
+				c.Header(idempotencyReplayedHeader, "true")
+				c.Data(int(idempotencyKey.ResponseStatus.Int32), "application/json; charset=utf-8", []byte(idempotencyKey.ResponseBody.String))
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if !isFinalIdempotentResponse(writer.Status()) {
+			err = m.IdempotencyKeyRepo.Release(context.WithoutCancel(c.Request.Context()), nil, idempotencyKey.ID)
+			if err != nil {
+				// key stays in progress, retry of the same request take it over after lock timeout
+				log.Error().Err(err).Str("idempotencyKey", key).Int64("idempotencyKeyId", idempotencyKey.ID).Msg("failed to release idempotency key")
+			}
+			return
+		}
+
+		// stored even when client is gone, its retry must get this response instead of running again
+		err = m.IdempotencyKeyRepo.Complete(context.WithoutCancel(c.Request.Context()), nil, idempotencyKey.ID, writer.Status(), writer.body.String())
+		if err != nil {
+			// key stays in progress, retry of the same request take it over after lock timeout
+			log.Error().Err(err).Str("idempotencyKey", key).Int64("idempotencyKeyId", idempotencyKey.ID).Msg("failed to store idempotency key response")
+		}
+	}
+}
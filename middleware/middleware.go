@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"assist-tix/config"
+	"assist-tix/repository"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,15 +14,29 @@ type Middleware interface {
 	PayloadPasser() gin.HandlerFunc
 	TokenAuthMiddleware() gin.HandlerFunc
 	OriginMiddleware() gin.HandlerFunc
+	IsAuthorized() gin.HandlerFunc
+	Idempotency() gin.HandlerFunc
+	WaitingRoom() gin.HandlerFunc
 }
 
 type MiddlewareImpl struct {
-	Env *config.EnvironmentVariable
+	Env                *config.EnvironmentVariable
+	IdempotencyKeyRepo repository.IdempotencyKeyRepository
+	EventSettingRepo   repository.EventSettingsRepository
+	WaitingRoomRepo    repository.WaitingRoomRepository
 }
 
-func NewMiddleware(env *config.EnvironmentVariable) Middleware {
+func NewMiddleware(
+	env *config.EnvironmentVariable,
+	idempotencyKeyRepo repository.IdempotencyKeyRepository,
+	eventSettingRepo repository.EventSettingsRepository,
+	waitingRoomRepo repository.WaitingRoomRepository,
+) Middleware {
 	return &MiddlewareImpl{
-		Env: env,
+		Env:                env,
+		IdempotencyKeyRepo: idempotencyKeyRepo,
+		EventSettingRepo:   eventSettingRepo,
+		WaitingRoomRepo:    waitingRoomRepo,
 	}
 }
 
@@ -38,7 +53,8 @@ func (m *MiddlewareImpl) CORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Max-Age", "86400")
 		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Max")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Max, Idempotency-Key, X-Queue-Token, X-Admission-Token")
+		c.Header("Access-Control-Expose-Headers", "Idempotency-Replayed")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Content-Security-Policy", "default-src 'self'; script-src 'self'; style-src 'self'; object-src 'none'; frame-ancestors 'none';")
 
@@ -55,7 +71,7 @@ func (m *MiddlewareImpl) CORSMiddleware() gin.HandlerFunc {
 			log.Info().Msg("Abort Options")
 			c.Header("Access-Control-Allow-Origin", "*")
 			c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, UPDATE")
-			c.Header("Access-Control-Allow-Headers", "Access-Control-Allow-Headers, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Max")
+			c.Header("Access-Control-Allow-Headers", "Access-Control-Allow-Headers, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Max, Idempotency-Key, X-Queue-Token, X-Admission-Token")
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const admissionTokenHeader = "X-Admission-Token"
+
+// WaitingRoom reject order of event with active waiting room unless it's sent with a valid X-Admission-Token header.
+// One admission token can only place a few order attempts, so it can't be shared to skip the queue
+func (m *MiddlewareImpl) WaitingRoom() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eventID := c.Param("eventId")
+
+		settings, err := m.EventSettingRepo.FindByEventId(c, nil, eventID)
+		if err != nil {
+			log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event settings")
+			lib.RespondError(c, http.StatusInternalServerError, "internal server error", err, lib.ErrorInternalServer.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		if !lib.MapEventSettings(settings).WaitingRoomActive {
+			c.Next()
+			return
+		}
+
+		token := c.GetHeader(admissionTokenHeader)
+		if token == "" {
+			lib.RespondError(c, http.StatusForbidden, lib.ErrorWaitingRoomAdmissionRequired.Error(), &lib.ErrorWaitingRoomAdmissionRequired, lib.ErrorWaitingRoomAdmissionRequired.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		claims, err := helper.VerifyWaitingRoomToken(m.Env, lib.WaitingRoomTokenTypeAdmission, eventID, token)
+		if err != nil {
+			log.Warn().Err(err).Str("eventId", eventID).Msg("invalid admission token")
+			lib.RespondError(c, http.StatusUnauthorized, lib.ErrorWaitingRoomTokenInvalid.Error(), &lib.ErrorWaitingRoomTokenInvalid, lib.ErrorWaitingRoomTokenInvalid.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		count, err := m.WaitingRoomRepo.UseAdmission(c, eventID, claims.Number, m.Env.WaitingRoom.AdmissionTTL)
+		if err != nil {
+			log.Error().Err(err).Str("eventId", eventID).Int64("number", claims.Number).Msg("failed to use admission")
+			lib.RespondError(c, http.StatusInternalServerError, "internal server error", err, lib.ErrorWaitingRoomStore.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		if count > int64(m.Env.WaitingRoom.MaxOrdersPerAdmission) {
+			lib.RespondError(c, http.StatusForbidden, lib.ErrorWaitingRoomAdmissionExhausted.Error(), &lib.ErrorWaitingRoomAdmissionExhausted, lib.ErrorWaitingRoomAdmissionExhausted.Code, m.Env.App.Debug)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -12,8 +12,8 @@ type Event struct {
 	Description string
 	Banner      string
 	EventTime   time.Time
-	// Status      string
-	VenueID string
+	Status      string // only CANCELED / POSTPONED is stored, empty means it follows event time
+	VenueID     string
 
 	PublishStatus string
 	IsSaleActive  bool
@@ -11,5 +11,7 @@ type EventSeatmapBook struct {
 	SeatRow    int
 	SeatColumn int
 
+	HoldTicketCategoryID string // set while the seat is held, its public stock is taken by the hold
+
 	CreatedAt time.Time
 }
@@ -38,4 +38,7 @@ type EventTicket struct {
 	IsCompliment bool
 
 	AdditionalInformation sql.NullString
+
+	InvalidatedAt      sql.NullTime
+	InvalidationReason sql.NullString
 }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type EventTicketCategoryPriceTier struct {
+	ID               string
+	TicketCategoryID string
+
+	Name    string
+	Price   int
+	StartAt time.Time
+	EndAt   time.Time
+
+	StockLimit sql.NullInt32 // null means only limited by the category stock
+	Sold       int
+
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
@@ -23,19 +23,31 @@ type EventTransaction struct {
 	AdditionalFeeDetails string
 	TotalAdminFee        int
 	GrandTotal           int
+	TicketQuantity       int
 
 	Fullname string
 	Email    string
+	ClientIP string // counted by per ip purchase limit
 	// PhoneNumber string
 
 	IsCompliment bool
 
+	IsRefunded     bool
+	RefundStatus   string // lib.EventTransactionRefundStatus*
+	RefundedAmount int
+
 	CreatedAt time.Time
 	UpdatedAt *time.Time
 
-	PaymentAdditionalInfo string // Virtual Account Number
-	ChannelTransactionID  string // For lookup purposes
+	PaymentAdditionalInfo string     // Virtual Account Number
+	ChannelTransactionID  string     // For lookup purposes
+	ChargeClaimedAt       *time.Time // set by async order before it calls gateway
+	BilledAt              *time.Time // set by async order before it sends the bill
 
 	PGOrderID       string
 	PGAdditionalFee int // Additional fee for payment gateway
+
+	VoucherID      string
+	VoucherCode    string
+	DiscountAmount int // subtracted from TotalPrice before fees
 }
@@ -6,8 +6,9 @@ import (
 )
 
 type EventTransactionItem struct {
-	ID            int
-	TransactionID string
+	ID               int
+	TransactionID    string
+	TicketCategoryID string
 
 	Quantity int
 
@@ -24,6 +25,8 @@ type EventTransactionItem struct {
 	AdditionalInformation sql.NullString
 	TotalPrice            int
 
+	SeatNeeds []string // seat attributes needed by ticket holder, e.g. WHEELCHAIR
+
 	CreatedAt time.Time
 	UpdatedAt *time.Time
 }
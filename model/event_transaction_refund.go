@@ -0,0 +1,33 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type EventTransactionRefund struct {
+	ID            int
+	TransactionID string
+	RefundNumber  string
+	RefundType    string // lib.RefundType*
+	Amount        int
+	Reason        string
+	Status        string // lib.RefundStatus*
+
+	PGRefundID     sql.NullString
+	FailureMessage sql.NullString
+
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+
+	Items []EventTransactionRefundItem
+}
+
+type EventTransactionRefundItem struct {
+	ID                int
+	RefundID          int
+	TransactionItemID int
+	Amount            int
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type EventTransactionStatusHistory struct {
+	ID            int
+	TransactionID string
+
+	FromStatus string
+	ToStatus   string
+	Actor      string
+	Reason     sql.NullString
+
+	CreatedAt time.Time
+}
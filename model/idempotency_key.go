@@ -0,0 +1,20 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type IdempotencyKey struct {
+	ID             int64
+	Key            string
+	RequestHash    string
+	Status         string // lib.IdempotencyKeyStatus*
+	ResponseStatus sql.NullInt32
+	ResponseBody   sql.NullString
+
+	LockedAt  time.Time
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Outbox struct {
+	ID        int64
+	Subject   string
+	MessageID string
+	Payload   []byte
+	Status    string // lib.OutboxStatus*
+	Attempts  int
+
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	PublishedAt   *time.Time
+
+	CreatedAt time.Time
+}
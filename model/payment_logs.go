@@ -11,4 +11,12 @@ type PaymentLog struct {
 	ErrorResponse string    `json:"error_response"`
 	Path          string    `json:"path"`
 	ErrorCode     string    `json:"error_code"`
+
+	TransactionID    string `json:"transaction_id"`
+	PaymentReference string `json:"payment_reference"`
+	Decision         string `json:"decision"`
+
+	ReplayOfID       *int `json:"replay_of_id"` // log replayed by this callback
+	SignatureSkipped bool `json:"signature_skipped"`
+	IsResolved       bool `json:"is_resolved"` // failed callback has a successful replay, only filled by FindFailed
 }
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type PaymentReconciliation struct {
+	ID            int
+	TransactionID string
+	OrderNumber   string
+	PaymentMethod string
+
+	LocalStatus      string
+	GatewayStatus    string
+	GatewayRawStatus sql.NullString
+	GrandTotal       int
+	PaidAmount       int
+
+	Result  string // lib.ReconciliationResult*
+	Message sql.NullString
+
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"assist-tix/domain"
+	"time"
+)
+
+// SeatHold is stored on redis until it expires, its seats are reserved by seat books with the hold id
+type SeatHold struct {
+	ID               string
+	EventID          string
+	TicketCategoryID string
+	VenueSectorID    string
+	Seats            []domain.SeatmapParam
+	Email            string // buyer, only order of the same email can use the hold
+	ClientIP         string
+	ExpiresAt        time.Time
+}
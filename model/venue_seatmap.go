@@ -1,10 +1,34 @@
 package model
 
+import (
+	"database/sql"
+	"time"
+)
+
 type VenueSectorSeatmap struct {
-	ID         int
-	SectorID   string
-	SeatRow    int
-	SeatColumn int
-	Label      string
-	Status     string
+	ID           int
+	SectorID     string
+	SeatRow      int
+	SeatColumn   int
+	SeatRowLabel int
+	Label        string
+	Status       string
+	Attributes   []string
+}
+
+// EventVenueSectorSeatmap override seat of venue sector for one event
+type EventVenueSectorSeatmap struct {
+	ID           int
+	EventID      string
+	SectorID     string
+	SeatRow      int
+	SeatColumn   int
+	SeatRowLabel int
+	Label        string
+	Status       string
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+
+	TicketCategoryID sql.NullString // price zone of seat, null means every ticket category of the sector
+	Attributes       []string       // nil keeps attributes of the venue seat
 }
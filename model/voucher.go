@@ -0,0 +1,47 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Voucher struct {
+	ID               string
+	EventID          string
+	TicketCategoryID sql.NullString // empty means every category of the event
+
+	Code          string
+	DiscountType  string // lib.VoucherDiscountType*
+	DiscountValue int
+	MaxDiscount   sql.NullInt32
+
+	UsageLimit            sql.NullInt32
+	UsageLimitPerEmail    sql.NullInt32
+	UsageLimitPerGarudaID sql.NullInt32
+
+	IsActive bool
+	StartAt  time.Time
+	EndAt    time.Time
+
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
+type VoucherUsage struct {
+	ID             int
+	VoucherID      string
+	TransactionID  string
+	Email          string
+	GarudaIDs      []string
+	DiscountAmount int
+
+	CreatedAt time.Time
+}
+
+// VoucherUsageCount is usage of a voucher which counts toward its limits
+type VoucherUsageCount struct {
+	Total int
+	Email int
+	// highest usage among the garuda ids
+	GarudaID int
+}
@@ -30,6 +30,8 @@ type EventRepository interface {
 	Count(ctx context.Context, tx pgx.Tx, param *domain.FilterEventParam) (res int64, err error)
 	Update(ctx context.Context, tx pgx.Tx, event model.Event) (err error)
 	SoftDelete(ctx context.Context, tx pgx.Tx, eventId string) (err error)
+	FindStatusById(ctx context.Context, tx pgx.Tx, eventId string) (status string, err error)
+	UpdateStatus(ctx context.Context, tx pgx.Tx, eventId, status string) (err error)
 }
 
 type EventRepositoryImpl struct {
@@ -141,6 +143,7 @@ func (r *EventRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, eventId s
 		banner_filename, 
 		event_time, 
 		venue_id, 
+		COALESCE(status, ''),
 		is_sale_active, 
 		publish_status, 
 		additional_information, 
@@ -163,6 +166,7 @@ func (r *EventRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, eventId s
 			&event.Banner,
 			&event.EventTime,
 			&event.VenueID,
+			&event.Status,
 			&event.IsSaleActive,
 			&event.PublishStatus,
 			&event.AdditionalInformation,
@@ -180,6 +184,7 @@ func (r *EventRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, eventId s
 			&event.Banner,
 			&event.EventTime,
 			&event.VenueID,
+			&event.Status,
 			&event.IsSaleActive,
 			&event.PublishStatus,
 			&event.AdditionalInformation,
@@ -627,3 +632,56 @@ func (r *EventRepositoryImpl) FindAllPaginated(ctx context.Context, tx pgx.Tx, p
 
 	return
 }
+
+// FindStatusById read stored status without cache, empty means event is not canceled or postponed
+func (r *EventRepositoryImpl) FindStatusById(ctx context.Context, tx pgx.Tx, eventId string) (status string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT COALESCE(status, '') FROM events WHERE id = $1 AND deleted_at IS NULL`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, eventId).Scan(&status)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, eventId).Scan(&status)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorEventNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// UpdateStatus store CANCELED / POSTPONED status, empty status clear it
+func (r *EventRepositoryImpl) UpdateStatus(ctx context.Context, tx pgx.Tx, eventId, status string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE events SET status = NULLIF($1, ''), updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND deleted_at IS NULL`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, status, eventId)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, status, eventId)
+	}
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return &lib.ErrorEventNotFound
+	}
+
+	err = r.RedisRepository.DeleteState(ctx, lib.EventDataKeyPrefix+eventId)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", eventId).Msg("failed to delete cached event")
+		err = nil
+	}
+
+	return
+}
@@ -16,6 +16,7 @@ type EventOrderInformationBookRepository interface {
 	CreateOrderInformation(ctx context.Context, tx pgx.Tx, eventId, email, fullname string) (id int, err error)
 	UpdateTransactionIdByID(ctx context.Context, tx pgx.Tx, id int, transactionId string) (err error)
 	ValidateOrderInformationByEmailEventId(ctx context.Context, tx pgx.Tx, eventId, email string) (err error)
+	DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (err error)
 }
 
 type EventOrderInformationBookRepositoryImpl struct {
@@ -105,3 +106,18 @@ func (r *EventOrderInformationBookRepositoryImpl) ValidateOrderInformationByEmai
 
 	return &lib.ErrorOrderInformationIsAlreadyBook
 }
+
+func (r *EventOrderInformationBookRepositoryImpl) DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM event_order_information_books WHERE event_transaction_id = $1`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionId)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionId)
+	}
+
+	return
+}
@@ -23,6 +23,19 @@ type EventSeatmapBookRepository interface {
 	FindSeatStatusByRowColumnEventSectorId(ctx context.Context, tx pgx.Tx, eventId, venueSectorId string, seatRow, seatColumn int) (res model.EventSeatmapBook, err error)
 	FindSeatBooksByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, venueSectorId string) (seatmap map[string]model.EventSeatmapBook, err error)
 	GetLastSeatOrderBySectorRowColumnId(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (res model.EventSeatmapBook, err error)
+	UpdateTransactionIdBySeats(ctx context.Context, tx pgx.Tx, eventId, venueSectorId, transactionId string, reqs []domain.SeatmapParam) (err error)
+	DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (released []model.EventSeatmapBook, err error)
+	DeleteByTransactionIdAndSeat(ctx context.Context, tx pgx.Tx, transactionId string, seatRow, seatColumn int) (released []model.EventSeatmapBook, err error)
+	FindSeatBooksByTransactionSectorId(ctx context.Context, tx pgx.Tx, transactionId, venueSectorId string) (seatmap map[string]model.EventSeatmapBook, err error)
+	UpdateHoldIdBySeats(ctx context.Context, tx pgx.Tx, hold model.SeatHold) (err error)
+	ConvertHoldToBook(ctx context.Context, tx pgx.Tx, holdId string) (converted int64, err error)
+	DeleteByHoldId(ctx context.Context, tx pgx.Tx, holdId string) (released []model.EventSeatmapBook, err error)
+	DeleteExpiredHoldsBySeats(ctx context.Context, tx pgx.Tx, eventId, venueSectorId string, reqs []domain.SeatmapParam) (released []model.EventSeatmapBook, err error)
+	DeleteExpiredHolds(ctx context.Context, tx pgx.Tx) (released []model.EventSeatmapBook, err error)
+	CountActiveHoldsByEmail(ctx context.Context, tx pgx.Tx, eventId, email, excludedHoldId string) (count int, err error)
+	CountActiveHoldsByClientIP(ctx context.Context, tx pgx.Tx, eventId, clientIP, excludedHoldId string) (count int, err error)
+	CountBySectorId(ctx context.Context, tx pgx.Tx, venueSectorId string) (count int, err error)
+	CountBySectorIdAndSeats(ctx context.Context, tx pgx.Tx, venueSectorId string, reqs []domain.SeatmapParam) (count int, err error)
 }
 
 type EventSeatmapBookRepositoryImpl struct {
@@ -237,3 +250,311 @@ func (r *EventSeatmapBookRepositoryImpl) GetLastSeatOrderBySectorRowColumnId(ctx
 
 	return
 }
+
+func (r *EventSeatmapBookRepositoryImpl) UpdateTransactionIdBySeats(ctx context.Context, tx pgx.Tx, eventId, venueSectorId, transactionId string, reqs []domain.SeatmapParam) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{transactionId, eventId, venueSectorId}
+	var placeholders []string
+
+	for _, req := range reqs {
+		base := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d::int, $%d::int)", base+1, base+2))
+		args = append(args, req.SeatRow, req.SeatColumn)
+	}
+
+	query := fmt.Sprintf(`UPDATE event_seatmap_books 
+		SET event_transaction_id = $1 
+	WHERE event_id = $2 
+		AND venue_sector_id = $3 
+		AND (seat_row, seat_column) IN (%s)`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, args...)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, args...)
+	}
+
+	return
+}
+
+func (r *EventSeatmapBookRepositoryImpl) DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (released []model.EventSeatmapBook, err error) {
+	query := `DELETE FROM event_seatmap_books WHERE event_transaction_id = $1` + seatBookReturning
+
+	return r.deleteSeatBooks(ctx, tx, query, transactionId)
+}
+
+func (r *EventSeatmapBookRepositoryImpl) DeleteByTransactionIdAndSeat(ctx context.Context, tx pgx.Tx, transactionId string, seatRow, seatColumn int) (released []model.EventSeatmapBook, err error) {
+	query := `DELETE FROM event_seatmap_books WHERE event_transaction_id = $1 AND seat_row = $2 AND seat_column = $3` + seatBookReturning
+
+	return r.deleteSeatBooks(ctx, tx, query, transactionId, seatRow, seatColumn)
+}
+
+// FindSeatBooksByTransactionSectorId find seats of sector booked for transaction, keyed by row and column
+func (r *EventSeatmapBookRepositoryImpl) FindSeatBooksByTransactionSectorId(ctx context.Context, tx pgx.Tx, transactionId, venueSectorId string) (seatmap map[string]model.EventSeatmapBook, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	seatmap = make(map[string]model.EventSeatmapBook)
+
+	query := `SELECT 
+		id, 
+		event_id,
+		venue_sector_id,
+		seat_row,
+		seat_column,
+		created_at
+	FROM event_seatmap_books 
+	WHERE event_transaction_id = $1
+	AND venue_sector_id = $2`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, transactionId, venueSectorId)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, transactionId, venueSectorId)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seatBook model.EventSeatmapBook
+		err = rows.Scan(
+			&seatBook.ID,
+			&seatBook.EventID,
+			&seatBook.VenueSectorID,
+			&seatBook.SeatRow,
+			&seatBook.SeatColumn,
+			&seatBook.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		seatmap[helper.ConvertRowColumnKey(seatBook.SeatRow, seatBook.SeatColumn)] = seatBook
+	}
+
+	err = rows.Err()
+	return
+}
+
+// UpdateHoldIdBySeats mark seats booked by CreateSeatBook as hold of its buyer, the hold is released when it's not converted before it expires
+func (r *EventSeatmapBookRepositoryImpl) UpdateHoldIdBySeats(ctx context.Context, tx pgx.Tx, hold model.SeatHold) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(hold.Seats) == 0 {
+		return nil
+	}
+
+	args := []interface{}{hold.ID, hold.ExpiresAt, hold.TicketCategoryID, hold.Email, hold.ClientIP, hold.EventID, hold.VenueSectorID}
+	var placeholders []string
+
+	for _, req := range hold.Seats {
+		base := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d::int, $%d::int)", base+1, base+2))
+		args = append(args, req.SeatRow, req.SeatColumn)
+	}
+
+	query := fmt.Sprintf(`UPDATE event_seatmap_books 
+		SET hold_id = $1, hold_expires_at = $2, hold_ticket_category_id = $3, hold_email = NULLIF($4, ''), hold_client_ip = NULLIF($5, '')
+	WHERE event_id = $6 
+		AND venue_sector_id = $7 
+		AND event_transaction_id IS NULL
+		AND (seat_row, seat_column) IN (%s)`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, args...)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, args...)
+	}
+
+	return
+}
+
+// ConvertHoldToBook turn unexpired hold into regular seat books, which are linked to transaction by UpdateTransactionIdBySeats
+func (r *EventSeatmapBookRepositoryImpl) ConvertHoldToBook(ctx context.Context, tx pgx.Tx, holdId string) (converted int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_seatmap_books 
+		SET hold_id = NULL, hold_expires_at = NULL, hold_ticket_category_id = NULL, hold_email = NULL, hold_client_ip = NULL
+	WHERE hold_id = $1 
+		AND event_transaction_id IS NULL
+		AND hold_expires_at > NOW()`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, holdId)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, holdId)
+	}
+	if err != nil {
+		return
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *EventSeatmapBookRepositoryImpl) DeleteByHoldId(ctx context.Context, tx pgx.Tx, holdId string) (released []model.EventSeatmapBook, err error) {
+	query := `DELETE FROM event_seatmap_books WHERE hold_id = $1 AND event_transaction_id IS NULL` + seatBookReturning
+
+	return r.deleteSeatBooks(ctx, tx, query, holdId)
+}
+
+// DeleteExpiredHoldsBySeats free seats whose hold is expired but not released yet, so they can be booked right away
+func (r *EventSeatmapBookRepositoryImpl) DeleteExpiredHoldsBySeats(ctx context.Context, tx pgx.Tx, eventId, venueSectorId string, reqs []domain.SeatmapParam) (released []model.EventSeatmapBook, err error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{eventId, venueSectorId}
+	var placeholders []string
+
+	for _, req := range reqs {
+		base := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d::int, $%d::int)", base+1, base+2))
+		args = append(args, req.SeatRow, req.SeatColumn)
+	}
+
+	query := fmt.Sprintf(`DELETE FROM event_seatmap_books 
+	WHERE event_id = $1 
+		AND venue_sector_id = $2 
+		AND hold_id IS NOT NULL
+		AND event_transaction_id IS NULL
+		AND hold_expires_at <= NOW()
+		AND (seat_row, seat_column) IN (%s)`, strings.Join(placeholders, ",")) + seatBookReturning
+
+	return r.deleteSeatBooks(ctx, tx, query, args...)
+}
+
+// DeleteExpiredHolds release every expired hold which isn't converted by order
+func (r *EventSeatmapBookRepositoryImpl) DeleteExpiredHolds(ctx context.Context, tx pgx.Tx) (released []model.EventSeatmapBook, err error) {
+	query := `DELETE FROM event_seatmap_books WHERE hold_id IS NOT NULL AND event_transaction_id IS NULL AND hold_expires_at <= NOW()` + seatBookReturning
+
+	return r.deleteSeatBooks(ctx, tx, query)
+}
+
+// CountActiveHoldsByEmail count unexpired held seats of email on event, seats of excluded hold aren't counted
+func (r *EventSeatmapBookRepositoryImpl) CountActiveHoldsByEmail(ctx context.Context, tx pgx.Tx, eventId, email, excludedHoldId string) (count int, err error) {
+	query := `SELECT COUNT(id) FROM event_seatmap_books
+		WHERE event_id = $1 AND lower(hold_email) = lower($2) AND hold_id IS DISTINCT FROM NULLIF($3, '')::uuid
+			AND hold_id IS NOT NULL AND event_transaction_id IS NULL AND hold_expires_at > NOW()`
+
+	return r.countActiveHolds(ctx, tx, query, eventId, email, excludedHoldId)
+}
+
+// CountActiveHoldsByClientIP count unexpired held seats of client ip on event, seats of excluded hold aren't counted
+func (r *EventSeatmapBookRepositoryImpl) CountActiveHoldsByClientIP(ctx context.Context, tx pgx.Tx, eventId, clientIP, excludedHoldId string) (count int, err error) {
+	query := `SELECT COUNT(id) FROM event_seatmap_books
+		WHERE event_id = $1 AND hold_client_ip = $2 AND hold_id IS DISTINCT FROM NULLIF($3, '')::uuid
+			AND hold_id IS NOT NULL AND event_transaction_id IS NULL AND hold_expires_at > NOW()`
+
+	return r.countActiveHolds(ctx, tx, query, eventId, clientIP, excludedHoldId)
+}
+
+func (r *EventSeatmapBookRepositoryImpl) countActiveHolds(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) (count int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, args...).Scan(&count)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, args...).Scan(&count)
+	}
+
+	return
+}
+
+// seatBookReturning return deleted seat books, their seats are available again
+const seatBookReturning = ` RETURNING id, event_id, venue_sector_id, seat_row, seat_column, COALESCE(hold_ticket_category_id::text, ''), created_at`
+
+func (r *EventSeatmapBookRepositoryImpl) deleteSeatBooks(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) (released []model.EventSeatmapBook, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, args...)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, args...)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var book model.EventSeatmapBook
+		err = rows.Scan(
+			&book.ID,
+			&book.EventID,
+			&book.VenueSectorID,
+			&book.SeatRow,
+			&book.SeatColumn,
+			&book.HoldTicketCategoryID,
+			&book.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+		released = append(released, book)
+	}
+
+	return released, rows.Err()
+}
+
+// CountBySectorId count seat books of sector across every event
+func (r *EventSeatmapBookRepositoryImpl) CountBySectorId(ctx context.Context, tx pgx.Tx, venueSectorId string) (count int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT COUNT(id) FROM event_seatmap_books WHERE venue_sector_id = $1`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, venueSectorId).Scan(&count)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, venueSectorId).Scan(&count)
+	}
+
+	return
+}
+
+// CountBySectorIdAndSeats count books and unexpired holds of the seats across every event
+func (r *EventSeatmapBookRepositoryImpl) CountBySectorIdAndSeats(ctx context.Context, tx pgx.Tx, venueSectorId string, reqs []domain.SeatmapParam) (count int, err error) {
+	if len(reqs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	args := []interface{}{venueSectorId}
+	var placeholders []string
+
+	for _, req := range reqs {
+		base := len(args)
+		placeholders = append(placeholders, fmt.Sprintf("($%d::int, $%d::int)", base+1, base+2))
+		args = append(args, req.SeatRow, req.SeatColumn)
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(id) FROM event_seatmap_books
+	WHERE venue_sector_id = $1
+		AND (hold_id IS NULL OR hold_expires_at > NOW())
+		AND (seat_row, seat_column) IN (%s)`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, args...).Scan(&count)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, args...).Scan(&count)
+	}
+
+	return
+}
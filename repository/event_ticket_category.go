@@ -27,11 +27,14 @@ type EventTicketCategoryRepository interface {
 	FindSeatmapByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (seats []entity.EventVenueSector, err error)
 	FindSeatmapStatusByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string, reqs []domain.SeatmapParam) (seatmap map[string]entity.EventVenueSector, err error)
 	BuyPublicTicketById(ctx context.Context, tx pgx.Tx, eventId, ticketCategoryId string, newStock int) (err error)
+	ReleasePublicTicketById(ctx context.Context, tx pgx.Tx, eventId, ticketCategoryId string, releaseTicket int) (err error)
 	SoftDelete(ctx context.Context, tx pgx.Tx, ticketCategoryId string) (err error)
 	FindLowestPriceTicketByEventIds(ctx context.Context, tx pgx.Tx, eventIds ...string) (res map[string]int, err error)
 	FindTotalSaleTicketByEventIds(ctx context.Context, tx pgx.Tx, eventIds ...string) (res map[string]int, err error)
 	FindSeatByRowsColumnsEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string, seatmaps ...domain.SeatmapParam) (seats map[string]entity.EventVenueSector, err error)
-	FindNAvailableSeatAfterSectorRowColumn(ctx context.Context, tx pgx.Tx, eventId, sectorId string, seatCount, seatRow, seatColumn int) (seats []entity.EventVenueSector, err error)
+	FindNAvailableSeatAfterSectorRowColumn(ctx context.Context, tx pgx.Tx, eventId, sectorId, ticketCategoryId string, requiredAttributes, excludedAttributes []string, seatCount, seatRow, seatColumn int) (seats []entity.EventVenueSector, err error)
+	LockSeatAssignment(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (err error)
+	FindAvailableSeatsByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId, ticketCategoryId string, excludedAttributes []string) (seats []entity.EventVenueSector, err error)
 }
 
 type EventTicketCategoryRepositoryImpl struct {
@@ -176,6 +179,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindByEventId(ctx context.Context, t
 
 	query := `SELECT 
 		id,
+		event_id,
+		venue_sector_id,
 		name, 
 		description,
 		price, 
@@ -209,6 +214,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindByEventId(ctx context.Context, t
 		var ticketCategory model.EventTicketCategory
 		rows.Scan(
 			&ticketCategory.ID,
+			&ticketCategory.EventID,
+			&ticketCategory.VenueSectorId,
 			&ticketCategory.Name,
 			&ticketCategory.Description,
 			&ticketCategory.Price,
@@ -329,7 +336,7 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatmapByEventSectorId(ctx conte
 		vssm.id, 
 		vssm.seat_row, 
 		vssm.seat_column, 
-		vssm.seat_row_label,
+		COALESCE(NULLIF(evssm.seat_row_label, 0), vssm.seat_row_label) AS seat_final_row_label,
 		CASE 
 			WHEN vssm.label != evssm.label THEN evssm.label
 			ELSE vssm.label
@@ -337,11 +344,13 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatmapByEventSectorId(ctx conte
 		CASE 
 			WHEN vssm.status != evssm.status THEN 
 				CASE 
-					WHEN evssm.status IN ('PREBOOK', 'COMPLIMENT') THEN 'UNAVAILABLE'
+					WHEN evssm.status IN ('PREBOOK', 'PREBOOKED', 'COMPLIMENT') THEN 'UNAVAILABLE'
 					ELSE evssm.status
 				END 
 			ELSE vssm.status
-		END AS seat_final_status
+		END AS seat_final_status,
+		COALESCE(evssm.ticket_category_id::text, '') AS seat_ticket_category_id,
+		COALESCE(evssm.attributes, vssm.attributes) AS seat_attributes
 	FROM venue_sector_seatmap_matrix vssm 
 	LEFT JOIN event_venue_sector_seatmap_matrix evssm 
 		ON vssm.sector_id = evssm.sector_id 
@@ -374,6 +383,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatmapByEventSectorId(ctx conte
 			&sectorSeatmap.SeatRowLabel,
 			&sectorSeatmap.Label,
 			&sectorSeatmap.Status,
+			&sectorSeatmap.TicketCategoryID,
+			&sectorSeatmap.Attributes,
 		)
 
 		seats = append(seats, sectorSeatmap)
@@ -413,6 +424,36 @@ func (r *EventTicketCategoryRepositoryImpl) BuyPublicTicketById(ctx context.Cont
 	return
 }
 
+// Give back public stock taken by BuyPublicTicketById, capped by total public stock
+func (r *EventTicketCategoryRepositoryImpl) ReleasePublicTicketById(ctx context.Context, tx pgx.Tx, eventId, ticketCategoryId string, releaseTicket int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_ticket_categories 
+		SET public_stock = LEAST(public_stock + $1, total_public_stock), 
+		updated_at = NOW() 
+	WHERE event_id = $2 
+		AND id = $3`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, releaseTicket, eventId, ticketCategoryId)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, releaseTicket, eventId, ticketCategoryId)
+	}
+
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		err = &lib.ErrorTicketCategoryNotFound
+		return
+	}
+
+	return
+}
+
 // Find seatmap by specific event, venue sector and seat row & colum
 func (r *EventTicketCategoryRepositoryImpl) FindSeatmapStatusByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string, reqs []domain.SeatmapParam) (seatmap map[string]entity.EventVenueSector, err error) {
 	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
@@ -431,11 +472,13 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatmapStatusByEventSectorId(ctx
 		CASE 
 			WHEN vssm.status != evssm.status THEN 
 				CASE 
-					WHEN evssm.status IN ('PREBOOK', 'COMPLIMENT') THEN 'UNAVAILABLE'
+					WHEN evssm.status IN ('PREBOOK', 'PREBOOKED', 'COMPLIMENT') THEN 'UNAVAILABLE'
 					ELSE evssm.status
 				END 
 			ELSE vssm.status
-		END AS seat_final_status
+		END AS seat_final_status,
+		COALESCE(evssm.ticket_category_id::text, '') AS seat_ticket_category_id,
+		COALESCE(evssm.attributes, vssm.attributes) AS seat_attributes
 	FROM venue_sector_seatmap_matrix vssm 
 	LEFT JOIN event_venue_sector_seatmap_matrix evssm 
 		ON vssm.sector_id = evssm.sector_id 
@@ -486,6 +529,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatmapStatusByEventSectorId(ctx
 			&sectorSeatmap.SeatColumn,
 			&sectorSeatmap.Label,
 			&sectorSeatmap.Status,
+			&sectorSeatmap.TicketCategoryID,
+			&sectorSeatmap.Attributes,
 		)
 
 		seatmap[helper.ConvertRowColumnKey(sectorSeatmap.SeatRow, sectorSeatmap.SeatColumn)] = sectorSeatmap
@@ -634,11 +679,13 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatByRowsColumnsEventSectorId(c
 		CASE 
 			WHEN vssm.status != evssm.status THEN 
 				CASE 
-					WHEN evssm.status IN ('PREBOOK', 'COMPLIMENT') THEN 'UNAVAILABLE'
+					WHEN evssm.status IN ('PREBOOK', 'PREBOOKED', 'COMPLIMENT') THEN 'UNAVAILABLE'
 					ELSE evssm.status
 				END 
 			ELSE vssm.status
-		END AS seat_final_status
+		END AS seat_final_status,
+		COALESCE(evssm.ticket_category_id::text, '') AS seat_ticket_category_id,
+		COALESCE(evssm.attributes, vssm.attributes) AS seat_attributes
 	FROM venue_sector_seatmap_matrix vssm 
 	LEFT JOIN event_venue_sector_seatmap_matrix evssm 
 		ON vssm.sector_id = evssm.sector_id 
@@ -682,6 +729,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatByRowsColumnsEventSectorId(c
 			&sectorSeatmap.SeatColumn,
 			&sectorSeatmap.Label,
 			&sectorSeatmap.Status,
+			&sectorSeatmap.TicketCategoryID,
+			&sectorSeatmap.Attributes,
 		)
 
 		seats[helper.ConvertRowColumnKey(sectorSeatmap.SeatRow, sectorSeatmap.SeatColumn)] = sectorSeatmap
@@ -690,7 +739,7 @@ func (r *EventTicketCategoryRepositoryImpl) FindSeatByRowsColumnsEventSectorId(c
 	return
 }
 
-func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColumn(ctx context.Context, tx pgx.Tx, eventId, sectorId string, seatCount, seatRow, seatColumn int) (seats []entity.EventVenueSector, err error) {
+func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColumn(ctx context.Context, tx pgx.Tx, eventId, sectorId, ticketCategoryId string, requiredAttributes, excludedAttributes []string, seatCount, seatRow, seatColumn int) (seats []entity.EventVenueSector, err error) {
 	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
 	defer cancel()
 
@@ -708,11 +757,13 @@ func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColu
 			CASE 
 				WHEN vssm.status != evssm.status THEN 
 					CASE 
-						WHEN evssm.status IN ('PREBOOK', 'COMPLIMENT') THEN 'UNAVAILABLE'
+						WHEN evssm.status IN ('PREBOOK', 'PREBOOKED', 'COMPLIMENT') THEN 'UNAVAILABLE'
 						ELSE evssm.status
 					END 
 				ELSE vssm.status
-			END AS seat_final_status
+			END AS seat_final_status,
+			COALESCE(evssm.ticket_category_id::text, '') AS seat_ticket_category_id,
+			COALESCE(evssm.attributes, vssm.attributes) AS seat_attributes
 		FROM venue_sector_seatmap_matrix vssm 
 		LEFT JOIN event_venue_sector_seatmap_matrix evssm 
 			ON vssm.sector_id = evssm.sector_id 
@@ -723,15 +774,18 @@ func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColu
 		AND (vssm.seat_row, vssm.seat_column) > ($3, $4)
 	) sub
 	WHERE seat_final_status = $5
+		AND seat_ticket_category_id IN ('', $7)
+		AND NOT (seat_attributes && $8)
+		AND (cardinality($9::text[]) = 0 OR seat_attributes && $9::text[])
 	ORDER BY seat_row ASC, seat_column ASC 
 	LIMIT $6`
 
 	var rows pgx.Rows
 
 	if tx != nil {
-		rows, err = tx.Query(ctx, query, eventId, sectorId, seatRow, seatColumn, lib.SeatmapStatusAvailable, seatCount)
+		rows, err = tx.Query(ctx, query, eventId, sectorId, seatRow, seatColumn, lib.SeatmapStatusAvailable, seatCount, ticketCategoryId, seatAttributes(excludedAttributes), seatAttributes(requiredAttributes))
 	} else {
-		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventId, sectorId, eventId, sectorId, seatRow, seatColumn, lib.SeatmapStatusAvailable, seatCount)
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventId, sectorId, seatRow, seatColumn, lib.SeatmapStatusAvailable, seatCount, ticketCategoryId, seatAttributes(excludedAttributes), seatAttributes(requiredAttributes))
 	}
 
 	if err != nil {
@@ -749,6 +803,8 @@ func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColu
 			&sectorSeatmap.SeatRowLabel,
 			&sectorSeatmap.Label,
 			&sectorSeatmap.Status,
+			&sectorSeatmap.TicketCategoryID,
+			&sectorSeatmap.Attributes,
 		)
 
 		seats = append(seats, sectorSeatmap)
@@ -762,3 +818,82 @@ func (r *EventTicketCategoryRepositoryImpl) FindNAvailableSeatAfterSectorRowColu
 
 	return
 }
+
+// LockSeatAssignment hold advisory lock of event sector until the transaction ends, so concurrent auto assignment
+// in the sector read available seats one after another without locking seat rows. Must be called inside transaction
+func (r *EventTicketCategoryRepositoryImpl) LockSeatAssignment(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `SELECT pg_advisory_xact_lock(hashtext('seat-assign:' || $1::text || ':' || $2::text))`
+
+	_, err = tx.Exec(ctx, query, eventId, sectorId)
+	return
+}
+
+// FindAvailableSeatsByEventSectorId find seats of sector which are available in the event, not booked yet
+// and sellable by the ticket category, ordered by row and column. Seats having any of excluded attributes are left out
+func (r *EventTicketCategoryRepositoryImpl) FindAvailableSeatsByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId, ticketCategoryId string, excludedAttributes []string) (seats []entity.EventVenueSector, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT 
+		vssm.id, 
+		vssm.seat_row, 
+		vssm.seat_column, 
+		vssm.seat_row_label, 
+		COALESCE(evssm.label, vssm.label) AS seat_final_label,
+		COALESCE(evssm.status, vssm.status) AS seat_final_status,
+		COALESCE(evssm.ticket_category_id::text, '') AS seat_ticket_category_id,
+		COALESCE(evssm.attributes, vssm.attributes) AS seat_attributes
+	FROM venue_sector_seatmap_matrix vssm 
+	LEFT JOIN event_venue_sector_seatmap_matrix evssm 
+		ON vssm.sector_id = evssm.sector_id 
+		AND vssm.seat_row = evssm.seat_row 
+		AND vssm.seat_column = evssm.seat_column
+		AND evssm.event_id = $1
+	WHERE vssm.sector_id = $2
+		AND COALESCE(evssm.status, vssm.status) = $3
+		AND (evssm.ticket_category_id IS NULL OR evssm.ticket_category_id = $4)
+		AND NOT (COALESCE(evssm.attributes, vssm.attributes) && $5)
+		AND NOT EXISTS (
+			SELECT 1 FROM event_seatmap_books esb
+			WHERE esb.event_id = $1
+				AND esb.venue_sector_id = vssm.sector_id
+				AND esb.seat_row = vssm.seat_row
+				AND esb.seat_column = vssm.seat_column
+		)
+	ORDER BY vssm.seat_row ASC, vssm.seat_column ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, eventId, sectorId, lib.SeatmapStatusAvailable, ticketCategoryId, seatAttributes(excludedAttributes))
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventId, sectorId, lib.SeatmapStatusAvailable, ticketCategoryId, seatAttributes(excludedAttributes))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sectorSeatmap entity.EventVenueSector
+		err = rows.Scan(
+			&sectorSeatmap.ID,
+			&sectorSeatmap.SeatRow,
+			&sectorSeatmap.SeatColumn,
+			&sectorSeatmap.SeatRowLabel,
+			&sectorSeatmap.Label,
+			&sectorSeatmap.Status,
+			&sectorSeatmap.TicketCategoryID,
+			&sectorSeatmap.Attributes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		seats = append(seats, sectorSeatmap)
+	}
+
+	return seats, rows.Err()
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type EventTicketCategoryPriceTierRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.EventTicketCategoryPriceTier) (res model.EventTicketCategoryPriceTier, err error)
+	FindByEventId(ctx context.Context, tx pgx.Tx, eventID string) (res map[string][]model.EventTicketCategoryPriceTier, err error)
+	FindByTicketCategoryId(ctx context.Context, tx pgx.Tx, ticketCategoryID string) (res []model.EventTicketCategoryPriceTier, err error)
+	SoftDelete(ctx context.Context, tx pgx.Tx, ticketCategoryID, priceTierID string) (err error)
+	Buy(ctx context.Context, tx pgx.Tx, priceTierID string, quantity int) (err error)
+	CreateTransactionPriceTier(ctx context.Context, tx pgx.Tx, transactionID string, tier model.EventTicketCategoryPriceTier, quantity int) (err error)
+	ReleaseByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (err error)
+}
+
+type EventTicketCategoryPriceTierRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewEventTicketCategoryPriceTierRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) EventTicketCategoryPriceTierRepository {
+	return &EventTicketCategoryPriceTierRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+const priceTierColumns = `id, event_ticket_category_id, name, price, start_at, end_at, stock_limit, sold, created_at, updated_at`
+
+func scanPriceTier(row pgx.Row, res *model.EventTicketCategoryPriceTier) error {
+	return row.Scan(
+		&res.ID,
+		&res.TicketCategoryID,
+		&res.Name,
+		&res.Price,
+		&res.StartAt,
+		&res.EndAt,
+		&res.StockLimit,
+		&res.Sold,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+}
+
+func (r *EventTicketCategoryPriceTierRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.EventTicketCategoryPriceTier) (res model.EventTicketCategoryPriceTier, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `INSERT INTO event_ticket_category_price_tiers (
+		event_ticket_category_id,
+		name,
+		price,
+		start_at,
+		end_at,
+		stock_limit,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	RETURNING ` + priceTierColumns
+
+	args := []interface{}{
+		req.TicketCategoryID,
+		req.Name,
+		req.Price,
+		req.StartAt,
+		req.EndAt,
+		req.StockLimit,
+	}
+
+	if tx != nil {
+		err = scanPriceTier(tx.QueryRow(ctx, query, args...), &res)
+	} else {
+		err = scanPriceTier(r.WrapDB.Postgres.QueryRow(ctx, query, args...), &res)
+	}
+
+	return
+}
+
+// FindByEventId find price tiers of every category of event, grouped by ticket category id and ordered by start time
+func (r *EventTicketCategoryPriceTierRepositoryImpl) FindByEventId(ctx context.Context, tx pgx.Tx, eventID string) (res map[string][]model.EventTicketCategoryPriceTier, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make(map[string][]model.EventTicketCategoryPriceTier)
+
+	query := `SELECT ` + priceTierColumns + ` FROM event_ticket_category_price_tiers
+		WHERE deleted_at IS NULL AND event_ticket_category_id IN (
+			SELECT id FROM event_ticket_categories WHERE event_id = $1 AND deleted_at IS NULL
+		)
+		ORDER BY start_at ASC, created_at ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, eventID)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventID)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier model.EventTicketCategoryPriceTier
+		err = scanPriceTier(rows, &tier)
+		if err != nil {
+			return
+		}
+		res[tier.TicketCategoryID] = append(res[tier.TicketCategoryID], tier)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// FindByTicketCategoryId find price tiers of category ordered by start time
+func (r *EventTicketCategoryPriceTierRepositoryImpl) FindByTicketCategoryId(ctx context.Context, tx pgx.Tx, ticketCategoryID string) (res []model.EventTicketCategoryPriceTier, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.EventTicketCategoryPriceTier, 0)
+
+	query := `SELECT ` + priceTierColumns + ` FROM event_ticket_category_price_tiers
+		WHERE event_ticket_category_id = $1 AND deleted_at IS NULL
+		ORDER BY start_at ASC, created_at ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, ticketCategoryID)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, ticketCategoryID)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier model.EventTicketCategoryPriceTier
+		err = scanPriceTier(rows, &tier)
+		if err != nil {
+			return
+		}
+		res = append(res, tier)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (r *EventTicketCategoryPriceTierRepositoryImpl) SoftDelete(ctx context.Context, tx pgx.Tx, ticketCategoryID, priceTierID string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_ticket_category_price_tiers SET deleted_at = NOW(), updated_at = NOW()
+		WHERE event_ticket_category_id = $1 AND id = $2 AND deleted_at IS NULL`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, ticketCategoryID, priceTierID)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, ticketCategoryID, priceTierID)
+	}
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		err = &lib.ErrorPriceTierNotFound
+	}
+
+	return
+}
+
+// Buy add quantity to sold count of tier, fails when it exceeds the tier stock limit
+func (r *EventTicketCategoryPriceTierRepositoryImpl) Buy(ctx context.Context, tx pgx.Tx, priceTierID string, quantity int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_ticket_category_price_tiers SET sold = sold + $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL AND (stock_limit IS NULL OR sold + $2 <= stock_limit)`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, priceTierID, quantity)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, priceTierID, quantity)
+	}
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		err = &lib.ErrorPriceTierSoldOut
+	}
+
+	return
+}
+
+func (r *EventTicketCategoryPriceTierRepositoryImpl) CreateTransactionPriceTier(ctx context.Context, tx pgx.Tx, transactionID string, tier model.EventTicketCategoryPriceTier, quantity int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `INSERT INTO event_transaction_price_tiers (
+		event_transaction_id,
+		price_tier_id,
+		quantity,
+		price,
+		created_at
+	) VALUES ($1, $2, $3, $4, NOW())`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionID, tier.ID, quantity, tier.Price)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionID, tier.ID, quantity, tier.Price)
+	}
+
+	return
+}
+
+// ReleaseByTransactionId give back sold count of tiers bought by expired transaction
+func (r *EventTicketCategoryPriceTierRepositoryImpl) ReleaseByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `WITH released AS (
+		DELETE FROM event_transaction_price_tiers WHERE event_transaction_id = $1
+		RETURNING price_tier_id, quantity
+	)
+	UPDATE event_ticket_category_price_tiers AS tier
+	SET sold = GREATEST(tier.sold - released.quantity, 0), updated_at = NOW()
+	FROM released
+	WHERE tier.id = released.price_tier_id`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionID)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionID)
+	}
+
+	return
+}
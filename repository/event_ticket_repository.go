@@ -14,6 +14,9 @@ import (
 type EventTicketRepository interface {
 	Create(ctx context.Context, tx pgx.Tx, eventTicket model.EventTicket) (id int, err error)
 	FindById(ctx context.Context, tx pgx.Tx, id string) (res model.EventTicket, err error)
+	FindByTransactionIdAndTicketNumber(ctx context.Context, tx pgx.Tx, transactionId, ticketNumber string) (res model.EventTicket, err error)
+	InvalidateByTransactionId(ctx context.Context, tx pgx.Tx, transactionId, reason string) (res []model.EventTicket, err error)
+	InvalidateOneByOwner(ctx context.Context, tx pgx.Tx, transactionId, email, fullname string, seatRow, seatColumn int, reason string) (res model.EventTicket, err error)
 }
 
 type EventTicketRepositoryImpl struct {
@@ -212,3 +215,134 @@ func (r *EventTicketRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, id
 
 	return
 }
+
+// FindByTransactionIdAndTicketNumber find ticket of the transaction with its seat and code
+func (r *EventTicketRepositoryImpl) FindByTransactionIdAndTicketNumber(ctx context.Context, tx pgx.Tx, transactionId, ticketNumber string) (res model.EventTicket, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT 
+		id,
+		event_id, 
+		ticket_category_id, 
+		event_transaction_id, 
+		ticket_number, 
+		sector_name,
+		COALESCE(area_code, ''),
+		COALESCE(seat_row, 0),
+		COALESCE(seat_column, 0),
+		seat_label,
+		ticket_code,
+		invalidated_at
+	FROM event_tickets
+	WHERE event_transaction_id = $1 AND ticket_number = $2`
+
+	var row pgx.Row
+	if tx != nil {
+		row = tx.QueryRow(ctx, query, transactionId, ticketNumber)
+	} else {
+		row = r.WrapDB.Postgres.QueryRow(ctx, query, transactionId, ticketNumber)
+	}
+
+	err = row.Scan(
+		&res.ID,
+		&res.EventID,
+		&res.TicketCategoryID,
+		&res.TransactionID,
+		&res.TicketNumber,
+		&res.SectorName,
+		&res.AreaCode,
+		&res.SeatRow,
+		&res.SeatColumn,
+		&res.SeatLabel,
+		&res.TicketCode,
+		&res.InvalidatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, &lib.EventTicketNotFound
+		}
+
+		return
+	}
+
+	return
+}
+
+// InvalidateByTransactionId invalidate every valid ticket of the transaction and return the invalidated tickets
+func (r *EventTicketRepositoryImpl) InvalidateByTransactionId(ctx context.Context, tx pgx.Tx, transactionId, reason string) (res []model.EventTicket, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	res = make([]model.EventTicket, 0)
+
+	query := `UPDATE event_tickets SET
+		invalidated_at = NOW(),
+		invalidation_reason = $1,
+		updated_at = NOW()
+	WHERE event_transaction_id = $2 AND invalidated_at IS NULL
+	RETURNING id, COALESCE(seat_row, 0), COALESCE(seat_column, 0)`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, reason, transactionId)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, reason, transactionId)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ticket := model.EventTicket{TransactionID: transactionId}
+		err = rows.Scan(&ticket.ID, &ticket.SeatRow, &ticket.SeatColumn)
+		if err != nil {
+			return
+		}
+
+		res = append(res, ticket)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// InvalidateOneByOwner invalidate one valid ticket issued for the owner, ticket on the given seat is preferred.
+// Ticket isn't linked to transaction item, so owner is the only way to find it. ID is 0 when no ticket is issued yet
+func (r *EventTicketRepositoryImpl) InvalidateOneByOwner(ctx context.Context, tx pgx.Tx, transactionId, email, fullname string, seatRow, seatColumn int, reason string) (res model.EventTicket, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_tickets SET
+		invalidated_at = NOW(),
+		invalidation_reason = $1,
+		updated_at = NOW()
+	WHERE id = (
+		SELECT id FROM event_tickets
+		WHERE event_transaction_id = $2
+			AND ticket_owner_email = $3
+			AND ticket_owner_full_name = $4
+			AND invalidated_at IS NULL
+		ORDER BY (seat_row = $5 AND seat_column = $6) DESC, id ASC
+		LIMIT 1
+		FOR UPDATE
+	)
+	RETURNING id, COALESCE(seat_row, 0), COALESCE(seat_column, 0)`
+
+	res.TransactionID = transactionId
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, reason, transactionId, email, fullname, seatRow, seatColumn).Scan(&res.ID, &res.SeatRow, &res.SeatColumn)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, reason, transactionId, email, fullname, seatRow, seatColumn).Scan(&res.ID, &res.SeatRow, &res.SeatColumn)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, nil
+		}
+		return
+	}
+
+	return
+}
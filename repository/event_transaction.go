@@ -9,6 +9,8 @@ import (
 	"assist-tix/model"
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 
 	"time"
 
@@ -21,12 +23,21 @@ type EventTransactionRepository interface {
 	CreateTransaction(ctx context.Context, tx pgx.Tx, eventId, eventTicketCategoryId string, req model.EventTransaction) (res model.EventTransaction, err error)
 	IsEmailAlreadyBookEvent(ctx context.Context, tx pgx.Tx, eventId, email string) (id string, err error)
 	FindByOrderNumber(ctx context.Context, tx pgx.Tx, orderNumber string) (res model.EventTransaction, err error)
-	MarkTransactionAsSuccess(ctx context.Context, tx pgx.Tx, transactionID string, successTime time.Time, pgOrderID string) (res model.EventTransaction, err error)
-	UpdatePaymentAdditionalInformation(ctx context.Context, tx pgx.Tx, transactionID, vaNo string) (err error)
+	UpdatePaymentAdditionalInformation(ctx context.Context, tx pgx.Tx, transactionID, vaNo, channelTransactionID string) (err error)
+	UpdateChargeClaimedAt(ctx context.Context, tx pgx.Tx, transactionID string, claimedAt *time.Time) (err error)
+	ClaimBill(ctx context.Context, tx pgx.Tx, transactionID string) (claimed bool, err error)
 	FindById(ctx context.Context, tx pgx.Tx, transactionID string) (resData dto.OrderDetails, err error)
 	FindTransactionDetailByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (res entity.EventTransaction, err error)
-	MarkTransactionAsFailed(ctx context.Context, tx pgx.Tx, transactionID string, pgOrderID string) (res model.EventTransaction, err error)
-	MarkTransactionStatus(ctx context.Context, tx pgx.Tx, transactionID string, status string, paidAt time.Time, pgOrderID string) (res model.EventTransaction, err error)
+	UpdateStatus(ctx context.Context, tx pgx.Tx, transactionID, fromStatus, toStatus string, paidAt *time.Time, pgOrderID string) (res model.EventTransaction, err error)
+	FindByIdForUpdate(ctx context.Context, tx pgx.Tx, transactionID string) (res model.EventTransaction, err error)
+	FindByOrderNumberForUpdate(ctx context.Context, tx pgx.Tx, orderNumber string) (res model.EventTransaction, err error)
+	FindPendingReconciliationCandidates(ctx context.Context, tx pgx.Tx, createdBefore time.Time, limit int) (res []model.EventTransaction, err error)
+	FindExpiredReconciliationCandidates(ctx context.Context, tx pgx.Tx, expiredSince time.Time, limit int) (res []model.EventTransaction, err error)
+	UpdateRefund(ctx context.Context, tx pgx.Tx, transactionID string, refundedAmount int, refundStatus string) (err error)
+	LockPurchaseLimit(ctx context.Context, tx pgx.Tx, keys ...string) (err error)
+	CountActiveTicketByEmail(ctx context.Context, tx pgx.Tx, eventId, email string) (count int, err error)
+	CountActiveTicketByClientIP(ctx context.Context, tx pgx.Tx, eventId, clientIP string) (count int, err error)
+	CountActiveTicketByGarudaIds(ctx context.Context, tx pgx.Tx, eventId string, garudaIds ...string) (res map[string]int, err error)
 }
 
 type EventTransactionRepositoryImpl struct {
@@ -97,8 +108,14 @@ func (r *EventTransactionRepositoryImpl) CreateTransaction(ctx context.Context,
 		is_compliment,
 
 		created_at,
-		pg_additional_fee
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW(), $16) RETURNING id, created_at`
+		pg_additional_fee,
+		ticket_quantity,
+
+		voucher_id,
+		voucher_code,
+		discount_amount,
+		client_ip
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW(), $16, $17, NULLIF($18, '')::uuid, NULLIF($19, ''), $20, NULLIF($21, '')) RETURNING id, created_at`
 
 	if tx != nil {
 		err = tx.QueryRow(ctx, query,
@@ -120,6 +137,11 @@ func (r *EventTransactionRepositoryImpl) CreateTransaction(ctx context.Context,
 			req.Fullname,
 			req.IsCompliment,
 			req.PGAdditionalFee, // Additional fee for payment gateway
+			req.TicketQuantity,
+			req.VoucherID,
+			req.VoucherCode,
+			req.DiscountAmount,
+			req.ClientIP,
 		).Scan(&req.ID, &req.CreatedAt)
 	} else {
 		err = r.WrapDB.Postgres.QueryRow(ctx, query,
@@ -141,6 +163,11 @@ func (r *EventTransactionRepositoryImpl) CreateTransaction(ctx context.Context,
 			req.Fullname,
 			req.IsCompliment,
 			req.PGAdditionalFee, // Additional fee for payment gateway
+			req.TicketQuantity,
+			req.VoucherID,
+			req.VoucherCode,
+			req.DiscountAmount,
+			req.ClientIP,
 		).Scan(&req.ID, &req.CreatedAt)
 	}
 
@@ -165,14 +192,49 @@ func (r *EventTransactionRepositoryImpl) FindByOrderNumber(ctx context.Context,
 	defer cancel()
 
 	query := `
-	SELECT id, event_id, event_ticket_category_id
+	SELECT 
+		id,
+		event_id,
+		event_ticket_category_id,
+		order_number,
+		transaction_status,
+		payment_method,
+		payment_channel,
+		payment_expired_at,
+		grand_total,
+		COALESCE(pg_order_id, ''),
+		COALESCE(channel_transaction_id, '')
 	FROM event_transactions 
 	WHERE order_number = $1 LIMIT 1`
 
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, orderNumber).Scan(&res.ID, &res.EventID, &res.TicketCategoryID)
+		err = tx.QueryRow(ctx, query, orderNumber).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.GrandTotal,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+		)
 	} else {
-		err = r.WrapDB.Postgres.QueryRow(ctx, query, orderNumber).Scan(&res.ID, &res.EventID, &res.TicketCategoryID)
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, orderNumber).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.GrandTotal,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+		)
 	}
 
 	if err != nil {
@@ -185,22 +247,23 @@ func (r *EventTransactionRepositoryImpl) FindByOrderNumber(ctx context.Context,
 	return
 }
 
-func (r *EventTransactionRepositoryImpl) MarkTransactionStatus(ctx context.Context, tx pgx.Tx, transactionID string, status string, paidAt time.Time, pgOrderID string) (res model.EventTransaction, err error) {
+// UpdatePaymentAdditionalInformation store what gateway return on charge, channelTransactionID is kept when empty
+func (r *EventTransactionRepositoryImpl) UpdatePaymentAdditionalInformation(ctx context.Context, tx pgx.Tx, transactionID, vaNo, channelTransactionID string) (err error) {
 	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
 	defer cancel()
 
-	query := `UPDATE event_transactions SET transaction_status = $1, paid_at = $2, pg_order_id = $3 WHERE id = $4 RETURNING id, created_at`
+	query := `UPDATE event_transactions SET payment_additional_information = $1, channel_transaction_id = COALESCE(NULLIF($2, ''), channel_transaction_id) WHERE id = $3`
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, status, paidAt, pgOrderID, transactionID).Scan(&res.ID, &res.CreatedAt)
+		_, err = tx.Exec(ctx, query, vaNo, channelTransactionID, transactionID)
 	} else {
-		err = r.WrapDB.Postgres.QueryRow(ctx, query, status, paidAt, pgOrderID, transactionID).Scan(&res.ID, &res.CreatedAt)
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, vaNo, channelTransactionID, transactionID)
 	}
 
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			if pgErr.Code == "23505" {
-				err = &lib.ErrorFailedToMarkTransactionAsSuccess
+				err = &lib.ErrorFailedToUpdateVANo
 			}
 		}
 
@@ -210,80 +273,39 @@ func (r *EventTransactionRepositoryImpl) MarkTransactionStatus(ctx context.Conte
 	return
 }
 
-func (r *EventTransactionRepositoryImpl) MarkTransactionAsSuccess(ctx context.Context, tx pgx.Tx, transactionID string, paidAt time.Time, pgOrderID string) (res model.EventTransaction, err error) {
+// UpdateChargeClaimedAt claim the charge of async order before gateway is called, nil releases the claim
+func (r *EventTransactionRepositoryImpl) UpdateChargeClaimedAt(ctx context.Context, tx pgx.Tx, transactionID string, claimedAt *time.Time) (err error) {
 	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
 	defer cancel()
 
-	query := `UPDATE event_transactions SET transaction_status = $1, paid_at = $2, pg_order_id = $3 WHERE id = $4 RETURNING id, created_at`
+	query := `UPDATE event_transactions SET charge_claimed_at = $1 WHERE id = $2`
 	if tx != nil {
-		err = tx.QueryRow(ctx, query, lib.EventTransactionStatusSuccess, paidAt, pgOrderID, transactionID).Scan(&res.ID, &res.CreatedAt)
+		_, err = tx.Exec(ctx, query, claimedAt, transactionID)
 	} else {
-		err = r.WrapDB.Postgres.QueryRow(ctx, query, lib.EventTransactionStatusSuccess, paidAt, pgOrderID, transactionID).Scan(&res.ID, &res.CreatedAt)
-	}
-
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				err = &lib.ErrorFailedToMarkTransactionAsSuccess
-			}
-		}
-
-		return
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, claimedAt, transactionID)
 	}
 
 	return
 }
 
-func (r *EventTransactionRepositoryImpl) MarkTransactionAsFailed(ctx context.Context, tx pgx.Tx, transactionID string, pgOrderID string) (res model.EventTransaction, err error) {
+// ClaimBill mark the bill of transaction as sent, claimed is false when another delivery claimed it first
+func (r *EventTransactionRepositoryImpl) ClaimBill(ctx context.Context, tx pgx.Tx, transactionID string) (claimed bool, err error) {
 	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
-	currentTime := time.Now()
 	defer cancel()
 
-	query := `UPDATE event_transactions SET transaction_status = $1,  pg_order_id = $2,updated_at = $3 WHERE id = $4 RETURNING id, created_at`
-	if tx != nil {
-		err = tx.QueryRow(ctx, query, lib.EventTransactionStatusFailed, pgOrderID, currentTime, transactionID).Scan(&res.ID, &res.CreatedAt)
-	} else {
-		err = r.WrapDB.Postgres.QueryRow(ctx, query, lib.EventTransactionStatusFailed, pgOrderID, currentTime, transactionID).Scan(&res.ID, &res.CreatedAt)
-	}
-
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				err = &lib.ErrorFailedToMarkTransactionAsSuccess
-			}
-		}
-
-		return
-	}
-
-	return
-}
+	query := `UPDATE event_transactions SET billed_at = NOW() WHERE id = $1 AND billed_at IS NULL`
 
-func (r *EventTransactionRepositoryImpl) UpdatePaymentAdditionalInformation(ctx context.Context, tx pgx.Tx, transactionID, vaNo string) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
-	defer cancel()
-
-	query := `UPDATE event_transactions SET payment_additional_information = $1 WHERE id = $2`
+	var tag pgconn.CommandTag
 	if tx != nil {
-		_, err = tx.Exec(ctx, query, vaNo, transactionID)
+		tag, err = tx.Exec(ctx, query, transactionID)
 	} else {
-		_, err = r.WrapDB.Postgres.Exec(ctx, query, vaNo, transactionID)
+		tag, err = r.WrapDB.Postgres.Exec(ctx, query, transactionID)
 	}
-
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				err = &lib.ErrorFailedToUpdateVANo
-			}
-		}
-
 		return
 	}
 
-	return
+	return tag.RowsAffected() == 1, nil
 }
 
 func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, transactionID string) (resData dto.OrderDetails, err error) {
@@ -310,7 +332,9 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 	etc.name,
 	v.country,
 	v.city,
-	et.pg_additional_fee
+	et.pg_additional_fee,
+	COALESCE(et.voucher_code, ''),
+	et.discount_amount
 	FROM event_transactions et
 	JOIN event_ticket_categories etc ON et.event_ticket_category_id = etc.id
 	JOIN events e ON et.event_id = e.id
@@ -322,7 +346,7 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 	et.order_number, et.paid_at, et.payment_expired_at, et.transaction_status,
 	et.payment_additional_information, et.payment_method,
 	et.grand_total, et.total_admin_fee, et.total_tax, et.total_price, 
-	etc.name, v.country, v.city, et.pg_additional_fee
+	etc.name, v.country, v.city, et.pg_additional_fee, et.voucher_code, et.discount_amount
 	LIMIT 1;
 	`
 	if tx != nil {
@@ -345,6 +369,8 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 			&res.Country,
 			&res.City,
 			&res.PGAdditionalFee, // Additional fee for payment gateway
+			&res.VoucherCode,
+			&res.DiscountAmount,
 		)
 	} else {
 		err = r.WrapDB.Postgres.QueryRow(ctx, query, transactionID).Scan(
@@ -366,6 +392,8 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 			&res.Country,
 			&res.City,
 			&res.PGAdditionalFee, // Additional fee for payment gateway
+			&res.VoucherCode,
+			&res.DiscountAmount,
 		)
 	}
 
@@ -413,7 +441,7 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 	}
 	for i, obj := range resAdditionalPayment {
 		if obj.IsPercentage {
-			resAdditionalPayment[i].CalculatedValue = (float64(res.TotalPrice) * obj.Value) / 100
+			resAdditionalPayment[i].CalculatedValue = (float64(res.TotalPrice-res.DiscountAmount) * obj.Value) / 100
 		} else {
 			resAdditionalPayment[i].CalculatedValue = obj.Value
 		}
@@ -437,6 +465,8 @@ func (r *EventTransactionRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx
 		TotalAdminFee:         res.TotalAdminFee,
 		TotalTax:              res.TotalTax,
 		TotalPrice:            res.TotalPrice,
+		VoucherCode:           res.VoucherCode,
+		DiscountAmount:        res.DiscountAmount,
 		TransactionQuantity:   res.TransactionQuantity,
 		Country:               res.Country, // Assuming country and city are not available in this query
 		City:                  res.City,
@@ -468,6 +498,8 @@ func (r *EventTransactionRepositoryImpl) FindTransactionDetailByTransactionId(ct
 		et.email,
 		et.is_compliment,
 		et.pg_additional_fee,
+		et.voucher_code,
+		et.discount_amount,
 
 		pm.id as payment_method_id,
 		pm.name as payment_method_name,
@@ -555,6 +587,8 @@ func (r *EventTransactionRepositoryImpl) FindTransactionDetailByTransactionId(ct
 			&res.Email,
 			&res.IsCompliment,
 			&res.PgAdditionalFee,
+			&res.VoucherCode,
+			&res.DiscountAmount,
 
 			&res.PaymentMethod.ID,
 			&res.PaymentMethod.Name,
@@ -626,6 +660,8 @@ func (r *EventTransactionRepositoryImpl) FindTransactionDetailByTransactionId(ct
 			&res.Email,
 			&res.IsCompliment,
 			&res.PgAdditionalFee,
+			&res.VoucherCode,
+			&res.DiscountAmount,
 
 			&res.PaymentMethod.ID,
 			&res.PaymentMethod.Name,
@@ -691,3 +727,408 @@ func (r *EventTransactionRepositoryImpl) FindTransactionDetailByTransactionId(ct
 
 	return
 }
+
+// FindByIdForUpdate lock the transaction row until tx is done, must be called inside a tx
+func (r *EventTransactionRepositoryImpl) FindByIdForUpdate(ctx context.Context, tx pgx.Tx, transactionID string) (res model.EventTransaction, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	// Old transaction doesn't have ticket_quantity, fallback to count the items
+	query := `SELECT 
+		et.id,
+		et.event_id,
+		et.event_ticket_category_id,
+		et.order_number,
+		et.transaction_status,
+		et.payment_method,
+		et.payment_channel,
+		et.payment_expired_at,
+		et.grand_total,
+		COALESCE(et.pg_order_id, ''),
+		COALESCE(et.channel_transaction_id, ''),
+		COALESCE(et.is_refunded, false),
+		et.refund_status,
+		et.refunded_amount,
+		COALESCE(NULLIF(et.ticket_quantity, 0), (SELECT COALESCE(SUM(eti.quantity), 0) FROM event_transaction_items eti WHERE eti.transaction_id = et.id))::int,
+		COALESCE(et.payment_additional_information, ''),
+		et.charge_claimed_at,
+		et.billed_at
+	FROM event_transactions et
+	WHERE et.id = $1
+	FOR UPDATE OF et`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, transactionID).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.GrandTotal,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+			&res.IsRefunded,
+			&res.RefundStatus,
+			&res.RefundedAmount,
+			&res.TicketQuantity,
+			&res.PaymentAdditionalInfo,
+			&res.ChargeClaimedAt,
+			&res.BilledAt,
+		)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, transactionID).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.GrandTotal,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+			&res.IsRefunded,
+			&res.RefundStatus,
+			&res.RefundedAmount,
+			&res.TicketQuantity,
+			&res.PaymentAdditionalInfo,
+			&res.ChargeClaimedAt,
+			&res.BilledAt,
+		)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorOrderNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// FindByOrderNumberForUpdate lock the transaction row so concurrent callback is processed one by one, must be called inside a tx
+func (r *EventTransactionRepositoryImpl) FindByOrderNumberForUpdate(ctx context.Context, tx pgx.Tx, orderNumber string) (res model.EventTransaction, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT 
+		id,
+		event_id,
+		event_ticket_category_id,
+		order_number,
+		transaction_status,
+		payment_method,
+		grand_total,
+		full_name,
+		email,
+		payment_channel,
+		payment_expired_at,
+		COALESCE(pg_order_id, ''),
+		COALESCE(channel_transaction_id, '')
+	FROM event_transactions
+	WHERE order_number = $1
+	LIMIT 1
+	FOR UPDATE`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, orderNumber).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.GrandTotal,
+			&res.Fullname,
+			&res.Email,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+		)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, orderNumber).Scan(
+			&res.ID,
+			&res.EventID,
+			&res.TicketCategoryID,
+			&res.OrderNumber,
+			&res.Status,
+			&res.PaymentMethod,
+			&res.GrandTotal,
+			&res.Fullname,
+			&res.Email,
+			&res.PaymentChannel,
+			&res.PaymentExpiredAt,
+			&res.PGOrderID,
+			&res.ChannelTransactionID,
+		)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorOrderNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// UpdateStatus change status only when current status is still fromStatus, so concurrent update can't override each other.
+// paidAt and pgOrderID are kept when empty. Use TransactionLifecycle instead of calling this directly
+func (r *EventTransactionRepositoryImpl) UpdateStatus(ctx context.Context, tx pgx.Tx, transactionID, fromStatus, toStatus string, paidAt *time.Time, pgOrderID string) (res model.EventTransaction, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_transactions SET 
+		transaction_status = $1,
+		paid_at = COALESCE($2, paid_at),
+		pg_order_id = COALESCE(NULLIF($3, ''), pg_order_id),
+		updated_at = NOW()
+	WHERE id = $4 AND transaction_status = $5
+	RETURNING id, transaction_status, created_at`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, toStatus, paidAt, pgOrderID, transactionID, fromStatus).Scan(&res.ID, &res.Status, &res.CreatedAt)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, toStatus, paidAt, pgOrderID, transactionID, fromStatus).Scan(&res.ID, &res.Status, &res.CreatedAt)
+	}
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorTransactionIsNotPending
+			if fromStatus != lib.EventTransactionStatusPending {
+				err = &lib.ErrorTransactionStatusTransitionInvalid
+			}
+			return
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == "23505" {
+				err = &lib.ErrorFailedToMarkTransactionAsSuccess
+			}
+		}
+
+		return
+	}
+
+	return
+}
+
+// FindPendingReconciliationCandidates find pending transaction created before createdBefore.
+// Compliment transaction is skipped since it never reach payment gateway, transaction already reported while pending is skipped too
+func (r *EventTransactionRepositoryImpl) FindPendingReconciliationCandidates(ctx context.Context, tx pgx.Tx, createdBefore time.Time, limit int) (res []model.EventTransaction, err error) {
+	return r.findReconciliationCandidates(ctx, tx, "et.created_at < $2", lib.EventTransactionStatusPending, createdBefore, limit)
+}
+
+// FindExpiredReconciliationCandidates find transaction expired after expiredSince to catch late payment.
+// Compliment transaction is skipped since it never reach payment gateway, transaction already reported while expired is skipped too
+func (r *EventTransactionRepositoryImpl) FindExpiredReconciliationCandidates(ctx context.Context, tx pgx.Tx, expiredSince time.Time, limit int) (res []model.EventTransaction, err error) {
+	return r.findReconciliationCandidates(ctx, tx, "et.payment_expired_at >= $2", lib.EventTransactionStatusExpired, expiredSince, limit)
+}
+
+func (r *EventTransactionRepositoryImpl) findReconciliationCandidates(ctx context.Context, tx pgx.Tx, timeCondition, status string, bound time.Time, limit int) (res []model.EventTransaction, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.EventTransaction, 0)
+
+	query := fmt.Sprintf(`SELECT 
+		et.id,
+		et.order_number,
+		et.transaction_status,
+		et.payment_method,
+		et.payment_channel,
+		et.payment_expired_at,
+		et.grand_total,
+		COALESCE(et.pg_order_id, ''),
+		COALESCE(et.channel_transaction_id, '')
+	FROM event_transactions et
+	WHERE et.is_compliment = false
+		AND et.transaction_status = $1
+		AND %s
+		AND NOT EXISTS (
+			SELECT 1 FROM payment_reconciliations pr
+			WHERE pr.event_transaction_id = et.id AND pr.local_status = et.transaction_status
+		)
+	ORDER BY et.created_at ASC
+	LIMIT $3`, timeCondition)
+
+	args := []interface{}{
+		status,
+		bound,
+		limit,
+	}
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, args...)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, args...)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction model.EventTransaction
+		err = rows.Scan(
+			&transaction.ID,
+			&transaction.OrderNumber,
+			&transaction.Status,
+			&transaction.PaymentMethod,
+			&transaction.PaymentChannel,
+			&transaction.PaymentExpiredAt,
+			&transaction.GrandTotal,
+			&transaction.PGOrderID,
+			&transaction.ChannelTransactionID,
+		)
+		if err != nil {
+			return
+		}
+
+		res = append(res, transaction)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// UpdateRefund store refund progress, transaction is marked as refunded once refund status is REFUNDED
+func (r *EventTransactionRepositoryImpl) UpdateRefund(ctx context.Context, tx pgx.Tx, transactionID string, refundedAmount int, refundStatus string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_transactions SET 
+		refunded_amount = $1,
+		refund_status = $2,
+		is_refunded = $3,
+		updated_at = NOW()
+	WHERE id = $4`
+
+	isRefunded := refundStatus == lib.EventTransactionRefundStatusRefunded
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, refundedAmount, refundStatus, isRefunded, transactionID)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, refundedAmount, refundStatus, isRefunded, transactionID)
+	}
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return &lib.ErrorOrderNotFound
+	}
+
+	return
+}
+
+// LockPurchaseLimit hold lock of purchase limit keys until the transaction ends, so concurrent orders of the same buyer are counted one after another.
+// Keys are locked in sorted order, so orders sharing several keys can't deadlock
+func (r *EventTransactionRepositoryImpl) LockPurchaseLimit(ctx context.Context, tx pgx.Tx, keys ...string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+
+	query := `SELECT pg_advisory_xact_lock(hashtext($1))`
+
+	for _, key := range sorted {
+		if tx != nil {
+			_, err = tx.Exec(ctx, query, key)
+		} else {
+			_, err = r.WrapDB.Postgres.Exec(ctx, query, key)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// CountActiveTicketByEmail count tickets ordered by email on event, expired and failed transactions aren't counted
+func (r *EventTransactionRepositoryImpl) CountActiveTicketByEmail(ctx context.Context, tx pgx.Tx, eventId, email string) (count int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT COALESCE(SUM(ticket_quantity), 0) FROM event_transactions
+		WHERE event_id = $1 AND lower(email) = lower($2) AND is_compliment = false AND transaction_status NOT IN ($3, $4)`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, eventId, email, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed).Scan(&count)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, eventId, email, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed).Scan(&count)
+	}
+
+	return
+}
+
+// CountActiveTicketByClientIP count tickets ordered from client ip on event, expired and failed transactions aren't counted
+func (r *EventTransactionRepositoryImpl) CountActiveTicketByClientIP(ctx context.Context, tx pgx.Tx, eventId, clientIP string) (count int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT COALESCE(SUM(ticket_quantity), 0) FROM event_transactions
+		WHERE event_id = $1 AND client_ip = $2 AND is_compliment = false AND transaction_status NOT IN ($3, $4)`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, eventId, clientIP, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed).Scan(&count)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, eventId, clientIP, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed).Scan(&count)
+	}
+
+	return
+}
+
+// CountActiveTicketByGarudaIds count tickets held by each garuda id on event, garuda id without ticket isn't in the result
+func (r *EventTransactionRepositoryImpl) CountActiveTicketByGarudaIds(ctx context.Context, tx pgx.Tx, eventId string, garudaIds ...string) (res map[string]int, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make(map[string]int)
+	if len(garudaIds) == 0 {
+		return
+	}
+
+	// books are counted instead of items, items of async order are only written after the order is committed
+	query := `SELECT b.garuda_id, COUNT(*) FROM event_transaction_garuda_id_books b
+		JOIN event_transactions et ON et.id = b.event_transaction_id
+		WHERE b.event_id = $1 AND b.garuda_id = ANY($2) AND et.is_compliment = false AND et.transaction_status NOT IN ($3, $4)
+		GROUP BY b.garuda_id`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, eventId, garudaIds, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventId, garudaIds, lib.EventTransactionStatusExpired, lib.EventTransactionStatusFailed)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var garudaId string
+		var count int
+		err = rows.Scan(&garudaId, &count)
+		if err != nil {
+			return
+		}
+		res[garudaId] = count
+	}
+
+	err = rows.Err()
+	return
+}
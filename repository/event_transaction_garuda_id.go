@@ -21,6 +21,8 @@ type EventTransactionGarudaIDRepository interface {
 	GetEventGarudaID(ctx context.Context, tx pgx.Tx, eventID string, garudaID string) (res model.EventTransactionGarudaID, err error)
 	CreateBatch(ctx context.Context, tx pgx.Tx, payloads dto.BulkGarudaIDRequest) (err error)
 	CreateGarudaIdBooks(ctx context.Context, tx pgx.Tx, eventId string, garudaIds ...string) (err error)
+	UpdateTransactionIdByGarudaIds(ctx context.Context, tx pgx.Tx, eventId, transactionId string, garudaIds ...string) (err error)
+	DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (err error)
 }
 
 type EventTransactionGarudaIDRepositoryImpl struct {
@@ -183,3 +185,37 @@ func (r *EventTransactionGarudaIDRepositoryImpl) CreateBatch(ctx context.Context
 
 	return nil
 }
+
+func (r *EventTransactionGarudaIDRepositoryImpl) UpdateTransactionIdByGarudaIds(ctx context.Context, tx pgx.Tx, eventId, transactionId string, garudaIds ...string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(garudaIds) == 0 {
+		return nil
+	}
+
+	query := `UPDATE event_transaction_garuda_id_books SET event_transaction_id = $1 WHERE event_id = $2 AND garuda_id = ANY($3)`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionId, eventId, garudaIds)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionId, eventId, garudaIds)
+	}
+
+	return
+}
+
+func (r *EventTransactionGarudaIDRepositoryImpl) DeleteByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM event_transaction_garuda_id_books WHERE event_transaction_id = $1`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionId)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionId)
+	}
+
+	return
+}
@@ -37,6 +37,7 @@ func (r *EventTransactionItemRepositoryImpl) CreateTransactionItems(ctx context.
 
 	query := `INSERT INTO event_transaction_items (
 		transaction_id,
+		event_ticket_category_id,
 		garuda_id,
 
 		quantity,
@@ -51,6 +52,7 @@ func (r *EventTransactionItemRepositoryImpl) CreateTransactionItems(ctx context.
 
 		additional_information,
 		total_price,
+		seat_needs,
 		
 		created_at
 	) VALUES `
@@ -58,23 +60,24 @@ func (r *EventTransactionItemRepositoryImpl) CreateTransactionItems(ctx context.
 	var placeholders []string
 
 	for i, req := range reqs {
-		base := i * 11
-		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NOW())",
-			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11))
+		base := i * 13
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NOW())",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8, base+9, base+10, base+11, base+12, base+13))
 
 		args = append(args,
 			req.TransactionID,
+			req.TicketCategoryID,
 			req.GarudaID,
 			req.Quantity,
 			req.Fullname,
 			req.Email,
 			req.PhoneNumber,
-			// req.TicketCategoryID,
 			req.SeatRow,
 			req.SeatColumn,
 			req.SeatLabel,
 			req.AdditionalInformation,
 			req.TotalPrice,
+			seatAttributes(req.SeatNeeds),
 		)
 	}
 
@@ -102,6 +105,7 @@ func (r *EventTransactionItemRepositoryImpl) GetTransactionItemsByTransactionId(
 	query := `SELECT
 		id,
 		transaction_id,
+		COALESCE(event_ticket_category_id::text, ''),
 		quantity,
 		seat_row,
 		seat_column,
@@ -112,6 +116,7 @@ func (r *EventTransactionItemRepositoryImpl) GetTransactionItemsByTransactionId(
 		phone_number,
 		additional_information,
 		total_price,
+		seat_needs,
 		created_at
 	FROM event_transaction_items 
 	WHERE transaction_id = $1`
@@ -131,6 +136,7 @@ func (r *EventTransactionItemRepositoryImpl) GetTransactionItemsByTransactionId(
 		rows.Scan(
 			&transactionItem.ID,
 			&transactionItem.TransactionID,
+			&transactionItem.TicketCategoryID,
 			&transactionItem.Quantity,
 			&transactionItem.SeatRow,
 			&transactionItem.SeatColumn,
@@ -141,6 +147,7 @@ func (r *EventTransactionItemRepositoryImpl) GetTransactionItemsByTransactionId(
 			&transactionItem.PhoneNumber,
 			&transactionItem.AdditionalInformation,
 			&transactionItem.TotalPrice,
+			&transactionItem.SeatNeeds,
 			&transactionItem.CreatedAt,
 		)
 
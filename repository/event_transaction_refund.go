@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type EventTransactionRefundRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.EventTransactionRefund) (res model.EventTransactionRefund, err error)
+	UpdateStatus(ctx context.Context, tx pgx.Tx, refundID int, status, pgRefundID, failureMessage string) (err error)
+	DeleteItemsByRefundId(ctx context.Context, tx pgx.Tx, refundID int) (err error)
+	FindByIdForUpdate(ctx context.Context, tx pgx.Tx, refundID int) (res model.EventTransactionRefund, err error)
+	FindByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (res []model.EventTransactionRefund, err error)
+}
+
+type EventTransactionRefundRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewEventTransactionRefundRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) EventTransactionRefundRepository {
+	return &EventTransactionRefundRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+// Create insert refund with its items, must be called inside a tx
+func (r *EventTransactionRefundRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.EventTransactionRefund) (res model.EventTransactionRefund, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	res = req
+
+	query := `INSERT INTO event_transaction_refunds (
+		event_transaction_id,
+		refund_number,
+		refund_type,
+		amount,
+		reason,
+		status,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, NOW()) RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, req.TransactionID, req.RefundNumber, req.RefundType, req.Amount, req.Reason, req.Status).Scan(&res.ID, &res.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			err = &lib.ErrorRefundInProgress
+		}
+		return
+	}
+
+	itemQuery := `INSERT INTO event_transaction_refund_items (
+		refund_id,
+		event_transaction_item_id,
+		amount,
+		created_at
+	) VALUES ($1, $2, $3, NOW()) RETURNING id, created_at`
+
+	for i, item := range res.Items {
+		item.RefundID = res.ID
+		err = tx.QueryRow(ctx, itemQuery, item.RefundID, item.TransactionItemID, item.Amount).Scan(&item.ID, &item.CreatedAt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				err = &lib.ErrorRefundItemAlreadyRefunded
+			}
+			return
+		}
+		res.Items[i] = item
+	}
+
+	return
+}
+
+func (r *EventTransactionRefundRepositoryImpl) UpdateStatus(ctx context.Context, tx pgx.Tx, refundID int, status, pgRefundID, failureMessage string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE event_transaction_refunds SET
+		status = $1,
+		pg_refund_id = COALESCE(NULLIF($2, ''), pg_refund_id),
+		failure_message = NULLIF($3, ''),
+		updated_at = NOW()
+	WHERE id = $4`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, status, pgRefundID, failureMessage, refundID)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, status, pgRefundID, failureMessage, refundID)
+	}
+
+	return
+}
+
+// DeleteItemsByRefundId free the items of failed refund, so they can be refunded again
+func (r *EventTransactionRefundRepositoryImpl) DeleteItemsByRefundId(ctx context.Context, tx pgx.Tx, refundID int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM event_transaction_refund_items WHERE refund_id = $1`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, refundID)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, refundID)
+	}
+
+	return
+}
+
+// FindByIdForUpdate lock the refund row until tx is done, items are not loaded
+func (r *EventTransactionRefundRepositoryImpl) FindByIdForUpdate(ctx context.Context, tx pgx.Tx, refundID int) (res model.EventTransactionRefund, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT
+		id,
+		event_transaction_id,
+		refund_number,
+		refund_type,
+		amount,
+		reason,
+		status,
+		pg_refund_id,
+		failure_message,
+		created_at,
+		updated_at
+	FROM event_transaction_refunds
+	WHERE id = $1
+	FOR UPDATE`
+
+	err = tx.QueryRow(ctx, query, refundID).Scan(
+		&res.ID,
+		&res.TransactionID,
+		&res.RefundNumber,
+		&res.RefundType,
+		&res.Amount,
+		&res.Reason,
+		&res.Status,
+		&res.PGRefundID,
+		&res.FailureMessage,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorRefundNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// FindByTransactionId return refunds of transaction from the oldest, including their items
+func (r *EventTransactionRefundRepositoryImpl) FindByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (res []model.EventTransactionRefund, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.EventTransactionRefund, 0)
+
+	query := `SELECT
+		etr.id,
+		etr.event_transaction_id,
+		etr.refund_number,
+		etr.refund_type,
+		etr.amount,
+		etr.reason,
+		etr.status,
+		etr.pg_refund_id,
+		etr.failure_message,
+		etr.created_at,
+		etr.updated_at,
+		etri.id,
+		etri.event_transaction_item_id,
+		etri.amount,
+		etri.created_at
+	FROM event_transaction_refunds etr
+	LEFT JOIN event_transaction_refund_items etri ON etri.refund_id = etr.id
+	WHERE etr.event_transaction_id = $1
+	ORDER BY etr.created_at ASC, etr.id ASC, etri.id ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, transactionID)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, transactionID)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			refund        model.EventTransactionRefund
+			itemID        *int
+			itemTrxItemID *int
+			itemAmount    *int
+			itemCreatedAt *time.Time
+		)
+		err = rows.Scan(
+			&refund.ID,
+			&refund.TransactionID,
+			&refund.RefundNumber,
+			&refund.RefundType,
+			&refund.Amount,
+			&refund.Reason,
+			&refund.Status,
+			&refund.PGRefundID,
+			&refund.FailureMessage,
+			&refund.CreatedAt,
+			&refund.UpdatedAt,
+			&itemID,
+			&itemTrxItemID,
+			&itemAmount,
+			&itemCreatedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		if len(res) == 0 || res[len(res)-1].ID != refund.ID {
+			refund.Items = make([]model.EventTransactionRefundItem, 0)
+			res = append(res, refund)
+		}
+
+		if itemID != nil {
+			last := &res[len(res)-1]
+			last.Items = append(last.Items, model.EventTransactionRefundItem{
+				ID:                *itemID,
+				RefundID:          refund.ID,
+				TransactionItemID: *itemTrxItemID,
+				Amount:            *itemAmount,
+				CreatedAt:         *itemCreatedAt,
+			})
+		}
+	}
+
+	err = rows.Err()
+	return
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/model"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type EventTransactionStatusHistoryRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.EventTransactionStatusHistory) (res model.EventTransactionStatusHistory, err error)
+	FindByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (res []model.EventTransactionStatusHistory, err error)
+}
+
+type EventTransactionStatusHistoryRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewEventTransactionStatusHistoryRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) EventTransactionStatusHistoryRepository {
+	return &EventTransactionStatusHistoryRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+func (r *EventTransactionStatusHistoryRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.EventTransactionStatusHistory) (res model.EventTransactionStatusHistory, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	res = req
+
+	query := `INSERT INTO event_transaction_status_history (
+		event_transaction_id,
+		from_status,
+		to_status,
+		actor,
+		reason,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id, created_at`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, req.TransactionID, req.FromStatus, req.ToStatus, req.Actor, req.Reason).Scan(&res.ID, &res.CreatedAt)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, req.TransactionID, req.FromStatus, req.ToStatus, req.Actor, req.Reason).Scan(&res.ID, &res.CreatedAt)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (r *EventTransactionStatusHistoryRepositoryImpl) FindByTransactionId(ctx context.Context, tx pgx.Tx, transactionId string) (res []model.EventTransactionStatusHistory, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.EventTransactionStatusHistory, 0)
+
+	query := `SELECT
+		id,
+		event_transaction_id,
+		from_status,
+		to_status,
+		actor,
+		reason,
+		created_at
+	FROM event_transaction_status_history
+	WHERE event_transaction_id = $1
+	ORDER BY created_at ASC, id ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, transactionId)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, transactionId)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var history model.EventTransactionStatusHistory
+		err = rows.Scan(
+			&history.ID,
+			&history.TransactionID,
+			&history.FromStatus,
+			&history.ToStatus,
+			&history.Actor,
+			&history.Reason,
+			&history.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		res = append(res, history)
+	}
+
+	err = rows.Err()
+	return
+}
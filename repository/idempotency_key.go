@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type IdempotencyKeyRepository interface {
+	Acquire(ctx context.Context, tx pgx.Tx, req model.IdempotencyKey, staleBefore time.Time) (res model.IdempotencyKey, acquired bool, err error)
+	FindByKey(ctx context.Context, tx pgx.Tx, key string) (res model.IdempotencyKey, err error)
+	Complete(ctx context.Context, tx pgx.Tx, id int64, responseStatus int, responseBody string) (err error)
+	Release(ctx context.Context, tx pgx.Tx, id int64) (err error)
+}
+
+type IdempotencyKeyRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewIdempotencyKeyRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) IdempotencyKeyRepository {
+	return &IdempotencyKeyRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+const idempotencyKeyColumns = `id, idempotency_key, request_hash, status, response_status, response_body, locked_at, expires_at, created_at, updated_at`
+
+func scanIdempotencyKey(row pgx.Row, res *model.IdempotencyKey) error {
+	return row.Scan(
+		&res.ID,
+		&res.Key,
+		&res.RequestHash,
+		&res.Status,
+		&res.ResponseStatus,
+		&res.ResponseBody,
+		&res.LockedAt,
+		&res.ExpiresAt,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+}
+
+// Acquire claim the key for a new request. Expired key is reused, and in progress key of the same request
+// locked before staleBefore is taken over since its request is considered dead.
+// acquired is false when the key is held by another request, use FindByKey to get it
+func (r *IdempotencyKeyRepositoryImpl) Acquire(ctx context.Context, tx pgx.Tx, req model.IdempotencyKey, staleBefore time.Time) (res model.IdempotencyKey, acquired bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `INSERT INTO idempotency_keys (
+		idempotency_key,
+		request_hash,
+		status,
+		locked_at,
+		expires_at,
+		created_at,
+		updated_at
+	) VALUES ($1, $2, $3, NOW(), $4, NOW(), NOW())
+	ON CONFLICT (idempotency_key) DO UPDATE SET
+		request_hash = EXCLUDED.request_hash,
+		status = EXCLUDED.status,
+		response_status = NULL,
+		response_body = NULL,
+		locked_at = NOW(),
+		expires_at = EXCLUDED.expires_at,
+		created_at = NOW(),
+		updated_at = NOW()
+	WHERE idempotency_keys.expires_at <= NOW()
+		OR (idempotency_keys.status = $3 AND idempotency_keys.request_hash = EXCLUDED.request_hash AND idempotency_keys.locked_at <= $5)
+	RETURNING ` + idempotencyKeyColumns
+
+	args := []interface{}{
+		req.Key,
+		req.RequestHash,
+		lib.IdempotencyKeyStatusInProgress,
+		req.ExpiresAt,
+		staleBefore,
+	}
+
+	if tx != nil {
+		err = scanIdempotencyKey(tx.QueryRow(ctx, query, args...), &res)
+	} else {
+		err = scanIdempotencyKey(r.WrapDB.Postgres.QueryRow(ctx, query, args...), &res)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, false, nil
+		}
+		return
+	}
+
+	return res, true, nil
+}
+
+func (r *IdempotencyKeyRepositoryImpl) FindByKey(ctx context.Context, tx pgx.Tx, key string) (res model.IdempotencyKey, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT ` + idempotencyKeyColumns + ` FROM idempotency_keys WHERE idempotency_key = $1`
+
+	if tx != nil {
+		err = scanIdempotencyKey(tx.QueryRow(ctx, query, key), &res)
+	} else {
+		err = scanIdempotencyKey(r.WrapDB.Postgres.QueryRow(ctx, query, key), &res)
+	}
+
+	return
+}
+
+func (r *IdempotencyKeyRepositoryImpl) Complete(ctx context.Context, tx pgx.Tx, id int64, responseStatus int, responseBody string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET status = $1, response_status = $2, response_body = $3, updated_at = NOW() WHERE id = $4`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, lib.IdempotencyKeyStatusCompleted, responseStatus, responseBody, id)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, lib.IdempotencyKeyStatusCompleted, responseStatus, responseBody, id)
+	}
+
+	return
+}
+
+// Release delete in progress key whose response isn't final, so retry of the same request runs again
+func (r *IdempotencyKeyRepositoryImpl) Release(ctx context.Context, tx pgx.Tx, id int64) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE id = $1 AND status = $2`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, id, lib.IdempotencyKeyStatusInProgress)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, id, lib.IdempotencyKeyStatusInProgress)
+	}
+
+	return
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.Outbox) (res model.Outbox, err error)
+	FindDueForUpdate(ctx context.Context, tx pgx.Tx, limit int) (res []model.Outbox, err error)
+	FindByIdForUpdate(ctx context.Context, tx pgx.Tx, id int64) (res model.Outbox, found bool, err error)
+	MarkPublished(ctx context.Context, tx pgx.Tx, id int64) (err error)
+	MarkAttemptFailed(ctx context.Context, tx pgx.Tx, id int64, status, lastError string, nextAttemptAt time.Time) (err error)
+}
+
+type OutboxRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewOutboxRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) OutboxRepository {
+	return &OutboxRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+const outboxColumns = `id, subject, message_id, payload, status, attempts, last_error, next_attempt_at, published_at, created_at`
+
+func scanOutbox(row pgx.Row, res *model.Outbox) error {
+	var payload string
+	err := row.Scan(
+		&res.ID,
+		&res.Subject,
+		&res.MessageID,
+		&payload,
+		&res.Status,
+		&res.Attempts,
+		&res.LastError,
+		&res.NextAttemptAt,
+		&res.PublishedAt,
+		&res.CreatedAt,
+	)
+	res.Payload = []byte(payload)
+	return err
+}
+
+// Create must be called with the transaction of the change which produce the message.
+// Message with the same message id is only stored once, the existing one is returned
+func (r *OutboxRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.Outbox) (res model.Outbox, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `INSERT INTO outbox (
+		subject,
+		message_id,
+		payload,
+		status,
+		next_attempt_at,
+		created_at
+	) VALUES ($1, $2, $3, $4, NOW(), NOW())
+	ON CONFLICT (message_id) DO UPDATE SET message_id = EXCLUDED.message_id
+	RETURNING ` + outboxColumns
+
+	args := []interface{}{
+		req.Subject,
+		req.MessageID,
+		string(req.Payload),
+		lib.OutboxStatusPending,
+	}
+
+	if tx != nil {
+		err = scanOutbox(tx.QueryRow(ctx, query, args...), &res)
+	} else {
+		err = scanOutbox(r.WrapDB.Postgres.QueryRow(ctx, query, args...), &res)
+	}
+
+	return
+}
+
+// FindDueForUpdate lock pending messages which are due, locked message is skipped so relays can run concurrently
+func (r *OutboxRepositoryImpl) FindDueForUpdate(ctx context.Context, tx pgx.Tx, limit int) (res []model.Outbox, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT ` + outboxColumns + ` FROM outbox
+		WHERE status = $1 AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at, id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, lib.OutboxStatusPending, limit)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, lib.OutboxStatusPending, limit)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var outbox model.Outbox
+		err = scanOutbox(rows, &outbox)
+		if err != nil {
+			return
+		}
+		res = append(res, outbox)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// FindByIdForUpdate lock pending message, found is false when it's already published or locked by another relay
+func (r *OutboxRepositoryImpl) FindByIdForUpdate(ctx context.Context, tx pgx.Tx, id int64) (res model.Outbox, found bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT ` + outboxColumns + ` FROM outbox
+		WHERE id = $1 AND status = $2
+		FOR UPDATE SKIP LOCKED`
+
+	if tx != nil {
+		err = scanOutbox(tx.QueryRow(ctx, query, id, lib.OutboxStatusPending), &res)
+	} else {
+		err = scanOutbox(r.WrapDB.Postgres.QueryRow(ctx, query, id, lib.OutboxStatusPending), &res)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, false, nil
+		}
+		return
+	}
+
+	return res, true, nil
+}
+
+func (r *OutboxRepositoryImpl) MarkPublished(ctx context.Context, tx pgx.Tx, id int64) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE outbox SET status = $1, attempts = attempts + 1, last_error = NULL, published_at = NOW() WHERE id = $2`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, lib.OutboxStatusPublished, id)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, lib.OutboxStatusPublished, id)
+	}
+
+	return
+}
+
+func (r *OutboxRepositoryImpl) MarkAttemptFailed(ctx context.Context, tx pgx.Tx, id int64, status, lastError string, nextAttemptAt time.Time) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE outbox SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $4`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, status, lastError, nextAttemptAt, id)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, status, lastError, nextAttemptAt, id)
+	}
+
+	return
+}
@@ -7,6 +7,8 @@ import (
 	"assist-tix/model"
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -14,6 +16,8 @@ import (
 
 type PaymentLogRepository interface {
 	Create(ctx context.Context, tx pgx.Tx, paymentLog model.PaymentLog) (res model.PaymentLog, err error)
+	FindById(ctx context.Context, tx pgx.Tx, id int) (res model.PaymentLog, err error)
+	FindFailed(ctx context.Context, tx pgx.Tx, since time.Time, transactionID string, limit int) (res []model.PaymentLog, err error)
 }
 
 type PaymentLogRepositoryImpl struct {
@@ -33,8 +37,8 @@ func NewPaymentLogRepository(
 func (r *PaymentLogRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, paymentLog model.PaymentLog) (res model.PaymentLog, err error) {
 	res = paymentLog
 	// Create a new payment log in the database
-	query := `INSERT INTO payment_logs ( header, body, response,  error_response, endpoint_path, error_code)
-			 VALUES ($1, $2, $3, $4, $5, $6) returning id`
+	query := `INSERT INTO payment_logs ( header, body, response,  error_response, endpoint_path, error_code, event_transaction_id, payment_reference, decision, replay_of_id, signature_skipped)
+			 VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, NULLIF($8, ''), NULLIF($9, ''), $10, $11) returning id`
 	if tx != nil {
 		err = tx.QueryRow(ctx, query,
 			paymentLog.Header,
@@ -42,7 +46,12 @@ func (r *PaymentLogRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, paymen
 			paymentLog.Response,
 			paymentLog.ErrorResponse,
 			paymentLog.Path,
-			paymentLog.ErrorCode).Scan(&res.ID)
+			paymentLog.ErrorCode,
+			paymentLog.TransactionID,
+			paymentLog.PaymentReference,
+			paymentLog.Decision,
+			paymentLog.ReplayOfID,
+			paymentLog.SignatureSkipped).Scan(&res.ID)
 	} else {
 		err = r.WrapDB.Postgres.QueryRow(ctx, query,
 			paymentLog.Header,
@@ -50,7 +59,12 @@ func (r *PaymentLogRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, paymen
 			paymentLog.Response,
 			paymentLog.ErrorResponse,
 			paymentLog.Path,
-			paymentLog.ErrorCode).Scan(&res.ID)
+			paymentLog.ErrorCode,
+			paymentLog.TransactionID,
+			paymentLog.PaymentReference,
+			paymentLog.Decision,
+			paymentLog.ReplayOfID,
+			paymentLog.SignatureSkipped).Scan(&res.ID)
 	}
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -65,3 +79,113 @@ func (r *PaymentLogRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, paymen
 	// If the insert was successful, return nil
 	return
 }
+
+const paymentLogColumns = `
+	id::text,
+	header,
+	body,
+	response,
+	created_at,
+	COALESCE(error_response, ''),
+	endpoint_path,
+	COALESCE(error_code, ''),
+	COALESCE(event_transaction_id::text, ''),
+	COALESCE(payment_reference, ''),
+	COALESCE(decision, ''),
+	replay_of_id,
+	signature_skipped`
+
+func scanPaymentLog(row pgx.Row, paymentLog *model.PaymentLog, extra ...any) error {
+	return row.Scan(append([]any{
+		&paymentLog.ID,
+		&paymentLog.Header,
+		&paymentLog.Body,
+		&paymentLog.Response,
+		&paymentLog.CreatedAt,
+		&paymentLog.ErrorResponse,
+		&paymentLog.Path,
+		&paymentLog.ErrorCode,
+		&paymentLog.TransactionID,
+		&paymentLog.PaymentReference,
+		&paymentLog.Decision,
+		&paymentLog.ReplayOfID,
+		&paymentLog.SignatureSkipped,
+	}, extra...)...)
+}
+
+func (r *PaymentLogRepositoryImpl) FindById(ctx context.Context, tx pgx.Tx, id int) (res model.PaymentLog, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT ` + paymentLogColumns + ` FROM payment_logs WHERE id = $1`
+
+	if tx != nil {
+		err = scanPaymentLog(tx.QueryRow(ctx, query, id), &res)
+	} else {
+		err = scanPaymentLog(r.WrapDB.Postgres.QueryRow(ctx, query, id), &res)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorPaymentLogNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// FindFailed return callbacks which ended with error since the given time, newest first. Accepted callback whose
+// transaction is still pending is also returned, it was never applied. IsResolved is true once one of its replay succeed
+func (r *PaymentLogRepositoryImpl) FindFailed(ctx context.Context, tx pgx.Tx, since time.Time, transactionID string, limit int) (res []model.PaymentLog, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.PaymentLog, 0)
+
+	args := []any{since, lib.CallbackDecisionAccepted, lib.EventTransactionStatusPending}
+	query := `SELECT ` + paymentLogColumns + `,
+		EXISTS (
+			SELECT 1 FROM payment_logs replay
+			WHERE replay.replay_of_id = payment_logs.id AND (replay.error_code IS NULL OR replay.error_code = '')
+		)
+	FROM payment_logs
+	WHERE created_at >= $1 AND (
+		(error_code IS NOT NULL AND error_code <> '')
+		OR (decision = $2 AND EXISTS (
+			SELECT 1 FROM event_transactions et
+			WHERE et.id = payment_logs.event_transaction_id AND et.transaction_status = $3
+		))
+	)`
+
+	if transactionID != "" {
+		args = append(args, transactionID)
+		query += ` AND event_transaction_id = $` + strconv.Itoa(len(args)) + `::uuid`
+	}
+
+	args = append(args, limit)
+	query += ` ORDER BY created_at DESC LIMIT $` + strconv.Itoa(len(args))
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, args...)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, args...)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var paymentLog model.PaymentLog
+		err = scanPaymentLog(rows, &paymentLog, &paymentLog.IsResolved)
+		if err != nil {
+			return
+		}
+
+		res = append(res, paymentLog)
+	}
+
+	err = rows.Err()
+	return
+}
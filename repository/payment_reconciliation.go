@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/model"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type PaymentReconciliationRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.PaymentReconciliation) (res model.PaymentReconciliation, err error)
+	FindSince(ctx context.Context, tx pgx.Tx, since time.Time, limit int) (res []model.PaymentReconciliation, err error)
+}
+
+type PaymentReconciliationRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewPaymentReconciliationRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) PaymentReconciliationRepository {
+	return &PaymentReconciliationRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+func (r *PaymentReconciliationRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.PaymentReconciliation) (res model.PaymentReconciliation, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	res = req
+
+	query := `INSERT INTO payment_reconciliations (
+		event_transaction_id,
+		order_number,
+		payment_method,
+		local_status,
+		gateway_status,
+		gateway_raw_status,
+		grand_total,
+		paid_amount,
+		result,
+		message,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()) RETURNING id, created_at`
+
+	args := []interface{}{
+		req.TransactionID,
+		req.OrderNumber,
+		req.PaymentMethod,
+		req.LocalStatus,
+		req.GatewayStatus,
+		req.GatewayRawStatus,
+		req.GrandTotal,
+		req.PaidAmount,
+		req.Result,
+		req.Message,
+	}
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, args...).Scan(&res.ID, &res.CreatedAt)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, args...).Scan(&res.ID, &res.CreatedAt)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// FindSince return reported discrepancies from the newest
+func (r *PaymentReconciliationRepositoryImpl) FindSince(ctx context.Context, tx pgx.Tx, since time.Time, limit int) (res []model.PaymentReconciliation, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.PaymentReconciliation, 0)
+
+	query := `SELECT
+		id,
+		event_transaction_id,
+		order_number,
+		payment_method,
+		local_status,
+		gateway_status,
+		gateway_raw_status,
+		grand_total,
+		paid_amount,
+		result,
+		message,
+		created_at
+	FROM payment_reconciliations
+	WHERE created_at >= $1
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, since, limit)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, since, limit)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reconciliation model.PaymentReconciliation
+		err = rows.Scan(
+			&reconciliation.ID,
+			&reconciliation.TransactionID,
+			&reconciliation.OrderNumber,
+			&reconciliation.PaymentMethod,
+			&reconciliation.LocalStatus,
+			&reconciliation.GatewayStatus,
+			&reconciliation.GatewayRawStatus,
+			&reconciliation.GrandTotal,
+			&reconciliation.PaidAmount,
+			&reconciliation.Result,
+			&reconciliation.Message,
+			&reconciliation.CreatedAt,
+		)
+		if err != nil {
+			return
+		}
+
+		res = append(res, reconciliation)
+	}
+
+	err = rows.Err()
+	return
+}
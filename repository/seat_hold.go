@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type SeatHoldRepository interface {
+	Create(ctx context.Context, hold model.SeatHold) (err error)
+	FindById(ctx context.Context, holdID string) (res model.SeatHold, err error)
+	Delete(ctx context.Context, holdID string) (err error)
+}
+
+type SeatHoldRepositoryImpl struct {
+	Client *redis.Client
+	Env    *config.EnvironmentVariable
+}
+
+func NewSeatHoldRepository(
+	client *redis.Client,
+	env *config.EnvironmentVariable,
+) SeatHoldRepository {
+	return &SeatHoldRepositoryImpl{
+		Client: client,
+		Env:    env,
+	}
+}
+
+// Create store hold until its expiry
+func (r *SeatHoldRepositoryImpl) Create(ctx context.Context, hold model.SeatHold) (err error) {
+	value, err := json.Marshal(hold)
+	if err != nil {
+		return
+	}
+
+	err = r.Client.Set(ctx, lib.SeatHoldKeyPrefix+hold.ID, value, time.Until(hold.ExpiresAt)).Err()
+	if err != nil {
+		return fmt.Errorf("could not store seat hold: %w", err)
+	}
+
+	return
+}
+
+// FindById find unexpired hold
+func (r *SeatHoldRepositoryImpl) FindById(ctx context.Context, holdID string) (res model.SeatHold, err error) {
+	value, err := r.Client.Get(ctx, lib.SeatHoldKeyPrefix+holdID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return res, &lib.ErrorSeatHoldNotFound
+		}
+		return res, fmt.Errorf("could not get seat hold: %w", err)
+	}
+
+	err = json.Unmarshal(value, &res)
+	return
+}
+
+func (r *SeatHoldRepositoryImpl) Delete(ctx context.Context, holdID string) (err error) {
+	err = r.Client.Del(ctx, lib.SeatHoldKeyPrefix+holdID).Err()
+	if err != nil {
+		return fmt.Errorf("could not delete seat hold: %w", err)
+	}
+
+	return
+}
@@ -20,6 +20,7 @@ type VenueSectorRepository interface {
 	FindByVenueId(ctx context.Context, tx pgx.Tx, venueId string) (sectors []model.VenueSector, err error)
 	FindById(ctx context.Context, tx pgx.Tx, sectorId string) (venue model.VenueSector, err error)
 	FindVenueSectorById(ctx context.Context, tx pgx.Tx, sectorId string) (venueSector entity.VenueSector, err error)
+	UpdateSeatmapGrid(ctx context.Context, tx pgx.Tx, sectorId string, rows, columns, capacity int) (err error)
 }
 
 type VenueSectorRepositoryImpl struct {
@@ -236,3 +237,32 @@ func (r *VenueSectorRepositoryImpl) FindByVenueId(ctx context.Context, tx pgx.Tx
 
 	return
 }
+
+// UpdateSeatmapGrid set dimensions and capacity of sector seatmap and drop its cached detail
+func (r *VenueSectorRepositoryImpl) UpdateSeatmapGrid(ctx context.Context, tx pgx.Tx, sectorId string, rows, columns, capacity int) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE venue_sectors
+	SET sector_row = $1,
+		sector_column = $2,
+		capacity = $3,
+		has_seatmap = true,
+		updated_at = NOW()
+	WHERE id = $4`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, rows, columns, capacity, sectorId)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, rows, columns, capacity, sectorId)
+	}
+	if err != nil {
+		return
+	}
+
+	if errCache := r.RedisRepository.DeleteState(ctx, lib.VenueSectorKeyPrefix+sectorId); errCache != nil {
+		log.Warn().Err(errCache).Str("sectorId", sectorId).Msg("failed to delete cached venue sector")
+	}
+
+	return
+}
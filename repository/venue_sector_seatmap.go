@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/domain"
+	"assist-tix/model"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type VenueSectorSeatmapRepository interface {
+	FindBySectorId(ctx context.Context, tx pgx.Tx, sectorId string) (seats []model.VenueSectorSeatmap, err error)
+	ReplaceBySectorId(ctx context.Context, tx pgx.Tx, sectorId string, seats []model.VenueSectorSeatmap) (err error)
+	UpsertSeats(ctx context.Context, tx pgx.Tx, sectorId string, seats []model.VenueSectorSeatmap) (err error)
+	FindOverridesByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (seats []model.EventVenueSectorSeatmap, err error)
+	UpsertOverrides(ctx context.Context, tx pgx.Tx, eventId, sectorId string, seats []model.EventVenueSectorSeatmap) (err error)
+	DeleteOverrides(ctx context.Context, tx pgx.Tx, eventId, sectorId string, reqs []domain.SeatmapParam) (err error)
+}
+
+type VenueSectorSeatmapRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewVenueSectorSeatmapRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) VenueSectorSeatmapRepository {
+	return &VenueSectorSeatmapRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+func (r *VenueSectorSeatmapRepositoryImpl) FindBySectorId(ctx context.Context, tx pgx.Tx, sectorId string) (seats []model.VenueSectorSeatmap, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT
+		id,
+		sector_id,
+		seat_row,
+		seat_column,
+		COALESCE(seat_row_label, 0),
+		COALESCE(label, ''),
+		COALESCE(status, ''),
+		attributes
+	FROM venue_sector_seatmap_matrix
+	WHERE sector_id = $1
+	ORDER BY seat_row ASC, seat_column ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, sectorId)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, sectorId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat model.VenueSectorSeatmap
+		err = rows.Scan(
+			&seat.ID,
+			&seat.SectorID,
+			&seat.SeatRow,
+			&seat.SeatColumn,
+			&seat.SeatRowLabel,
+			&seat.Label,
+			&seat.Status,
+			&seat.Attributes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		seats = append(seats, seat)
+	}
+
+	return seats, rows.Err()
+}
+
+// ReplaceBySectorId remove every seat of sector and copy the new grid
+func (r *VenueSectorSeatmapRepositoryImpl) ReplaceBySectorId(ctx context.Context, tx pgx.Tx, sectorId string, seats []model.VenueSectorSeatmap) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM venue_sector_seatmap_matrix WHERE sector_id = $1`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, sectorId)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, sectorId)
+	}
+	if err != nil {
+		return
+	}
+
+	rows := make([][]interface{}, 0, len(seats))
+	for _, seat := range seats {
+		rows = append(rows, []interface{}{sectorId, seat.SeatRow, seat.SeatColumn, seat.SeatRowLabel, seat.Label, seat.Status, seatAttributes(seat.Attributes)})
+	}
+
+	columns := []string{"sector_id", "seat_row", "seat_column", "seat_row_label", "label", "status", "attributes"}
+
+	if tx != nil {
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"venue_sector_seatmap_matrix"}, columns, pgx.CopyFromRows(rows))
+	} else {
+		_, err = r.WrapDB.Postgres.CopyFrom(ctx, pgx.Identifier{"venue_sector_seatmap_matrix"}, columns, pgx.CopyFromRows(rows))
+	}
+
+	return
+}
+
+func (r *VenueSectorSeatmapRepositoryImpl) UpsertSeats(ctx context.Context, tx pgx.Tx, sectorId string, seats []model.VenueSectorSeatmap) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(seats) == 0 {
+		return
+	}
+
+	args := []interface{}{sectorId}
+	var placeholders []string
+	for i, seat := range seats {
+		base := (i * 6) + 1
+		placeholders = append(placeholders, fmt.Sprintf("($1, $%d, $%d, $%d, $%d, $%d, $%d, NOW())",
+			base+1, base+2, base+3, base+4, base+5, base+6))
+
+		args = append(args, seat.SeatRow, seat.SeatColumn, seat.SeatRowLabel, seat.Label, seat.Status, seatAttributes(seat.Attributes))
+	}
+
+	query := fmt.Sprintf(`INSERT INTO venue_sector_seatmap_matrix (
+		sector_id,
+		seat_row,
+		seat_column,
+		seat_row_label,
+		label,
+		status,
+		attributes,
+		created_at
+	) VALUES %s
+	ON CONFLICT (sector_id, seat_row, seat_column) DO UPDATE SET
+		seat_row_label = EXCLUDED.seat_row_label,
+		label = EXCLUDED.label,
+		status = EXCLUDED.status,
+		attributes = EXCLUDED.attributes,
+		updated_at = NOW()`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, args...)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, args...)
+	}
+
+	return
+}
+
+func (r *VenueSectorSeatmapRepositoryImpl) FindOverridesByEventSectorId(ctx context.Context, tx pgx.Tx, eventId, sectorId string) (seats []model.EventVenueSectorSeatmap, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT
+		id,
+		event_id,
+		sector_id,
+		seat_row,
+		seat_column,
+		COALESCE(seat_row_label, 0),
+		COALESCE(label, ''),
+		COALESCE(status, ''),
+		ticket_category_id,
+		attributes,
+		created_at,
+		updated_at
+	FROM event_venue_sector_seatmap_matrix
+	WHERE event_id = $1
+		AND sector_id = $2
+	ORDER BY seat_row ASC, seat_column ASC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, eventId, sectorId)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventId, sectorId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat model.EventVenueSectorSeatmap
+		err = rows.Scan(
+			&seat.ID,
+			&seat.EventID,
+			&seat.SectorID,
+			&seat.SeatRow,
+			&seat.SeatColumn,
+			&seat.SeatRowLabel,
+			&seat.Label,
+			&seat.Status,
+			&seat.TicketCategoryID,
+			&seat.Attributes,
+			&seat.CreatedAt,
+			&seat.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		seats = append(seats, seat)
+	}
+
+	return seats, rows.Err()
+}
+
+func (r *VenueSectorSeatmapRepositoryImpl) UpsertOverrides(ctx context.Context, tx pgx.Tx, eventId, sectorId string, seats []model.EventVenueSectorSeatmap) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(seats) == 0 {
+		return
+	}
+
+	args := []interface{}{eventId, sectorId}
+	var placeholders []string
+	for i, seat := range seats {
+		base := (i * 7) + 2
+		placeholders = append(placeholders, fmt.Sprintf("($1, $2, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NOW())",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7))
+
+		args = append(args, seat.SeatRow, seat.SeatColumn, seat.SeatRowLabel, seat.Label, seat.Status, seat.TicketCategoryID, seat.Attributes)
+	}
+
+	query := fmt.Sprintf(`INSERT INTO event_venue_sector_seatmap_matrix (
+		event_id,
+		sector_id,
+		seat_row,
+		seat_column,
+		seat_row_label,
+		label,
+		status,
+		ticket_category_id,
+		attributes,
+		created_at
+	) VALUES %s
+	ON CONFLICT (event_id, sector_id, seat_row, seat_column) DO UPDATE SET
+		seat_row_label = EXCLUDED.seat_row_label,
+		label = EXCLUDED.label,
+		status = EXCLUDED.status,
+		ticket_category_id = EXCLUDED.ticket_category_id,
+		attributes = EXCLUDED.attributes,
+		updated_at = NOW()`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, args...)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, args...)
+	}
+
+	return
+}
+
+// DeleteOverrides put seats of event back to the venue seatmap
+func (r *VenueSectorSeatmapRepositoryImpl) DeleteOverrides(ctx context.Context, tx pgx.Tx, eventId, sectorId string, reqs []domain.SeatmapParam) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if len(reqs) == 0 {
+		return
+	}
+
+	args := []interface{}{eventId, sectorId}
+	var placeholders []string
+	for i, req := range reqs {
+		base := (i * 2) + 2
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d)", base+1, base+2))
+		args = append(args, req.SeatRow, req.SeatColumn)
+	}
+
+	query := fmt.Sprintf(`DELETE FROM event_venue_sector_seatmap_matrix
+	WHERE event_id = $1
+		AND sector_id = $2
+		AND (seat_row, seat_column) IN (%s)`, strings.Join(placeholders, ","))
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, args...)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, args...)
+	}
+
+	return
+}
+
+// seatAttributes keep venue seat without attribute as empty array, the column is not nullable
+func seatAttributes(attributes []string) []string {
+	if attributes == nil {
+		return []string{}
+	}
+	return attributes
+}
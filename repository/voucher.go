@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type VoucherRepository interface {
+	Create(ctx context.Context, tx pgx.Tx, req model.Voucher) (res model.Voucher, err error)
+	FindByEventId(ctx context.Context, tx pgx.Tx, eventID string) (res []model.Voucher, err error)
+	FindByEventIdAndCode(ctx context.Context, tx pgx.Tx, eventID, code string) (res model.Voucher, err error)
+	FindByEventIdAndCodeForUpdate(ctx context.Context, tx pgx.Tx, eventID, code string) (res model.Voucher, err error)
+	SoftDelete(ctx context.Context, tx pgx.Tx, eventID, voucherID string) (err error)
+	CountUsages(ctx context.Context, tx pgx.Tx, voucherID, email string, garudaIDs []string) (res model.VoucherUsageCount, err error)
+	CreateUsage(ctx context.Context, tx pgx.Tx, req model.VoucherUsage) (err error)
+	DeleteUsageByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (err error)
+}
+
+type VoucherRepositoryImpl struct {
+	WrapDB *database.WrapDB
+	Env    *config.EnvironmentVariable
+}
+
+func NewVoucherRepository(
+	wrapDB *database.WrapDB,
+	env *config.EnvironmentVariable,
+) VoucherRepository {
+	return &VoucherRepositoryImpl{
+		WrapDB: wrapDB,
+		Env:    env,
+	}
+}
+
+const voucherColumns = `id, event_id, event_ticket_category_id, code, discount_type, discount_value, max_discount,
+	usage_limit, usage_limit_per_email, usage_limit_per_garuda_id, is_active, start_at, end_at, created_at, updated_at`
+
+func scanVoucher(row pgx.Row, res *model.Voucher) error {
+	return row.Scan(
+		&res.ID,
+		&res.EventID,
+		&res.TicketCategoryID,
+		&res.Code,
+		&res.DiscountType,
+		&res.DiscountValue,
+		&res.MaxDiscount,
+		&res.UsageLimit,
+		&res.UsageLimitPerEmail,
+		&res.UsageLimitPerGarudaID,
+		&res.IsActive,
+		&res.StartAt,
+		&res.EndAt,
+		&res.CreatedAt,
+		&res.UpdatedAt,
+	)
+}
+
+func (r *VoucherRepositoryImpl) Create(ctx context.Context, tx pgx.Tx, req model.Voucher) (res model.Voucher, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `INSERT INTO vouchers (
+		event_id,
+		event_ticket_category_id,
+		code,
+		discount_type,
+		discount_value,
+		max_discount,
+		usage_limit,
+		usage_limit_per_email,
+		usage_limit_per_garuda_id,
+		is_active,
+		start_at,
+		end_at,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+	RETURNING ` + voucherColumns
+
+	args := []interface{}{
+		req.EventID,
+		req.TicketCategoryID,
+		req.Code,
+		req.DiscountType,
+		req.DiscountValue,
+		req.MaxDiscount,
+		req.UsageLimit,
+		req.UsageLimitPerEmail,
+		req.UsageLimitPerGarudaID,
+		req.IsActive,
+		req.StartAt,
+		req.EndAt,
+	}
+
+	if tx != nil {
+		err = scanVoucher(tx.QueryRow(ctx, query, args...), &res)
+	} else {
+		err = scanVoucher(r.WrapDB.Postgres.QueryRow(ctx, query, args...), &res)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			err = &lib.ErrorVoucherCodeAlreadyExists
+		}
+		return
+	}
+
+	return
+}
+
+func (r *VoucherRepositoryImpl) FindByEventId(ctx context.Context, tx pgx.Tx, eventID string) (res []model.Voucher, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	res = make([]model.Voucher, 0)
+
+	query := `SELECT ` + voucherColumns + ` FROM vouchers
+		WHERE event_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC`
+
+	var rows pgx.Rows
+	if tx != nil {
+		rows, err = tx.Query(ctx, query, eventID)
+	} else {
+		rows, err = r.WrapDB.Postgres.Query(ctx, query, eventID)
+	}
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var voucher model.Voucher
+		err = scanVoucher(rows, &voucher)
+		if err != nil {
+			return
+		}
+		res = append(res, voucher)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// FindByEventIdAndCode find voucher by its code, code is matched case insensitively
+func (r *VoucherRepositoryImpl) FindByEventIdAndCode(ctx context.Context, tx pgx.Tx, eventID, code string) (res model.Voucher, err error) {
+	return r.findByEventIdAndCode(ctx, tx, eventID, code, "")
+}
+
+// FindByEventIdAndCodeForUpdate lock the voucher until tx is done, so its usages are counted and written one order at a time
+func (r *VoucherRepositoryImpl) FindByEventIdAndCodeForUpdate(ctx context.Context, tx pgx.Tx, eventID, code string) (res model.Voucher, err error) {
+	return r.findByEventIdAndCode(ctx, tx, eventID, code, " FOR UPDATE")
+}
+
+func (r *VoucherRepositoryImpl) findByEventIdAndCode(ctx context.Context, tx pgx.Tx, eventID, code, lock string) (res model.Voucher, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	query := `SELECT ` + voucherColumns + ` FROM vouchers
+		WHERE event_id = $1 AND code = UPPER($2) AND deleted_at IS NULL` + lock
+
+	if tx != nil {
+		err = scanVoucher(tx.QueryRow(ctx, query, eventID, code), &res)
+	} else {
+		err = scanVoucher(r.WrapDB.Postgres.QueryRow(ctx, query, eventID, code), &res)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = &lib.ErrorVoucherNotFound
+		}
+		return
+	}
+
+	return
+}
+
+func (r *VoucherRepositoryImpl) SoftDelete(ctx context.Context, tx pgx.Tx, eventID, voucherID string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `UPDATE vouchers SET deleted_at = NOW(), updated_at = NOW() WHERE event_id = $1 AND id = $2 AND deleted_at IS NULL`
+
+	var cmdTag pgconn.CommandTag
+	if tx != nil {
+		cmdTag, err = tx.Exec(ctx, query, eventID, voucherID)
+	} else {
+		cmdTag, err = r.WrapDB.Postgres.Exec(ctx, query, eventID, voucherID)
+	}
+	if err != nil {
+		return
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		err = &lib.ErrorVoucherNotFound
+	}
+
+	return
+}
+
+// CountUsages count usages of voucher in total, by the email and the highest among the garuda ids
+func (r *VoucherRepositoryImpl) CountUsages(ctx context.Context, tx pgx.Tx, voucherID, email string, garudaIDs []string) (res model.VoucherUsageCount, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Read)
+	defer cancel()
+
+	if garudaIDs == nil {
+		garudaIDs = []string{}
+	}
+
+	query := `SELECT
+		COUNT(*)::int,
+		COUNT(*) FILTER (WHERE lower(email) = lower($2))::int,
+		COALESCE((
+			SELECT MAX(usage_count) FROM (
+				SELECT COUNT(*)::int AS usage_count
+				FROM voucher_usages vu, unnest(vu.garuda_ids) AS garuda_id
+				WHERE vu.voucher_id = $1 AND garuda_id = ANY($3::varchar[])
+				GROUP BY garuda_id
+			) garuda_usages
+		), 0)
+	FROM voucher_usages
+	WHERE voucher_id = $1`
+
+	if tx != nil {
+		err = tx.QueryRow(ctx, query, voucherID, email, garudaIDs).Scan(&res.Total, &res.Email, &res.GarudaID)
+	} else {
+		err = r.WrapDB.Postgres.QueryRow(ctx, query, voucherID, email, garudaIDs).Scan(&res.Total, &res.Email, &res.GarudaID)
+	}
+
+	return
+}
+
+func (r *VoucherRepositoryImpl) CreateUsage(ctx context.Context, tx pgx.Tx, req model.VoucherUsage) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	if req.GarudaIDs == nil {
+		req.GarudaIDs = []string{}
+	}
+
+	query := `INSERT INTO voucher_usages (
+		voucher_id,
+		event_transaction_id,
+		email,
+		garuda_ids,
+		discount_amount,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, NOW())`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, req.VoucherID, req.TransactionID, req.Email, req.GarudaIDs, req.DiscountAmount)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, req.VoucherID, req.TransactionID, req.Email, req.GarudaIDs, req.DiscountAmount)
+	}
+
+	return
+}
+
+// DeleteUsageByTransactionId give back the usage of expired transaction
+func (r *VoucherRepositoryImpl) DeleteUsageByTransactionId(ctx context.Context, tx pgx.Tx, transactionID string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Env.Database.Timeout.Write)
+	defer cancel()
+
+	query := `DELETE FROM voucher_usages WHERE event_transaction_id = $1`
+
+	if tx != nil {
+		_, err = tx.Exec(ctx, query, transactionID)
+	} else {
+		_, err = r.WrapDB.Postgres.Exec(ctx, query, transactionID)
+	}
+
+	return
+}
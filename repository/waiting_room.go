@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"assist-tix/config"
+	"assist-tix/lib"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type WaitingRoomRepository interface {
+	Join(ctx context.Context, eventID string) (number int64, err error)
+	Advance(ctx context.Context, eventID string, admissionPerMinute int) (admitted int64, err error)
+	UseAdmission(ctx context.Context, eventID string, number int64, ttl time.Duration) (count int64, err error)
+}
+
+type WaitingRoomRepositoryImpl struct {
+	Client *redis.Client
+	Env    *config.EnvironmentVariable
+}
+
+func NewWaitingRoomRepository(
+	client *redis.Client,
+	env *config.EnvironmentVariable,
+) WaitingRoomRepository {
+	return &WaitingRoomRepositoryImpl{
+		Client: client,
+		Env:    env,
+	}
+}
+
+// queue of event is kept this long after its last activity
+const waitingRoomKeyTTL = 24 * time.Hour
+
+func waitingRoomKey(eventID, field string) string {
+	return lib.WaitingRoomKeyPrefix + eventID + ":" + field
+}
+
+// Join give the next queue number of event
+func (r *WaitingRoomRepositoryImpl) Join(ctx context.Context, eventID string) (number int64, err error) {
+	key := waitingRoomKey(eventID, "last")
+
+	pipe := r.Client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, waitingRoomKeyTTL)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not join waiting room: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// advanceScript admit queue numbers by the time passed since the last admission.
+// When everyone is admitted the clock restarts, so idle time isn't saved up as a burst
+var advanceScript = redis.NewScript(`
+local last = tonumber(redis.call('GET', KEYS[1]) or '0')
+local admitted = tonumber(redis.call('GET', KEYS[2]) or '0')
+local now = tonumber(ARGV[1])
+local perMinute = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local admittedAt = tonumber(redis.call('GET', KEYS[3]) or ARGV[1])
+
+local batch = math.floor((now - admittedAt) * perMinute / 60000)
+admitted = math.min(admitted + batch, last)
+if admitted >= last then
+	admittedAt = now
+else
+	admittedAt = admittedAt + math.floor(batch * 60000 / perMinute)
+end
+
+redis.call('SET', KEYS[2], admitted, 'EX', ttl)
+redis.call('SET', KEYS[3], admittedAt, 'EX', ttl)
+return admitted
+`)
+
+// Advance admit queue numbers at admissionPerMinute and return the highest admitted number
+func (r *WaitingRoomRepositoryImpl) Advance(ctx context.Context, eventID string, admissionPerMinute int) (admitted int64, err error) {
+	keys := []string{
+		waitingRoomKey(eventID, "last"),
+		waitingRoomKey(eventID, "admitted"),
+		waitingRoomKey(eventID, "admitted_at"),
+	}
+
+	admitted, err = advanceScript.Run(ctx, r.Client, keys, time.Now().UnixMilli(), admissionPerMinute, int(waitingRoomKeyTTL.Seconds())).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("could not advance waiting room: %w", err)
+	}
+
+	return admitted, nil
+}
+
+// UseAdmission count order attempt made with admission of queue number
+func (r *WaitingRoomRepositoryImpl) UseAdmission(ctx context.Context, eventID string, number int64, ttl time.Duration) (count int64, err error) {
+	key := waitingRoomKey(eventID, fmt.Sprintf("admission:%d", number))
+
+	pipe := r.Client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not use admission: %w", err)
+	}
+
+	return incr.Val(), nil
+}
@@ -14,6 +14,7 @@ import (
 
 	sentrygin "github.com/getsentry/sentry-go/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -26,6 +27,11 @@ type Handler struct {
 	EventHandler               handler.EventHandler
 	EventTicketCategoryHandler handler.EventTicketCategoryHandler
 	EventTransaction           handler.EventTransactionHandler
+	Refund                     handler.RefundHandler
+	Voucher                    handler.VoucherHandler
+	WaitingRoom                handler.WaitingRoomHandler
+	SeatHold                   handler.SeatHoldHandler
+	Seatmap                    handler.SeatmapHandler
 	Middleware                 middleware.Middleware
 }
 
@@ -35,6 +41,12 @@ func NewRouter(handler Handler) *gin.Engine {
 	}
 
 	router := gin.Default()
+	// client ip is used by purchase limit and rate limit, so forwarded headers are only read from known proxies
+	err := router.SetTrustedProxies(handler.Env.Api.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Strs("trustedProxies", handler.Env.Api.TrustedProxies).Msg("invalid trusted proxies")
+	}
+	router.TrustedPlatform = handler.Env.Api.TrustedPlatform
 	if handler.Env.Api.CorsEnable {
 		router.Use(handler.Middleware.CORSMiddleware())
 	}
@@ -13,6 +13,7 @@ func RouterApiV1(mode string, h Handler, rg *gin.RouterGroup) {
 	}
 	EventRouter(h, r)
 	ExternalRouter(h, r)
+	AdminRouter(h, r)
 }
 
 func OrganizerRouter(h Handler, rg *gin.RouterGroup) {
@@ -54,8 +55,15 @@ func EventRouter(h Handler, rg *gin.RouterGroup) {
 	// Validate book email
 	r.GET("/:eventId/email-books/:email", h.EventTransaction.IsEmailAlreadyBook)
 	r.GET("/:eventId/payment-methods", h.EventTransaction.GetAvailablePaymentMethods)
+	r.POST("/:eventId/vouchers/preview", h.Voucher.Preview)
+	r.POST("/:eventId/waiting-room", h.WaitingRoom.Join)
+	r.GET("/:eventId/waiting-room/status", h.WaitingRoom.Status)
+	r.POST("/:eventId/ticket-categories/:ticketCategoryId/seat-holds", h.Middleware.OriginMiddleware(), h.Middleware.WaitingRoom(), h.SeatHold.Create)
+	r.DELETE("/:eventId/seat-holds/:holdId", h.SeatHold.Release)
 
 	r.GET("/transactions/:transactionId", h.Middleware.TokenAuthMiddleware(), h.EventTransaction.GetTransactionDetails)
+	r.GET("/transactions/:transactionId/tickets/:ticketNumber/seatmap/image", h.Middleware.TokenAuthMiddleware(), h.Seatmap.RenderTicketSeatmap)
+	r.GET("/transactions/:transactionId/tickets/:ticketNumber/barcode", h.Middleware.TokenAuthMiddleware(), h.EventTransaction.GetTicketBarcode)
 
 	EventTicketCategories(h, r)
 }
@@ -68,16 +76,20 @@ func EventTicketCategories(h Handler, rg *gin.RouterGroup) {
 		rg.GET("/:eventId/ticket-categories/:ticketCategoryId", h.EventTicketCategoryHandler.GetById)
 		rg.GET("/:eventId/ticket-categories/:ticketCategoryId/seatmap", h.EventTicketCategoryHandler.GetSeatmap)
 	}
+	rg.GET("/:eventId/ticket-categories/:ticketCategoryId/seatmap/stream", h.EventTicketCategoryHandler.WatchSeatmap)
+	rg.GET("/:eventId/ticket-categories/:ticketCategoryId/seatmap/image", h.Seatmap.RenderEventSeatmap)
 	// rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order", h.EventTransaction.CreateTransaction)
 	if h.Env.Transaction.UseV2 {
-		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order", h.Middleware.OriginMiddleware(), h.EventTransaction.CreateTransactionV2)
+		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order", h.Middleware.OriginMiddleware(), h.Middleware.Idempotency(), h.Middleware.WaitingRoom(), h.EventTransaction.CreateTransactionV2)
+		// cart order is processed by async order, so it's only served with v2
+		rg.POST("/:eventId/orders", h.Middleware.OriginMiddleware(), h.Middleware.Idempotency(), h.Middleware.WaitingRoom(), h.EventTransaction.CreateCartTransaction)
 	} else {
-		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order", h.Middleware.OriginMiddleware(), h.EventTransaction.CreateTransaction)
+		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order", h.Middleware.OriginMiddleware(), h.Middleware.Idempotency(), h.Middleware.WaitingRoom(), h.EventTransaction.CreateTransaction)
 	}
 	if h.Env.App.Debug {
 		rg.POST("/:eventId/ticket-categories", h.EventTicketCategoryHandler.Create)
-		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order/v2", h.EventTransaction.CreateTransactionV2)
-		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order/v1", h.EventTransaction.CreateTransaction)
+		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order/v2", h.Middleware.Idempotency(), h.Middleware.WaitingRoom(), h.EventTransaction.CreateTransactionV2)
+		rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order/v1", h.Middleware.Idempotency(), h.Middleware.WaitingRoom(), h.EventTransaction.CreateTransaction)
 	}
 
 	// rg.POST("/:eventId/ticket-categories/:ticketCategoryId/order/paylabs-vasnap", h.EventTransaction.PaylabsVASnap)
@@ -94,3 +106,36 @@ func ExternalRouter(h Handler, rg *gin.RouterGroup) {
 		r.POST("/paylabs/qris/callback", h.Middleware.PayloadPasser(), h.EventTransaction.CallbackQRISPaylabs)
 	}
 }
+
+func AdminRouter(h Handler, rg *gin.RouterGroup) {
+	r := rg.Group("/admin", h.Middleware.IsAuthorized())
+
+	r.GET("/transactions/:transactionId/status-histories", h.EventTransaction.GetStatusHistories)
+	r.POST("/transactions/orders/:orderNumber/reconcile", h.EventTransaction.ReconcileTransaction)
+	r.GET("/payment-reconciliations", h.EventTransaction.GetPaymentReconciliations)
+	r.GET("/payment-logs/failed", h.EventTransaction.GetFailedPaymentCallbacks)
+	r.POST("/payment-logs/:paymentLogId/replay", h.EventTransaction.ReplayPaymentCallback)
+
+	r.PUT("/events/:eventId/status", h.EventHandler.UpdateStatus)
+	r.POST("/transactions/:transactionId/refunds", h.Refund.RequestRefund)
+	r.GET("/transactions/:transactionId/refunds", h.Refund.GetRefunds)
+	r.POST("/refunds/:refundId/complete", h.Refund.CompleteManualRefund)
+	r.POST("/refunds/:refundId/resolve", h.Refund.ResolveRefund)
+
+	r.POST("/events/:eventId/vouchers", h.Voucher.Create)
+	r.GET("/events/:eventId/vouchers", h.Voucher.GetByEventId)
+	r.DELETE("/events/:eventId/vouchers/:voucherId", h.Voucher.Delete)
+
+	r.POST("/events/:eventId/ticket-categories/:ticketCategoryId/price-tiers", h.EventTicketCategoryHandler.CreatePriceTier)
+	r.GET("/events/:eventId/ticket-categories/:ticketCategoryId/price-tiers", h.EventTicketCategoryHandler.GetPriceTiers)
+	r.DELETE("/events/:eventId/ticket-categories/:ticketCategoryId/price-tiers/:priceTierId", h.EventTicketCategoryHandler.DeletePriceTier)
+
+	r.GET("/venues/:venueId/sectors/:sectorId/seatmap", h.Seatmap.GetSectorSeatmap)
+	r.GET("/venues/:venueId/sectors/:sectorId/seatmap/image", h.Seatmap.RenderSectorSeatmap)
+	r.PUT("/venues/:venueId/sectors/:sectorId/seatmap", h.Seatmap.ReplaceSectorSeatmap)
+	r.POST("/venues/:venueId/sectors/:sectorId/seatmap/import", h.Seatmap.ImportSectorSeatmap)
+	r.PATCH("/venues/:venueId/sectors/:sectorId/seatmap/seats", h.Seatmap.UpdateSectorSeats)
+	r.GET("/events/:eventId/sectors/:sectorId/seatmap-overrides", h.Seatmap.GetEventSeatmapOverrides)
+	r.PUT("/events/:eventId/sectors/:sectorId/seatmap-overrides", h.Seatmap.UpsertEventSeatmapOverrides)
+	r.DELETE("/events/:eventId/sectors/:sectorId/seatmap-overrides", h.Seatmap.DeleteEventSeatmapOverrides)
+}
@@ -23,6 +23,7 @@ type EventService interface {
 	Delete(ctx context.Context, eventId string) (err error)
 	FindByGarudaID(ctx context.Context, eventID, garudaID string) (dto.VerifyGarudaIDResponse, error)
 	GetActiveSettingByEventId(ctx context.Context, eventId string) (res dto.EventSettingsResponse, err error)
+	UpdateStatus(ctx context.Context, eventId, status string) (err error)
 }
 
 type EventServiceImpl struct {
@@ -251,6 +252,20 @@ func (s *EventServiceImpl) Delete(ctx context.Context, eventId string) (err erro
 	return
 }
 
+// UpdateStatus mark event as canceled or postponed, empty status put event back to follow its event time
+func (s *EventServiceImpl) UpdateStatus(ctx context.Context, eventId, status string) (err error) {
+	log.Info().Str("eventId", eventId).Str("status", status).Msg("Update event status")
+
+	err = s.EventRepo.UpdateStatus(ctx, nil, eventId, status)
+	if err != nil {
+		return
+	}
+
+	log.Info().Msg("Success update event status")
+
+	return
+}
+
 func (s *EventServiceImpl) GetAllEventPaginated(ctx context.Context, filter dto.FilterEventRequest, pagination dto.PaginationParam) (res dto.PaginatedEvents, err error) {
 	log.Info().Str("Search", filter.Search).Str("Status", filter.Status).Int("TargetPage", int(pagination.TargetPage)).Msg("Get paginated events")
 
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GetTicketBarcode render code of the ticket as png, invalidated ticket can't be scanned so it has no barcode
+func (s *EventTransactionServiceImpl) GetTicketBarcode(ctx context.Context, transactionID, ticketNumber, format string) (res dto.TicketBarcodeResponse, err error) {
+	if format == "" {
+		format = helper.TicketBarcodeQR
+	}
+
+	ticket, err := s.EventTicketRepo.FindByTransactionIdAndTicketNumber(ctx, nil, transactionID, ticketNumber)
+	if err != nil {
+		log.Warn().Err(err).Str("transactionId", transactionID).Str("ticketNumber", ticketNumber).Msg("failed to find ticket")
+		return
+	}
+	if ticket.InvalidatedAt.Valid {
+		return res, &lib.ErrorTicketInvalidated
+	}
+
+	res.Body, err = helper.RenderTicketBarcode(ticket.TicketCode, format, s.Env.TicketBarcode.ModuleSize)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Str("format", format).Msg("failed to render ticket barcode")
+		return
+	}
+	res.ContentType = "image/png"
+
+	return
+}
@@ -5,10 +5,13 @@ import (
 	"assist-tix/database"
 	"assist-tix/dto"
 	"assist-tix/helper"
+	internalDomain "assist-tix/internal/domain"
 	"assist-tix/lib"
 	"assist-tix/model"
 	"assist-tix/repository"
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -18,8 +21,12 @@ type EventTicketCategoryService interface {
 	GetVenueTicketsByEventId(ctx context.Context, eventId string) (res dto.VenueEventTicketCategoryResponse, err error)
 	GetByEventId(ctx context.Context, eventId string) (res []dto.DetailEventTicketCategoryResponse, err error)
 	GetById(ctx context.Context, eventId string, ticketCategoryId string) (res dto.DetailEventTicketCategoryResponse, err error)
-	GetSeatmapByTicketCategoryId(ctx context.Context, eventId, ticketCategoryId string) (res dto.EventSectorSeatmapResponse, err error)
+	GetSeatmapByTicketCategoryId(ctx context.Context, eventId, ticketCategoryId string, filter dto.SeatmapFilterQuery) (res dto.EventSectorSeatmapResponse, err error)
+	WatchSeatmap(ctx context.Context, eventId, ticketCategoryId string, resyncToken uint64) (changes <-chan internalDomain.SeatmapChangeMessage, resync bool, err error)
 	Delete(ctx context.Context, eventId, ticketCategoryId string) (err error)
+	CreatePriceTier(ctx context.Context, eventId, ticketCategoryId string, req dto.CreateTicketCategoryPriceTierRequest) (res dto.TicketCategoryPriceTierResponse, err error)
+	GetPriceTiers(ctx context.Context, eventId, ticketCategoryId string) (res []dto.TicketCategoryPriceTierResponse, err error)
+	DeletePriceTier(ctx context.Context, eventId, ticketCategoryId, priceTierId string) (err error)
 }
 
 type EventTicketCategoryServiceImpl struct {
@@ -30,8 +37,10 @@ type EventTicketCategoryServiceImpl struct {
 	EventRepository               repository.EventRepository
 	EventTicketCategoryRepository repository.EventTicketCategoryRepository
 	EventSeatmapBookRepository    repository.EventSeatmapBookRepository
+	PriceTierRepository           repository.EventTicketCategoryPriceTierRepository
 
 	GCSStorageRepo repository.GCSStorageRepository
+	SeatmapWatcher internalDomain.SeatmapWatcher
 }
 
 func NewEventTicketCategoryService(
@@ -43,6 +52,8 @@ func NewEventTicketCategoryService(
 	eventTicketCategoryRepository repository.EventTicketCategoryRepository,
 	eventSeatmapBookRepository repository.EventSeatmapBookRepository,
 	gcsStorageRepo repository.GCSStorageRepository,
+	priceTierRepository repository.EventTicketCategoryPriceTierRepository,
+	seatmapWatcher internalDomain.SeatmapWatcher,
 ) EventTicketCategoryService {
 	return &EventTicketCategoryServiceImpl{
 		DB:                            db,
@@ -53,6 +64,8 @@ func NewEventTicketCategoryService(
 		EventTicketCategoryRepository: eventTicketCategoryRepository,
 		EventSeatmapBookRepository:    eventSeatmapBookRepository,
 		GCSStorageRepo:                gcsStorageRepo,
+		PriceTierRepository:           priceTierRepository,
+		SeatmapWatcher:                seatmapWatcher,
 	}
 }
 
@@ -130,9 +143,15 @@ func (s *EventTicketCategoryServiceImpl) GetByEventId(ctx context.Context, event
 		return
 	}
 
+	priceTiers, err := s.PriceTierRepository.FindByEventId(ctx, nil, eventId)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
 	res = make([]dto.DetailEventTicketCategoryResponse, 0)
 	for _, val := range ticketCategories {
-		res = append(res, lib.MapEventTicketCategoryModelToDetailEventTicketCategoryResponse(val))
+		res = append(res, mapTicketCategoryPriceTiers(lib.MapEventTicketCategoryModelToDetailEventTicketCategoryResponse(val), priceTiers[val.ID], now))
 	}
 
 	log.Info().Int("count", len(res)).Msg("success get ticket categories by event id")
@@ -170,10 +189,18 @@ func (s *EventTicketCategoryServiceImpl) GetVenueTicketsByEventId(ctx context.Co
 		return
 	}
 
+	priceTiers, err := s.PriceTierRepository.FindByEventId(ctx, nil, eventId)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
 	tickets := make([]dto.DetailEventPublicTicketCategoryResponse, 0)
 	for _, val := range ticketCategories {
 		if val.TotalPublicStock != 0 || val.PublicStock != 0 {
-			tickets = append(tickets, lib.MapEntityTicketCategoryToDetailEventPublicTicketCategoryResponse(val))
+			ticket := lib.MapEntityTicketCategoryToDetailEventPublicTicketCategoryResponse(val)
+			ticket.Price, ticket.PriceTier, ticket.NextPriceChangeAt = currentPriceTier(priceTiers[val.ID], ticket.Price, now)
+			tickets = append(tickets, ticket)
 		}
 	}
 
@@ -196,7 +223,12 @@ func (s *EventTicketCategoryServiceImpl) GetById(ctx context.Context, eventId st
 		return
 	}
 
-	res = lib.MapEventTicketCategoryModelToDetailEventTicketCategoryResponse(ticketCategory)
+	priceTiers, err := s.PriceTierRepository.FindByTicketCategoryId(ctx, nil, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	res = mapTicketCategoryPriceTiers(lib.MapEventTicketCategoryModelToDetailEventTicketCategoryResponse(ticketCategory), priceTiers, time.Now())
 	log.Info().Msg("success get ticket category by id")
 	return
 }
@@ -219,7 +251,7 @@ func (s *EventTicketCategoryServiceImpl) Delete(ctx context.Context, eventId, ti
 	return
 }
 
-func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx context.Context, eventId, ticketCategoryId string) (res dto.EventSectorSeatmapResponse, err error) {
+func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx context.Context, eventId, ticketCategoryId string, filter dto.SeatmapFilterQuery) (res dto.EventSectorSeatmapResponse, err error) {
 	log.Info().Msg("get seatmap by ticket category id")
 
 	tx, err := s.DB.Postgres.Begin(ctx)
@@ -246,11 +278,21 @@ func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx contex
 		return
 	}
 
+	// token is taken before reading seats, changes during the read are streamed again which is harmless
+	var resyncToken string
+	lastSequence, errSequence := s.SeatmapWatcher.LastSequence(ctx)
+	if errSequence != nil {
+		log.Warn().Err(errSequence).Msg("failed to find seatmap resync token")
+	} else {
+		resyncToken = strconv.FormatUint(lastSequence, 10)
+	}
+
 	log.Info().Str("eventId", eventId).Str("sectorId", eventTickets.VenueSectorId).Msg("find seatmap by event sector id")
 	seatmapRes, err := s.EventTicketCategoryRepository.FindSeatmapByEventSectorId(ctx, tx, eventId, eventTickets.VenueSectorId)
 	if err != nil {
 		return
 	}
+	seatmapRes = filterSeatsByAttributes(seatmapRes, filter.Attributes, filter.ExcludeAttributes)
 
 	log.Info().Str("eventId", eventId).Str("sectorId", eventTickets.VenueSectorId).Msg("find seatmap book by event sector id")
 	eventSeatmapBooks, err := s.EventSeatmapBookRepository.FindSeatBooksByEventSectorId(ctx, tx, eventId, eventTickets.VenueSectorId)
@@ -258,24 +300,56 @@ func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx contex
 		return
 	}
 
+	log.Info().Str("eventId", eventId).Msg("find ticket categories of sector")
+	ticketCategories, err := s.EventTicketCategoryRepository.FindByEventId(ctx, tx, eventId)
+	if err != nil {
+		return
+	}
+
+	priceTiers, err := s.PriceTierRepository.FindByEventId(ctx, tx, eventId)
+	if err != nil {
+		return
+	}
+
+	res = dto.EventSectorSeatmapResponse{
+		ID:               sector.ID,
+		Name:             sector.Name,
+		Color:            sector.SectorColor,
+		AreaCode:         sector.AreaCode,
+		TicketCategories: make([]dto.SeatmapTicketCategoryResponse, 0),
+	}
+
+	now := time.Now()
+	prices := make(map[string]int)
+	for _, val := range ticketCategories {
+		if val.VenueSectorId != sector.ID {
+			continue
+		}
+
+		price, _, _ := currentPriceTier(priceTiers[val.ID], val.Price, now)
+		prices[val.ID] = price
+		res.TicketCategories = append(res.TicketCategories, dto.SeatmapTicketCategoryResponse{
+			ID:       val.ID,
+			Name:     val.Name,
+			Code:     val.Code,
+			Price:    price,
+			Entrance: val.Entrance,
+		})
+	}
+
 	log.Info().Msg("mapping seatmap row and column sector")
 	var (
-		currentRow   int = -1
-		currentSeats []dto.SectorSeatmapResponse
+		currentRow      int = -1
+		currentRowLabel int
+		currentSeats    []dto.SectorSeatmapResponse
 
 		seatmap = make([]dto.SectorSeatmapRowResponse, 0)
 	)
 
-	res = dto.EventSectorSeatmapResponse{
-		ID:       "192309123",
-		Name:     "COK",
-		Color:    "asd",
-		AreaCode: "DAOSHDLJS D",
-	}
-
 	for i, val := range seatmapRes {
 		if currentRow == -1 {
 			currentRow = val.SeatRow
+			currentRowLabel = val.SeatRowLabel
 		}
 
 		_, ok := eventSeatmapBooks[helper.ConvertRowColumnKey(val.SeatRow, val.SeatColumn)]
@@ -283,28 +357,39 @@ func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx contex
 			val.Status = lib.SeatmapStatusUnavailable
 		}
 
+		// seat without price zone is sold by the requested ticket category as well
+		if val.TicketCategoryID == "" {
+			val.TicketCategoryID = eventTickets.ID
+		}
+
 		seat := dto.SectorSeatmapResponse{
-			Column: val.SeatColumn,
-			Label:  val.Label,
-			Status: val.Status,
+			Column:           val.SeatColumn,
+			Label:            val.Label,
+			Status:           val.Status,
+			TicketCategoryID: val.TicketCategoryID,
+			Price:            prices[val.TicketCategoryID],
+			Attributes:       val.Attributes,
 		}
 
 		if val.SeatRow != currentRow {
 			seatmap = append(seatmap, dto.SectorSeatmapRowResponse{
-				Row:   currentRow,
-				Seats: currentSeats,
+				Row:      currentRow,
+				RowLabel: currentRowLabel,
+				Seats:    currentSeats,
 			})
 
 			currentSeats = nil
 			currentRow = val.SeatRow
+			currentRowLabel = val.SeatRowLabel
 		}
 
 		currentSeats = append(currentSeats, seat)
 
 		if i == len(seatmapRes)-1 {
 			seatmap = append(seatmap, dto.SectorSeatmapRowResponse{
-				Row:   currentRow,
-				Seats: currentSeats,
+				Row:      currentRow,
+				RowLabel: currentRowLabel,
+				Seats:    currentSeats,
 			})
 		}
 	}
@@ -315,8 +400,112 @@ func (s *EventTicketCategoryServiceImpl) GetSeatmapByTicketCategoryId(ctx contex
 	}
 
 	res.Seatmap = seatmap
+	res.ResyncToken = resyncToken
 
 	log.Info().Msg("success get seatmap by ticket category id")
 
 	return
 }
+
+// WatchSeatmap stream seat status changes of ticket category sector after resync token, zero token only streams new changes
+func (s *EventTicketCategoryServiceImpl) WatchSeatmap(ctx context.Context, eventId, ticketCategoryId string, resyncToken uint64) (changes <-chan internalDomain.SeatmapChangeMessage, resync bool, err error) {
+	eventTickets, err := s.EventTicketCategoryRepository.FindByIdAndEventId(ctx, nil, eventId, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	sector, err := s.VenueSectorRepository.FindById(ctx, nil, eventTickets.VenueSectorId)
+	if err != nil {
+		return
+	}
+
+	if !sector.HasSeatmap {
+		err = &lib.ErrorVenueSectorDoesntHaveSeatmap
+		return
+	}
+
+	changes, resync, err = s.SeatmapWatcher.Watch(ctx, eventId, sector.ID, resyncToken)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventId).Str("sectorId", sector.ID).Msg("failed to watch seatmap changes")
+		return nil, false, &lib.ErrorSeatmapStreamUnavailable
+	}
+
+	log.Info().Str("eventId", eventId).Str("sectorId", sector.ID).Uint64("resyncToken", resyncToken).Bool("resync", resync).Msg("watch seatmap changes")
+	return
+}
+
+func (s *EventTicketCategoryServiceImpl) CreatePriceTier(ctx context.Context, eventId, ticketCategoryId string, req dto.CreateTicketCategoryPriceTierRequest) (res dto.TicketCategoryPriceTierResponse, err error) {
+	log.Info().Str("eventId", eventId).Str("ticketCategoryId", ticketCategoryId).Str("name", req.Name).Msg("create price tier")
+	_, err = s.EventTicketCategoryRepository.FindByIdAndEventId(ctx, nil, eventId, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	if !req.EndAt.After(req.StartAt) {
+		return res, &lib.ErrorPriceTierInvalid
+	}
+
+	priceTier := model.EventTicketCategoryPriceTier{
+		TicketCategoryID: ticketCategoryId,
+		Name:             req.Name,
+		Price:            req.Price,
+		StartAt:          req.StartAt,
+		EndAt:            req.EndAt,
+	}
+	if req.StockLimit != nil {
+		priceTier.StockLimit = helper.ToSQLInt32(int32(*req.StockLimit))
+	}
+
+	priceTier, err = s.PriceTierRepository.Create(ctx, nil, priceTier)
+	if err != nil {
+		return
+	}
+
+	log.Info().Str("priceTierId", priceTier.ID).Msg("success create price tier")
+	return toPriceTierResponse(priceTier), nil
+}
+
+func (s *EventTicketCategoryServiceImpl) GetPriceTiers(ctx context.Context, eventId, ticketCategoryId string) (res []dto.TicketCategoryPriceTierResponse, err error) {
+	_, err = s.EventTicketCategoryRepository.FindByIdAndEventId(ctx, nil, eventId, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	priceTiers, err := s.PriceTierRepository.FindByTicketCategoryId(ctx, nil, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	res = make([]dto.TicketCategoryPriceTierResponse, 0, len(priceTiers))
+	for _, val := range priceTiers {
+		res = append(res, toPriceTierResponse(val))
+	}
+
+	return
+}
+
+// DeletePriceTier stop the tier from being used, transactions which already bought it keep their price
+func (s *EventTicketCategoryServiceImpl) DeletePriceTier(ctx context.Context, eventId, ticketCategoryId, priceTierId string) (err error) {
+	_, err = s.EventTicketCategoryRepository.FindByIdAndEventId(ctx, nil, eventId, ticketCategoryId)
+	if err != nil {
+		return
+	}
+
+	err = s.PriceTierRepository.SoftDelete(ctx, nil, ticketCategoryId, priceTierId)
+	if err != nil {
+		return
+	}
+
+	log.Info().Str("priceTierId", priceTierId).Msg("success delete price tier")
+	return
+}
+
+func mapTicketCategoryPriceTiers(res dto.DetailEventTicketCategoryResponse, priceTiers []model.EventTicketCategoryPriceTier, now time.Time) dto.DetailEventTicketCategoryResponse {
+	res.Price, res.PriceTier, res.NextPriceChangeAt = currentPriceTier(priceTiers, res.Price, now)
+	res.PriceTiers = make([]dto.TicketCategoryPriceTierResponse, 0, len(priceTiers))
+	for _, val := range priceTiers {
+		res.PriceTiers = append(res.PriceTiers, toPriceTierResponse(val))
+	}
+
+	return res
+}
@@ -7,6 +7,10 @@ import (
 	"assist-tix/dto"
 	"assist-tix/entity"
 	"assist-tix/helper"
+	internalDomain "assist-tix/internal/domain"
+	"assist-tix/internal/domain/async_callback"
+	"assist-tix/internal/domain/async_order"
+	"assist-tix/internal/domain/payment"
 	"assist-tix/internal/job"
 	"assist-tix/internal/usecase"
 	"assist-tix/lib"
@@ -14,12 +18,10 @@ import (
 	"assist-tix/repository"
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"net/http"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,8 +32,6 @@ import (
 
 type EventTransactionService interface {
 	CreateEventTransaction(ctx *gin.Context, eventId, ticketCategoryId string, req dto.CreateEventTransaction) (res dto.EventTransactionResponse, err error)
-	paylabsVASnap(ctx *gin.Context, transaction model.EventTransaction, eventName string) (vaNo string, err error)
-	paylabsQris(ctx *gin.Context, transaction model.EventTransaction, productName string) (barcode string, err error)
 	ValidateEmailIsAlreadyBook(ctx *gin.Context, eventId, email string) (err error)
 	GetAvailablePaymentMethods(ctx *gin.Context, eventId string) (res []dto.EventGrouppedPaymentMethodsResponse, err error)
 	CallbackVASnap(ctx *gin.Context, req dto.SnapCallbackPaymentRequest) (res dto.CallbackSnapResponse, err error)
@@ -40,6 +40,15 @@ type EventTransactionService interface {
 	CallbackQRISPaylabsV2(ctx *gin.Context, req dto.QRISCallbackRequest) (res dto.QRISCallbackResponse, err error)
 	FindById(ctx context.Context, transactionID string) (res dto.OrderDetails, err error)
 	CreateEventTransactionV2(ctx *gin.Context, eventId, ticketCategoryId string, req dto.CreateEventTransaction) (res dto.EventTransactionResponse, err error)
+	CreateEventTransactionCart(ctx *gin.Context, eventId string, req dto.CreateEventTransactionCart) (res dto.EventTransactionCartResponse, err error)
+	ExpireTransaction(ctx context.Context, transactionID string) (err error)
+	FindStatusHistories(ctx context.Context, transactionID string) (res []dto.EventTransactionStatusHistoryResponse, err error)
+	ReconcileTransactions(ctx context.Context) (res dto.PaymentReconciliationReport, err error)
+	ReconcileTransaction(ctx context.Context, orderNumber string) (res dto.PaymentReconciliationResponse, err error)
+	FindPaymentReconciliations(ctx context.Context, since time.Time, limit int) (res []dto.PaymentReconciliationResponse, err error)
+	ProcessAsyncOrder(ctx context.Context, order async_order.AsyncOrder) (err error)
+	ProcessAsyncCallback(ctx context.Context, callback async_callback.AsyncCallback) (err error)
+	GetTicketBarcode(ctx context.Context, transactionID, ticketNumber, format string) (res dto.TicketBarcodeResponse, err error)
 }
 
 type EventTransactionServiceImpl struct {
@@ -57,10 +66,20 @@ type EventTransactionServiceImpl struct {
 	VenueSectorRepo               repository.VenueSectorRepository
 	PaymentMethodRepo             repository.PaymentMethodRepository
 	PaymentLogsRepo               repository.PaymentLogRepository
+	PaymentReconciliationRepo     repository.PaymentReconciliationRepository
+	VoucherRepo                   repository.VoucherRepository
+	PriceTierRepo                 repository.EventTicketCategoryPriceTierRepository
+	SeatHoldRepo                  repository.SeatHoldRepository
 
 	CheckStatusTransactionJob job.CheckStatusTransactionJob
 
 	TransactionUseCase usecase.TransactionUsecase
+	SeatmapUseCase     usecase.SeatmapUsecase
+	OutboxRelay        OutboxRelay
+
+	PaymentGateways internalDomain.PaymentGateways
+
+	TransactionLifecycle TransactionLifecycle
 }
 
 func NewEventTransactionService(
@@ -79,7 +98,15 @@ func NewEventTransactionService(
 	paymentMethodRepo repository.PaymentMethodRepository,
 	checkStatusTransactionJob job.CheckStatusTransactionJob,
 	paymentLogsRepo repository.PaymentLogRepository,
+	paymentReconciliationRepo repository.PaymentReconciliationRepository,
 	transactionUseCase usecase.TransactionUsecase,
+	seatmapUseCase usecase.SeatmapUsecase,
+	outboxRelay OutboxRelay,
+	paymentGateways internalDomain.PaymentGateways,
+	transactionLifecycle TransactionLifecycle,
+	voucherRepo repository.VoucherRepository,
+	priceTierRepo repository.EventTicketCategoryPriceTierRepository,
+	seatHoldRepo repository.SeatHoldRepository,
 ) EventTransactionService {
 	return &EventTransactionServiceImpl{
 		DB:                            db,
@@ -96,10 +123,20 @@ func NewEventTransactionService(
 		PaymentMethodRepo:             paymentMethodRepo,
 		EventTicketRepo:               eventTicketRepo,
 		PaymentLogsRepo:               paymentLogsRepo,
+		PaymentReconciliationRepo:     paymentReconciliationRepo,
+		VoucherRepo:                   voucherRepo,
+		PriceTierRepo:                 priceTierRepo,
+		SeatHoldRepo:                  seatHoldRepo,
 
 		CheckStatusTransactionJob: checkStatusTransactionJob,
 
 		TransactionUseCase: transactionUseCase,
+		SeatmapUseCase:     seatmapUseCase,
+		OutboxRelay:        outboxRelay,
+
+		PaymentGateways: paymentGateways,
+
+		TransactionLifecycle: transactionLifecycle,
 	}
 }
 
@@ -261,6 +298,16 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 	}
 	defer tx.Rollback(ctx)
 
+	log.Info().Msg("validate purchase limit of buyer")
+	limitParam := purchaseLimitParam{EventID: eventId, Email: req.Email, ClientIP: ctx.ClientIP(), Quantity: buyCount, HoldID: req.SeatHoldID}
+	if eventSettings.GarudaIdVerification {
+		limitParam.GarudaIDs = orderGarudaIDs(req.Items)
+	}
+	err = s.validatePurchaseLimit(ctx, tx, eventSettings, limitParam)
+	if err != nil {
+		return
+	}
+
 	log.Info().Str("eventId", eventId).Msg("validate email is booked in the event")
 	orderInformationBookId, err := s.EventOrderInformationBookRepo.CreateOrderInformation(ctx, tx, eventId, req.Email, req.Fullname)
 	if err != nil {
@@ -268,6 +315,14 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		return
 	}
 
+	// stock taken by the hold is bought again by the order below, hold is converted to the order seats in the same tx
+	if req.SeatHoldID != "" {
+		err = s.returnSeatHoldStock(ctx, tx, eventId, ticketCategoryId, req.SeatHoldID)
+		if err != nil {
+			return
+		}
+	}
+
 	log.Info().Str("eventId", eventId).Str("ticketCategoryId", ticketCategoryId).Msg("find ticket category by id and event id")
 	ticketCategory, err := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, tx, eventId, ticketCategoryId)
 	if err != nil {
@@ -296,6 +351,12 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		return
 	}
 
+	log.Info().Msg("apply price tier of ticket category")
+	priceTier, err := s.applyPriceTier(ctx, tx, &ticketCategory, buyCount)
+	if err != nil {
+		return
+	}
+
 	now := time.Now()
 	expiryOrder := now.Add(s.Env.Transaction.ExpirationDuration)
 	orderNumber := helper.GeneraeteOrderNumber()
@@ -310,15 +371,19 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		Status:      lib.PaymentStatusPending,
 
 		PaymentMethod:    req.PaymentMethod,
-		PaymentChannel:   lib.PaymentChannelPaylabs,
+		PaymentChannel:   strings.ToUpper(paymentMethod.PaymentChannel),
 		PaymentExpiredAt: expiryOrder,
+
+		TicketQuantity: buyCount,
+		ClientIP:       ctx.ClientIP(),
 	}
 
 	// If venue doesn't have seatmap it will always empty
 	var selectedSectorSeatmap map[string]entity.EventVenueSector
+	var seatParams []domain.SeatmapParam
+	var seatmapChange model.Outbox
 	if venueSector.HasSeatmap {
 		log.Info().Msg("venueSector in ticket category has seatmap")
-		var seatParams []domain.SeatmapParam
 		for _, val := range req.Items {
 			seatParams = append(seatParams, domain.SeatmapParam{
 				SeatRow:    val.SeatRow,
@@ -326,8 +391,15 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 			})
 		}
 
-		if s.Env.App.AutoAssignSeat {
-			// TODO: Add assign seat
+		if req.SeatHoldID != "" {
+			log.Info().Str("seatHoldId", req.SeatHoldID).Msg("use held seats")
+			seatParams, selectedSectorSeatmap, err = s.useSeatHold(ctx, tx, eventId, ticketCategoryId, req.SeatHoldID, req.Email, eventSettings.SeatAttributesRequireDeclaration, req.Items)
+			if err != nil {
+				return
+			}
+		} else if s.Env.App.AutoAssignSeat {
+			// adjacent seats are assigned when tickets are issued, seats of items aren't booked
+			seatParams = nil
 		} else {
 			// Checking choosen seat is in available status
 			log.Info().Msg("checking choosen seat is in available status")
@@ -342,6 +414,11 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 				if !ok {
 					err = &lib.ErrorBookedSeatNotFound
 					return
+				} else if seat.TicketCategoryID != "" && seat.TicketCategoryID != ticketCategoryId {
+					err = &lib.ErrorSeatNotInTicketCategory
+					return
+				} else if err = checkSeatEligible(seat, eventSettings.SeatAttributesRequireDeclaration, val.SeatNeeds); err != nil {
+					return
 				} else {
 					switch seat.Status {
 					case lib.SeatmapStatusUnavailable:
@@ -362,10 +439,17 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 				sentry.CaptureException(err)
 				return
 			}
+
+			seatmapChange, err = s.SeatmapUseCase.SendSeatmapChange(ctx, tx, eventId, ticketCategory.VenueSectorId, lib.SeatmapStatusUnavailable, toSeatmapSeats(seatParams))
+			if err != nil {
+				log.Error().Err(err).Msg("failed to write seatmap change")
+				return
+			}
 		}
 	}
 
 	// TODO: Checking bulk garuda id
+	var garudaIds []string
 	if eventSettings.GarudaIdVerification {
 		// var hasAdult bool
 		// Verify garuda id
@@ -377,8 +461,6 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		// 	return res, &lib.ErrorGarudaIDAlreadyUsed
 		// }
 
-		var garudaIds []string
-
 		for _, val := range req.Items {
 			garudaIds = append(garudaIds, val.GarudaID)
 		}
@@ -455,6 +537,22 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 
 	// Calculate price
 	transaction.TotalPrice = ticketCategory.Price * len(req.Items)
+
+	var voucherRedemption voucherRedemption
+	if req.VoucherCode != "" {
+		voucherRedemption, err = s.redeemVoucher(ctx, tx, eventId, req.VoucherCode, req.Email, garudaIds, []voucherLine{
+			{TicketCategoryID: ticketCategoryId, TotalPrice: transaction.TotalPrice},
+		})
+		if err != nil {
+			return
+		}
+		transaction.VoucherID = voucherRedemption.Voucher.ID
+		transaction.VoucherCode = voucherRedemption.Voucher.Code
+		transaction.DiscountAmount = voucherRedemption.Total
+	}
+
+	// fees are counted from the price after discount
+	discountedPrice := transaction.TotalPrice - transaction.DiscountAmount
 	additionalFees, err := s.EventSettingRepo.FindAdditionalFee(ctx, nil, eventId)
 	if err != nil {
 		sentry.CaptureException(err)
@@ -467,14 +565,14 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		if fee.IsTax {
 			if fee.IsPercentage {
 				totalTaxPercentage += fee.Value
-				transaction.TotalTax += int(float64(transaction.TotalPrice) * fee.Value / 100)
+				transaction.TotalTax += int(float64(discountedPrice) * fee.Value / 100)
 			} else {
 				transaction.TotalTax += int(fee.Value)
 			}
 		} else {
 			if fee.IsPercentage {
 				totalAdminFeePercentage += fee.Value
-				transaction.TotalAdminFee += int(float64(transaction.TotalPrice) * fee.Value / 100)
+				transaction.TotalAdminFee += int(float64(discountedPrice) * fee.Value / 100)
 			} else {
 				transaction.TotalAdminFee += int(fee.Value)
 			}
@@ -493,12 +591,12 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 
 	transaction.AdminFeePercentage = float32(totalAdminFeePercentage)
 	transaction.TaxPercentage = float32(totalTaxPercentage)
-	transaction.GrandTotal = transaction.TotalPrice + transaction.TotalTax + transaction.TotalAdminFee
+	transaction.GrandTotal = discountedPrice + transaction.TotalTax + transaction.TotalAdminFee
 	// transaction.AdminFeePercentage = float32(eventSettings.AdminFeePercentage)
 	// log.Info().Int("TotalAdminFee", totalAdminFee).Float32("AdminFeePercentage", transaction.AdminFeePercentage).Msg("calculate admin fee")
 	pgAdditionalFee := 0
 
-	transaction.GrandTotal = transaction.TotalPrice + transaction.TotalTax + transaction.TotalAdminFee
+	transaction.GrandTotal = discountedPrice + transaction.TotalTax + transaction.TotalAdminFee
 	if paymentMethod.IsPercentage {
 		log.Info().Msg("payment method is percentage, calculating additional fee")
 		pgAdditionalFee = int(float64(transaction.GrandTotal) * paymentMethod.AdditionalFee / 100)
@@ -523,6 +621,20 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 	transaction.ID = transactionRes.ID
 	transaction.CreatedAt = transactionRes.CreatedAt
 
+	err = s.recordPriceTier(ctx, tx, transaction.ID, priceTier, buyCount)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+
+	if transaction.VoucherID != "" {
+		err = s.createVoucherUsage(ctx, tx, voucherRedemption, transaction, garudaIds)
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+	}
+
 	// Update order information book to set transactionId
 	err = s.EventOrderInformationBookRepo.UpdateTransactionIdByID(ctx, tx, orderInformationBookId, transaction.ID)
 	if err != nil {
@@ -530,8 +642,22 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		return
 	}
 
+	// Link books to transaction, so it can be released when transaction is expired
+	err = s.EventSeatmapBookRepo.UpdateTransactionIdBySeats(ctx, tx, eventId, ticketCategory.VenueSectorId, transaction.ID, seatParams)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = s.EventTransactionGarudaIDRepo.UpdateTransactionIdByGarudaIds(ctx, tx, eventId, transaction.ID, garudaIds...)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+
 	var transactionItems []model.EventTransactionItem
-	for _, item := range req.Items {
+	itemPrices := discountedItemPrices(ticketCategory.Price, len(req.Items), transaction.DiscountAmount)
+	for i, item := range req.Items {
 		var garudaId sql.NullString = helper.ToSQLString(item.GarudaID)
 
 		var fullName sql.NullString
@@ -556,14 +682,15 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		}
 
 		transactionItem := model.EventTransactionItem{
-			TransactionID: transaction.ID,
-			// TicketCategoryID:      ticketCategoryId,
+			TransactionID:    transaction.ID,
+			TicketCategoryID: ticketCategoryId,
 
 			Quantity: 1,
 
 			SeatRow:    item.SeatRow,
 			SeatColumn: item.SeatColumn,
 			SeatLabel:  seatLabel,
+			SeatNeeds:  item.SeatNeeds,
 
 			GarudaID:    garudaId,
 			Fullname:    fullName,
@@ -571,7 +698,7 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 			PhoneNumber: phoneNumber,
 
 			AdditionalInformation: sql.NullString{String: item.AdditionalInformation},
-			TotalPrice:            ticketCategory.Price,
+			TotalPrice:            itemPrices[i],
 
 			CreatedAt: transaction.CreatedAt,
 		}
@@ -588,35 +715,42 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		return
 	}
 	var paymentAdditionalInformation string
-	// mapping paylabs va snap or qris
+	// create charge on payment gateway based on payment channel
 	if s.Env.Paylabs.ActivePayment {
-		if helper.IsVA(transaction.PaymentMethod) {
-			log.Info().Msg("payment method is VA, calling paylabs snap")
-			var errPaylabs error
-			paymentAdditionalInformation, errPaylabs = s.paylabsVASnap(ctx, transaction, event.Name+" - "+ticketCategory.Name)
-			if errPaylabs != nil {
-				log.Error().Err(errPaylabs).Msg("failed to get paylabs va number")
-				err = &lib.ErrorTransactionPaylabs
-				return
-			}
-			log.Info().Str("paymentAdditionalInformation", paymentAdditionalInformation).Msg("got payment additional information")
-		} else if helper.IsQRIS(transaction.PaymentMethod) {
-			var errPaylabs error
-			log.Info().Msg("payment method is QRIS, calling paylabs qris")
-			paymentAdditionalInformation, errPaylabs = s.paylabsQris(ctx, transaction, event.Name+" - "+ticketCategory.Name)
-			if errPaylabs != nil {
-				log.Error().Err(errPaylabs).Msg("failed to get paylabs qris barcode")
-				err = &lib.ErrorTransactionPaylabs
-				return
-			}
+		paymentGateway, errGateway := s.PaymentGateways.Get(paymentMethod.PaymentChannel)
+		if errGateway != nil {
+			log.Error().Err(errGateway).Str("paymentChannel", paymentMethod.PaymentChannel).Msg("payment channel is not supported")
+			err = errGateway
+			return
+		}
 
+		log.Info().Str("paymentChannel", transaction.PaymentChannel).Str("paymentMethod", transaction.PaymentMethod).Msg("create charge on payment gateway")
+		charge, errCharge := paymentGateway.CreateCharge(ctx, payment.ChargeRequest{
+			TransactionID: transaction.ID,
+			OrderNumber:   transaction.OrderNumber,
+			PaymentMethod: transaction.PaymentMethod,
+			Amount:        transaction.GrandTotal,
+			CustomerName:  transaction.Fullname,
+			CustomerEmail: transaction.Email,
+			ProductName:   event.Name + " - " + ticketCategory.Name,
+			ClientIP:      ctx.ClientIP(),
+			ExpiredAt:     transaction.PaymentExpiredAt,
+		})
+		if errCharge != nil {
+			sentry.CaptureException(errCharge)
+			log.Error().Err(errCharge).Msg("failed to create charge on payment gateway")
+			err = errCharge
+			return
 		}
+		paymentAdditionalInformation = charge.PaymentAdditionalInfo
+		transaction.ChannelTransactionID = charge.PGOrderID
+		log.Info().Str("paymentAdditionalInformation", paymentAdditionalInformation).Msg("got payment additional information")
 	} else {
 		transaction.PaymentMethod = "WITHOUT_PAYMENT"
 	}
 	transaction.PaymentAdditionalInfo = paymentAdditionalInformation
 
-	err = s.EventTransactionRepo.UpdatePaymentAdditionalInformation(ctx, tx, transaction.ID, paymentAdditionalInformation)
+	err = s.EventTransactionRepo.UpdatePaymentAdditionalInformation(ctx, tx, transaction.ID, paymentAdditionalInformation, transaction.ChannelTransactionID)
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Error().Err(err).Msg("Failed to update VA number")
@@ -634,6 +768,10 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		sentry.CaptureException(err)
 		return
 	}
+	if req.SeatHoldID != "" {
+		s.deleteSeatHold(ctx, req.SeatHoldID)
+	}
+	s.OutboxRelay.RelayMessage(ctx, seatmapChange)
 
 	// Kickin check status transaction
 	marginTimeReleaseData := 30 * time.Second
@@ -917,6 +1055,8 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 		OrderNumber:        orderNumber,
 		PaymentMethod:      req.PaymentMethod,
 		TotalPrice:         transaction.TotalPrice,
+		VoucherCode:        transaction.VoucherCode,
+		DiscountAmount:     transaction.DiscountAmount,
 		TaxPercentage:      transaction.TaxPercentage,
 		TotalTax:           transaction.TotalTax,
 		AdminFeePercentage: transaction.AdminFeePercentage,
@@ -934,191 +1074,6 @@ func (s *EventTransactionServiceImpl) CreateEventTransaction(ctx *gin.Context, e
 	return
 }
 
-// static Eventtransaction without any business logic
-func (s *EventTransactionServiceImpl) paylabsVASnap(ctx *gin.Context, transaction model.EventTransaction, eventName string) (vaNo string, err error) {
-	//  VA SNAP Init
-	expiredDate := transaction.PaymentExpiredAt.Format("2006-01-02T15:04:05+07:00")
-	date := time.Now().Format("2006-01-02T15:04:05.999+07:00")
-	merchantId := s.Env.Paylabs.AccountID[len(s.Env.Paylabs.AccountID)-6:]
-	partnerServiceId := s.Env.Paylabs.AccountID[:8]
-	idRequest := transaction.ID
-	// Generate a random 20-digit customer number as a string
-
-	privateKeyPEM := s.Env.Paylabs.PrivateKey                     // Private key in PEM format
-	totalPriceStr := strconv.Itoa(transaction.GrandTotal) + ".00" // Amount with 2 decimal
-	payload := dto.VirtualAccountSnapRequest{
-		PartnerServiceID:    partnerServiceId,                 // 8 characters
-		CustomerNo:          transaction.ID[:20],              // Fixed 20-digit value
-		VirtualAccountNo:    transaction.ID[:20] + merchantId, // 28-digit composite value
-		VirtualAccountName:  transaction.Fullname,             // Payer name
-		VirtualAccountEmail: transaction.Email,                // Payer email
-		// VirtualAccountPhone: req.Items[0].PhoneNumber,  // Mobile phone number in Indonesian format
-		TrxID: transaction.OrderNumber, // Merchant transaction number
-		TotalAmount: dto.Amount{
-			Value:    totalPriceStr, // Amount with 2 decimal
-			Currency: "IDR",         // Fixed currency
-		},
-		AdditionalInfo: dto.AdditionalInfo{
-			PaymentType: transaction.PaymentMethod, // Payment type
-		},
-
-		ExpiredDate: expiredDate, // ISO-8601 formatted expiration
-	}
-
-	log.Info().Msgf("Creating event transaction with ID: %s", idRequest)
-
-	// VA
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err)
-		return
-	}
-	log.Info().Msgf("JSON Payload: %s", jsonData)
-
-	// Hash the JSON body
-	shaJson := sha256.Sum256(jsonData)
-
-	signature := helper.GenerateSnapSignature(shaJson, date, privateKeyPEM)
-	log.Info().Msgf("Payload: %x", shaJson)
-	// Create HTTP headers
-	headers := map[string]string{
-		"X-TIMESTAMP":   date,
-		"X-SIGNATURE":   signature,
-		"X-PARTNER-ID":  merchantId,
-		"X-EXTERNAL-ID": idRequest,
-		"X-IP-ADDRESS":  ctx.ClientIP(),
-		"Content-Type":  "application/json",
-	}
-	log.Info().Msgf("Headers: %v", headers)
-
-	// Send HTTP request
-	url := s.Env.Paylabs.BaseUrl + "/api/v1.0/transfer-va/create-va"
-	paylabsReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err)
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-	for key, value := range headers {
-		paylabsReq.Header.Set(key, value)
-	}
-	client := &http.Client{}
-	resp, err := client.Do(paylabsReq)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to send request to Paylabs")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-	defer resp.Body.Close()
-	log.Info().Msgf("Response Status: %s", resp.Status)
-	// Decode response
-	var responsePaylabs map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&responsePaylabs)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to decode response from Paylabs")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-	log.Info().Interface("Resp", responsePaylabs).Msgf("response from paylabs")
-
-	var virtualAccountData = responsePaylabs["virtualAccountData"].(map[string]interface{})
-	paylabsVaNumber, _ := virtualAccountData["virtualAccountNo"].(string)
-	return paylabsVaNumber, nil
-}
-func (s *EventTransactionServiceImpl) paylabsQris(ctx *gin.Context, transaction model.EventTransaction, productName string) (barcode string, err error) {
-	// Define the request data
-	currentTime := time.Now().Local() // UTC +07:00
-	date := currentTime.Format("2006-01-02T15:04:05.999+07:00")
-	merchantId := s.Env.Paylabs.AccountID[len(s.Env.Paylabs.AccountID)-6:] // 6 characters
-	merchantTradeNo := transaction.OrderNumber                             // 20 characters
-	requestID := helper.GenerateRequestID()
-	path := "/qris/create"
-	privateKeyPem := s.Env.Paylabs.PrivateKey // Private key in PEM format
-	// VA
-	var jsonBody = dto.PaylabsQRISRequest{
-		MerchantID:      merchantId,                                   // 6 characters
-		MerchantTradeNo: merchantTradeNo,                              // 8 characters
-		RequestID:       requestID,                                    // 20 characters //for lookup purposes
-		PaymentType:     "QRIS",                                       // Payment type
-		Amount:          strconv.Itoa(transaction.GrandTotal) + ".00", // Amount with 2 decimal
-		ProductName:     productName,
-		Expire:          int(s.Env.Transaction.ExpirationDuration.Seconds()),      // ISO-8601 formatted expiration
-		NotifyURL:       s.Env.Api.Url + "/api/v1/external/paylabs/qris/callback", // Callback URL
-	}
-
-	log.Info().Msgf("Creating event transaction with ID: %s", requestID)
-	// Encode JSON body
-	jsonData, err := json.Marshal(jsonBody)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to encode JSON body for Paylabs QRIS")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-
-	// Hash the JSON body
-	shaJson := sha256.Sum256(jsonData)
-	signature := helper.GenerateQRISSignature(shaJson, date, privateKeyPem)
-
-	// Create HTTP headers
-	headers := map[string]string{
-		"X-TIMESTAMP":  date,
-		"X-SIGNATURE":  signature,
-		"X-PARTNER-ID": merchantId,
-		"X-REQUEST-ID": requestID,
-		"Content-Type": "application/json;charset=utf-8",
-	}
-
-	// Send HTTP request
-	url := s.Env.Paylabs.BaseUrl + "/payment/v2" + path
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to create new request for Paylabs QRIS")
-		err = &lib.ErrorTransactionPaylabs
-		return
-
-	}
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to send request to Paylabs")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-	defer resp.Body.Close()
-	log.Info().Interface("response", resp)
-	// Decode response
-	var response map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to decode response from Paylabs")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-	log.Info().Interface("Response", response).Msg("Response from Paylabs QRIS")
-	barcode, ok := response["qrCode"].(string)
-	if !ok {
-		err = &lib.ErrorTransactionPaylabs
-		log.Error().Err(err).Msg("Failed to get QR code from response")
-		err = &lib.ErrorTransactionPaylabs
-		return
-	}
-
-	// Print response
-
-	return barcode, nil
-}
-
 func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.SnapCallbackPaymentRequest) (res dto.CallbackSnapResponse, err error) {
 	log.Info().Msg("Processing Paylabs VA snap callback")
 	header := map[string]interface{}{}
@@ -1148,9 +1103,9 @@ func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.S
 	json.Compact(&buf, []byte(rawPayload))
 	log.Info().Msgf("Raw Payload: %s", buf.String())
 	log.Info().Msgf("Request URL: %v", req)
-	isValid := helper.IsValidPaylabsRequest(ctx, "/transfer-va/payment", buf.String(), s.Env.Paylabs.PublicKey)
-	if !isValid {
-		return dto.CallbackSnapResponse{}, &lib.ErrorCallbackSignatureInvalid
+	err = s.verifyPaymentCallback(ctx, lib.PaymentChannelPaylabs, lib.PaymentGroupVirtualAccount, buf.Bytes())
+	if err != nil {
+		return dto.CallbackSnapResponse{}, err
 	}
 	//  actual callback processing
 	transactionData, err := s.EventTransactionRepo.FindByOrderNumber(ctx, tx, *req.TrxId)
@@ -1193,7 +1148,15 @@ func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.S
 	}
 	log.Info().Msgf("Transaction time: %v", transactionTime)
 
-	markResult, err := s.EventTransactionRepo.MarkTransactionAsSuccess(ctx, tx, transactionData.ID, transactionTime, req.PaymentRequestId)
+	markResult, err := s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+		TransactionID: transactionData.ID,
+		From:          lib.EventTransactionStatusPending,
+		To:            lib.EventTransactionStatusSuccess,
+		Actor:         lib.EventTransactionActorCallback,
+		Reason:        "va payment success",
+		PaidAt:        &transactionTime,
+		PGOrderID:     req.PaymentRequestId,
+	})
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Error().Err(err).Msg("Failed to mark transaction as success")
@@ -1253,15 +1216,8 @@ func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.S
 		}
 		defer tx.Rollback(ctx)
 
-		log.Info().Str("SectorID", transactionDetail.VenueSector.ID).Str("EventID", transactionDetail.Event.ID).Msg("find last order seatmap")
-		res, err := s.EventSeatmapBookRepo.GetLastSeatOrderBySectorRowColumnId(ctx, tx, transactionDetail.Event.ID, transactionDetail.VenueSector.ID)
-		if err != nil {
-			log.Error().Err(err).Str("EventID", transactionDetail.Event.ID).Str("SectorID", transactionDetail.VenueSector.ID).Msg("failed to get last seat last order in event and sector")
-			return
-		}
-
-		log.Info().Str("SectorID", transactionDetail.VenueSector.ID).Str("EventID", transactionDetail.Event.ID).Int("Num", len(transactionItems)).Int("LastRow", res.SeatRow).Int("LastColumn", res.SeatColumn).Msg("find available seats for auto assign")
-		availableSeats, err := s.EventTicketCategoryRepo.FindNAvailableSeatAfterSectorRowColumn(ctx, tx, transactionDetail.Event.ID, transactionDetail.VenueSector.ID, len(transactionItems), res.SeatRow, res.SeatColumn)
+		log.Info().Str("SectorID", transactionDetail.VenueSector.ID).Str("EventID", transactionDetail.Event.ID).Int("Num", len(transactionItems)).Msg("assign seats of transaction items")
+		availableSeats, seatmapChanges, err := s.assignItemSeats(ctx, tx, transactionDetail.ID, transactionDetail.Event.ID, transactionDetail.VenueSector.ID, transactionDetail.TicketCategory.ID, eventSettings.SeatAttributesRequireDeclaration, transactionItems)
 		if err != nil {
 			var tixErr *lib.TIXError
 			if errors.As(err, &tixErr) {
@@ -1270,7 +1226,7 @@ func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.S
 					return
 				}
 			}
-			log.Error().Err(err).Msg("failed to find available seats in sector by row and column")
+			log.Error().Err(err).Msg("failed to assign seats of transaction items")
 			return
 		}
 
@@ -1330,6 +1286,7 @@ func (s *EventTransactionServiceImpl) CallbackVASnap(ctx *gin.Context, req dto.S
 			sentry.CaptureException(err)
 			log.Warn().Err(err).Msg("failed to create eticket")
 		}
+		relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
 
 		for _, val := range eventTickets {
 			err = s.TransactionUseCase.SendETicket(
@@ -1372,9 +1329,55 @@ func (s *EventTransactionServiceImpl) CallbackVA(ctx *gin.Context, req dto.Payla
 		log.Error().Err(err).Msg("Failed to marshal callback request")
 		return
 	}
-	isValid := helper.IsValidPaylabsRequest(ctx, ctx.Request.URL.Path, string(stringifyPayload), s.Env.Paylabs.PublicKey)
-	if !isValid {
-		return errors.New("invalid signature")
+	return s.verifyPaymentCallback(ctx, lib.PaymentChannelPaylabs, req.PaymentType, stringifyPayload)
+}
+
+// verifyPaymentCallback verify callback signature using payment gateway of given payment channel
+func (s *EventTransactionServiceImpl) verifyPaymentCallback(ctx *gin.Context, paymentChannel, paymentMethod string, body []byte) (err error) {
+	if replay, ok := paymentCallbackReplayFrom(ctx); ok && replay.SkipSignatureVerification {
+		log.Warn().Int("replayOfId", replay.PaymentLogID).Msg("signature verification is skipped by admin on callback replay")
+		return nil
+	}
+
+	paymentGateway, err := s.PaymentGateways.Get(paymentChannel)
+	if err != nil {
+		return
+	}
+
+	headers := make(map[string]string, len(ctx.Request.Header))
+	for key := range ctx.Request.Header {
+		headers[strings.ToUpper(key)] = ctx.GetHeader(key)
+	}
+
+	err = paymentGateway.VerifyCallback(ctx, payment.CallbackRequest{
+		PaymentMethod: paymentMethod,
+		Path:          ctx.FullPath(),
+		Headers:       headers,
+		Body:          body,
+	})
+	if err != nil {
+		ctx.Set(lib.PaymentLogDecisionKey, lib.CallbackDecisionRejectedSignature)
+		return
+	}
+
+	return nil
+}
+
+// signPaymentCallbackResponse set signature headers required by payment gateway on callback response
+func (s *EventTransactionServiceImpl) signPaymentCallbackResponse(ctx *gin.Context, paymentChannel, requestID string, body []byte) (err error) {
+	paymentGateway, err := s.PaymentGateways.Get(paymentChannel)
+	if err != nil {
+		return
+	}
+
+	headers, err := paymentGateway.SignCallbackResponse(ctx, ctx.FullPath(), requestID, body)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sign callback response")
+		return
+	}
+
+	for key, value := range headers {
+		ctx.Header(key, value)
 	}
 	return
 }
@@ -1517,9 +1520,9 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 	json.Compact(&buf, []byte(rawPayload))
 	log.Info().Msgf("Raw Payload: %s", buf.String())
 	log.Info().Msgf("Request URL: %v", req)
-	isValid := helper.IsValidPaylabsRequest(ctx, ctx.FullPath(), buf.String(), s.Env.Paylabs.PublicKey)
-	if !isValid {
-		return res, &lib.ErrorCallbackSignatureInvalid
+	err = s.verifyPaymentCallback(ctx, lib.PaymentChannelPaylabs, req.PaymentType, buf.Bytes())
+	if err != nil {
+		return res, err
 	}
 	var isSuccess bool
 	if req.Status == "02" {
@@ -1550,6 +1553,7 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 	// Parse the time string in Asia/Jakarta location
 	var paidAt time.Time
 	var markResult model.EventTransaction
+	var seatmapChanges []model.Outbox
 	if isSuccess {
 		t, errConvertTime := time.ParseInLocation(layout, req.SuccessTime, loc)
 		if errConvertTime != nil {
@@ -1560,7 +1564,15 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 		paidAt = t
 		log.Info().Msgf("Transaction time: %v", t)
 
-		markResult, err = s.EventTransactionRepo.MarkTransactionAsSuccess(ctx, tx, transactionData.ID, t, req.PaymentMethodInfo.RRN)
+		markResult, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+			TransactionID: transactionData.ID,
+			From:          lib.EventTransactionStatusPending,
+			To:            lib.EventTransactionStatusSuccess,
+			Actor:         lib.EventTransactionActorCallback,
+			Reason:        "qris payment success",
+			PaidAt:        &t,
+			PGOrderID:     req.PaymentMethodInfo.RRN,
+		})
 		if err != nil {
 			sentry.CaptureException(err)
 			log.Error().Err(err).Msg("Failed to mark transaction as success")
@@ -1568,12 +1580,24 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 		}
 		isSuccess = true
 	} else {
-		markResult, err = s.EventTransactionRepo.MarkTransactionAsFailed(ctx, tx, transactionData.ID, req.PaymentMethodInfo.RRN)
+		markResult, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+			TransactionID: transactionData.ID,
+			From:          lib.EventTransactionStatusPending,
+			To:            lib.EventTransactionStatusFailed,
+			Actor:         lib.EventTransactionActorCallback,
+			Reason:        "qris payment failed",
+			PGOrderID:     req.PaymentMethodInfo.RRN,
+		})
 		if err != nil {
 			sentry.CaptureException(err)
 			log.Error().Err(err).Msg("Failed to mark transaction as failed")
 			return
 		}
+
+		seatmapChanges, err = s.releaseTransactionBooks(ctx, tx, transactionData)
+		if err != nil {
+			return
+		}
 	}
 	transactionDetail, err := s.EventTransactionRepo.FindTransactionDetailByTransactionId(ctx, tx, transactionData.ID)
 	if err != nil {
@@ -1594,12 +1618,10 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 		log.Error().Err(err).Msg("Failed to commit transaction")
 		return
 	}
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
 	// sent email to users
 	// -----------------signature recipe----------
-	date := time.Now().Format("2006-01-02T15:04:05.999+07:00")
-	path := ctx.FullPath()
-	partnerID := s.Env.Paylabs.AccountID
-	privateKey := s.Env.Paylabs.PrivateKey
 	requestID := helper.GenerateRequestID()
 	// -----------------signature recipe----------
 	log.Info().Msgf("Transaction marked as success: %v", markResult)
@@ -1742,14 +1764,11 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabs(ctx *gin.Context, req
 		}()
 
 	}
-	// Hash the JSON body
-	shaJson := sha256.Sum256(jsonData)
-	signature := helper.GenerateSignature(shaJson, path, date, privateKey)
-	ctx.Header("X-PARTNER-ID", partnerID)
-	ctx.Header("X-REQUEST-ID", requestID)
-	ctx.Header("X-TIMESTAMP", date)
-	ctx.Header("X-SIGNATURE", signature)
-	ctx.Header("Content-Type", "application/json;charset=utf-8")
+	err = s.signPaymentCallbackResponse(ctx, lib.PaymentChannelPaylabs, requestID, jsonData)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
 	log.Info().Msg("success process callback qris paylabs")
 	return
 
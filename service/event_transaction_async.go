@@ -0,0 +1,686 @@
+package service
+
+import (
+	"assist-tix/domain"
+	"assist-tix/dto"
+	"assist-tix/entity"
+	"assist-tix/helper"
+	"assist-tix/internal/domain/async_callback"
+	"assist-tix/internal/domain/async_order"
+	"assist-tix/internal/domain/payment"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	internalDomain "assist-tix/internal/domain"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ProcessAsyncOrder continue order created by CreateEventTransactionV2: write the items, create the charge on payment gateway
+// and send the bill. Items are committed along with the charge claim, then the charge is created outside db transaction so
+// the transaction row isn't locked while gateway is called, and the charge is saved in a short db transaction.
+// Claim keeps concurrent deliveries from creating a second charge, the bill is marked as sent so it's sent once.
+func (s *EventTransactionServiceImpl) ProcessAsyncOrder(ctx context.Context, order async_order.AsyncOrder) (err error) {
+	transactionID := order.Transaction.ID
+	log.Info().Str("transactionId", transactionID).Msg("process async order")
+
+	claim, err := s.claimAsyncOrderCharge(ctx, order)
+	if err != nil || claim.Processed {
+		return
+	}
+
+	billTransaction := order.Transaction
+	billTransaction.PaymentAdditionalInfo = claim.Transaction.PaymentAdditionalInfo
+	billTransaction.ChannelTransactionID = claim.Transaction.ChannelTransactionID
+	if claim.Charge {
+		charge, errCharge := s.createAsyncOrderCharge(ctx, order, claim.TakeOver)
+		if errCharge != nil {
+			return errCharge
+		}
+		billTransaction.PaymentAdditionalInfo = charge.PaymentAdditionalInfo
+		billTransaction.ChannelTransactionID = charge.PGOrderID
+
+		saved, errSave := s.saveAsyncOrderCharge(ctx, billTransaction)
+		if errSave != nil || !saved {
+			return errSave
+		}
+	}
+
+	billed, err := s.EventTransactionRepo.ClaimBill(ctx, nil, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to mark bill as sent")
+		return
+	}
+	if !billed {
+		log.Info().Str("transactionId", transactionID).Msg("bill is already sent by another delivery")
+		return nil
+	}
+
+	// order is already processed, failed email must not redeliver it
+	errBill := s.TransactionUseCase.SendBill(ctx,
+		billTransaction.Email,
+		billTransaction.Fullname,
+		order.UseGarudaId,
+		order.ItemCount,
+		order.TransactionAccessToken,
+		order.PaymentMethod,
+		order.Event,
+		billTransaction,
+		order.TicketCategory,
+		order.VenueSector,
+	)
+	if errBill != nil {
+		sentry.CaptureException(errBill)
+		log.Warn().Err(errBill).Str("transactionId", transactionID).Msg("error send bill to email")
+	}
+
+	log.Info().Str("transactionId", transactionID).Msg("async order processed")
+	return nil
+}
+
+// asyncOrderClaim is state of async order once its items are written. Charge is true when this delivery claimed the charge,
+// TakeOver is true when it took over the claim of delivery which didn't finish in time
+type asyncOrderClaim struct {
+	Processed   bool
+	Charge      bool
+	TakeOver    bool
+	Transaction model.EventTransaction
+}
+
+// claimAsyncOrderCharge write items of async order unless they are written already and claim its charge under the row lock.
+// Processed is true when the order needs nothing more, ex: transaction is no longer pending or its bill is sent
+func (s *EventTransactionServiceImpl) claimAsyncOrderCharge(ctx context.Context, order async_order.AsyncOrder) (claim asyncOrderClaim, err error) {
+	transactionID := order.Transaction.ID
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, transactionID)
+	if err != nil {
+		if errors.Is(err, &lib.ErrorOrderNotFound) {
+			return claim, fmt.Errorf("%w: %w", internalDomain.ErrUnprocessableMessage, err)
+		}
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to lock transaction")
+		return
+	}
+	claim.Transaction = transaction
+
+	if transaction.Status != lib.EventTransactionStatusPending {
+		log.Info().Str("transactionId", transactionID).Str("status", transaction.Status).Msg("transaction is no longer pending, skip async order")
+		claim.Processed = true
+		return
+	}
+
+	if transaction.BilledAt != nil {
+		log.Info().Str("transactionId", transactionID).Msg("async order is already processed")
+		claim.Processed = true
+		return
+	}
+
+	// cart order items are committed with the transaction
+	if !order.ItemsCreated {
+		err = s.createAsyncOrderItems(ctx, tx, order)
+		if err != nil {
+			return
+		}
+	}
+
+	switch {
+	case !s.Env.Paylabs.ActivePayment || transaction.ChannelTransactionID != "":
+		// only the bill is left
+	case transaction.ChargeClaimedAt != nil && time.Since(*transaction.ChargeClaimedAt) < s.Env.Transaction.ChargeClaimTimeout:
+		log.Info().Str("transactionId", transactionID).Time("claimedAt", *transaction.ChargeClaimedAt).Msg("charge is claimed by another delivery")
+		return claim, &lib.ErrorAsyncOrderChargeInProgress
+	default:
+		if transaction.ChargeClaimedAt != nil {
+			log.Warn().Str("transactionId", transactionID).Time("claimedAt", *transaction.ChargeClaimedAt).Msg("charge claim is stale, take it over")
+			claim.TakeOver = true
+		}
+
+		claimedAt := time.Now()
+		err = s.EventTransactionRepo.UpdateChargeClaimedAt(ctx, tx, transactionID, &claimedAt)
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to claim charge")
+			return
+		}
+		claim.Charge = true
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		return
+	}
+
+	return
+}
+
+// createAsyncOrderItems write items of async order unless another delivery wrote them already
+func (s *EventTransactionServiceImpl) createAsyncOrderItems(ctx context.Context, tx pgx.Tx, order async_order.AsyncOrder) (err error) {
+	transactionID := order.Transaction.ID
+
+	existingItems, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction items")
+		return
+	}
+	if len(existingItems) > 0 {
+		return nil
+	}
+
+	if len(order.EventTransactionItem) == 0 {
+		return fmt.Errorf("%w: async order without item", internalDomain.ErrUnprocessableMessage)
+	}
+
+	// order published before items carry their category
+	for i := range order.EventTransactionItem {
+		if order.EventTransactionItem[i].TicketCategoryID == "" {
+			order.EventTransactionItem[i].TicketCategoryID = order.TicketCategory.ID
+		}
+	}
+
+	log.Info().Str("transactionId", transactionID).Int("count", len(order.EventTransactionItem)).Msg("insert transaction item")
+	err = s.EventTransactionItemRepo.CreateTransactionItems(ctx, tx, order.EventTransactionItem)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to create transaction items")
+		return
+	}
+
+	return nil
+}
+
+// createAsyncOrderCharge create the charge claimed by this delivery. Taken over claim is checked on gateway first,
+// charge of dead delivery can't be recovered since gateway doesn't return its payment information, so it's left to be checked manually.
+// Claim is released when gateway surely didn't create the charge, otherwise it's kept until it's stale
+func (s *EventTransactionServiceImpl) createAsyncOrderCharge(ctx context.Context, order async_order.AsyncOrder, takeOver bool) (charge payment.ChargeResponse, err error) {
+	transaction := order.Transaction
+
+	paymentGateway, err := s.PaymentGateways.Get(order.PaymentMethod.PaymentChannel)
+	if err != nil {
+		log.Error().Err(err).Str("paymentChannel", order.PaymentMethod.PaymentChannel).Msg("payment channel is not supported")
+		return charge, fmt.Errorf("%w: %w", internalDomain.ErrUnprocessableMessage, err)
+	}
+
+	if takeOver {
+		_, err = paymentGateway.InquireStatus(ctx, payment.InquiryRequest{
+			TransactionID: transaction.ID,
+			OrderNumber:   transaction.OrderNumber,
+			PaymentMethod: transaction.PaymentMethod,
+		})
+		if err == nil {
+			err = fmt.Errorf("%w: charge of order %s exists on payment gateway but isn't saved", internalDomain.ErrUnprocessableMessage, transaction.OrderNumber)
+			sentry.CaptureException(err)
+			log.Error().Err(err).Str("transactionId", transaction.ID).Msg("charge of taken over claim is already created")
+			return
+		}
+		if !errors.Is(err, &lib.ErrorPaylabsTransactionNotFound) {
+			log.Error().Err(err).Str("transactionId", transaction.ID).Msg("failed to check charge of taken over claim")
+			return
+		}
+	}
+
+	log.Info().Str("paymentChannel", transaction.PaymentChannel).Str("paymentMethod", transaction.PaymentMethod).Msg("create charge on payment gateway")
+	charge, err = paymentGateway.CreateCharge(ctx, payment.ChargeRequest{
+		TransactionID: transaction.ID,
+		OrderNumber:   transaction.OrderNumber,
+		PaymentMethod: transaction.PaymentMethod,
+		Amount:        transaction.GrandTotal,
+		CustomerName:  transaction.Fullname,
+		CustomerEmail: transaction.Email,
+		ProductName:   order.Event.Name + " - " + order.TicketCategory.Name,
+		ClientIP:      order.ClientIP,
+		ExpiredAt:     transaction.PaymentExpiredAt,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transaction.ID).Msg("failed to create charge on payment gateway")
+		if errors.Is(err, &lib.ErrorPaylabsResultUnknown) {
+			return
+		}
+
+		errRelease := s.EventTransactionRepo.UpdateChargeClaimedAt(ctx, nil, transaction.ID, nil)
+		if errRelease != nil {
+			log.Error().Err(errRelease).Str("transactionId", transaction.ID).Msg("failed to release charge claim")
+		}
+		return
+	}
+
+	return
+}
+
+// saveAsyncOrderCharge store payment information of the charge. Saved is false when transaction is no longer pending
+// or another delivery of the order saved its charge first
+func (s *EventTransactionServiceImpl) saveAsyncOrderCharge(ctx context.Context, billTransaction model.EventTransaction) (saved bool, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, billTransaction.ID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", billTransaction.ID).Msg("failed to lock transaction")
+		return
+	}
+
+	if transaction.Status != lib.EventTransactionStatusPending {
+		log.Warn().Str("transactionId", billTransaction.ID).Str("status", transaction.Status).Msg("transaction is no longer pending, charge is not saved")
+		return false, nil
+	}
+
+	if transaction.ChannelTransactionID != "" {
+		log.Info().Str("transactionId", billTransaction.ID).Msg("charge is already saved by another delivery")
+		return false, nil
+	}
+
+	err = s.EventTransactionRepo.UpdatePaymentAdditionalInformation(ctx, tx, billTransaction.ID, billTransaction.PaymentAdditionalInfo, billTransaction.ChannelTransactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", billTransaction.ID).Msg("failed to update payment additional information")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		return
+	}
+
+	return true, nil
+}
+
+// ProcessAsyncCallback issue the tickets of paid transaction, mark it as success and send invoice and e-tickets.
+// Tickets are written with the status transition, so redelivered callback of success transaction is skipped.
+func (s *EventTransactionServiceImpl) ProcessAsyncCallback(ctx context.Context, callback async_callback.AsyncCallback) (err error) {
+	transactionID := callback.TransactionId
+	log.Info().Str("transactionId", transactionID).Msg("process async callback")
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, transactionID)
+	if err != nil {
+		if errors.Is(err, &lib.ErrorOrderNotFound) {
+			return fmt.Errorf("%w: %w", internalDomain.ErrUnprocessableMessage, err)
+		}
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to lock transaction")
+		return
+	}
+
+	if transaction.Status != lib.EventTransactionStatusProcessingTicket {
+		log.Info().Str("transactionId", transactionID).Str("status", transaction.Status).Msg("transaction is not processing ticket, skip async callback")
+		return nil
+	}
+
+	transactionDetail, err := s.EventTransactionRepo.FindTransactionDetailByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction detail")
+		return
+	}
+
+	rawEventSettings, err := s.EventSettingRepo.FindByEventId(ctx, tx, transactionDetail.Event.ID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", transactionDetail.Event.ID).Msg("failed to find event settings")
+		return
+	}
+	eventSettings := lib.MapEventSettings(rawEventSettings)
+
+	transactionItems, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction items")
+		return
+	}
+
+	eventTickets, seatmapChanges, err := s.issueTickets(ctx, tx, transactionDetail, eventSettings, transactionItems)
+	if err != nil {
+		return
+	}
+
+	_, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+		TransactionID: transactionID,
+		From:          lib.EventTransactionStatusProcessingTicket,
+		To:            lib.EventTransactionStatusSuccess,
+		Actor:         lib.EventTransactionActorSystem,
+		Reason:        "tickets issued",
+	})
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to mark transaction as success")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		return
+	}
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
+	// tickets are already issued, failed email must not redeliver the callback
+	additionalFees, errFee := s.EventSettingRepo.FindAdditionalFee(ctx, nil, transactionDetail.Event.ID)
+	if errFee != nil {
+		log.Error().Err(errFee).Msg("failed get event settings for invoice")
+	}
+	if transactionDetail.PgAdditionalFee.Int32 > 0 {
+		// TODO: refactor to dynamic NOT HARD CODED
+		additionalFees = append(additionalFees, entity.AdditionalFee{
+			ID:           "payment_fee",
+			Name:         "Payment Fee",
+			IsPercentage: false,
+			Value:        float64(transactionDetail.PgAdditionalFee.Int32),
+			IsTax:        false,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		})
+	}
+
+	errEmail := s.TransactionUseCase.SendInvoice(
+		ctx,
+		transactionDetail.Email,
+		transactionDetail.Fullname,
+		eventSettings.GarudaIdVerification,
+		len(transactionItems),
+		additionalFees,
+		transactionDetail,
+		callback.CallbackTime,
+	)
+	if errEmail != nil {
+		sentry.CaptureException(errEmail)
+		log.Warn().Err(errEmail).Str("transactionId", transactionID).Msg("failed to send job invoice")
+	}
+
+	for _, eventTicket := range eventTickets {
+		errEmail = s.TransactionUseCase.SendETicket(ctx, eventSettings.GarudaIdVerification, eventTicket, transactionDetail)
+		if errEmail != nil {
+			sentry.CaptureException(errEmail)
+			log.Warn().Err(errEmail).Int("ticketId", eventTicket.ID).Msg("failed to send job eticket")
+		}
+	}
+
+	log.Info().Str("transactionId", transactionID).Int("tickets", len(eventTickets)).Msg("async callback processed")
+	return nil
+}
+
+// issueTickets create ticket of each named item. Seat is auto assigned after the last booked seat of the item sector
+// and booked for the transaction, so it's released with the transaction. Seatmap changes of assigned seats are relayed once tx is committed
+func (s *EventTransactionServiceImpl) issueTickets(ctx context.Context, tx pgx.Tx, transactionDetail entity.EventTransaction, eventSettings dto.EventSettings, transactionItems []model.EventTransactionItem) (eventTickets []model.EventTicket, seatmapChanges []model.Outbox, err error) {
+	// items of cart order belong to different categories, each issued with its own sector
+	var categoryIDs []string
+	itemsByCategory := make(map[string][]model.EventTransactionItem)
+	for _, item := range transactionItems {
+		if !item.Email.Valid || !item.Fullname.Valid {
+			continue
+		}
+
+		categoryID := item.TicketCategoryID
+		if categoryID == "" {
+			categoryID = transactionDetail.TicketCategory.ID
+		}
+		if _, ok := itemsByCategory[categoryID]; !ok {
+			categoryIDs = append(categoryIDs, categoryID)
+		}
+		itemsByCategory[categoryID] = append(itemsByCategory[categoryID], item)
+	}
+
+	for _, categoryID := range categoryIDs {
+		ticketCategory := transactionDetail.TicketCategory
+		venueSector := transactionDetail.VenueSector
+		if categoryID != transactionDetail.TicketCategory.ID {
+			category, errCategory := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, tx, transactionDetail.Event.ID, categoryID)
+			if errCategory != nil {
+				log.Error().Err(errCategory).Str("ticketCategoryId", categoryID).Msg("failed to find ticket category of transaction item")
+				return nil, nil, errCategory
+			}
+			ticketCategory = entity.TicketCategory{
+				ID:       category.ID,
+				EventID:  transactionDetail.Event.ID,
+				Name:     category.Name,
+				Price:    category.Price,
+				Code:     category.Code,
+				Entrance: category.Entrance,
+			}
+
+			venueSector, err = s.VenueSectorRepo.FindVenueSectorById(ctx, tx, category.VenueSectorId)
+			if err != nil {
+				log.Error().Err(err).Str("venueSectorId", category.VenueSectorId).Msg("failed to find venue sector of transaction item")
+				return
+			}
+		}
+
+		var (
+			categoryTickets        []model.EventTicket
+			categorySeatmapChanges []model.Outbox
+		)
+		categoryTickets, categorySeatmapChanges, err = s.issueCategoryTickets(ctx, tx, transactionDetail, eventSettings, ticketCategory, venueSector, itemsByCategory[categoryID])
+		if err != nil {
+			return
+		}
+		eventTickets = append(eventTickets, categoryTickets...)
+		seatmapChanges = append(seatmapChanges, categorySeatmapChanges...)
+	}
+
+	return
+}
+
+func (s *EventTransactionServiceImpl) issueCategoryTickets(ctx context.Context, tx pgx.Tx, transactionDetail entity.EventTransaction, eventSettings dto.EventSettings, ticketCategory entity.TicketCategory, venueSector entity.VenueSector, ticketItems []model.EventTransactionItem) (eventTickets []model.EventTicket, seatmapChanges []model.Outbox, err error) {
+	eventID := transactionDetail.Event.ID
+	sectorID := venueSector.ID
+
+	var availableSeats []entity.EventVenueSector
+	if venueSector.HasSeatmap {
+		// seats chosen or held by buyer are already booked for the transaction
+		bookedSeats, errBooked := s.EventSeatmapBookRepo.FindSeatBooksByTransactionSectorId(ctx, tx, transactionDetail.ID, sectorID)
+		if errBooked != nil {
+			log.Error().Err(errBooked).Str("transactionId", transactionDetail.ID).Msg("failed to find booked seats of transaction")
+			return nil, nil, errBooked
+		}
+		if !itemSeatsBooked(bookedSeats, ticketItems) {
+			availableSeats, seatmapChanges, err = s.assignItemSeats(ctx, tx, transactionDetail.ID, eventID, sectorID, ticketCategory.ID, eventSettings.SeatAttributesRequireDeclaration, ticketItems)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	for i, item := range ticketItems {
+		ticketCode, errCode := helper.GenerateTicketCode()
+		if errCode != nil {
+			log.Error().Err(errCode).Msg("failed to generate ticket code")
+			return nil, seatmapChanges, errCode
+		}
+
+		eventTicket := model.EventTicket{
+			EventID:          eventID,
+			TicketCategoryID: ticketCategory.ID,
+			TransactionID:    transactionDetail.ID,
+
+			TicketOwnerEmail:       item.Email.String,
+			TicketOwnerFullname:    item.Fullname.String,
+			TicketOwnerPhoneNumber: item.PhoneNumber,
+			TicketOwnerGarudaId:    item.GarudaID,
+			TicketNumber:           helper.GenerateTicketNumber(helper.PREFIX_TICKET_NUMBER),
+			TicketCode:             ticketCode,
+
+			EventTime:    transactionDetail.Event.EventTime,
+			EventVenue:   transactionDetail.Event.Venue.Name,
+			EventCity:    transactionDetail.Event.Venue.City,
+			EventCountry: transactionDetail.Event.Venue.Country,
+
+			SectorName: venueSector.Name,
+			AreaCode:   venueSector.AreaCode.String,
+			Entrance:   ticketCategory.Entrance,
+
+			IsCompliment: false,
+		}
+		if availableSeats != nil {
+			eventTicket.SeatRow = availableSeats[i].SeatRow
+			eventTicket.SeatColumn = availableSeats[i].SeatColumn
+			eventTicket.SeatRowLabel = helper.ToSQLInt16(int16(availableSeats[i].SeatRowLabel))
+			eventTicket.SeatLabel = helper.ToSQLString(availableSeats[i].Label)
+		} else {
+			eventTicket.SeatRow = item.SeatRow
+			eventTicket.SeatColumn = item.SeatColumn
+			eventTicket.SeatLabel = item.SeatLabel
+		}
+
+		eventTicket.ID, err = s.EventTicketRepo.Create(ctx, tx, eventTicket)
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", transactionDetail.ID).Msg("failed to create eticket")
+			return
+		}
+		eventTickets = append(eventTickets, eventTicket)
+	}
+
+	return
+}
+
+// assignItemSeats assign seats to items grouped by their declared needs, each group gets seats having one of its needs
+// and never seats with attributes it doesn't declare. Returned seats are in the order of items
+func (s *EventTransactionServiceImpl) assignItemSeats(ctx context.Context, tx pgx.Tx, transactionID, eventID, sectorID, ticketCategoryID string, requireDeclaration []string, items []model.EventTransactionItem) (seats []entity.EventVenueSector, seatmapChanges []model.Outbox, err error) {
+	seats = make([]entity.EventVenueSector, len(items))
+	for _, group := range groupItemsBySeatNeeds(items) {
+		excluded := assignmentExcludedSeatAttributes(requireDeclaration, group.needs)
+		groupSeats, seatmapChange, errAssign := s.assignSeats(ctx, tx, transactionID, eventID, sectorID, ticketCategoryID, requiredSeatAttributes(group.needs), excluded, avoidedSeatAttributes(excluded), len(group.items))
+		if errAssign != nil {
+			return nil, nil, errAssign
+		}
+		seatmapChanges = append(seatmapChanges, seatmapChange)
+
+		for i, idx := range group.items {
+			err = checkSeatEligible(groupSeats[i], requireDeclaration, items[idx].SeatNeeds)
+			if err != nil {
+				log.Error().Err(err).Str("transactionId", transactionID).Int("itemId", items[idx].ID).Msg("assigned seat is not eligible for item")
+				return nil, nil, err
+			}
+			seats[idx] = groupSeats[i]
+		}
+	}
+
+	return
+}
+
+// assignSeats book n available seats of sector, which are sellable by the ticket category, for the transaction.
+// With auto assign seat it locks seat assignment of the sector and picks adjacent seats, otherwise the next n seats after the last booked seat.
+// Seats must have one of required attributes when it's set. Seats with excluded attributes are never assigned,
+// seats with avoided attributes only when there are not enough other seats
+func (s *EventTransactionServiceImpl) assignSeats(ctx context.Context, tx pgx.Tx, transactionID, eventID, sectorID, ticketCategoryID string, required, excluded, avoided []string, num int) (availableSeats []entity.EventVenueSector, seatmapChange model.Outbox, err error) {
+	if s.Env.App.AutoAssignSeat {
+		availableSeats, err = s.findAdjacentSeats(ctx, tx, eventID, sectorID, ticketCategoryID, required, excluded, avoided, num)
+	} else {
+		availableSeats, err = s.findNextSeats(ctx, tx, eventID, sectorID, ticketCategoryID, required, excluded, avoided, num)
+	}
+	if err != nil {
+		return
+	}
+
+	seatParams := make([]domain.SeatmapParam, 0, len(availableSeats))
+	for _, seat := range availableSeats {
+		seatParams = append(seatParams, domain.SeatmapParam{SeatRow: seat.SeatRow, SeatColumn: seat.SeatColumn})
+	}
+
+	// concurrent assignment of the same seat fails here and is retried by redelivery
+	err = s.EventSeatmapBookRepo.CreateSeatBook(ctx, tx, eventID, sectorID, seatParams)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to book assigned seats")
+		return
+	}
+
+	err = s.EventSeatmapBookRepo.UpdateTransactionIdBySeats(ctx, tx, eventID, sectorID, transactionID, seatParams)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to link assigned seats to transaction")
+		return
+	}
+
+	seatmapChange, err = s.SeatmapUseCase.SendSeatmapChange(ctx, tx, eventID, sectorID, lib.SeatmapStatusUnavailable, toSeatmapSeats(seatParams))
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to write seatmap change")
+		return
+	}
+
+	return
+}
+
+// findAdjacentSeats lock seat assignment of event sector so concurrent assignment waits for this one to be committed,
+// then pick seats of the same row or nearby rows. Seats without avoided attributes are tried first
+func (s *EventTransactionServiceImpl) findAdjacentSeats(ctx context.Context, tx pgx.Tx, eventID, sectorID, ticketCategoryID string, required, excluded, avoided []string, num int) (seats []entity.EventVenueSector, err error) {
+	err = s.EventTicketCategoryRepo.LockSeatAssignment(ctx, tx, eventID, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to lock seat assignment of sector")
+		return
+	}
+
+	available, err := s.EventTicketCategoryRepo.FindAvailableSeatsByEventSectorId(ctx, tx, eventID, sectorID, ticketCategoryID, excluded)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to find available seats in sector")
+		return
+	}
+	available = filterSeatsByAttributes(available, required, nil)
+
+	preferred := make([]entity.EventVenueSector, 0, len(available))
+	for _, seat := range available {
+		if !seatHasAnyAttribute(seat, avoided) {
+			preferred = append(preferred, seat)
+		}
+	}
+
+	seats = helper.FindAdjacentSeats(preferred, num)
+	if seats == nil {
+		seats = helper.FindAdjacentSeats(available, num)
+	}
+	if seats == nil {
+		log.Error().Str("eventId", eventID).Str("sectorId", sectorID).Int("num", num).Int("available", len(available)).Msg("available seats not match with requested seats")
+		return nil, &lib.ErrorSeatAvailableSeatNotMatcheWithRequestSeats
+	}
+
+	log.Info().Str("eventId", eventID).Str("sectorId", sectorID).Int("num", num).Int("firstRow", seats[0].SeatRow).Int("lastRow", seats[len(seats)-1].SeatRow).Msg("adjacent seats assigned")
+	return
+}
+
+func (s *EventTransactionServiceImpl) findNextSeats(ctx context.Context, tx pgx.Tx, eventID, sectorID, ticketCategoryID string, required, excluded, avoided []string, num int) (seats []entity.EventVenueSector, err error) {
+	lastSeat, err := s.EventSeatmapBookRepo.GetLastSeatOrderBySectorRowColumnId(ctx, tx, eventID, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to get last seat order in event and sector")
+		return
+	}
+
+	log.Info().Str("sectorId", sectorID).Str("eventId", eventID).Int("num", num).Int("lastRow", lastSeat.SeatRow).Int("lastColumn", lastSeat.SeatColumn).Msg("find available seats for auto assign")
+	seats, err = s.EventTicketCategoryRepo.FindNAvailableSeatAfterSectorRowColumn(ctx, tx, eventID, sectorID, ticketCategoryID, required, avoided, num, lastSeat.SeatRow, lastSeat.SeatColumn)
+	var tixErr *lib.TIXError
+	if errors.As(err, &tixErr) && tixErr.Code == lib.ErrorSeatAvailableSeatNotMatcheWithRequestSeats.Code {
+		// not enough seats without avoided attributes, take the rest of sellable seats
+		seats, err = s.EventTicketCategoryRepo.FindNAvailableSeatAfterSectorRowColumn(ctx, tx, eventID, sectorID, ticketCategoryID, required, excluded, num, lastSeat.SeatRow, lastSeat.SeatColumn)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to find available seats in sector")
+		return
+	}
+
+	return
+}
+
+// itemSeatsBooked check every item has a seat which is booked for the transaction
+func itemSeatsBooked(bookedSeats map[string]model.EventSeatmapBook, ticketItems []model.EventTransactionItem) bool {
+	if len(bookedSeats) < len(ticketItems) {
+		return false
+	}
+
+	for _, item := range ticketItems {
+		if _, ok := bookedSeats[helper.ConvertRowColumnKey(item.SeatRow, item.SeatColumn)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type paymentCallbackParam struct {
+	OrderNumber      string
+	PaymentReference string // VA PaymentRequestId / QRIS RRN, stored as pg_order_id once accepted
+	IsSuccess        bool
+	PaidAmount       string // Amount with 2 decimal
+	Currency         string // empty when gateway doesn't send currency
+}
+
+// guardPaymentCallback lock the transaction and decide whether the callback can be processed.
+// duplicate is true when the same payment was already processed, caller must acknowledge it without processing again.
+// The decision is passed to payment logs through gin context.
+func (s *EventTransactionServiceImpl) guardPaymentCallback(ctx *gin.Context, tx pgx.Tx, param paymentCallbackParam) (transaction model.EventTransaction, duplicate bool, err error) {
+	ctx.Set(lib.PaymentLogReferenceKey, param.PaymentReference)
+
+	transaction, err = s.EventTransactionRepo.FindByOrderNumberForUpdate(ctx, tx, param.OrderNumber)
+	if err != nil {
+		if errors.Is(err, &lib.ErrorOrderNotFound) {
+			ctx.Set(lib.PaymentLogDecisionKey, lib.CallbackDecisionNotFound)
+		}
+		log.Error().Err(err).Str("orderNumber", param.OrderNumber).Msg("failed to find transaction by order number")
+		return
+	}
+	ctx.Set(lib.PaymentLogTransactionIDKey, transaction.ID)
+
+	duplicate, decision, err := checkPaymentResult(transaction, param)
+	ctx.Set(lib.PaymentLogDecisionKey, decision)
+
+	return transaction, duplicate, err
+}
+
+// checkPaymentResult compare payment result reported by gateway with the locked transaction
+func checkPaymentResult(transaction model.EventTransaction, param paymentCallbackParam) (duplicate bool, decision string, err error) {
+	if transaction.Status != lib.EventTransactionStatusPending {
+		// paylabs retry callback until it got success response, acknowledge the one we already processed
+		if param.PaymentReference != "" && transaction.PGOrderID == param.PaymentReference {
+			log.Info().Str("transactionId", transaction.ID).Str("status", transaction.Status).Str("paymentReference", param.PaymentReference).Msg("duplicate payment callback, skip processing")
+			return true, lib.CallbackDecisionDuplicate, nil
+		}
+
+		log.Warn().Str("transactionId", transaction.ID).Str("status", transaction.Status).Str("paymentReference", param.PaymentReference).Msg("payment callback for non pending transaction")
+		return false, lib.CallbackDecisionRejectedStatus, &lib.ErrorTransactionIsNotPending
+	}
+
+	if param.IsSuccess {
+		if param.Currency != "" && param.Currency != lib.PaymentCurrencyIDR {
+			log.Warn().Str("transactionId", transaction.ID).Str("currency", param.Currency).Msg("payment callback currency mismatch")
+			return false, lib.CallbackDecisionRejectedCurrency, &lib.ErrorCallbackCurrencyMismatch
+		}
+
+		paidAmount, errParse := strconv.ParseFloat(param.PaidAmount, 64)
+		if errParse != nil || int(math.Round(paidAmount)) != transaction.GrandTotal {
+			log.Warn().Str("transactionId", transaction.ID).Str("paidAmount", param.PaidAmount).Int("grandTotal", transaction.GrandTotal).Msg("payment callback amount mismatch")
+			return false, lib.CallbackDecisionRejectedAmount, &lib.ErrorCallbackAmountMismatch
+		}
+	}
+
+	return false, lib.CallbackDecisionAccepted, nil
+}
+
+type paymentSettlement struct {
+	IsSuccess        bool
+	PaidAt           time.Time
+	PaymentReference string
+	Actor            string // lib.EventTransactionActor*
+	Reason           string
+}
+
+// settlePayment apply accepted payment result to pending transaction.
+// Paid transaction is handed to async callback consumer to issue the tickets through outbox,
+// failed transaction releases everything it books. Caller must relay the outboxes once tx is committed.
+// Shared by callback and reconciliation
+func (s *EventTransactionServiceImpl) settlePayment(ctx context.Context, tx pgx.Tx, transactionID string, param paymentSettlement) (res model.EventTransaction, outboxes []model.Outbox, err error) {
+	if !param.IsSuccess {
+		res, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+			TransactionID: transactionID,
+			From:          lib.EventTransactionStatusPending,
+			To:            lib.EventTransactionStatusFailed,
+			Actor:         param.Actor,
+			Reason:        param.Reason,
+			PGOrderID:     param.PaymentReference,
+		})
+		if err != nil {
+			return
+		}
+
+		var transaction model.EventTransaction
+		transaction, err = s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, transactionID)
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find failed transaction")
+			return
+		}
+
+		outboxes, err = s.releaseTransactionBooks(ctx, tx, transaction)
+		return
+	}
+
+	res, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+		TransactionID: transactionID,
+		From:          lib.EventTransactionStatusPending,
+		To:            lib.EventTransactionStatusProcessingTicket,
+		Actor:         param.Actor,
+		Reason:        param.Reason,
+		PaidAt:        &param.PaidAt,
+		PGOrderID:     param.PaymentReference,
+	})
+	if err != nil {
+		return
+	}
+
+	outbox, err := s.TransactionUseCase.SendAsyncCallback(ctx, tx, transactionID, param.PaidAt)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("error write async callback to outbox")
+		return
+	}
+	outboxes = append(outboxes, outbox)
+
+	return
+}
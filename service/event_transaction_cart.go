@@ -0,0 +1,551 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/entity"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// cartLine is items of the same ticket category in cart order
+type cartLine struct {
+	TicketCategory model.EventTicketCategory
+	VenueSector    entity.VenueSector
+	PriceTier      model.EventTicketCategoryPriceTier // empty when category price is used
+	Items          []dto.OrderItemEventTransaction
+
+	TotalPrice     int
+	DiscountAmount int
+	TotalTax       int
+	TotalAdminFee  int
+}
+
+// CreateEventTransactionCart create a single transaction of items across ticket categories of the same event.
+// Stock of every category is bought in the same tx, so the order is created only when all of them are available.
+// Items are written with the transaction, the charge is created by the async order like CreateEventTransactionV2.
+func (s *EventTransactionServiceImpl) CreateEventTransactionCart(ctx *gin.Context, eventId string, req dto.CreateEventTransactionCart) (res dto.EventTransactionCartResponse, err error) {
+	log.Info().Interface("Payload", req).Str("eventId", eventId).Str("paymentMethod", req.PaymentMethod).Msg("create event cart transaction")
+
+	event, err := s.EventRepo.FindById(ctx, nil, eventId)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = validateEventOnSale(event)
+	if err != nil {
+		return
+	}
+
+	paymentMethod, err := s.PaymentMethodRepo.ValidatePaymentCodeIsActive(ctx, nil, req.PaymentMethod)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to validate payment method")
+		sentry.CaptureException(err)
+		return
+	}
+
+	settings, err := s.EventSettingRepo.FindByEventId(ctx, nil, eventId)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find event settings by event id")
+		sentry.CaptureException(err)
+		return
+	}
+	eventSettings := lib.MapEventSettings(settings)
+
+	buyCount := len(req.Items)
+	log.Info().Int("count", buyCount).Int("MaxAdultTicketPerTransaction", eventSettings.MaxAdultTicketPerTransaction).Msg("buy items")
+	if buyCount > eventSettings.MaxAdultTicketPerTransaction {
+		log.Error().Msg("buy count exceed the limit")
+		err = &lib.ErrorPurchaseQuantityExceedTheLimit
+		return
+	}
+
+	// lines keep the order of the first item of each category, the first line is the transaction category
+	var categoryIDs []string
+	lines := make(map[string]*cartLine)
+	orderItems := make([]dto.OrderItemEventTransaction, 0, len(req.Items))
+	for _, item := range req.Items {
+		line, ok := lines[item.TicketCategoryID]
+		if !ok {
+			line = &cartLine{}
+			lines[item.TicketCategoryID] = line
+			categoryIDs = append(categoryIDs, item.TicketCategoryID)
+		}
+		line.Items = append(line.Items, item.OrderItemEventTransaction)
+		orderItems = append(orderItems, item.OrderItemEventTransaction)
+	}
+
+	detailGarudaID := make(map[string]GarudaIdDetail)
+	var garudaIds []string
+	if eventSettings.GarudaIdVerification {
+		detailGarudaID, err = s.verifyGarudaIDs(orderItems)
+		if err != nil {
+			return
+		}
+
+		for _, item := range orderItems {
+			garudaIds = append(garudaIds, item.GarudaID)
+		}
+	} else {
+		for _, item := range orderItems {
+			if !helper.IsValidEmail(item.Email) || !helper.ValidatePhoneNumber(item.PhoneNumber) || !helper.IsValidUsername(item.FullName) {
+				log.Error().Msg("Invalid email, phone number or full name")
+				return res, &lib.ErrorBadRequest
+			}
+		}
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// buyer keys are locked before stock rows, the same order as single category order
+	err = s.validatePurchaseLimit(ctx, tx, eventSettings, purchaseLimitParam{
+		EventID:   eventId,
+		Email:     req.Email,
+		ClientIP:  ctx.ClientIP(),
+		GarudaIDs: garudaIds,
+		Quantity:  buyCount,
+	})
+	if err != nil {
+		return
+	}
+
+	orderInformationBookId, err := s.EventOrderInformationBookRepo.CreateOrderInformation(ctx, tx, eventId, req.Email, req.Fullname)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create order information book")
+		sentry.CaptureException(err)
+		return
+	}
+
+	// stock rows are locked in id order, so concurrent carts of the same categories can't deadlock
+	lockOrder := make([]string, len(categoryIDs))
+	copy(lockOrder, categoryIDs)
+	sort.Strings(lockOrder)
+	for _, categoryID := range lockOrder {
+		line := lines[categoryID]
+
+		line.TicketCategory, err = s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, tx, eventId, categoryID)
+		if err != nil {
+			log.Error().Err(err).Str("ticketCategoryId", categoryID).Msg("failed to find ticket category by id and event id")
+			return
+		}
+
+		line.VenueSector, err = s.VenueSectorRepo.FindVenueSectorById(ctx, tx, line.TicketCategory.VenueSectorId)
+		if err != nil {
+			log.Error().Err(err).Str("venueSectorId", line.TicketCategory.VenueSectorId).Msg("failed to find venue sector by id")
+			return
+		}
+
+		log.Info().Str("ticketCategoryId", categoryID).Int("publicStock", line.TicketCategory.PublicStock).Int("count", len(line.Items)).Msg("buy public stock of cart line")
+		err = s.EventTicketCategoryRepo.BuyPublicTicketById(ctx, tx, eventId, categoryID, len(line.Items))
+		if err != nil {
+			log.Error().Err(err).Str("ticketCategoryId", categoryID).Msg("failed to update stock public ticket by ticket category id")
+			return
+		}
+
+		line.PriceTier, err = s.applyPriceTier(ctx, tx, &line.TicketCategory, len(line.Items))
+		if err != nil {
+			return
+		}
+	}
+
+	if eventSettings.GarudaIdVerification {
+		err = s.EventTransactionGarudaIDRepo.CreateGarudaIdBooks(ctx, tx, eventId, garudaIds...)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create garuda id books")
+			return
+		}
+	}
+
+	now := time.Now()
+	orderNumber := helper.GeneraeteOrderNumber()
+	log.Info().Str("OrderNumber", orderNumber).Msg("generated order number")
+
+	transaction := model.EventTransaction{
+		Fullname: req.Fullname,
+		Email:    req.Email,
+
+		OrderNumber: orderNumber,
+		Status:      lib.PaymentStatusPending,
+
+		PaymentMethod:    req.PaymentMethod,
+		PaymentChannel:   strings.ToUpper(paymentMethod.PaymentChannel),
+		PaymentExpiredAt: now.Add(s.Env.Transaction.ExpirationDuration),
+
+		TicketQuantity: buyCount,
+		ClientIP:       ctx.ClientIP(),
+	}
+
+	additionalFees, err := s.EventSettingRepo.FindAdditionalFee(ctx, nil, eventId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Error().Err(err).Msg("failed to find additional fees for event")
+		return
+	}
+
+	// percentage fee is counted on each line, fixed fee once on the transaction like single category order
+	var totalTaxPercentage float64
+	var totalAdminFeePercentage float64
+	for _, fee := range additionalFees {
+		switch {
+		case fee.IsTax && fee.IsPercentage:
+			totalTaxPercentage += fee.Value
+		case fee.IsTax:
+			transaction.TotalTax += int(fee.Value)
+		case fee.IsPercentage:
+			totalAdminFeePercentage += fee.Value
+		default:
+			transaction.TotalAdminFee += int(fee.Value)
+		}
+	}
+
+	voucherLines := make([]voucherLine, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		line := lines[categoryID]
+		line.TotalPrice = line.TicketCategory.Price * len(line.Items)
+		voucherLines = append(voucherLines, voucherLine{TicketCategoryID: categoryID, TotalPrice: line.TotalPrice})
+	}
+
+	var voucherRedemption voucherRedemption
+	if req.VoucherCode != "" {
+		voucherRedemption, err = s.redeemVoucher(ctx, tx, eventId, req.VoucherCode, req.Email, garudaIds, voucherLines)
+		if err != nil {
+			return
+		}
+		transaction.VoucherID = voucherRedemption.Voucher.ID
+		transaction.VoucherCode = voucherRedemption.Voucher.Code
+		for i, categoryID := range categoryIDs {
+			lines[categoryID].DiscountAmount = voucherRedemption.Discounts[i]
+		}
+	}
+
+	for _, categoryID := range categoryIDs {
+		line := lines[categoryID]
+		// fees are counted from the price after discount
+		discountedPrice := line.TotalPrice - line.DiscountAmount
+		for _, fee := range additionalFees {
+			if !fee.IsPercentage {
+				continue
+			}
+			if fee.IsTax {
+				line.TotalTax += int(float64(discountedPrice) * fee.Value / 100)
+			} else {
+				line.TotalAdminFee += int(float64(discountedPrice) * fee.Value / 100)
+			}
+		}
+
+		transaction.TotalPrice += line.TotalPrice
+		transaction.DiscountAmount += line.DiscountAmount
+		transaction.TotalTax += line.TotalTax
+		transaction.TotalAdminFee += line.TotalAdminFee
+	}
+
+	if len(additionalFees) > 0 {
+		additionalFeeStr, errMarshal := json.Marshal(additionalFees)
+		if errMarshal != nil {
+			log.Error().Err(errMarshal).Msg("failed to marshal additional fees")
+			return res, &lib.ErrorInternalServer
+		}
+		transaction.AdditionalFeeDetails = string(additionalFeeStr)
+	}
+
+	transaction.AdminFeePercentage = float32(totalAdminFeePercentage)
+	transaction.TaxPercentage = float32(totalTaxPercentage)
+	transaction.GrandTotal = transaction.TotalPrice - transaction.DiscountAmount + transaction.TotalTax + transaction.TotalAdminFee
+	if paymentMethod.IsPercentage {
+		transaction.PGAdditionalFee = int(float64(transaction.GrandTotal) * paymentMethod.AdditionalFee / 100)
+	} else {
+		transaction.PGAdditionalFee = int(paymentMethod.AdditionalFee)
+	}
+	transaction.GrandTotal += transaction.PGAdditionalFee
+	log.Info().Int("PGAdditionalFee", transaction.PGAdditionalFee).Int("GrandTotal", transaction.GrandTotal).Msg("got grand total price")
+
+	firstLine := lines[categoryIDs[0]]
+	transactionRes, err := s.EventTransactionRepo.CreateTransaction(ctx, tx, eventId, firstLine.TicketCategory.ID, transaction)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create transaction in database")
+		sentry.CaptureException(err)
+		return
+	}
+	transaction.ID = transactionRes.ID
+	transaction.CreatedAt = transactionRes.CreatedAt
+	log.Info().Str("TransactionID", transaction.ID).Msg("transaction created")
+
+	for _, categoryID := range categoryIDs {
+		err = s.recordPriceTier(ctx, tx, transaction.ID, lines[categoryID].PriceTier, len(lines[categoryID].Items))
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+	}
+
+	if transaction.VoucherID != "" {
+		err = s.createVoucherUsage(ctx, tx, voucherRedemption, transaction, garudaIds)
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+	}
+
+	// Link books to transaction, so it can be released when transaction is expired
+	err = s.EventOrderInformationBookRepo.UpdateTransactionIdByID(ctx, tx, orderInformationBookId, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update transaction id of order information book")
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = s.EventTransactionGarudaIDRepo.UpdateTransactionIdByGarudaIds(ctx, tx, eventId, transaction.ID, garudaIds...)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update transaction id of garuda id books")
+		sentry.CaptureException(err)
+		return
+	}
+
+	var transactionItems []model.EventTransactionItem
+	for _, categoryID := range categoryIDs {
+		line := lines[categoryID]
+		itemPrices := discountedItemPrices(line.TicketCategory.Price, len(line.Items), line.DiscountAmount)
+		for i, item := range line.Items {
+			transactionItem := model.EventTransactionItem{
+				TransactionID:    transaction.ID,
+				TicketCategoryID: categoryID,
+
+				Quantity: 1,
+
+				SeatRow:    item.SeatRow,
+				SeatColumn: item.SeatColumn,
+				SeatNeeds:  item.SeatNeeds,
+
+				GarudaID:    helper.ToSQLString(item.GarudaID),
+				Fullname:    helper.ToSQLString(item.FullName),
+				Email:       helper.ToSQLString(item.Email),
+				PhoneNumber: helper.ToSQLString(item.PhoneNumber),
+
+				AdditionalInformation: sql.NullString{String: item.AdditionalInformation},
+				TotalPrice:            itemPrices[i],
+
+				CreatedAt: transaction.CreatedAt,
+			}
+			if eventSettings.GarudaIdVerification {
+				transactionItem.Fullname = helper.ToSQLString(detailGarudaID[item.GarudaID].Name)
+				transactionItem.Email = helper.ToSQLString(detailGarudaID[item.GarudaID].Email)
+				transactionItem.PhoneNumber = helper.ToSQLString(detailGarudaID[item.GarudaID].PhoneNumber)
+			}
+
+			transactionItems = append(transactionItems, transactionItem)
+		}
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Int("count", len(transactionItems)).Msg("insert transaction item")
+	err = s.EventTransactionItemRepo.CreateTransactionItems(ctx, tx, transactionItems)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create transaction items")
+		sentry.CaptureException(err)
+		return
+	}
+
+	accessToken, err := helper.GenerateAccessToken(s.Env, transaction.ID)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Error().Err(err).Msg("failed to generate access token")
+		return
+	}
+
+	// bill shows the transaction category, items are listed on the invoice
+	asyncOrder, err := s.TransactionUseCase.SendAsyncOrder(ctx,
+		tx,
+		eventSettings.GarudaIdVerification,
+		len(transactionItems),
+		accessToken,
+		paymentMethod,
+		event,
+		transaction,
+		firstLine.TicketCategory,
+		firstLine.VenueSector,
+		nil,
+		true,
+		ctx.ClientIP(),
+		orderInformationBookId)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Error().Err(err).Msg("error write async order to outbox")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	s.OutboxRelay.RelayMessage(ctx, asyncOrder)
+
+	// Kickin check status transaction
+	marginTimeReleaseData := 30 * time.Second
+
+	err = s.CheckStatusTransactionJob.EnqueueCheckTransaction(ctx, transaction.ID, s.Env.Transaction.ExpirationDuration+marginTimeReleaseData, s.Env.Asynq.ProcessTimeout)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Error().Err(err).Str("TransactionId", transaction.ID).Msg("failed to kick job check status transaction")
+		return
+	}
+
+	res = dto.EventTransactionCartResponse{
+		EventTransactionResponse: dto.EventTransactionResponse{
+			OrderNumber:        orderNumber,
+			PaymentMethod:      req.PaymentMethod,
+			TotalPrice:         transaction.TotalPrice,
+			VoucherCode:        transaction.VoucherCode,
+			DiscountAmount:     transaction.DiscountAmount,
+			TaxPercentage:      transaction.TaxPercentage,
+			TotalTax:           transaction.TotalTax,
+			AdminFeePercentage: transaction.AdminFeePercentage,
+			TotalAdminFee:      transaction.TotalAdminFee,
+			GrandTotal:         transaction.GrandTotal,
+			ExpiredAt:          transaction.PaymentExpiredAt,
+			CreatedAt:          transaction.CreatedAt,
+			AccessToken:        accessToken,
+			TransactionID:      transaction.ID,
+			PgAdditionalFee:    transaction.PGAdditionalFee,
+		},
+	}
+	for _, categoryID := range categoryIDs {
+		line := lines[categoryID]
+		res.Lines = append(res.Lines, dto.EventTransactionCartLineResponse{
+			TicketCategoryID:   categoryID,
+			TicketCategoryName: line.TicketCategory.Name,
+			Quantity:           len(line.Items),
+			Price:              line.TicketCategory.Price,
+			TotalPrice:         line.TotalPrice,
+			DiscountAmount:     line.DiscountAmount,
+			TotalTax:           line.TotalTax,
+			TotalAdminFee:      line.TotalAdminFee,
+		})
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Int("lines", len(res.Lines)).Msg("success create cart transaction")
+	return
+}
+
+func validateEventOnSale(event model.Event) (err error) {
+	if event.PublishStatus != lib.EventPublishStatusPublished {
+		return &lib.ErrorEventNotFound
+	}
+
+	if !event.IsSaleActive {
+		return &lib.ErrorEventSaleIsPaused
+	}
+
+	now := time.Now()
+	if now.After(event.EndSaleAt.Time) {
+		log.Error().Msg("event sale is already over")
+		return &lib.ErrorEventSaleAlreadyOver
+	}
+	if !(now.After(event.StartSaleAt.Time) && now.Before(event.EndSaleAt.Time)) {
+		log.Error().Msg("event sale is not started yet")
+		return &lib.ErrorEventSaleIsNotStartedYet
+	}
+
+	return
+}
+
+// verifyGarudaIDs verify every garuda id of the items to external service concurrently,
+// the order must contain at least one adult
+func (s *EventTransactionServiceImpl) verifyGarudaIDs(items []dto.OrderItemEventTransaction) (detailGarudaID map[string]GarudaIdDetail, err error) {
+	usedGarudaID := make(map[string]struct{})
+	for _, item := range items {
+		if item.GarudaID == "" {
+			log.Error().Msg("GarudaID is required")
+			return nil, &lib.ErrorBadRequest
+		}
+		if _, ok := usedGarudaID[item.GarudaID]; ok {
+			log.Warn().Str("GarudaID", item.GarudaID).Msg("Duplicate GarudaID on payload")
+			return nil, &lib.ErrorDuplicateGarudaIDPayload
+		}
+		usedGarudaID[item.GarudaID] = struct{}{}
+	}
+
+	type garudaIDResult struct {
+		detail GarudaIdDetail
+		age    int
+		err    error
+	}
+
+	var wg sync.WaitGroup
+	results := make([]garudaIDResult, len(items))
+	for i, item := range items {
+		wg.Add(1)
+		go func(i int, garudaID string) {
+			defer wg.Done()
+
+			externalResp, errExternal := helper.VerifyUserGarudaIDByID(s.Env.GarudaID.BaseUrl, garudaID, s.Env.GarudaID.ApiKey)
+			if errExternal != nil || externalResp == nil {
+				log.Error().Err(errExternal).Str("garudaId", garudaID).Msg("failed to verify garuda id")
+				results[i].err = &lib.ErrorGetGarudaID
+				return
+			}
+
+			if !externalResp.Success {
+				switch externalResp.ErrorCode {
+				case 40401:
+					results[i].err = &lib.ErrorGarudaIDNotFound
+				case 42205:
+					results[i].err = &lib.ErrorGarudaIDBlacklisted
+				case 40909:
+					results[i].err = &lib.ErrorGarudaIDInvalid
+				case 40910:
+					results[i].err = &lib.ErrorGarudaIDRejected
+				default:
+					results[i].err = &lib.ErrorGetGarudaID
+				}
+				return
+			}
+
+			results[i].age = externalResp.Data.Age
+			results[i].detail = GarudaIdDetail{
+				GarudaID:    externalResp.Data.FansID,
+				Name:        externalResp.Data.Name,
+				PhoneNumber: externalResp.Data.PhoneNumber,
+				Email:       externalResp.Data.Email,
+			}
+		}(i, item.GarudaID)
+	}
+	wg.Wait()
+
+	detailGarudaID = make(map[string]GarudaIdDetail)
+	hasAdult := false
+	for _, result := range results {
+		if result.err != nil {
+			return nil, result.err
+		}
+
+		detailGarudaID[result.detail.GarudaID] = result.detail
+		if result.age > s.Env.GarudaID.MinimumAge {
+			hasAdult = true
+		}
+	}
+
+	if !hasAdult {
+		log.Error().Msg("transaction must contain at least one adult ticket")
+		return nil, &lib.TransactionWithoutAdultError
+	}
+
+	return
+}
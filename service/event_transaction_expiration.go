@@ -0,0 +1,186 @@
+package service
+
+import (
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ExpireTransaction mark pending transaction as expired and release everything it books.
+// Transaction row is locked first, so payment callback can't mark it at the same time.
+func (s *EventTransactionServiceImpl) ExpireTransaction(ctx context.Context, transactionID string) (err error) {
+	log.Info().Str("transactionId", transactionID).Msg("checking transaction expiration")
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err := s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction")
+		return
+	}
+
+	if transaction.Status != lib.EventTransactionStatusPending {
+		log.Info().Str("transactionId", transactionID).Str("status", transaction.Status).Msg("transaction is not pending, skip expiration")
+		return nil
+	}
+
+	if time.Now().Before(transaction.PaymentExpiredAt) {
+		log.Warn().Str("transactionId", transactionID).Time("paymentExpiredAt", transaction.PaymentExpiredAt).Msg("transaction is not expired yet")
+		return &lib.ErrorTransactionIsNotExpiredYet
+	}
+
+	_, err = s.TransactionLifecycle.Transition(ctx, tx, TransactionTransition{
+		TransactionID: transaction.ID,
+		From:          lib.EventTransactionStatusPending,
+		To:            lib.EventTransactionStatusExpired,
+		Actor:         lib.EventTransactionActorExpiryJob,
+		Reason:        "payment expired",
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to mark transaction as expired")
+		sentry.CaptureException(err)
+		return
+	}
+
+	seatmapChanges, err := s.releaseTransactionBooks(ctx, tx, transaction)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Str("orderNumber", transaction.OrderNumber).Msg("transaction expired")
+
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
+	return
+}
+
+// releaseTransactionBooks give back everything a pending transaction books: public stock, seats, garuda ids,
+// order information, price tier and voucher usage. Caller must relay the returned seatmap changes once tx is committed.
+// Shared by expiration and failed payment
+func (s *EventTransactionServiceImpl) releaseTransactionBooks(ctx context.Context, tx pgx.Tx, transaction model.EventTransaction) (seatmapChanges []model.Outbox, err error) {
+	transactionItems, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction items")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Int("ticketQuantity", transaction.TicketQuantity).Msg("release public stock ticket")
+	released, err := releaseItemsPublicStock(ctx, tx, s.EventTicketCategoryRepo, transaction, transactionItems)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release public stock ticket")
+		sentry.CaptureException(err)
+		return
+	}
+
+	// items of async order may not be written yet, the rest of the quantity belongs to the transaction category
+	if remaining := transaction.TicketQuantity - released; remaining > 0 {
+		err = s.EventTicketCategoryRepo.ReleasePublicTicketById(ctx, tx, transaction.EventID, transaction.TicketCategoryID, remaining)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release public stock ticket")
+			sentry.CaptureException(err)
+			return
+		}
+	}
+
+	log.Info().Msg("release seat books")
+	releasedSeats, err := s.EventSeatmapBookRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release seat books")
+		sentry.CaptureException(err)
+		return
+	}
+
+	seatmapChanges, err = sendReleasedSeats(ctx, tx, &s.SeatmapUseCase, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write seatmap change")
+		return
+	}
+
+	log.Info().Msg("release garuda id books")
+	err = s.EventTransactionGarudaIDRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release garuda id books")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Msg("release order information books")
+	err = s.EventOrderInformationBookRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release order information books")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Msg("release price tiers")
+	err = s.PriceTierRepo.ReleaseByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release price tiers")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Msg("release voucher usage")
+	err = s.VoucherRepo.DeleteUsageByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release voucher usage")
+		sentry.CaptureException(err)
+		return
+	}
+
+	return
+}
+
+// releaseItemsPublicStock give back public stock of each item to its own ticket category,
+// item without category belongs to the transaction category
+func releaseItemsPublicStock(ctx context.Context, tx pgx.Tx, ticketCategoryRepo repository.EventTicketCategoryRepository, transaction model.EventTransaction, items []model.EventTransactionItem) (released int, err error) {
+	var categoryIDs []string
+	quantities := make(map[string]int)
+	for _, item := range items {
+		categoryID := item.TicketCategoryID
+		if categoryID == "" {
+			categoryID = transaction.TicketCategoryID
+		}
+		if _, ok := quantities[categoryID]; !ok {
+			categoryIDs = append(categoryIDs, categoryID)
+		}
+		quantities[categoryID] += item.Quantity
+	}
+
+	// same lock order as cart order buy the stock
+	sort.Strings(categoryIDs)
+	for _, categoryID := range categoryIDs {
+		if quantities[categoryID] <= 0 {
+			continue
+		}
+
+		err = ticketCategoryRepo.ReleasePublicTicketById(ctx, tx, transaction.EventID, categoryID, quantities[categoryID])
+		if err != nil {
+			return
+		}
+		released += quantities[categoryID]
+	}
+
+	return
+}
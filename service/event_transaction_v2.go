@@ -8,9 +8,9 @@ import (
 	"assist-tix/lib"
 	"assist-tix/model"
 	"bytes"
-	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
+	"strings"
 	"sync"
 	"time"
 
@@ -181,6 +181,16 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 	}
 	defer tx.Rollback(ctx)
 
+	log.Info().Msg("validate purchase limit of buyer")
+	limitParam := purchaseLimitParam{EventID: eventId, Email: req.Email, ClientIP: ctx.ClientIP(), Quantity: buyCount, HoldID: req.SeatHoldID}
+	if eventSettings.GarudaIdVerification {
+		limitParam.GarudaIDs = orderGarudaIDs(req.Items)
+	}
+	err = s.validatePurchaseLimit(ctx, tx, eventSettings, limitParam)
+	if err != nil {
+		return
+	}
+
 	log.Info().Str("eventId", eventId).Msg("validate email is booked in the event")
 	orderInformationBookId, err := s.EventOrderInformationBookRepo.CreateOrderInformation(ctx, tx, eventId, req.Email, req.Fullname)
 	if err != nil {
@@ -189,6 +199,14 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		return
 	}
 
+	// stock taken by the hold is bought again by the order below, hold is converted to the order seats in the same tx
+	if req.SeatHoldID != "" {
+		err = s.returnSeatHoldStock(ctx, tx, eventId, ticketCategoryId, req.SeatHoldID)
+		if err != nil {
+			return
+		}
+	}
+
 	log.Info().Str("eventId", eventId).Str("ticketCategoryId", ticketCategoryId).Msg("find ticket category by id and event id")
 	ticketCategory, err := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, tx, eventId, ticketCategoryId)
 	if err != nil {
@@ -221,6 +239,12 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		return
 	}
 
+	log.Info().Msg("apply price tier of ticket category")
+	priceTier, err := s.applyPriceTier(ctx, tx, &ticketCategory, buyCount)
+	if err != nil {
+		return
+	}
+
 	now := time.Now()
 	expiryOrder := now.Add(s.Env.Transaction.ExpirationDuration)
 	orderNumber := helper.GeneraeteOrderNumber()
@@ -235,14 +259,26 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		Status:      lib.PaymentStatusPending,
 
 		PaymentMethod:    req.PaymentMethod,
-		PaymentChannel:   lib.PaymentChannelPaylabs,
+		PaymentChannel:   strings.ToUpper(paymentMethod.PaymentChannel),
 		PaymentExpiredAt: expiryOrder,
+
+		TicketQuantity: buyCount,
+		ClientIP:       ctx.ClientIP(),
 	}
 
 	// If venue doesn't have seatmap it will always empty
 	var selectedSectorSeatmap map[string]entity.EventVenueSector
+	var seatParams []domain.SeatmapParam
 	if venueSector.HasSeatmap {
 		log.Info().Msg("venueSector in ticket category has seatmap")
+		// held seats are booked for the order, otherwise seats are auto assigned when tickets are issued
+		if req.SeatHoldID != "" {
+			log.Info().Str("seatHoldId", req.SeatHoldID).Msg("use held seats")
+			seatParams, selectedSectorSeatmap, err = s.useSeatHold(ctx, tx, eventId, ticketCategoryId, req.SeatHoldID, req.Email, eventSettings.SeatAttributesRequireDeclaration, req.Items)
+			if err != nil {
+				return
+			}
+		}
 		// var seatParams []domain.SeatmapParam
 		// for _, val := range req.Items {
 		// 	seatParams = append(seatParams, domain.SeatmapParam{
@@ -291,6 +327,7 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 	}
 
 	// TODO: Checking bulk garuda id
+	var garudaIds []string
 	if eventSettings.GarudaIdVerification {
 		// var hasAdult bool
 		// Verify garuda id
@@ -302,8 +339,6 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		// 	return res, &lib.ErrorGarudaIDAlreadyUsed
 		// }
 
-		var garudaIds []string
-
 		for _, val := range req.Items {
 			garudaIds = append(garudaIds, val.GarudaID)
 		}
@@ -326,6 +361,22 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 
 	// Calculate price
 	transaction.TotalPrice = ticketCategory.Price * len(req.Items)
+
+	var voucherRedemption voucherRedemption
+	if req.VoucherCode != "" {
+		voucherRedemption, err = s.redeemVoucher(ctx, tx, eventId, req.VoucherCode, req.Email, garudaIds, []voucherLine{
+			{TicketCategoryID: ticketCategoryId, TotalPrice: transaction.TotalPrice},
+		})
+		if err != nil {
+			return
+		}
+		transaction.VoucherID = voucherRedemption.Voucher.ID
+		transaction.VoucherCode = voucherRedemption.Voucher.Code
+		transaction.DiscountAmount = voucherRedemption.Total
+	}
+
+	// fees are counted from the price after discount
+	discountedPrice := transaction.TotalPrice - transaction.DiscountAmount
 	additionalFees, err := s.EventSettingRepo.FindAdditionalFee(ctx, nil, eventId)
 	if err != nil {
 		sentry.CaptureException(err)
@@ -338,14 +389,14 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		if fee.IsTax {
 			if fee.IsPercentage {
 				totalTaxPercentage += fee.Value
-				transaction.TotalTax += int(float64(transaction.TotalPrice) * fee.Value / 100)
+				transaction.TotalTax += int(float64(discountedPrice) * fee.Value / 100)
 			} else {
 				transaction.TotalTax += int(fee.Value)
 			}
 		} else {
 			if fee.IsPercentage {
 				totalAdminFeePercentage += fee.Value
-				transaction.TotalAdminFee += int(float64(transaction.TotalPrice) * fee.Value / 100)
+				transaction.TotalAdminFee += int(float64(discountedPrice) * fee.Value / 100)
 			} else {
 				transaction.TotalAdminFee += int(fee.Value)
 			}
@@ -364,11 +415,11 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 
 	transaction.AdminFeePercentage = float32(totalAdminFeePercentage)
 	transaction.TaxPercentage = float32(totalTaxPercentage)
-	transaction.GrandTotal = transaction.TotalPrice + transaction.TotalTax + transaction.TotalAdminFee
+	transaction.GrandTotal = discountedPrice + transaction.TotalTax + transaction.TotalAdminFee
 	// transaction.AdminFeePercentage = float32(eventSettings.AdminFeePercentage)
 	// log.Info().Int("TotalAdminFee", totalAdminFee).Float32("AdminFeePercentage", transaction.AdminFeePercentage).Msg("calculate admin fee")
 	pgAdditionalFee := 0
-	transaction.GrandTotal = transaction.TotalPrice + transaction.TotalTax + transaction.TotalAdminFee
+	transaction.GrandTotal = discountedPrice + transaction.TotalTax + transaction.TotalAdminFee
 	if paymentMethod.IsPercentage {
 		log.Info().Msg("payment method is percentage, calculating additional fee")
 		pgAdditionalFee = int(float64(transaction.GrandTotal) * paymentMethod.AdditionalFee / 100)
@@ -401,8 +452,45 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 	transaction.ID = transactionRes.ID
 	transaction.CreatedAt = transactionRes.CreatedAt
 
+	err = s.recordPriceTier(ctx, tx, transaction.ID, priceTier, buyCount)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
+
+	if transaction.VoucherID != "" {
+		err = s.createVoucherUsage(ctx, tx, voucherRedemption, transaction, garudaIds)
+		if err != nil {
+			sentry.CaptureException(err)
+			return
+		}
+	}
+
+	// Link books to transaction, so it can be released when transaction is expired
+	err = s.EventOrderInformationBookRepo.UpdateTransactionIdByID(ctx, tx, orderInformationBookId, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update transaction id of order information book")
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = s.EventTransactionGarudaIDRepo.UpdateTransactionIdByGarudaIds(ctx, tx, eventId, transaction.ID, garudaIds...)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update transaction id of garuda id books")
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = s.EventSeatmapBookRepo.UpdateTransactionIdBySeats(ctx, tx, eventId, ticketCategory.VenueSectorId, transaction.ID, seatParams)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update transaction id of seat books")
+		sentry.CaptureException(err)
+		return
+	}
+
 	var transactionItems []model.EventTransactionItem
-	for _, item := range req.Items {
+	itemPrices := discountedItemPrices(ticketCategory.Price, len(req.Items), transaction.DiscountAmount)
+	for i, item := range req.Items {
 		var garudaId sql.NullString = helper.ToSQLString(item.GarudaID)
 
 		var fullName sql.NullString
@@ -427,14 +515,15 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		}
 
 		transactionItem := model.EventTransactionItem{
-			TransactionID: transaction.ID,
-			// TicketCategoryID:      ticketCategoryId,
+			TransactionID:    transaction.ID,
+			TicketCategoryID: ticketCategoryId,
 
 			Quantity: 1,
 
 			SeatRow:    item.SeatRow,
 			SeatColumn: item.SeatColumn,
 			SeatLabel:  seatLabel,
+			SeatNeeds:  item.SeatNeeds,
 
 			GarudaID:    garudaId,
 			Fullname:    fullName,
@@ -442,7 +531,7 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 			PhoneNumber: phoneNumber,
 
 			AdditionalInformation: sql.NullString{String: item.AdditionalInformation},
-			TotalPrice:            ticketCategory.Price,
+			TotalPrice:            itemPrices[i],
 
 			CreatedAt: transaction.CreatedAt,
 		}
@@ -451,12 +540,6 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 	}
 	log.Info().Str("transactionId", transaction.ID).Int("count", len(transactionItems)).Msg("create transaction item")
 
-	err = tx.Commit(ctx)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to commit transaction")
-		sentry.CaptureException(err)
-		return
-	}
 	accessToken, err := helper.GenerateAccessToken(s.Env, transaction.ID)
 	if err != nil {
 		sentry.CaptureException(err)
@@ -464,7 +547,9 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		return
 	}
 
-	err = s.TransactionUseCase.SendAsyncOrder(ctx,
+	// written with the order, so the order is never committed without its async order
+	asyncOrder, err := s.TransactionUseCase.SendAsyncOrder(ctx,
+		tx,
 		eventSettings.GarudaIdVerification,
 		len(transactionItems),
 		accessToken,
@@ -474,13 +559,36 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		ticketCategory,
 		venueSector,
 		transactionItems,
+		false,
 		ctx.ClientIP(),
 		orderInformationBookId)
 	if err != nil {
 		sentry.CaptureException(err)
-		log.Warn().Err(err).Msg("error send async order to nats")
+		log.Error().Err(err).Msg("error write async order to outbox")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
 		return
 	}
+	s.OutboxRelay.RelayMessage(ctx, asyncOrder)
+	if req.SeatHoldID != "" {
+		s.deleteSeatHold(ctx, req.SeatHoldID)
+	}
+
+	// Kickin check status transaction
+	marginTimeReleaseData := 30 * time.Second
+
+	err = s.CheckStatusTransactionJob.EnqueueCheckTransaction(ctx, transaction.ID, s.Env.Transaction.ExpirationDuration+marginTimeReleaseData, s.Env.Asynq.ProcessTimeout)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.Error().Err(err).Str("TransactionId", transaction.ID).Msg("failed to kick job check status transaction")
+		return
+	}
+
 	// set via cookie
 	// helper.SetAccessToken(ctx, accessToken)
 	// TODO ADD JWT
@@ -488,6 +596,8 @@ func (s *EventTransactionServiceImpl) CreateEventTransactionV2(ctx *gin.Context,
 		OrderNumber:        orderNumber,
 		PaymentMethod:      req.PaymentMethod,
 		TotalPrice:         transaction.TotalPrice,
+		VoucherCode:        transaction.VoucherCode,
+		DiscountAmount:     transaction.DiscountAmount,
 		TaxPercentage:      transaction.TaxPercentage,
 		TotalTax:           transaction.TotalTax,
 		AdminFeePercentage: transaction.AdminFeePercentage,
@@ -535,20 +645,26 @@ func (s *EventTransactionServiceImpl) CallbackVASnapV2(ctx *gin.Context, req dto
 	json.Compact(&buf, []byte(rawPayload))
 	log.Info().Msgf("Raw Payload: %s", buf.String())
 	log.Info().Msgf("Request URL: %v", req)
-	isValid := helper.IsValidPaylabsRequest(ctx, "/transfer-va/payment", buf.String(), s.Env.Paylabs.PublicKey)
-	if !isValid {
-		return dto.CallbackSnapResponse{}, &lib.ErrorCallbackSignatureInvalid
+	err = s.verifyPaymentCallback(ctx, lib.PaymentChannelPaylabs, lib.PaymentGroupVirtualAccount, buf.Bytes())
+	if err != nil {
+		return dto.CallbackSnapResponse{}, err
+	}
+	if req.TrxId == nil {
+		return res, &lib.ErrorOrderNotFound
 	}
 	//  actual callback processing
-	transactionData, err := s.EventTransactionRepo.FindByOrderNumber(ctx, tx, *req.TrxId)
+	transactionData, duplicate, err := s.guardPaymentCallback(ctx, tx, paymentCallbackParam{
+		OrderNumber:      *req.TrxId,
+		PaymentReference: req.PaymentRequestId,
+		IsSuccess:        true,
+		PaidAmount:       req.PaidAmount.Value,
+		Currency:         req.PaidAmount.Currency,
+	})
 	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to find transaction by order number")
 		return
 	}
-	if transactionData.ID == "" {
-		log.Error().Msg("Transaction not found")
-		return res, &lib.ErrorOrderNotFound
+	if duplicate {
+		return s.snapCallbackResponse(ctx, transactionData, req), nil
 	}
 
 	// MOVE TO ASYNC
@@ -580,28 +696,25 @@ func (s *EventTransactionServiceImpl) CallbackVASnapV2(ctx *gin.Context, req dto
 	}
 	log.Info().Msgf("Transaction time: %v", transactionTime)
 
-	markResult, err := s.EventTransactionRepo.MarkTransactionStatus(ctx, tx, transactionData.ID, lib.EventTransactionStatusProcessingTicket, transactionTime, req.PaymentRequestId)
+	markResult, settleOutboxes, err := s.settlePayment(ctx, tx, transactionData.ID, paymentSettlement{
+		IsSuccess:        true,
+		PaidAt:           transactionTime,
+		PaymentReference: req.PaymentRequestId,
+		Actor:            lib.EventTransactionActorCallback,
+		Reason:           "va payment success",
+	})
 	if err != nil {
 		sentry.CaptureException(err)
 		log.Error().Err(err).Msg("Failed to mark transaction as success")
 		return
 	}
 
-	err = s.TransactionUseCase.SendAsyncCallback(ctx,
-		transactionData.ID,
-		transactionTime,
-	)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.Warn().Err(err).Msg("error send async callback to nats")
-		return
-	}
-
 	err = tx.Commit(ctx)
 	if err != nil {
 		sentry.CaptureException(err)
 		return
 	}
+	relayOutboxes(ctx, s.OutboxRelay, settleOutboxes)
 
 	// MOVE TO ASYNC ORDER
 	// sent invoice email to users with goroutine
@@ -742,6 +855,13 @@ func (s *EventTransactionServiceImpl) CallbackVASnapV2(ctx *gin.Context, req dto
 	// 	}
 	// }()
 
+	log.Info().Msgf("Transaction marked as success: %v", markResult)
+
+	return s.snapCallbackResponse(ctx, transactionData, req), nil
+}
+
+// snapCallbackResponse build success response for VA snap callback, also used to acknowledge duplicate callback
+func (s *EventTransactionServiceImpl) snapCallbackResponse(ctx *gin.Context, transactionData model.EventTransaction, req dto.SnapCallbackPaymentRequest) (res dto.CallbackSnapResponse) {
 	serviceCode := "25"
 	caseCode := "00"
 	res.ResponseCode = "200" + serviceCode + caseCode
@@ -756,7 +876,6 @@ func (s *EventTransactionServiceImpl) CallbackVASnapV2(ctx *gin.Context, req dto
 
 	ctx.Header("Content-Type", "application/json")
 	ctx.Header("X-TIMESTAMP", time.Now().Format("2006-01-02T15:04:05.999+07:00"))
-	log.Info().Msgf("Transaction marked as success: %v", markResult)
 
 	return
 }
@@ -792,9 +911,9 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 	json.Compact(&buf, []byte(rawPayload))
 	log.Info().Msgf("Raw Payload: %s", buf.String())
 	log.Info().Msgf("Request URL: %v", req)
-	isValid := helper.IsValidPaylabsRequest(ctx, ctx.FullPath(), buf.String(), s.Env.Paylabs.PublicKey)
-	if !isValid {
-		return res, &lib.ErrorCallbackSignatureInvalid
+	err = s.verifyPaymentCallback(ctx, lib.PaymentChannelPaylabs, req.PaymentType, buf.Bytes())
+	if err != nil {
+		return res, err
 	}
 	var isSuccess bool
 	if req.Status == "02" {
@@ -803,16 +922,15 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 		isSuccess = false
 	}
 	//  actual callback processing
-	transactionData, err := s.EventTransactionRepo.FindByOrderNumber(ctx, tx, req.MerchantTradeNo)
+	transactionData, duplicate, err := s.guardPaymentCallback(ctx, tx, paymentCallbackParam{
+		OrderNumber:      req.MerchantTradeNo,
+		PaymentReference: req.PaymentMethodInfo.RRN,
+		IsSuccess:        isSuccess,
+		PaidAmount:       req.Amount,
+	})
 	if err != nil {
-		sentry.CaptureException(err)
-		log.Error().Err(err).Msg("Failed to find transaction by order number")
 		return
 	}
-	if transactionData.ID == "" {
-		log.Error().Msg("Transaction not found")
-		return res, &lib.ErrorOrderNotFound
-	}
 
 	layout := "20060102150405"
 	loc, err := time.LoadLocation("Asia/Jakarta")
@@ -823,8 +941,13 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 	}
 
 	// Parse the time string in Asia/Jakarta location
-	var markResult model.EventTransaction
-	if isSuccess {
+	var (
+		markResult     model.EventTransaction
+		settleOutboxes []model.Outbox
+	)
+	if duplicate {
+		markResult = transactionData
+	} else if isSuccess {
 		paidAt, errConvertTime := time.ParseInLocation(layout, req.SuccessTime, loc)
 		if errConvertTime != nil {
 			log.Error().Err(errConvertTime).Msg("Failed to parse transaction time")
@@ -833,27 +956,26 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 		transactionData.PaidAt = &paidAt
 		log.Info().Msgf("Transaction time: %v", paidAt)
 
-		// markResult, err = s.EventTransactionRepo.MarkTransactionAsSuccess(ctx, tx, transactionData.ID, t, req.PaymentMethodInfo.RRN)
-		markResult, err = s.EventTransactionRepo.MarkTransactionStatus(ctx, tx, transactionData.ID, lib.EventTransactionStatusProcessingTicket, paidAt, req.PaymentMethodInfo.RRN)
+		markResult, settleOutboxes, err = s.settlePayment(ctx, tx, transactionData.ID, paymentSettlement{
+			IsSuccess:        true,
+			PaidAt:           paidAt,
+			PaymentReference: req.PaymentMethodInfo.RRN,
+			Actor:            lib.EventTransactionActorCallback,
+			Reason:           "qris payment success",
+		})
 		if err != nil {
 			sentry.CaptureException(err)
 			log.Error().Err(err).Msg("Failed to mark transaction as success")
 			return
 		}
 		isSuccess = true
-
-		err = s.TransactionUseCase.SendAsyncCallback(ctx,
-			transactionData.ID,
-			paidAt,
-		)
-		if err != nil {
-			sentry.CaptureException(err)
-			log.Warn().Err(err).Msg("error send async callback to nats")
-			return
-		}
-
 	} else {
-		markResult, err = s.EventTransactionRepo.MarkTransactionAsFailed(ctx, tx, transactionData.ID, req.PaymentMethodInfo.RRN)
+		markResult, settleOutboxes, err = s.settlePayment(ctx, tx, transactionData.ID, paymentSettlement{
+			IsSuccess:        false,
+			PaymentReference: req.PaymentMethodInfo.RRN,
+			Actor:            lib.EventTransactionActorCallback,
+			Reason:           "qris payment failed",
+		})
 		if err != nil {
 			sentry.CaptureException(err)
 			log.Error().Err(err).Msg("Failed to mark transaction as failed")
@@ -880,13 +1002,10 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 		log.Error().Err(err).Msg("Failed to commit transaction")
 		return
 	}
+	relayOutboxes(ctx, s.OutboxRelay, settleOutboxes)
 
 	// sent email to users
 	// -----------------signature recipe----------
-	date := time.Now().Format("2006-01-02T15:04:05.999+07:00")
-	path := ctx.FullPath()
-	partnerID := s.Env.Paylabs.AccountID
-	privateKey := s.Env.Paylabs.PrivateKey
 	requestID := helper.GenerateRequestID()
 	// -----------------signature recipe----------
 	log.Info().Msgf("Transaction marked as success: %v", markResult)
@@ -1029,14 +1148,11 @@ func (s *EventTransactionServiceImpl) CallbackQRISPaylabsV2(ctx *gin.Context, re
 		// }()
 
 	}
-	// Hash the JSON body
-	shaJson := sha256.Sum256(jsonData)
-	signature := helper.GenerateSignature(shaJson, path, date, privateKey)
-	ctx.Header("X-PARTNER-ID", partnerID)
-	ctx.Header("X-REQUEST-ID", requestID)
-	ctx.Header("X-TIMESTAMP", date)
-	ctx.Header("X-SIGNATURE", signature)
-	ctx.Header("Content-Type", "application/json;charset=utf-8")
+	err = s.signPaymentCallbackResponse(ctx, lib.PaymentChannelPaylabs, requestID, jsonData)
+	if err != nil {
+		sentry.CaptureException(err)
+		return
+	}
 	log.Info().Msg("success process callback qris paylabs")
 	return
 
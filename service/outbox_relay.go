@@ -0,0 +1,175 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/internal/domain"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// OutboxRelay publish outbox messages to nats. Message is published with its message id,
+// so publishing it again after a crash between publish and mark is dropped by jetstream
+type OutboxRelay interface {
+	// Relay publish due pending messages, run by worker scheduler
+	Relay(ctx context.Context) (published int, err error)
+	// RelayMessage publish message right after its transaction is committed, failure is left to Relay
+	RelayMessage(ctx context.Context, outbox model.Outbox)
+}
+
+type OutboxRelayImpl struct {
+	DB             *database.WrapDB
+	Env            *config.EnvironmentVariable
+	OutboxRepo     repository.OutboxRepository
+	EventPublisher domain.EventPublisher
+}
+
+func NewOutboxRelay(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	outboxRepo repository.OutboxRepository,
+	publisher domain.EventPublisher,
+) OutboxRelay {
+	return &OutboxRelayImpl{
+		DB:             db,
+		Env:            env,
+		OutboxRepo:     outboxRepo,
+		EventPublisher: publisher,
+	}
+}
+
+func (s *OutboxRelayImpl) Relay(ctx context.Context) (published int, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	messages, err := s.OutboxRepo.FindDueForUpdate(ctx, tx, s.Env.Outbox.BatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find due outbox messages")
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	for _, message := range messages {
+		var ok bool
+		ok, err = s.publish(ctx, tx, message)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			published++
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		return 0, err
+	}
+
+	log.Info().Int("due", len(messages)).Int("published", published).Msg("outbox relayed")
+	return
+}
+
+func (s *OutboxRelayImpl) RelayMessage(ctx context.Context, outbox model.Outbox) {
+	if outbox.ID == 0 {
+		return
+	}
+
+	err := s.relayMessage(ctx, outbox.ID)
+	if err != nil {
+		log.Warn().Err(err).Int64("outboxId", outbox.ID).Msg("failed to relay outbox message, left to outbox relay job")
+	}
+}
+
+func (s *OutboxRelayImpl) relayMessage(ctx context.Context, id int64) (err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	message, found, err := s.OutboxRepo.FindByIdForUpdate(ctx, tx, id)
+	if err != nil || !found {
+		// not found means it's published or being relayed by another relay
+		return
+	}
+
+	_, err = s.publish(ctx, tx, message)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(ctx)
+}
+
+// publish message and record the attempt, err is only returned when the attempt can't be recorded
+func (s *OutboxRelayImpl) publish(ctx context.Context, tx pgx.Tx, message model.Outbox) (ok bool, err error) {
+	publishCtx, cancel := context.WithTimeout(ctx, s.Env.Outbox.PublishTimeout)
+	errPublish := s.EventPublisher.PublishMsg(publishCtx, message.Subject, message.MessageID, message.Payload)
+	cancel()
+
+	if errPublish == nil {
+		err = s.OutboxRepo.MarkPublished(ctx, tx, message.ID)
+		if err != nil {
+			log.Error().Err(err).Int64("outboxId", message.ID).Msg("failed to mark outbox message as published")
+			return
+		}
+
+		log.Info().Int64("outboxId", message.ID).Str("subject", message.Subject).Str("messageId", message.MessageID).Msg("outbox message published")
+		return true, nil
+	}
+
+	attempts := message.Attempts + 1
+	status := lib.OutboxStatusPending
+	if attempts >= s.Env.Outbox.MaxAttempts {
+		status = lib.OutboxStatusFailed
+	}
+
+	err = s.OutboxRepo.MarkAttemptFailed(ctx, tx, message.ID, status, errPublish.Error(), time.Now().Add(s.retryInterval(attempts)))
+	if err != nil {
+		log.Error().Err(err).Int64("outboxId", message.ID).Msg("failed to record outbox publish attempt")
+		return
+	}
+
+	if status == lib.OutboxStatusFailed {
+		log.Error().Err(errPublish).Int64("outboxId", message.ID).Str("subject", message.Subject).Int("attempts", attempts).Msg("outbox message reached max attempts")
+		sentry.CaptureException(errPublish)
+		return
+	}
+
+	log.Warn().Err(errPublish).Int64("outboxId", message.ID).Str("subject", message.Subject).Int("attempts", attempts).Msg("failed to publish outbox message")
+	return
+}
+
+// retryInterval double the interval on each failed attempt
+func (s *OutboxRelayImpl) retryInterval(attempts int) time.Duration {
+	interval := s.Env.Outbox.RetryInterval
+	for i := 1; i < attempts && interval < s.Env.Outbox.MaxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > s.Env.Outbox.MaxRetryInterval {
+		interval = s.Env.Outbox.MaxRetryInterval
+	}
+
+	return interval
+}
+
+// relayOutboxes publish outbox messages right after their transaction is committed
+func relayOutboxes(ctx context.Context, relay OutboxRelay, outboxes []model.Outbox) {
+	for _, outbox := range outboxes {
+		relay.RelayMessage(ctx, outbox)
+	}
+}
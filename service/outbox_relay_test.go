@@ -0,0 +1,66 @@
+package service
+
+import (
+	"assist-tix/config"
+	"testing"
+	"time"
+)
+
+func TestOutboxRelayRetryInterval(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryInterval    time.Duration
+		maxRetryInterval time.Duration
+		attempts         int
+		want             time.Duration
+	}{
+		{
+			name:             "first failed attempt",
+			retryInterval:    10 * time.Second,
+			maxRetryInterval: 5 * time.Minute,
+			attempts:         1,
+			want:             10 * time.Second,
+		},
+		{
+			name:             "doubled on each failed attempt",
+			retryInterval:    10 * time.Second,
+			maxRetryInterval: 5 * time.Minute,
+			attempts:         4,
+			want:             80 * time.Second,
+		},
+		{
+			name:             "capped at max retry interval",
+			retryInterval:    10 * time.Second,
+			maxRetryInterval: 5 * time.Minute,
+			attempts:         6,
+			want:             5 * time.Minute,
+		},
+		{
+			name:             "many attempts stay at max retry interval",
+			retryInterval:    10 * time.Second,
+			maxRetryInterval: 5 * time.Minute,
+			attempts:         100,
+			want:             5 * time.Minute,
+		},
+		{
+			name:             "retry interval above max",
+			retryInterval:    10 * time.Minute,
+			maxRetryInterval: 5 * time.Minute,
+			attempts:         1,
+			want:             5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &config.EnvironmentVariable{}
+			env.Outbox.RetryInterval = tt.retryInterval
+			env.Outbox.MaxRetryInterval = tt.maxRetryInterval
+
+			relay := &OutboxRelayImpl{Env: env}
+			if got := relay.retryInterval(tt.attempts); got != tt.want {
+				t.Errorf("retryInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,19 @@ package service
 import (
 	"assist-tix/config"
 	"assist-tix/database"
+	"assist-tix/dto"
+	"assist-tix/lib"
 	"assist-tix/model"
 	"assist-tix/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
@@ -12,12 +23,18 @@ import (
 
 type PaymentLogsService interface {
 	Create(ctx *gin.Context, request, header, response, errCode, errResponse string) (res model.PaymentLog, err error)
+	FindFailedCallbacks(ctx context.Context, since time.Time, transactionID string, limit int) (res []dto.PaymentLogResponse, err error)
+	ReplayCallback(ctx context.Context, paymentLogID int, skipSignatureVerification bool) (res dto.ReplayPaymentCallbackResponse, err error)
+	SetCallbackDispatcher(dispatcher http.Handler)
 }
 
 type PaymentLogsServiceImpl struct {
 	DB              *database.WrapDB
 	Env             *config.EnvironmentVariable
 	PaymentLogsRepo repository.PaymentLogRepository
+
+	// router which serve the callback endpoints, set once router is built
+	CallbackDispatcher http.Handler
 }
 
 func NewPaymentLogsService(
@@ -32,6 +49,23 @@ func NewPaymentLogsService(
 	}
 }
 
+type paymentCallbackReplayKey struct{}
+
+// paymentCallbackReplay is carried by request context of replayed callback, it can't be set from outside
+type paymentCallbackReplay struct {
+	PaymentLogID              int
+	SkipSignatureVerification bool
+}
+
+func paymentCallbackReplayFrom(ctx *gin.Context) (replay paymentCallbackReplay, ok bool) {
+	if ctx.Request == nil {
+		return
+	}
+
+	replay, ok = ctx.Request.Context().Value(paymentCallbackReplayKey{}).(paymentCallbackReplay)
+	return
+}
+
 func (s *PaymentLogsServiceImpl) Create(ctx *gin.Context, request, header, response, errCode, errResponse string) (res model.PaymentLog, err error) {
 	paymentLog := model.PaymentLog{
 		Header:        header,
@@ -40,6 +74,16 @@ func (s *PaymentLogsServiceImpl) Create(ctx *gin.Context, request, header, respo
 		Path:          ctx.FullPath(),
 		ErrorCode:     errCode,
 		ErrorResponse: errResponse,
+
+		// set by callback processing, see EventTransactionServiceImpl.guardPaymentCallback
+		TransactionID:    ctx.GetString(lib.PaymentLogTransactionIDKey),
+		PaymentReference: ctx.GetString(lib.PaymentLogReferenceKey),
+		Decision:         ctx.GetString(lib.PaymentLogDecisionKey),
+	}
+
+	if replay, ok := paymentCallbackReplayFrom(ctx); ok {
+		paymentLog.ReplayOfID = &replay.PaymentLogID
+		paymentLog.SignatureSkipped = replay.SkipSignatureVerification
 	}
 
 	res, err = s.PaymentLogsRepo.Create(ctx, nil, paymentLog)
@@ -50,3 +94,118 @@ func (s *PaymentLogsServiceImpl) Create(ctx *gin.Context, request, header, respo
 
 	return
 }
+
+func (s *PaymentLogsServiceImpl) SetCallbackDispatcher(dispatcher http.Handler) {
+	s.CallbackDispatcher = dispatcher
+}
+
+func (s *PaymentLogsServiceImpl) FindFailedCallbacks(ctx context.Context, since time.Time, transactionID string, limit int) (res []dto.PaymentLogResponse, err error) {
+	paymentLogs, err := s.PaymentLogsRepo.FindFailed(ctx, nil, since, transactionID, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find failed payment callbacks")
+		return
+	}
+
+	res = make([]dto.PaymentLogResponse, 0, len(paymentLogs))
+	for _, paymentLog := range paymentLogs {
+		res = append(res, toPaymentLogResponse(paymentLog))
+	}
+
+	return
+}
+
+// ReplayCallback re-drive logged failed callback through the current callback handler with its original header and body.
+// The replay is logged as a new payment log pointing to the replayed one
+func (s *PaymentLogsServiceImpl) ReplayCallback(ctx context.Context, paymentLogID int, skipSignatureVerification bool) (res dto.ReplayPaymentCallbackResponse, err error) {
+	paymentLog, err := s.PaymentLogsRepo.FindById(ctx, nil, paymentLogID)
+	if err != nil {
+		log.Error().Err(err).Int("paymentLogId", paymentLogID).Msg("failed to find payment log")
+		return
+	}
+
+	if !strings.HasPrefix(paymentLog.Path, s.paymentCallbackPathPrefix()) {
+		return res, &lib.ErrorPaymentLogNotReplayable
+	}
+
+	// accepted callback without error may never be applied, replay of applied one is acknowledged as duplicate
+	if paymentLog.ErrorCode == "" && paymentLog.Decision != lib.CallbackDecisionAccepted {
+		return res, &lib.ErrorPaymentLogNotFailed
+	}
+
+	replayCtx := context.WithValue(ctx, paymentCallbackReplayKey{}, paymentCallbackReplay{
+		PaymentLogID:              paymentLogID,
+		SkipSignatureVerification: skipSignatureVerification,
+	})
+
+	req, err := http.NewRequestWithContext(replayCtx, http.MethodPost, paymentLog.Path, bytes.NewReader([]byte(paymentLog.Body)))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build replay request")
+		return
+	}
+
+	// header is logged as gin request header, header logged before the callback is parsed may be empty
+	var header map[string][]string
+	if paymentLog.Header != "" {
+		err = json.Unmarshal([]byte(paymentLog.Header), &header)
+		if err != nil {
+			log.Error().Err(err).Int("paymentLogId", paymentLogID).Msg("failed to parse logged header")
+			return
+		}
+	}
+	for key, values := range header {
+		if strings.EqualFold(key, "Content-Length") {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	log.Info().Int("paymentLogId", paymentLogID).Str("path", paymentLog.Path).Bool("skipSignatureVerification", skipSignatureVerification).Msg("replay payment callback")
+
+	recorder := httptest.NewRecorder()
+	s.CallbackDispatcher.ServeHTTP(recorder, req)
+
+	res = dto.ReplayPaymentCallbackResponse{
+		ReplayOfID:       paymentLogID,
+		StatusCode:       recorder.Code,
+		Response:         recorder.Body.String(),
+		SignatureSkipped: skipSignatureVerification,
+	}
+
+	log.Info().Int("paymentLogId", paymentLogID).Int("statusCode", recorder.Code).Msg("payment callback replayed")
+
+	return
+}
+
+// paymentCallbackPathPrefix is path of external router, built the same way router mounts it.
+// Only callback endpoints are replayable, other logged path must not be re-driven from payment logs
+func (s *PaymentLogsServiceImpl) paymentCallbackPathPrefix() string {
+	apiPath := "/api"
+	if s.Env.Api.BasePath != "/" {
+		apiPath = path.Join(s.Env.Api.BasePath, apiPath)
+	}
+
+	return path.Join(apiPath, "v1", "external") + "/"
+}
+
+func toPaymentLogResponse(paymentLog model.PaymentLog) dto.PaymentLogResponse {
+	id, _ := strconv.Atoi(paymentLog.ID)
+
+	return dto.PaymentLogResponse{
+		ID:               id,
+		Path:             paymentLog.Path,
+		Header:           paymentLog.Header,
+		Body:             paymentLog.Body,
+		Response:         paymentLog.Response,
+		ErrorCode:        paymentLog.ErrorCode,
+		ErrorResponse:    paymentLog.ErrorResponse,
+		TransactionID:    paymentLog.TransactionID,
+		PaymentReference: paymentLog.PaymentReference,
+		Decision:         paymentLog.Decision,
+		ReplayOfID:       paymentLog.ReplayOfID,
+		SignatureSkipped: paymentLog.SignatureSkipped,
+		IsResolved:       paymentLog.IsResolved,
+		CreatedAt:        paymentLog.CreatedAt,
+	}
+}
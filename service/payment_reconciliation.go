@@ -0,0 +1,264 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/internal/domain/payment"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog/log"
+)
+
+// ReconcileTransactions ask payment gateway for the status of pending transaction and recently expired transaction,
+// so lost callback doesn't leave paid order expired or stuck on pending. Every discrepancy found is reported once per local status.
+func (s *EventTransactionServiceImpl) ReconcileTransactions(ctx context.Context) (res dto.PaymentReconciliationReport, err error) {
+	res.Discrepancies = make([]dto.PaymentReconciliationResponse, 0)
+	if !s.Env.Paylabs.ActivePayment {
+		log.Info().Msg("payment is not active, skip reconciliation")
+		return
+	}
+
+	// pending and expired get their own batch, so expired transaction can't crowd out pending one
+	now := time.Now()
+	candidates, err := s.EventTransactionRepo.FindPendingReconciliationCandidates(ctx, nil, now.Add(-s.Env.Reconciliation.PendingAge), s.Env.Reconciliation.BatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find pending reconciliation candidates")
+		sentry.CaptureException(err)
+		return
+	}
+
+	expiredCandidates, err := s.EventTransactionRepo.FindExpiredReconciliationCandidates(ctx, nil, now.Add(-s.Env.Reconciliation.ExpiredLookback), s.Env.Reconciliation.BatchSize)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find expired reconciliation candidates")
+		sentry.CaptureException(err)
+		return
+	}
+	candidates = append(candidates, expiredCandidates...)
+	log.Info().Int("candidates", len(candidates)).Msg("start payment reconciliation")
+
+	var needAction int
+	for _, candidate := range candidates {
+		result, errReconcile := s.reconcileTransaction(ctx, candidate)
+		if errReconcile != nil {
+			// keep going, the transaction is picked again on next run
+			log.Error().Err(errReconcile).Str("transactionId", candidate.ID).Msg("failed to reconcile transaction")
+			sentry.CaptureException(errReconcile)
+			continue
+		}
+		res.Checked++
+
+		if result.Result == lib.ReconciliationResultInSync {
+			continue
+		}
+		if result.Result == lib.ReconciliationResultPaidAfterExpired || result.Result == lib.ReconciliationResultAmountMismatch {
+			needAction++
+		}
+		res.Discrepancies = append(res.Discrepancies, toPaymentReconciliationResponse(result))
+	}
+
+	log.Info().Int("checked", res.Checked).Int("discrepancies", len(res.Discrepancies)).Int("needAction", needAction).Msg("payment reconciliation done")
+	if needAction > 0 {
+		sentry.CaptureMessage("payment reconciliation found " + strconv.Itoa(needAction) + " transaction need manual action")
+	}
+
+	return
+}
+
+// ReconcileTransaction reconcile single transaction on demand, result is returned even when it's in sync
+func (s *EventTransactionServiceImpl) ReconcileTransaction(ctx context.Context, orderNumber string) (res dto.PaymentReconciliationResponse, err error) {
+	transaction, err := s.EventTransactionRepo.FindByOrderNumber(ctx, nil, orderNumber)
+	if err != nil {
+		log.Error().Err(err).Str("orderNumber", orderNumber).Msg("failed to find transaction by order number")
+		return
+	}
+	if transaction.ID == "" {
+		return res, &lib.ErrorOrderNotFound
+	}
+
+	result, err := s.reconcileTransaction(ctx, transaction)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transaction.ID).Msg("failed to reconcile transaction")
+		return
+	}
+
+	return toPaymentReconciliationResponse(result), nil
+}
+
+func (s *EventTransactionServiceImpl) FindPaymentReconciliations(ctx context.Context, since time.Time, limit int) (res []dto.PaymentReconciliationResponse, err error) {
+	reconciliations, err := s.PaymentReconciliationRepo.FindSince(ctx, nil, since, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find payment reconciliations")
+		return
+	}
+
+	res = make([]dto.PaymentReconciliationResponse, 0, len(reconciliations))
+	for _, reconciliation := range reconciliations {
+		res = append(res, toPaymentReconciliationResponse(reconciliation))
+	}
+
+	return
+}
+
+// reconcileTransaction compare gateway status with the locked transaction and settle it through the same path as callback.
+// err is only returned when reconciliation can't be done, gateway failure is reported as INQUIRY_FAILED instead
+func (s *EventTransactionServiceImpl) reconcileTransaction(ctx context.Context, transaction model.EventTransaction) (res model.PaymentReconciliation, err error) {
+	res = model.PaymentReconciliation{
+		TransactionID: transaction.ID,
+		OrderNumber:   transaction.OrderNumber,
+		PaymentMethod: transaction.PaymentMethod,
+		LocalStatus:   transaction.Status,
+		GatewayStatus: lib.PaymentStatusUnknown,
+		GrandTotal:    transaction.GrandTotal,
+		Result:        lib.ReconciliationResultInSync,
+	}
+
+	// inquiry is done before locking, so the row isn't locked while waiting for gateway
+	inquiry, errInquiry := s.inquirePayment(ctx, transaction)
+	if errInquiry != nil {
+		log.Warn().Err(errInquiry).Str("transactionId", transaction.ID).Msg("failed to inquire payment status")
+		res.Result = lib.ReconciliationResultInquiryFailed
+		res.Message = helper.ToSQLString(errInquiry.Error())
+		return s.reportReconciliation(ctx, res)
+	}
+	res.GatewayStatus = inquiry.Status
+	res.GatewayRawStatus = helper.ToSQLString(inquiry.RawStatus)
+	res.PaidAmount = inquiry.PaidAmount
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := s.EventTransactionRepo.FindByOrderNumberForUpdate(ctx, tx, transaction.OrderNumber)
+	if err != nil {
+		log.Error().Err(err).Str("orderNumber", transaction.OrderNumber).Msg("failed to lock transaction")
+		return
+	}
+	res.LocalStatus = locked.Status
+
+	var (
+		expire         bool
+		settleOutboxes []model.Outbox
+	)
+	switch {
+	case inquiry.Status == lib.PaymentStatusSuccess && locked.Status == lib.EventTransactionStatusPending:
+		_, _, errCheck := checkPaymentResult(locked, paymentCallbackParam{
+			OrderNumber:      locked.OrderNumber,
+			PaymentReference: inquiry.PGOrderID,
+			IsSuccess:        true,
+			PaidAmount:       strconv.Itoa(inquiry.PaidAmount),
+		})
+		if errCheck != nil {
+			res.Result = lib.ReconciliationResultAmountMismatch
+			res.Message = helper.ToSQLString(errCheck.Error())
+			break
+		}
+
+		paidAt := time.Now()
+		if inquiry.PaidAt != nil {
+			paidAt = *inquiry.PaidAt
+		}
+
+		_, settleOutboxes, err = s.settlePayment(ctx, tx, locked.ID, paymentSettlement{
+			IsSuccess:        true,
+			PaidAt:           paidAt,
+			PaymentReference: inquiry.PGOrderID,
+			Actor:            lib.EventTransactionActorReconciliation,
+			Reason:           "payment success confirmed by gateway inquiry",
+		})
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", locked.ID).Msg("failed to settle paid transaction")
+			return
+		}
+		res.Result = lib.ReconciliationResultMarkedPaid
+	case inquiry.Status == lib.PaymentStatusSuccess && locked.Status == lib.EventTransactionStatusExpired:
+		// seats and stock are already released, can't be reopened automatically
+		res.Result = lib.ReconciliationResultPaidAfterExpired
+		res.Message = helper.ToSQLString("payment received after transaction expired, need refund or manual issuance")
+	case inquiry.Status == lib.PaymentStatusFailed && locked.Status == lib.EventTransactionStatusPending:
+		_, settleOutboxes, err = s.settlePayment(ctx, tx, locked.ID, paymentSettlement{
+			IsSuccess:        false,
+			PaymentReference: inquiry.PGOrderID,
+			Actor:            lib.EventTransactionActorReconciliation,
+			Reason:           "payment failed confirmed by gateway inquiry",
+		})
+		if err != nil {
+			log.Error().Err(err).Str("transactionId", locked.ID).Msg("failed to settle failed transaction")
+			return
+		}
+		res.Result = lib.ReconciliationResultMarkedFailed
+	case locked.Status == lib.EventTransactionStatusPending && time.Now().After(locked.PaymentExpiredAt):
+		// not paid and expiry job missed it
+		expire = true
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		return
+	}
+	relayOutboxes(ctx, s.OutboxRelay, settleOutboxes)
+
+	if expire {
+		err = s.ExpireTransaction(ctx, locked.ID)
+		if err != nil {
+			return
+		}
+		res.Result = lib.ReconciliationResultExpired
+		res.Message = helper.ToSQLString("unpaid transaction was not expired by expiry job")
+	}
+
+	if res.Result == lib.ReconciliationResultInSync {
+		return
+	}
+
+	log.Warn().Str("transactionId", res.TransactionID).Str("localStatus", res.LocalStatus).Str("gatewayStatus", res.GatewayStatus).Str("result", res.Result).Msg("payment reconciliation discrepancy")
+	return s.reportReconciliation(ctx, res)
+}
+
+func (s *EventTransactionServiceImpl) inquirePayment(ctx context.Context, transaction model.EventTransaction) (res payment.InquiryResponse, err error) {
+	paymentGateway, err := s.PaymentGateways.Get(transaction.PaymentChannel)
+	if err != nil {
+		return
+	}
+
+	return paymentGateway.InquireStatus(ctx, payment.InquiryRequest{
+		TransactionID: transaction.ID,
+		OrderNumber:   transaction.OrderNumber,
+		PaymentMethod: transaction.PaymentMethod,
+		PGOrderID:     transaction.ChannelTransactionID,
+	})
+}
+
+func (s *EventTransactionServiceImpl) reportReconciliation(ctx context.Context, req model.PaymentReconciliation) (res model.PaymentReconciliation, err error) {
+	res, err = s.PaymentReconciliationRepo.Create(ctx, nil, req)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", req.TransactionID).Msg("failed to report payment reconciliation")
+		return
+	}
+
+	return
+}
+
+func toPaymentReconciliationResponse(reconciliation model.PaymentReconciliation) dto.PaymentReconciliationResponse {
+	return dto.PaymentReconciliationResponse{
+		TransactionID:    reconciliation.TransactionID,
+		OrderNumber:      reconciliation.OrderNumber,
+		PaymentMethod:    reconciliation.PaymentMethod,
+		LocalStatus:      reconciliation.LocalStatus,
+		GatewayStatus:    reconciliation.GatewayStatus,
+		GatewayRawStatus: reconciliation.GatewayRawStatus.String,
+		GrandTotal:       reconciliation.GrandTotal,
+		PaidAmount:       reconciliation.PaidAmount,
+		Result:           reconciliation.Result,
+		Message:          reconciliation.Message.String,
+		CreatedAt:        reconciliation.CreatedAt,
+	}
+}
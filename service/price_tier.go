@@ -0,0 +1,112 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/model"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// resolvePriceTier return the first tier by start time which is running at now and still has stock for quantity.
+// Category price is used when no tier is found
+func resolvePriceTier(tiers []model.EventTicketCategoryPriceTier, now time.Time, quantity int) (res model.EventTicketCategoryPriceTier, found bool) {
+	for _, tier := range tiers {
+		if now.Before(tier.StartAt) || !now.Before(tier.EndAt) {
+			continue
+		}
+		if tier.StockLimit.Valid && int(tier.StockLimit.Int32)-tier.Sold < quantity {
+			continue
+		}
+		return tier, true
+	}
+
+	return
+}
+
+// nextPriceChangeAt return the nearest time the resolved tier may change, by the end of current tier or start of another tier
+func nextPriceChangeAt(tiers []model.EventTicketCategoryPriceTier, now time.Time) *time.Time {
+	var next *time.Time
+	for _, tier := range tiers {
+		for _, at := range []time.Time{tier.StartAt, tier.EndAt} {
+			if at.After(now) && (next == nil || at.Before(*next)) {
+				next = &at
+			}
+		}
+	}
+
+	return next
+}
+
+func toPriceTierResponse(tier model.EventTicketCategoryPriceTier) dto.TicketCategoryPriceTierResponse {
+	res := dto.TicketCategoryPriceTierResponse{
+		ID:      tier.ID,
+		Name:    tier.Name,
+		Price:   tier.Price,
+		StartAt: tier.StartAt,
+		EndAt:   tier.EndAt,
+		Sold:    tier.Sold,
+	}
+	if tier.StockLimit.Valid {
+		stockLimit := int(tier.StockLimit.Int32)
+		remaining := max(stockLimit-tier.Sold, 0)
+		res.StockLimit = &stockLimit
+		res.RemainingStock = &remaining
+	}
+
+	return res
+}
+
+// currentPriceTier resolve price shown for a single ticket of category
+func currentPriceTier(tiers []model.EventTicketCategoryPriceTier, categoryPrice int, now time.Time) (price int, tier *dto.TicketCategoryPriceTierResponse, changeAt *time.Time) {
+	price = categoryPrice
+	if resolved, found := resolvePriceTier(tiers, now, 1); found {
+		price = resolved.Price
+		tierRes := toPriceTierResponse(resolved)
+		tier = &tierRes
+	}
+
+	return price, tier, nextPriceChangeAt(tiers, now)
+}
+
+// applyPriceTier buy the active tier of category for quantity and set category price to the tier price.
+// Returned tier is empty when category price is used
+func (s *EventTransactionServiceImpl) applyPriceTier(ctx context.Context, tx pgx.Tx, ticketCategory *model.EventTicketCategory, quantity int) (res model.EventTicketCategoryPriceTier, err error) {
+	tiers, err := s.PriceTierRepo.FindByTicketCategoryId(ctx, tx, ticketCategory.ID)
+	if err != nil {
+		log.Error().Err(err).Str("ticketCategoryId", ticketCategory.ID).Msg("failed to find price tiers")
+		return
+	}
+
+	tier, found := resolvePriceTier(tiers, time.Now(), quantity)
+	if !found {
+		return
+	}
+
+	err = s.PriceTierRepo.Buy(ctx, tx, tier.ID, quantity)
+	if err != nil {
+		log.Warn().Err(err).Str("priceTierId", tier.ID).Int("quantity", quantity).Msg("failed to buy price tier")
+		return
+	}
+
+	log.Info().Str("ticketCategoryId", ticketCategory.ID).Str("priceTierId", tier.ID).Str("priceTier", tier.Name).Int("price", tier.Price).Msg("price tier applied")
+	ticketCategory.Price = tier.Price
+	return tier, nil
+}
+
+// recordPriceTier link bought tier to transaction, so it's given back when the transaction expired
+func (s *EventTransactionServiceImpl) recordPriceTier(ctx context.Context, tx pgx.Tx, transactionID string, tier model.EventTicketCategoryPriceTier, quantity int) (err error) {
+	if tier.ID == "" {
+		return
+	}
+
+	err = s.PriceTierRepo.CreateTransactionPriceTier(ctx, tx, transactionID, tier, quantity)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Str("priceTierId", tier.ID).Msg("failed to record price tier of transaction")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/repository"
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// purchaseLimitParam is the buyer of an order or seat hold, quantity is the tickets it adds for email and ip.
+// Every garuda id adds one ticket for itself. Seats of HoldID are already in the quantity of the order using it
+type purchaseLimitParam struct {
+	EventID   string
+	Email     string
+	ClientIP  string
+	GarudaIDs []string
+	Quantity  int
+	HoldID    string
+}
+
+func (s *EventTransactionServiceImpl) validatePurchaseLimit(ctx context.Context, tx pgx.Tx, settings dto.EventSettings, param purchaseLimitParam) (err error) {
+	return validatePurchaseLimit(ctx, tx, s.EventTransactionRepo, s.EventSeatmapBookRepo, settings, param)
+}
+
+// validatePurchaseLimit check tickets of buyer across non expired transactions and active seat holds of event with the new order don't exceed the event limits.
+// Must be called inside the order or hold transaction before it's created, buyer keys stay locked until it's committed
+func validatePurchaseLimit(ctx context.Context, tx pgx.Tx, transactionRepo repository.EventTransactionRepository, seatBookRepo repository.EventSeatmapBookRepository, settings dto.EventSettings, param purchaseLimitParam) (err error) {
+	keyPrefix := "purchase-limit:" + param.EventID + ":"
+
+	var keys []string
+	email := strings.ToLower(param.Email)
+	if settings.MaxTicketPerEmail > 0 && email != "" {
+		keys = append(keys, keyPrefix+"email:"+email)
+	}
+	if settings.MaxTicketPerIP > 0 && param.ClientIP != "" {
+		keys = append(keys, keyPrefix+"ip:"+param.ClientIP)
+	}
+	if settings.MaxTicketPerGarudaID > 0 {
+		for _, garudaID := range param.GarudaIDs {
+			keys = append(keys, keyPrefix+"garuda:"+garudaID)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	err = transactionRepo.LockPurchaseLimit(ctx, tx, keys...)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", param.EventID).Msg("failed to lock purchase limit")
+		return
+	}
+
+	if settings.MaxTicketPerEmail > 0 && email != "" {
+		count, errCount := transactionRepo.CountActiveTicketByEmail(ctx, tx, param.EventID, email)
+		if errCount != nil {
+			log.Error().Err(errCount).Str("eventId", param.EventID).Msg("failed to count tickets of email")
+			return errCount
+		}
+		held, errCount := seatBookRepo.CountActiveHoldsByEmail(ctx, tx, param.EventID, email, param.HoldID)
+		if errCount != nil {
+			log.Error().Err(errCount).Str("eventId", param.EventID).Msg("failed to count held seats of email")
+			return errCount
+		}
+		count += held
+		if count+param.Quantity > settings.MaxTicketPerEmail {
+			log.Warn().Str("eventId", param.EventID).Str("email", email).Int("count", count).Int("quantity", param.Quantity).Int("limit", settings.MaxTicketPerEmail).Msg("purchase limit of email is reached")
+			return &lib.ErrorPurchaseLimitEmailReached
+		}
+	}
+
+	if settings.MaxTicketPerIP > 0 && param.ClientIP != "" {
+		count, errCount := transactionRepo.CountActiveTicketByClientIP(ctx, tx, param.EventID, param.ClientIP)
+		if errCount != nil {
+			log.Error().Err(errCount).Str("eventId", param.EventID).Msg("failed to count tickets of client ip")
+			return errCount
+		}
+		held, errCount := seatBookRepo.CountActiveHoldsByClientIP(ctx, tx, param.EventID, param.ClientIP, param.HoldID)
+		if errCount != nil {
+			log.Error().Err(errCount).Str("eventId", param.EventID).Msg("failed to count held seats of client ip")
+			return errCount
+		}
+		count += held
+		if count+param.Quantity > settings.MaxTicketPerIP {
+			log.Warn().Str("eventId", param.EventID).Str("clientIp", param.ClientIP).Int("count", count).Int("quantity", param.Quantity).Int("limit", settings.MaxTicketPerIP).Msg("purchase limit of client ip is reached")
+			return &lib.ErrorPurchaseLimitIPReached
+		}
+	}
+
+	if settings.MaxTicketPerGarudaID > 0 && len(param.GarudaIDs) > 0 {
+		counts, errCount := transactionRepo.CountActiveTicketByGarudaIds(ctx, tx, param.EventID, param.GarudaIDs...)
+		if errCount != nil {
+			log.Error().Err(errCount).Str("eventId", param.EventID).Msg("failed to count tickets of garuda ids")
+			return errCount
+		}
+		for _, garudaID := range param.GarudaIDs {
+			if counts[garudaID]+1 > settings.MaxTicketPerGarudaID {
+				log.Warn().Str("eventId", param.EventID).Str("garudaId", garudaID).Int("count", counts[garudaID]).Int("limit", settings.MaxTicketPerGarudaID).Msg("purchase limit of garuda id is reached")
+				return &lib.ErrorPurchaseLimitGarudaIDReached
+			}
+		}
+	}
+
+	return
+}
+
+func orderGarudaIDs(items []dto.OrderItemEventTransaction) (res []string) {
+	for _, item := range items {
+		res = append(res, item.GarudaID)
+	}
+
+	return
+}
@@ -0,0 +1,644 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/dto"
+	internalDomain "assist-tix/internal/domain"
+	"assist-tix/internal/domain/payment"
+	"assist-tix/internal/usecase"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type RefundService interface {
+	RequestRefund(ctx context.Context, transactionID string, req dto.RefundTransactionRequest) (res dto.EventTransactionRefundResponse, err error)
+	CompleteManualRefund(ctx context.Context, refundID int) (res dto.EventTransactionRefundResponse, err error)
+	ResolveRefund(ctx context.Context, refundID int, req dto.ResolveRefundRequest) (res dto.EventTransactionRefundResponse, err error)
+	FindRefunds(ctx context.Context, transactionID string) (res []dto.EventTransactionRefundResponse, err error)
+}
+
+type RefundServiceImpl struct {
+	DB                            *database.WrapDB
+	Env                           *config.EnvironmentVariable
+	EventRepo                     repository.EventRepository
+	EventTicketCategoryRepo       repository.EventTicketCategoryRepository
+	EventTransactionRepo          repository.EventTransactionRepository
+	EventTransactionItemRepo      repository.EventTransactionItemRepository
+	EventTransactionRefundRepo    repository.EventTransactionRefundRepository
+	EventSeatmapBookRepo          repository.EventSeatmapBookRepository
+	EventTransactionGarudaIDRepo  repository.EventTransactionGarudaIDRepository
+	EventOrderInformationBookRepo repository.EventOrderInformationBookRepository
+	EventTicketRepo               repository.EventTicketRepository
+	SeatmapUseCase                usecase.SeatmapUsecase
+	OutboxRelay                   OutboxRelay
+
+	PaymentGateways internalDomain.PaymentGateways
+}
+
+func NewRefundService(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventRepo repository.EventRepository,
+	eventTicketCategoryRepo repository.EventTicketCategoryRepository,
+	eventTransactionRepo repository.EventTransactionRepository,
+	eventTransactionItemRepo repository.EventTransactionItemRepository,
+	eventTransactionRefundRepo repository.EventTransactionRefundRepository,
+	eventSeatmapBookRepo repository.EventSeatmapBookRepository,
+	eventTransactionGarudaIDRepo repository.EventTransactionGarudaIDRepository,
+	eventOrderInformationBookRepo repository.EventOrderInformationBookRepository,
+	eventTicketRepo repository.EventTicketRepository,
+	seatmapUseCase usecase.SeatmapUsecase,
+	outboxRelay OutboxRelay,
+	paymentGateways internalDomain.PaymentGateways,
+) RefundService {
+	return &RefundServiceImpl{
+		DB:                            db,
+		Env:                           env,
+		EventRepo:                     eventRepo,
+		EventTicketCategoryRepo:       eventTicketCategoryRepo,
+		EventTransactionRepo:          eventTransactionRepo,
+		EventTransactionItemRepo:      eventTransactionItemRepo,
+		EventTransactionRefundRepo:    eventTransactionRefundRepo,
+		EventSeatmapBookRepo:          eventSeatmapBookRepo,
+		EventTransactionGarudaIDRepo:  eventTransactionGarudaIDRepo,
+		EventOrderInformationBookRepo: eventOrderInformationBookRepo,
+		EventTicketRepo:               eventTicketRepo,
+		SeatmapUseCase:                seatmapUseCase,
+		OutboxRelay:                   outboxRelay,
+		PaymentGateways:               paymentGateways,
+	}
+}
+
+// RequestRefund refund the whole remaining amount when no item is given, otherwise only the given items.
+// Refund is reserved first so concurrent request is rejected, then gateway is called outside db transaction.
+func (s *RefundServiceImpl) RequestRefund(ctx context.Context, transactionID string, req dto.RefundTransactionRequest) (res dto.EventTransactionRefundResponse, err error) {
+	refund, transaction, err := s.reserveRefund(ctx, transactionID, req)
+	if err != nil {
+		return
+	}
+
+	status := lib.RefundStatusSuccess
+	var pgRefundID, failureMessage string
+
+	paymentGateway, err := s.PaymentGateways.Get(transaction.PaymentChannel)
+	if err == nil {
+		var refundRes payment.RefundResponse
+		refundRes, err = paymentGateway.Refund(ctx, payment.RefundRequest{
+			TransactionID: transaction.ID,
+			OrderNumber:   transaction.OrderNumber,
+			RefundNumber:  refund.RefundNumber,
+			PaymentMethod: transaction.PaymentMethod,
+			PGOrderID:     transaction.ChannelTransactionID,
+			Amount:        transaction.GrandTotal,
+			RefundAmount:  refund.Amount,
+			Reason:        refund.Reason,
+		})
+		pgRefundID = refundRes.PGRefundID
+	}
+
+	if err != nil {
+		if errors.Is(err, &lib.ErrorPaymentOperationNotSupported) {
+			log.Warn().Str("refundNumber", refund.RefundNumber).Str("paymentMethod", transaction.PaymentMethod).Msg("gateway can't refund this payment method, refund must be transferred manually")
+			status = lib.RefundStatusManualRequired
+		} else {
+			log.Error().Err(err).Str("refundNumber", refund.RefundNumber).Msg("failed to refund payment on gateway")
+			sentry.CaptureException(err)
+			status = lib.RefundStatusFailed
+			failureMessage = err.Error()
+		}
+		err = nil
+	}
+
+	refund, err = s.finishRefund(ctx, refund.ID, time.Time{}, status, pgRefundID, failureMessage)
+	if err != nil {
+		return
+	}
+
+	res = toEventTransactionRefundResponse(refund)
+	return
+}
+
+// reserveRefund validate the request and store it as PROCESSING refund, so its items can't be refunded twice
+func (s *RefundServiceImpl) reserveRefund(ctx context.Context, transactionID string, req dto.RefundTransactionRequest) (refund model.EventTransactionRefund, transaction model.EventTransaction, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	transaction, err = s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction")
+		return
+	}
+
+	if transaction.Status != lib.EventTransactionStatusSuccess {
+		err = &lib.ErrorRefundTransactionNotPaid
+		return
+	}
+
+	eventStatus, err := s.EventRepo.FindStatusById(ctx, tx, transaction.EventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", transaction.EventID).Msg("failed to find event status")
+		return
+	}
+
+	if eventStatus != lib.EventStatusCanceled && eventStatus != lib.EventStatusPostponed {
+		err = &lib.ErrorRefundEventNotEligible
+		return
+	}
+
+	refunds, err := s.EventTransactionRefundRepo.FindByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction refunds")
+		return
+	}
+
+	items, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction items")
+		return
+	}
+
+	refund, err = buildRefund(transaction, refunds, items, req)
+	if err != nil {
+		return
+	}
+
+	refund, err = s.EventTransactionRefundRepo.Create(ctx, tx, refund)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transaction.ID).Msg("failed to create refund")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Str("refundNumber", refund.RefundNumber).Int("amount", refund.Amount).Msg("refund reserved")
+	return
+}
+
+// buildRefund count refund of the request from the transaction, its refunds and items. Items of refund which isn't failed
+// can't be refunded again, and refunds of the transaction never exceed its grand total
+func buildRefund(transaction model.EventTransaction, refunds []model.EventTransactionRefund, items []model.EventTransactionItem, req dto.RefundTransactionRequest) (refund model.EventTransactionRefund, err error) {
+	refundedItems := make(map[int]bool)
+	reservedAmount := 0
+	for _, r := range refunds {
+		switch r.Status {
+		case lib.RefundStatusProcessing:
+			err = &lib.ErrorRefundInProgress
+			return
+		case lib.RefundStatusFailed:
+			continue
+		}
+
+		reservedAmount += r.Amount
+		for _, item := range r.Items {
+			refundedItems[item.TransactionItemID] = true
+		}
+	}
+
+	refund = model.EventTransactionRefund{
+		TransactionID: transaction.ID,
+		RefundNumber:  fmt.Sprintf("%s-R%d", transaction.OrderNumber, len(refunds)+1),
+		RefundType:    lib.RefundTypeItem,
+		Reason:        req.Reason,
+		Status:        lib.RefundStatusProcessing,
+		Items:         make([]model.EventTransactionRefundItem, 0),
+	}
+
+	if len(req.ItemIDs) == 0 {
+		// full refund also return admin fee and tax, so it take the remaining paid amount instead of item price
+		refund.RefundType = lib.RefundTypeFull
+		refund.Amount = transaction.GrandTotal - reservedAmount
+		for _, item := range items {
+			if refundedItems[item.ID] {
+				continue
+			}
+			refund.Items = append(refund.Items, model.EventTransactionRefundItem{
+				TransactionItemID: item.ID,
+				Amount:            item.TotalPrice,
+			})
+		}
+	} else {
+		for _, itemID := range req.ItemIDs {
+			idx := slices.IndexFunc(items, func(item model.EventTransactionItem) bool {
+				return item.ID == itemID
+			})
+			if idx < 0 {
+				err = &lib.ErrorRefundItemNotFound
+				return
+			}
+
+			if refundedItems[itemID] {
+				err = &lib.ErrorRefundItemAlreadyRefunded
+				return
+			}
+			refundedItems[itemID] = true
+
+			refund.Amount += items[idx].TotalPrice
+			refund.Items = append(refund.Items, model.EventTransactionRefundItem{
+				TransactionItemID: itemID,
+				Amount:            items[idx].TotalPrice,
+			})
+		}
+	}
+
+	if refund.Amount <= 0 || reservedAmount+refund.Amount > transaction.GrandTotal {
+		err = &lib.ErrorRefundAmountExceeded
+		return
+	}
+
+	return
+}
+
+// finishRefund store gateway result. Once refund is accepted (success or manual), tickets of refunded items are invalidated
+// and, unless event is canceled, stock and seats are released to be sold again. Non zero staleBefore only finishes refund created before it
+func (s *RefundServiceImpl) finishRefund(ctx context.Context, refundID int, staleBefore time.Time, status, pgRefundID, failureMessage string) (refund model.EventTransactionRefund, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	refund, err = s.EventTransactionRefundRepo.FindByIdForUpdate(ctx, tx, refundID)
+	if err != nil {
+		log.Error().Err(err).Int("refundId", refundID).Msg("failed to find refund")
+		return
+	}
+
+	// refund may be resolved by admin while gateway call was still running
+	if refund.Status != lib.RefundStatusProcessing {
+		err = &lib.ErrorRefundNotProcessing
+		return
+	}
+
+	if !staleBefore.IsZero() && refund.CreatedAt.After(staleBefore) {
+		err = &lib.ErrorRefundNotStale
+		return
+	}
+
+	transaction, err := s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, refund.TransactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", refund.TransactionID).Msg("failed to find transaction")
+		return
+	}
+
+	err = s.EventTransactionRefundRepo.UpdateStatus(ctx, tx, refund.ID, status, pgRefundID, failureMessage)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update refund status")
+		sentry.CaptureException(err)
+		return
+	}
+
+	var seatmapChanges []model.Outbox
+	if status == lib.RefundStatusFailed {
+		err = s.EventTransactionRefundRepo.DeleteItemsByRefundId(ctx, tx, refund.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release refund items")
+			sentry.CaptureException(err)
+			return
+		}
+	} else {
+		seatmapChanges, err = s.releaseRefundedItems(ctx, tx, transaction, refund)
+		if err != nil {
+			return
+		}
+	}
+
+	err = s.updateTransactionRefund(ctx, tx, transaction.ID)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Str("refundNumber", refund.RefundNumber).Str("status", status).Msg("refund finished")
+
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
+	refund, err = s.findRefund(ctx, nil, transaction.ID, refund.ID)
+	return
+}
+
+// releaseRefundedItems invalidate tickets of refunded items and give back their stock and seats.
+// Returned outbox messages are seatmap changes of released seats, relayed once tx is committed
+func (s *RefundServiceImpl) releaseRefundedItems(ctx context.Context, tx pgx.Tx, transaction model.EventTransaction, refund model.EventTransactionRefund) (seatmapChanges []model.Outbox, err error) {
+	reason := fmt.Sprintf("refund %s", refund.RefundNumber)
+
+	refund, err = s.findRefund(ctx, tx, transaction.ID, refund.ID)
+	if err != nil {
+		return
+	}
+
+	items, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transaction.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction items")
+		return
+	}
+
+	refundedItems := make([]model.EventTransactionItem, 0, len(refund.Items))
+	for _, refundItem := range refund.Items {
+		idx := slices.IndexFunc(items, func(item model.EventTransactionItem) bool {
+			return item.ID == refundItem.TransactionItemID
+		})
+		if idx >= 0 {
+			refundedItems = append(refundedItems, items[idx])
+		}
+	}
+
+	log.Info().Str("transactionId", transaction.ID).Int("itemCount", len(refundedItems)).Msg("invalidate refunded tickets")
+	if refund.RefundType == lib.RefundTypeFull {
+		_, err = s.EventTicketRepo.InvalidateByTransactionId(ctx, tx, transaction.ID, reason)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to invalidate tickets")
+			sentry.CaptureException(err)
+			return
+		}
+	} else {
+		for _, item := range refundedItems {
+			_, err = s.EventTicketRepo.InvalidateOneByOwner(ctx, tx, transaction.ID, item.Email.String, item.Fullname.String, item.SeatRow, item.SeatColumn, reason)
+			if err != nil {
+				log.Error().Err(err).Int("transactionItemId", item.ID).Msg("failed to invalidate ticket")
+				sentry.CaptureException(err)
+				return
+			}
+		}
+	}
+
+	eventStatus, err := s.EventRepo.FindStatusById(ctx, tx, transaction.EventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", transaction.EventID).Msg("failed to find event status")
+		return
+	}
+
+	// canceled event won't be sold anymore, no need to return the stock
+	if eventStatus == lib.EventStatusCanceled {
+		return
+	}
+
+	log.Info().Int("itemCount", len(refundedItems)).Msg("release public stock ticket")
+	_, err = releaseItemsPublicStock(ctx, tx, s.EventTicketCategoryRepo, transaction, refundedItems)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release public stock ticket")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Msg("release seat books")
+	if refund.RefundType == lib.RefundTypeFull {
+		var releasedSeats []model.EventSeatmapBook
+		releasedSeats, err = s.EventSeatmapBookRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release seat books")
+			sentry.CaptureException(err)
+			return
+		}
+
+		seatmapChanges, err = sendReleasedSeats(ctx, tx, &s.SeatmapUseCase, releasedSeats)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to write seatmap change")
+			return
+		}
+
+		log.Info().Msg("release garuda id books")
+		err = s.EventTransactionGarudaIDRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release garuda id books")
+			sentry.CaptureException(err)
+			return
+		}
+
+		log.Info().Msg("release order information books")
+		err = s.EventOrderInformationBookRepo.DeleteByTransactionId(ctx, tx, transaction.ID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to release order information books")
+			sentry.CaptureException(err)
+			return
+		}
+
+		return
+	}
+
+	var releasedSeats []model.EventSeatmapBook
+	for _, item := range refundedItems {
+		if item.SeatRow == 0 && item.SeatColumn == 0 {
+			continue
+		}
+
+		var released []model.EventSeatmapBook
+		released, err = s.EventSeatmapBookRepo.DeleteByTransactionIdAndSeat(ctx, tx, transaction.ID, item.SeatRow, item.SeatColumn)
+		if err != nil {
+			log.Error().Err(err).Int("transactionItemId", item.ID).Msg("failed to release seat book")
+			sentry.CaptureException(err)
+			return
+		}
+		releasedSeats = append(releasedSeats, released...)
+	}
+
+	seatmapChanges, err = sendReleasedSeats(ctx, tx, &s.SeatmapUseCase, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write seatmap change")
+		return
+	}
+
+	return
+}
+
+// updateTransactionRefund recalculate refund progress of transaction from its refunds.
+// Only successful refund count as refunded, manual refund is counted once it is completed
+func (s *RefundServiceImpl) updateTransactionRefund(ctx context.Context, tx pgx.Tx, transactionID string) (err error) {
+	refunds, err := s.EventTransactionRefundRepo.FindByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction refunds")
+		return
+	}
+
+	items, err := s.EventTransactionItemRepo.GetTransactionItemsByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find transaction items")
+		return
+	}
+
+	refundedAmount := 0
+	refundedItems := make(map[int]bool)
+	for _, refund := range refunds {
+		if refund.Status != lib.RefundStatusSuccess {
+			continue
+		}
+
+		refundedAmount += refund.Amount
+		for _, item := range refund.Items {
+			refundedItems[item.TransactionItemID] = true
+		}
+	}
+
+	refundStatus := lib.EventTransactionRefundStatusNone
+	if refundedAmount > 0 {
+		refundStatus = lib.EventTransactionRefundStatusPartiallyRefunded
+		if len(refundedItems) == len(items) {
+			refundStatus = lib.EventTransactionRefundStatusRefunded
+		}
+	}
+
+	err = s.EventTransactionRepo.UpdateRefund(ctx, tx, transactionID, refundedAmount, refundStatus)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to update transaction refund")
+		sentry.CaptureException(err)
+		return
+	}
+
+	return
+}
+
+// CompleteManualRefund mark refund which is transferred manually by finance as success
+func (s *RefundServiceImpl) CompleteManualRefund(ctx context.Context, refundID int) (res dto.EventTransactionRefundResponse, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		sentry.CaptureException(err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	refund, err := s.EventTransactionRefundRepo.FindByIdForUpdate(ctx, tx, refundID)
+	if err != nil {
+		log.Error().Err(err).Int("refundId", refundID).Msg("failed to find refund")
+		return
+	}
+
+	if refund.Status != lib.RefundStatusManualRequired {
+		err = &lib.ErrorRefundNotManual
+		return
+	}
+
+	// lock transaction so its refund progress is recalculated one at a time
+	_, err = s.EventTransactionRepo.FindByIdForUpdate(ctx, tx, refund.TransactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", refund.TransactionID).Msg("failed to find transaction")
+		return
+	}
+
+	err = s.EventTransactionRefundRepo.UpdateStatus(ctx, tx, refund.ID, lib.RefundStatusSuccess, "", "")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update refund status")
+		sentry.CaptureException(err)
+		return
+	}
+
+	err = s.updateTransactionRefund(ctx, tx, refund.TransactionID)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit transaction")
+		sentry.CaptureException(err)
+		return
+	}
+
+	log.Info().Str("transactionId", refund.TransactionID).Str("refundNumber", refund.RefundNumber).Msg("manual refund completed")
+
+	refund, err = s.findRefund(ctx, nil, refund.TransactionID, refund.ID)
+	if err != nil {
+		return
+	}
+
+	res = toEventTransactionRefundResponse(refund)
+	return
+}
+
+// ResolveRefund finish refund which is stuck in processing with the result admin got from gateway,
+// so the transaction can be refunded again. Only stale refund is resolved to not race the running request
+func (s *RefundServiceImpl) ResolveRefund(ctx context.Context, refundID int, req dto.ResolveRefundRequest) (res dto.EventTransactionRefundResponse, err error) {
+	log.Warn().Int("refundId", refundID).Str("status", req.Status).Msg("resolve stale refund")
+
+	refund, err := s.finishRefund(ctx, refundID, time.Now().Add(-s.Env.Refund.StaleAfter), req.Status, req.PGRefundID, req.FailureMessage)
+	if err != nil {
+		return
+	}
+
+	res = toEventTransactionRefundResponse(refund)
+	return
+}
+
+func (s *RefundServiceImpl) FindRefunds(ctx context.Context, transactionID string) (res []dto.EventTransactionRefundResponse, err error) {
+	refunds, err := s.EventTransactionRefundRepo.FindByTransactionId(ctx, nil, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction refunds")
+		return
+	}
+
+	res = make([]dto.EventTransactionRefundResponse, 0, len(refunds))
+	for _, refund := range refunds {
+		res = append(res, toEventTransactionRefundResponse(refund))
+	}
+
+	return
+}
+
+func (s *RefundServiceImpl) findRefund(ctx context.Context, tx pgx.Tx, transactionID string, refundID int) (res model.EventTransactionRefund, err error) {
+	refunds, err := s.EventTransactionRefundRepo.FindByTransactionId(ctx, tx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction refunds")
+		return
+	}
+
+	idx := slices.IndexFunc(refunds, func(refund model.EventTransactionRefund) bool {
+		return refund.ID == refundID
+	})
+	if idx < 0 {
+		return res, &lib.ErrorRefundNotFound
+	}
+
+	return refunds[idx], nil
+}
+
+func toEventTransactionRefundResponse(refund model.EventTransactionRefund) dto.EventTransactionRefundResponse {
+	items := make([]dto.EventTransactionRefundItemResponse, 0, len(refund.Items))
+	for _, item := range refund.Items {
+		items = append(items, dto.EventTransactionRefundItemResponse{
+			TransactionItemID: item.TransactionItemID,
+			Amount:            item.Amount,
+		})
+	}
+
+	return dto.EventTransactionRefundResponse{
+		ID:             refund.ID,
+		TransactionID:  refund.TransactionID,
+		RefundNumber:   refund.RefundNumber,
+		RefundType:     refund.RefundType,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+		Status:         refund.Status,
+		PGRefundID:     refund.PGRefundID.String,
+		FailureMessage: refund.FailureMessage.String,
+		Items:          items,
+		CreatedAt:      refund.CreatedAt,
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// refundItem is transaction item id and refunded amount of a refund item
+type refundItem [2]int
+
+func refundItemsOf(refund model.EventTransactionRefund) []refundItem {
+	items := make([]refundItem, 0, len(refund.Items))
+	for _, item := range refund.Items {
+		items = append(items, refundItem{item.TransactionItemID, item.Amount})
+	}
+	return items
+}
+
+func refundOf(status string, amount int, itemIDs ...int) model.EventTransactionRefund {
+	refund := model.EventTransactionRefund{Status: status, Amount: amount}
+	for _, itemID := range itemIDs {
+		refund.Items = append(refund.Items, model.EventTransactionRefundItem{TransactionItemID: itemID, Amount: 100000})
+	}
+	return refund
+}
+
+func TestBuildRefund(t *testing.T) {
+	// three items of 100000, grand total include admin fee and tax
+	transaction := model.EventTransaction{ID: "trx", OrderNumber: "ORD-1", GrandTotal: 330000}
+	items := []model.EventTransactionItem{
+		{ID: 1, TotalPrice: 100000},
+		{ID: 2, TotalPrice: 100000},
+		{ID: 3, TotalPrice: 100000},
+	}
+
+	tests := []struct {
+		name       string
+		refunds    []model.EventTransactionRefund
+		itemIDs    []int
+		wantType   string
+		wantAmount int
+		wantNumber string
+		wantItems  []refundItem
+		wantErr    error
+	}{
+		{
+			name:       "full refund return grand total",
+			wantType:   lib.RefundTypeFull,
+			wantAmount: 330000,
+			wantNumber: "ORD-1-R1",
+			wantItems:  []refundItem{{1, 100000}, {2, 100000}, {3, 100000}},
+		},
+		{
+			name:       "full refund after item refund return the remaining amount",
+			refunds:    []model.EventTransactionRefund{refundOf(lib.RefundStatusSuccess, 100000, 1)},
+			wantType:   lib.RefundTypeFull,
+			wantAmount: 230000,
+			wantNumber: "ORD-1-R2",
+			wantItems:  []refundItem{{2, 100000}, {3, 100000}},
+		},
+		{
+			name:       "item refund",
+			itemIDs:    []int{1, 3},
+			wantType:   lib.RefundTypeItem,
+			wantAmount: 200000,
+			wantNumber: "ORD-1-R1",
+			wantItems:  []refundItem{{1, 100000}, {3, 100000}},
+		},
+		{
+			name:       "item of failed refund can be refunded again",
+			refunds:    []model.EventTransactionRefund{refundOf(lib.RefundStatusFailed, 100000, 1)},
+			itemIDs:    []int{1},
+			wantType:   lib.RefundTypeItem,
+			wantAmount: 100000,
+			wantNumber: "ORD-1-R2",
+			wantItems:  []refundItem{{1, 100000}},
+		},
+		{
+			name:    "item of manual refund is already refunded",
+			refunds: []model.EventTransactionRefund{refundOf(lib.RefundStatusManualRequired, 100000, 2)},
+			itemIDs: []int{2},
+			wantErr: &lib.ErrorRefundItemAlreadyRefunded,
+		},
+		{
+			name:    "same item twice in request",
+			itemIDs: []int{1, 1},
+			wantErr: &lib.ErrorRefundItemAlreadyRefunded,
+		},
+		{
+			name:    "item of another transaction",
+			itemIDs: []int{4},
+			wantErr: &lib.ErrorRefundItemNotFound,
+		},
+		{
+			name:    "refund in progress",
+			refunds: []model.EventTransactionRefund{refundOf(lib.RefundStatusProcessing, 100000, 1)},
+			itemIDs: []int{2},
+			wantErr: &lib.ErrorRefundInProgress,
+		},
+		{
+			name:    "fully refunded transaction",
+			refunds: []model.EventTransactionRefund{refundOf(lib.RefundStatusSuccess, 330000, 1, 2, 3)},
+			wantErr: &lib.ErrorRefundAmountExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund, err := buildRefund(transaction, tt.refunds, items, dto.RefundTransactionRequest{ItemIDs: tt.itemIDs, Reason: "event canceled"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("buildRefund() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if refund.RefundType != tt.wantType || refund.Amount != tt.wantAmount || refund.RefundNumber != tt.wantNumber {
+				t.Errorf("buildRefund() = %s %d %s, want %s %d %s", refund.RefundType, refund.Amount, refund.RefundNumber, tt.wantType, tt.wantAmount, tt.wantNumber)
+			}
+			if refund.Status != lib.RefundStatusProcessing {
+				t.Errorf("buildRefund() status = %s, want %s", refund.Status, lib.RefundStatusProcessing)
+			}
+			if got := refundItemsOf(refund); !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("buildRefund() items = %v, want %v", got, tt.wantItems)
+			}
+		})
+	}
+}
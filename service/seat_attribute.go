@@ -0,0 +1,119 @@
+package service
+
+import (
+	"assist-tix/entity"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"slices"
+	"strings"
+)
+
+var seatAttributes = []string{lib.SeatAttributeWheelchair, lib.SeatAttributeCompanion, lib.SeatAttributeRestrictedView, lib.SeatAttributeAwayFans}
+
+// accessibleSeatAttributes are kept for buyers who need them, auto assignment never gives them to item which doesn't declare the need
+var accessibleSeatAttributes = []string{lib.SeatAttributeWheelchair, lib.SeatAttributeCompanion}
+
+// seatNeedGroup is items of an order which declare the same needs, their seats are assigned together
+type seatNeedGroup struct {
+	needs []string
+	items []int // index of items
+}
+
+// groupItemsBySeatNeeds group items by their declared needs, groups are in order of their first item
+func groupItemsBySeatNeeds(items []model.EventTransactionItem) (groups []seatNeedGroup) {
+	indexes := make(map[string]int)
+	for i, item := range items {
+		needs := slices.Clone(item.SeatNeeds)
+		slices.Sort(needs)
+		key := strings.Join(needs, ",")
+
+		idx, ok := indexes[key]
+		if !ok {
+			idx = len(groups)
+			indexes[key] = idx
+			groups = append(groups, seatNeedGroup{needs: needs})
+		}
+		groups[idx].items = append(groups[idx].items, i)
+	}
+	return
+}
+
+// requiredSeatAttributes are needs which the assigned seat must have one of, restricted view is only accepted, not needed
+func requiredSeatAttributes(needs []string) (required []string) {
+	for _, need := range needs {
+		if need != lib.SeatAttributeRestrictedView {
+			required = append(required, need)
+		}
+	}
+	return
+}
+
+// excludedSeatAttributes are attributes which require declaration but aren't declared, seats having them can't be sold to the order
+func excludedSeatAttributes(requireDeclaration, needs []string) (excluded []string) {
+	for _, attribute := range requireDeclaration {
+		if !slices.Contains(needs, attribute) {
+			excluded = append(excluded, attribute)
+		}
+	}
+	return
+}
+
+// assignmentExcludedSeatAttributes extend excluded attributes with accessible seats which aren't needed,
+// seats having them are never auto assigned to the needs
+func assignmentExcludedSeatAttributes(requireDeclaration, needs []string) (excluded []string) {
+	excluded = excludedSeatAttributes(requireDeclaration, needs)
+	for _, attribute := range accessibleSeatAttributes {
+		if !slices.Contains(needs, attribute) && !slices.Contains(excluded, attribute) {
+			excluded = append(excluded, attribute)
+		}
+	}
+	return
+}
+
+// avoidedSeatAttributes extend excluded attributes with restricted view, auto assignment tries seats without them first
+func avoidedSeatAttributes(excluded []string) (avoided []string) {
+	avoided = append(avoided, excluded...)
+	if !slices.Contains(avoided, lib.SeatAttributeRestrictedView) {
+		avoided = append(avoided, lib.SeatAttributeRestrictedView)
+	}
+	return
+}
+
+// checkSeatEligible make sure every attribute of seat which requires declaration is declared in needs
+func checkSeatEligible(seat entity.EventVenueSector, requireDeclaration, needs []string) error {
+	for _, attribute := range excludedSeatAttributes(requireDeclaration, needs) {
+		if slices.Contains(seat.Attributes, attribute) {
+			return &lib.ErrorSeatNotEligible
+		}
+	}
+	return nil
+}
+
+// seatHasAnyAttribute check seat has at least one of attributes
+func seatHasAnyAttribute(seat entity.EventVenueSector, attributes []string) bool {
+	for _, attribute := range attributes {
+		if slices.Contains(seat.Attributes, attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSeatsByAttributes keep seats having any of attributes, when it is set, and none of excluded attributes
+func filterSeatsByAttributes(seats []entity.EventVenueSector, attributes, excluded []string) []entity.EventVenueSector {
+	if len(attributes) == 0 && len(excluded) == 0 {
+		return seats
+	}
+
+	filtered := make([]entity.EventVenueSector, 0, len(seats))
+	for _, seat := range seats {
+		if len(attributes) > 0 && !seatHasAnyAttribute(seat, attributes) {
+			continue
+		}
+		if seatHasAnyAttribute(seat, excluded) {
+			continue
+		}
+		filtered = append(filtered, seat)
+	}
+	return filtered
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/domain"
+	"assist-tix/dto"
+	"assist-tix/entity"
+	"assist-tix/helper"
+	"assist-tix/internal/usecase"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type SeatHoldService interface {
+	Create(ctx context.Context, eventID, ticketCategoryID, clientIP string, req dto.CreateSeatHoldRequest) (res dto.SeatHoldResponse, err error)
+	Release(ctx context.Context, eventID, holdID, email string) (err error)
+	ReleaseExpired(ctx context.Context) (released int64, err error)
+}
+
+type SeatHoldServiceImpl struct {
+	DB                      *database.WrapDB
+	Env                     *config.EnvironmentVariable
+	EventRepo               repository.EventRepository
+	EventSettingRepo        repository.EventSettingsRepository
+	EventTicketCategoryRepo repository.EventTicketCategoryRepository
+	EventTransactionRepo    repository.EventTransactionRepository
+	VenueSectorRepo         repository.VenueSectorRepository
+	EventSeatmapBookRepo    repository.EventSeatmapBookRepository
+	SeatHoldRepo            repository.SeatHoldRepository
+	SeatmapUseCase          usecase.SeatmapUsecase
+	OutboxRelay             OutboxRelay
+}
+
+func NewSeatHoldService(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventRepo repository.EventRepository,
+	eventSettingRepo repository.EventSettingsRepository,
+	eventTicketCategoryRepo repository.EventTicketCategoryRepository,
+	eventTransactionRepo repository.EventTransactionRepository,
+	venueSectorRepo repository.VenueSectorRepository,
+	eventSeatmapBookRepo repository.EventSeatmapBookRepository,
+	seatHoldRepo repository.SeatHoldRepository,
+	seatmapUseCase usecase.SeatmapUsecase,
+	outboxRelay OutboxRelay,
+) SeatHoldService {
+	return &SeatHoldServiceImpl{
+		DB:                      db,
+		Env:                     env,
+		EventRepo:               eventRepo,
+		EventSettingRepo:        eventSettingRepo,
+		EventTicketCategoryRepo: eventTicketCategoryRepo,
+		EventTransactionRepo:    eventTransactionRepo,
+		VenueSectorRepo:         venueSectorRepo,
+		EventSeatmapBookRepo:    eventSeatmapBookRepo,
+		SeatHoldRepo:            seatHoldRepo,
+		SeatmapUseCase:          seatmapUseCase,
+		OutboxRelay:             outboxRelay,
+	}
+}
+
+// validateSelectedSeats check every seat exists in sector seatmap, can be booked, is sold by the ticket category
+// and its attributes which require declaration are declared in needs
+func validateSelectedSeats(sectorSeatmap map[string]entity.EventVenueSector, seats []domain.SeatmapParam, ticketCategoryID string, requireDeclaration, needs []string) (err error) {
+	for _, val := range seats {
+		seat, ok := sectorSeatmap[helper.ConvertRowColumnKey(val.SeatRow, val.SeatColumn)]
+		if !ok {
+			return &lib.ErrorBookedSeatNotFound
+		}
+		if seat.TicketCategoryID != "" && seat.TicketCategoryID != ticketCategoryID {
+			return &lib.ErrorSeatNotInTicketCategory
+		}
+		err = checkSeatEligible(seat, requireDeclaration, needs)
+		if err != nil {
+			return
+		}
+
+		switch seat.Status {
+		case lib.SeatmapStatusUnavailable:
+			return &lib.ErrorSeatIsAlreadyBooked
+		case lib.SeatmapStatusDisable:
+			return &lib.ErrorBookedSeatNotFound
+		}
+	}
+
+	return
+}
+
+// Create reserve seats and their public stock of ticket category sector until the hold expires.
+// Held seats count to the purchase limit of buyer like ordered tickets
+func (s *SeatHoldServiceImpl) Create(ctx context.Context, eventID, ticketCategoryID, clientIP string, req dto.CreateSeatHoldRequest) (res dto.SeatHoldResponse, err error) {
+	event, err := s.EventRepo.FindById(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event")
+		return
+	}
+
+	err = validateEventOnSale(event)
+	if err != nil {
+		return
+	}
+
+	settings, err := s.EventSettingRepo.FindByEventId(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event settings")
+		return
+	}
+	eventSettings := lib.MapEventSettings(settings)
+	if len(req.Seats) > eventSettings.MaxAdultTicketPerTransaction {
+		return res, &lib.ErrorPurchaseQuantityExceedTheLimit
+	}
+
+	seats := make([]domain.SeatmapParam, 0, len(req.Seats))
+	selected := make(map[string]struct{})
+	for _, seat := range req.Seats {
+		key := helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)
+		if _, ok := selected[key]; ok {
+			return res, &lib.ErrorBadRequest
+		}
+		selected[key] = struct{}{}
+		seats = append(seats, domain.SeatmapParam{SeatRow: seat.SeatRow, SeatColumn: seat.SeatColumn})
+	}
+
+	ticketCategory, err := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, nil, eventID, ticketCategoryID)
+	if err != nil {
+		log.Error().Err(err).Str("ticketCategoryId", ticketCategoryID).Msg("failed to find ticket category by id and event id")
+		return
+	}
+
+	venueSector, err := s.VenueSectorRepo.FindVenueSectorById(ctx, nil, ticketCategory.VenueSectorId)
+	if err != nil {
+		log.Error().Err(err).Str("venueSectorId", ticketCategory.VenueSectorId).Msg("failed to find venue sector by id")
+		return
+	}
+	if !venueSector.HasSeatmap {
+		return res, &lib.ErrorSeatmapNotAvailable
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	err = validatePurchaseLimit(ctx, tx, s.EventTransactionRepo, s.EventSeatmapBookRepo, eventSettings, purchaseLimitParam{
+		EventID:  eventID,
+		Email:    req.Email,
+		ClientIP: clientIP,
+		Quantity: len(seats),
+	})
+	if err != nil {
+		return
+	}
+
+	expiredHolds, err := s.EventSeatmapBookRepo.DeleteExpiredHoldsBySeats(ctx, tx, eventID, venueSector.ID, seats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release expired holds of seats")
+		return
+	}
+
+	err = releaseHeldStock(ctx, tx, s.EventTicketCategoryRepo, expiredHolds)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release public stock of expired holds")
+		return
+	}
+
+	sectorSeatmap, err := s.EventTicketCategoryRepo.FindSeatmapStatusByEventSectorId(ctx, tx, eventID, venueSector.ID, seats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find seatmap status")
+		return
+	}
+
+	err = validateSelectedSeats(sectorSeatmap, seats, ticketCategoryID, eventSettings.SeatAttributesRequireDeclaration, req.SeatNeeds)
+	if err != nil {
+		return
+	}
+
+	// held seats take public stock, so paid order using the hold always has ticket to issue
+	err = s.EventTicketCategoryRepo.BuyPublicTicketById(ctx, tx, eventID, ticketCategoryID, len(seats))
+	if err != nil {
+		log.Warn().Err(err).Str("ticketCategoryId", ticketCategoryID).Msg("failed to take public stock of held seats")
+		return
+	}
+
+	// unique seat book is the reservation, concurrent hold or order of the same seat fails here
+	err = s.EventSeatmapBookRepo.CreateSeatBook(ctx, tx, eventID, venueSector.ID, seats)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", eventID).Msg("failed to book held seats")
+		return
+	}
+
+	hold := model.SeatHold{
+		ID:               uuid.NewString(),
+		EventID:          eventID,
+		TicketCategoryID: ticketCategoryID,
+		VenueSectorID:    venueSector.ID,
+		Seats:            seats,
+		Email:            req.Email,
+		ClientIP:         clientIP,
+		ExpiresAt:        time.Now().Add(s.Env.SeatHold.TTL),
+	}
+
+	err = s.EventSeatmapBookRepo.UpdateHoldIdBySeats(ctx, tx, hold)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", hold.ID).Msg("failed to mark seat books as hold")
+		return
+	}
+
+	seatmapChange, err := s.SeatmapUseCase.SendSeatmapChange(ctx, tx, eventID, venueSector.ID, lib.SeatmapStatusUnavailable, toSeatmapSeats(seats))
+	if err != nil {
+		log.Error().Err(err).Str("holdId", hold.ID).Msg("failed to write seatmap change")
+		return
+	}
+
+	err = s.SeatHoldRepo.Create(ctx, hold)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", hold.ID).Msg("failed to store seat hold")
+		return res, &lib.ErrorSeatHoldStore
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", hold.ID).Msg("failed to commit seat hold")
+		if errDelete := s.SeatHoldRepo.Delete(context.WithoutCancel(ctx), hold.ID); errDelete != nil {
+			log.Warn().Err(errDelete).Str("holdId", hold.ID).Msg("failed to delete seat hold")
+		}
+		return
+	}
+
+	log.Info().Str("holdId", hold.ID).Str("eventId", eventID).Int("seats", len(seats)).Time("expiresAt", hold.ExpiresAt).Msg("seats held")
+
+	s.OutboxRelay.RelayMessage(ctx, seatmapChange)
+
+	res = dto.SeatHoldResponse{
+		HoldID:           hold.ID,
+		EventID:          eventID,
+		TicketCategoryID: ticketCategoryID,
+		Seats:            make([]dto.SeatHoldSeatResponse, 0, len(seats)),
+		ExpiresAt:        hold.ExpiresAt,
+	}
+	for _, seat := range seats {
+		sectorSeat := sectorSeatmap[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)]
+		res.Seats = append(res.Seats, dto.SeatHoldSeatResponse{
+			SeatRow:    seat.SeatRow,
+			SeatColumn: seat.SeatColumn,
+			SeatLabel:  sectorSeat.Label,
+		})
+	}
+
+	return
+}
+
+// Release give back held seats before the hold expires. Hold of another buyer is reported as not found
+func (s *SeatHoldServiceImpl) Release(ctx context.Context, eventID, holdID, email string) (err error) {
+	hold, err := s.SeatHoldRepo.FindById(ctx, holdID)
+	if err != nil {
+		log.Warn().Err(err).Str("holdId", holdID).Msg("failed to find seat hold")
+		return
+	}
+
+	if hold.EventID != eventID || !strings.EqualFold(hold.Email, email) {
+		return &lib.ErrorSeatHoldNotFound
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	releasedSeats, err := s.EventSeatmapBookRepo.DeleteByHoldId(ctx, tx, holdID)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to release held seats")
+		return
+	}
+
+	err = releaseHeldStock(ctx, tx, s.EventTicketCategoryRepo, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to release public stock of held seats")
+		return
+	}
+
+	seatmapChanges, err := sendReleasedSeats(ctx, tx, &s.SeatmapUseCase, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to write seatmap change")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to commit released seats")
+		return
+	}
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
+	err = s.SeatHoldRepo.Delete(ctx, holdID)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to delete seat hold")
+		return &lib.ErrorSeatHoldStore
+	}
+
+	log.Info().Str("holdId", holdID).Str("eventId", eventID).Msg("seat hold released")
+	return
+}
+
+// ReleaseExpired release seats of every expired hold which isn't used by order
+func (s *SeatHoldServiceImpl) ReleaseExpired(ctx context.Context) (released int64, err error) {
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	releasedSeats, err := s.EventSeatmapBookRepo.DeleteExpiredHolds(ctx, tx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release expired seat holds")
+		return
+	}
+
+	err = releaseHeldStock(ctx, tx, s.EventTicketCategoryRepo, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to release public stock of expired seat holds")
+		return
+	}
+
+	seatmapChanges, err := sendReleasedSeats(ctx, tx, &s.SeatmapUseCase, releasedSeats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write seatmap change")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit released seat holds")
+		return
+	}
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+
+	return int64(len(releasedSeats)), nil
+}
+
+// releaseHeldStock give back public stock taken by released held seats, seat book which isn't a hold never took stock
+func releaseHeldStock(ctx context.Context, tx pgx.Tx, ticketCategoryRepo repository.EventTicketCategoryRepository, released []model.EventSeatmapBook) (err error) {
+	type eventCategory struct {
+		eventID          string
+		ticketCategoryID string
+	}
+
+	var keys []eventCategory
+	quantities := make(map[eventCategory]int)
+	for _, book := range released {
+		if book.HoldTicketCategoryID == "" {
+			continue
+		}
+		key := eventCategory{eventID: book.EventID, ticketCategoryID: book.HoldTicketCategoryID}
+		if _, ok := quantities[key]; !ok {
+			keys = append(keys, key)
+		}
+		quantities[key]++
+	}
+
+	// same lock order as cart order buy the stock
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ticketCategoryID < keys[j].ticketCategoryID
+	})
+	for _, key := range keys {
+		err = ticketCategoryRepo.ReleasePublicTicketById(ctx, tx, key.eventID, key.ticketCategoryID, quantities[key])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// returnSeatHoldStock give back public stock taken by hold before the order using it buys its own stock.
+// Must be called inside the order tx, which fails when the hold can't be converted by useSeatHold
+func (s *EventTransactionServiceImpl) returnSeatHoldStock(ctx context.Context, tx pgx.Tx, eventID, ticketCategoryID, holdID string) (err error) {
+	hold, err := s.SeatHoldRepo.FindById(ctx, holdID)
+	if err != nil {
+		log.Warn().Err(err).Str("holdId", holdID).Msg("failed to find seat hold")
+		return
+	}
+
+	if hold.EventID != eventID || hold.TicketCategoryID != ticketCategoryID {
+		return &lib.ErrorSeatHoldMismatch
+	}
+
+	err = s.EventTicketCategoryRepo.ReleasePublicTicketById(ctx, tx, eventID, ticketCategoryID, len(hold.Seats))
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to release public stock of seat hold")
+		return
+	}
+
+	return
+}
+
+// useSeatHold convert hold of the same buyer into seat books of the order, seats of items must be exactly the held seats.
+// Returned seatmap has the label of each seat
+func (s *EventTransactionServiceImpl) useSeatHold(ctx context.Context, tx pgx.Tx, eventID, ticketCategoryID, holdID, email string, requireDeclaration []string, items []dto.OrderItemEventTransaction) (seats []domain.SeatmapParam, sectorSeatmap map[string]entity.EventVenueSector, err error) {
+	hold, err := s.SeatHoldRepo.FindById(ctx, holdID)
+	if err != nil {
+		log.Warn().Err(err).Str("holdId", holdID).Msg("failed to find seat hold")
+		return
+	}
+
+	if hold.EventID != eventID || hold.TicketCategoryID != ticketCategoryID || len(hold.Seats) != len(items) || !strings.EqualFold(hold.Email, email) {
+		return nil, nil, &lib.ErrorSeatHoldMismatch
+	}
+
+	held := make(map[string]struct{}, len(hold.Seats))
+	for _, seat := range hold.Seats {
+		held[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)] = struct{}{}
+	}
+	for _, item := range items {
+		key := helper.ConvertRowColumnKey(item.SeatRow, item.SeatColumn)
+		if _, ok := held[key]; !ok {
+			return nil, nil, &lib.ErrorSeatHoldMismatch
+		}
+		delete(held, key)
+	}
+
+	converted, err := s.EventSeatmapBookRepo.ConvertHoldToBook(ctx, tx, holdID)
+	if err != nil {
+		log.Error().Err(err).Str("holdId", holdID).Msg("failed to convert seat hold")
+		return
+	}
+	if converted != int64(len(hold.Seats)) {
+		log.Warn().Str("holdId", holdID).Int64("converted", converted).Int("seats", len(hold.Seats)).Msg("seat hold is expired")
+		return nil, nil, &lib.ErrorSeatHoldNotFound
+	}
+
+	sectorSeatmap, err = s.EventTicketCategoryRepo.FindSeatmapStatusByEventSectorId(ctx, tx, eventID, hold.VenueSectorID, hold.Seats)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find seatmap status")
+		return
+	}
+
+	// need is declared by ticket holder of the seat, not by the hold
+	for _, item := range items {
+		err = checkSeatEligible(sectorSeatmap[helper.ConvertRowColumnKey(item.SeatRow, item.SeatColumn)], requireDeclaration, item.SeatNeeds)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	log.Info().Str("holdId", holdID).Int64("seats", converted).Msg("seat hold converted to seat books")
+	return hold.Seats, sectorSeatmap, nil
+}
+
+// deleteSeatHold remove hold used by committed order, its seats are already books of the order
+func (s *EventTransactionServiceImpl) deleteSeatHold(ctx context.Context, holdID string) {
+	err := s.SeatHoldRepo.Delete(context.WithoutCancel(ctx), holdID)
+	if err != nil {
+		log.Warn().Err(err).Str("holdId", holdID).Msg("failed to delete used seat hold")
+	}
+}
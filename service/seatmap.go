@@ -0,0 +1,664 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/domain"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/internal/domain/seatmap"
+	"assist-tix/internal/usecase"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/xuri/excelize/v2"
+)
+
+// seatmapMaxGridSize is max rows and max columns of sector grid, same as ReplaceSectorSeatmapRequest binding
+const seatmapMaxGridSize = 500
+
+type SeatmapService interface {
+	GetSectorSeatmap(ctx context.Context, venueID, sectorID string) (res dto.VenueSectorSeatmapResponse, err error)
+	ReplaceSectorSeatmap(ctx context.Context, venueID, sectorID string, req dto.ReplaceSectorSeatmapRequest) (res dto.VenueSectorSeatmapResponse, err error)
+	ImportSectorSeatmap(ctx context.Context, venueID, sectorID, fileName string, file io.Reader) (res dto.VenueSectorSeatmapResponse, err error)
+	UpdateSectorSeats(ctx context.Context, venueID, sectorID string, req dto.UpdateSectorSeatsRequest) (res dto.VenueSectorSeatmapResponse, err error)
+	GetEventSeatmapOverrides(ctx context.Context, eventID, sectorID string) (res []dto.SectorSeatmapRowColumnResponse, err error)
+	UpsertEventSeatmapOverrides(ctx context.Context, eventID, sectorID string, req dto.UpsertEventSeatmapOverridesRequest) (res []dto.SectorSeatmapRowColumnResponse, err error)
+	DeleteEventSeatmapOverrides(ctx context.Context, eventID, sectorID string, req dto.DeleteEventSeatmapOverridesRequest) (err error)
+	RenderSectorSeatmap(ctx context.Context, venueID, sectorID, format string) (res dto.SeatmapImageResponse, err error)
+	RenderEventSeatmap(ctx context.Context, eventID, ticketCategoryID, format string) (res dto.SeatmapImageResponse, err error)
+	RenderTicketSeatmap(ctx context.Context, transactionID, ticketNumber, format string) (res dto.SeatmapImageResponse, err error)
+}
+
+type SeatmapServiceImpl struct {
+	DB                      *database.WrapDB
+	Env                     *config.EnvironmentVariable
+	EventRepo               repository.EventRepository
+	EventTicketCategoryRepo repository.EventTicketCategoryRepository
+	VenueSectorRepo         repository.VenueSectorRepository
+	VenueSectorSeatmapRepo  repository.VenueSectorSeatmapRepository
+	EventSeatmapBookRepo    repository.EventSeatmapBookRepository
+	EventTicketRepo         repository.EventTicketRepository
+	GCSStorageRepo          repository.GCSStorageRepository
+	SeatmapUseCase          usecase.SeatmapUsecase
+	OutboxRelay             OutboxRelay
+}
+
+func NewSeatmapService(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventRepo repository.EventRepository,
+	eventTicketCategoryRepo repository.EventTicketCategoryRepository,
+	venueSectorRepo repository.VenueSectorRepository,
+	venueSectorSeatmapRepo repository.VenueSectorSeatmapRepository,
+	eventSeatmapBookRepo repository.EventSeatmapBookRepository,
+	eventTicketRepo repository.EventTicketRepository,
+	gcsStorageRepo repository.GCSStorageRepository,
+	seatmapUseCase usecase.SeatmapUsecase,
+	outboxRelay OutboxRelay,
+) SeatmapService {
+	return &SeatmapServiceImpl{
+		DB:                      db,
+		Env:                     env,
+		EventRepo:               eventRepo,
+		EventTicketCategoryRepo: eventTicketCategoryRepo,
+		VenueSectorRepo:         venueSectorRepo,
+		VenueSectorSeatmapRepo:  venueSectorSeatmapRepo,
+		EventSeatmapBookRepo:    eventSeatmapBookRepo,
+		EventTicketRepo:         eventTicketRepo,
+		GCSStorageRepo:          gcsStorageRepo,
+		SeatmapUseCase:          seatmapUseCase,
+		OutboxRelay:             outboxRelay,
+	}
+}
+
+// findVenueSector find sector which belongs to the venue
+func (s *SeatmapServiceImpl) findVenueSector(ctx context.Context, venueID, sectorID string) (sector model.VenueSector, err error) {
+	sector, err = s.VenueSectorRepo.FindById(ctx, nil, sectorID)
+	if err != nil {
+		log.Warn().Err(err).Str("sectorId", sectorID).Msg("failed to find venue sector")
+		return
+	}
+
+	if sector.VenueID != venueID {
+		return sector, &lib.ErrorVenueSectorNotFound
+	}
+
+	return
+}
+
+func (s *SeatmapServiceImpl) GetSectorSeatmap(ctx context.Context, venueID, sectorID string) (res dto.VenueSectorSeatmapResponse, err error) {
+	sector, err := s.findVenueSector(ctx, venueID, sectorID)
+	if err != nil {
+		return
+	}
+
+	seats, err := s.VenueSectorSeatmapRepo.FindBySectorId(ctx, nil, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to find seatmap of sector")
+		return
+	}
+
+	return mapVenueSectorSeatmap(sector.ID, sector.SectorRow, sector.SectorColumn, sector.Capacity, seats), nil
+}
+
+// ReplaceSectorSeatmap replace the whole grid of sector, it's rejected once any seat of sector is booked
+func (s *SeatmapServiceImpl) ReplaceSectorSeatmap(ctx context.Context, venueID, sectorID string, req dto.ReplaceSectorSeatmapRequest) (res dto.VenueSectorSeatmapResponse, err error) {
+	if len(req.Seats) == 0 {
+		for row := 1; row <= req.Rows; row++ {
+			for column := 1; column <= req.Columns; column++ {
+				req.Seats = append(req.Seats, dto.SectorSeatmapSeatRequest{Row: row, Column: column})
+			}
+		}
+	}
+
+	seats, err := buildSectorSeats(req.Rows, req.Columns, req.Seats)
+	if err != nil {
+		return
+	}
+
+	return s.replaceSectorSeatmap(ctx, venueID, sectorID, req.Rows, req.Columns, seats)
+}
+
+// ImportSectorSeatmap replace grid of sector from csv or xlsx file, the first row is header of
+// row, column, row_label, label and status columns. Grid size is taken from the largest row and column
+func (s *SeatmapServiceImpl) ImportSectorSeatmap(ctx context.Context, venueID, sectorID, fileName string, file io.Reader) (res dto.VenueSectorSeatmapResponse, err error) {
+	var records [][]string
+	switch strings.ToLower(helper.GetFileExtension(fileName)) {
+	case "csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+	case "xlsx":
+		var xlsx *excelize.File
+		xlsx, err = excelize.OpenReader(file)
+		if err != nil {
+			break
+		}
+		defer xlsx.Close()
+		records, err = xlsx.GetRows(xlsx.GetSheetName(0))
+	default:
+		return res, &lib.ErrorSeatmapFileInvalid
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("fileName", fileName).Msg("failed to read seatmap file")
+		return res, &lib.ErrorSeatmapFileInvalid
+	}
+
+	reqs, err := parseSeatmapRecords(records)
+	if err != nil {
+		return
+	}
+
+	var rows, columns int
+	for _, req := range reqs {
+		rows = max(rows, req.Row)
+		columns = max(columns, req.Column)
+	}
+	if rows > seatmapMaxGridSize || columns > seatmapMaxGridSize {
+		log.Warn().Str("sectorId", sectorID).Int("rows", rows).Int("columns", columns).Msg("imported seatmap grid is too large")
+		return res, &lib.ErrorSeatmapGridTooLarge
+	}
+
+	seats, err := buildSectorSeats(rows, columns, reqs)
+	if err != nil {
+		return
+	}
+
+	return s.replaceSectorSeatmap(ctx, venueID, sectorID, rows, columns, seats)
+}
+
+func (s *SeatmapServiceImpl) replaceSectorSeatmap(ctx context.Context, venueID, sectorID string, rows, columns int, seats []model.VenueSectorSeatmap) (res dto.VenueSectorSeatmapResponse, err error) {
+	_, err = s.findVenueSector(ctx, venueID, sectorID)
+	if err != nil {
+		return
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	booked, err := s.EventSeatmapBookRepo.CountBySectorId(ctx, tx, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to count seat books of sector")
+		return
+	}
+	if booked > 0 {
+		log.Warn().Str("sectorId", sectorID).Int("booked", booked).Msg("seatmap of sector with booked seats can't be replaced")
+		return res, &lib.ErrorSeatmapHasBookings
+	}
+
+	err = s.VenueSectorSeatmapRepo.ReplaceBySectorId(ctx, tx, sectorID, seats)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to replace seatmap of sector")
+		return
+	}
+
+	capacity := countAvailableSeats(seats)
+	err = s.VenueSectorRepo.UpdateSeatmapGrid(ctx, tx, sectorID, rows, columns, capacity)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to update seatmap grid of sector")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to commit seatmap of sector")
+		return
+	}
+
+	log.Info().Str("sectorId", sectorID).Int("rows", rows).Int("columns", columns).Int("seats", len(seats)).Int("capacity", capacity).Msg("seatmap of sector replaced")
+	return mapVenueSectorSeatmap(sectorID, rows, columns, capacity, seats), nil
+}
+
+// UpdateSectorSeats edit label, row label or status of seats, new seat must be inside the grid.
+// Seats which are booked or held by any event are rejected, their ticket and stream would not see the change
+func (s *SeatmapServiceImpl) UpdateSectorSeats(ctx context.Context, venueID, sectorID string, req dto.UpdateSectorSeatsRequest) (res dto.VenueSectorSeatmapResponse, err error) {
+	sector, err := s.findVenueSector(ctx, venueID, sectorID)
+	if err != nil {
+		return
+	}
+
+	seats, err := buildSectorSeats(sector.SectorRow, sector.SectorColumn, req.Seats)
+	if err != nil {
+		return
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	positions := make([]domain.SeatmapParam, 0, len(seats))
+	for _, seat := range seats {
+		positions = append(positions, domain.SeatmapParam{SeatRow: seat.SeatRow, SeatColumn: seat.SeatColumn})
+	}
+
+	booked, err := s.EventSeatmapBookRepo.CountBySectorIdAndSeats(ctx, tx, sectorID, positions)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to count seat books of seats")
+		return
+	}
+	if booked > 0 {
+		log.Warn().Str("sectorId", sectorID).Int("booked", booked).Msg("booked or held seats of sector can't be edited")
+		return res, &lib.ErrorSeatmapSeatsBooked
+	}
+
+	err = s.VenueSectorSeatmapRepo.UpsertSeats(ctx, tx, sectorID, seats)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to update seats of sector")
+		return
+	}
+
+	grid, err := s.VenueSectorSeatmapRepo.FindBySectorId(ctx, tx, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to find seatmap of sector")
+		return
+	}
+
+	capacity := countAvailableSeats(grid)
+	err = s.VenueSectorRepo.UpdateSeatmapGrid(ctx, tx, sectorID, sector.SectorRow, sector.SectorColumn, capacity)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to update seatmap grid of sector")
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to commit seats of sector")
+		return
+	}
+
+	return mapVenueSectorSeatmap(sectorID, sector.SectorRow, sector.SectorColumn, capacity, grid), nil
+}
+
+// findEventSector find event and sector of the event venue
+func (s *SeatmapServiceImpl) findEventSector(ctx context.Context, eventID, sectorID string) (sector model.VenueSector, err error) {
+	event, err := s.EventRepo.FindById(ctx, nil, eventID)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", eventID).Msg("failed to find event")
+		return
+	}
+
+	return s.findVenueSector(ctx, event.VenueID, sectorID)
+}
+
+func (s *SeatmapServiceImpl) GetEventSeatmapOverrides(ctx context.Context, eventID, sectorID string) (res []dto.SectorSeatmapRowColumnResponse, err error) {
+	_, err = s.findEventSector(ctx, eventID, sectorID)
+	if err != nil {
+		return
+	}
+
+	overrides, err := s.VenueSectorSeatmapRepo.FindOverridesByEventSectorId(ctx, nil, eventID, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to find seatmap overrides")
+		return
+	}
+
+	return mapEventSeatmapOverrides(overrides), nil
+}
+
+// UpsertEventSeatmapOverrides change seats of sector only for the event, e.g. block seats for camera
+func (s *SeatmapServiceImpl) UpsertEventSeatmapOverrides(ctx context.Context, eventID, sectorID string, req dto.UpsertEventSeatmapOverridesRequest) (res []dto.SectorSeatmapRowColumnResponse, err error) {
+	_, err = s.findEventSector(ctx, eventID, sectorID)
+	if err != nil {
+		return
+	}
+
+	grid, err := s.VenueSectorSeatmapRepo.FindBySectorId(ctx, nil, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to find seatmap of sector")
+		return
+	}
+
+	ticketCategories, err := s.EventTicketCategoryRepo.FindByEventId(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find ticket categories of event")
+		return
+	}
+	sectorCategories := make(map[string]struct{})
+	for _, category := range ticketCategories {
+		if category.VenueSectorId == sectorID {
+			sectorCategories[category.ID] = struct{}{}
+		}
+	}
+
+	venueSeats := make(map[string]model.VenueSectorSeatmap, len(grid))
+	for _, seat := range grid {
+		venueSeats[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)] = seat
+	}
+
+	overrides := make([]model.EventVenueSectorSeatmap, 0, len(req.Seats))
+	selected := make(map[string]struct{}, len(req.Seats))
+	for _, val := range req.Seats {
+		key := helper.ConvertRowColumnKey(val.Row, val.Column)
+		venueSeat, ok := venueSeats[key]
+		if !ok {
+			return nil, &lib.ErrorBookedSeatNotFound
+		}
+		if _, ok := selected[key]; ok {
+			return nil, &lib.ErrorSeatmapInvalid
+		}
+		selected[key] = struct{}{}
+
+		override := model.EventVenueSectorSeatmap{
+			SeatRow:      val.Row,
+			SeatColumn:   val.Column,
+			SeatRowLabel: val.RowLabel,
+			Label:        val.Label,
+			Status:       val.Status,
+			Attributes:   val.Attributes,
+		}
+		if val.TicketCategoryID != "" {
+			// price zone must be one of the categories sold in the sector of the event
+			if _, ok := sectorCategories[val.TicketCategoryID]; !ok {
+				return nil, &lib.ErrorSeatmapTicketCategoryInvalid
+			}
+			override.TicketCategoryID = helper.ToSQLString(val.TicketCategoryID)
+		}
+		if override.SeatRowLabel == 0 {
+			override.SeatRowLabel = venueSeat.SeatRowLabel
+		}
+		if override.Label == "" {
+			override.Label = venueSeat.Label
+		}
+		overrides = append(overrides, override)
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.VenueSectorSeatmapRepo.UpsertOverrides(ctx, tx, eventID, sectorID, overrides)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to upsert seatmap overrides")
+		return
+	}
+
+	seatmapChanges, err := s.sendSeatStatusChanges(ctx, tx, eventID, sectorID, overrides)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit seatmap overrides")
+		return
+	}
+
+	log.Info().Str("eventId", eventID).Str("sectorId", sectorID).Int("seats", len(overrides)).Msg("seatmap overrides of event updated")
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+	return s.GetEventSeatmapOverrides(ctx, eventID, sectorID)
+}
+
+func (s *SeatmapServiceImpl) DeleteEventSeatmapOverrides(ctx context.Context, eventID, sectorID string, req dto.DeleteEventSeatmapOverridesRequest) (err error) {
+	_, err = s.findEventSector(ctx, eventID, sectorID)
+	if err != nil {
+		return
+	}
+
+	grid, err := s.VenueSectorSeatmapRepo.FindBySectorId(ctx, nil, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to find seatmap of sector")
+		return
+	}
+	venueSeats := make(map[string]model.VenueSectorSeatmap, len(grid))
+	for _, seat := range grid {
+		venueSeats[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)] = seat
+	}
+
+	// seat goes back to its venue status once its override is deleted
+	seats := make([]domain.SeatmapParam, 0, len(req.Seats))
+	restored := make([]model.EventVenueSectorSeatmap, 0, len(req.Seats))
+	for _, seat := range req.Seats {
+		seats = append(seats, domain.SeatmapParam{SeatRow: seat.Row, SeatColumn: seat.Column})
+		if venueSeat, ok := venueSeats[helper.ConvertRowColumnKey(seat.Row, seat.Column)]; ok {
+			restored = append(restored, model.EventVenueSectorSeatmap{
+				SeatRow:    venueSeat.SeatRow,
+				SeatColumn: venueSeat.SeatColumn,
+				Status:     venueSeat.Status,
+			})
+		}
+	}
+
+	tx, err := s.DB.Postgres.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to begin transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.VenueSectorSeatmapRepo.DeleteOverrides(ctx, tx, eventID, sectorID, seats)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to delete seatmap overrides")
+		return
+	}
+
+	seatmapChanges, err := s.sendSeatStatusChanges(ctx, tx, eventID, sectorID, restored)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to commit deleted seatmap overrides")
+		return
+	}
+
+	relaySeatmapChanges(ctx, s.OutboxRelay, seatmapChanges)
+	return
+}
+
+// sendSeatStatusChanges write seatmap change of each status, seats booked for the event stay unavailable so they're skipped
+func (s *SeatmapServiceImpl) sendSeatStatusChanges(ctx context.Context, tx pgx.Tx, eventID, sectorID string, seats []model.EventVenueSectorSeatmap) (outboxes []model.Outbox, err error) {
+	booked, err := s.EventSeatmapBookRepo.FindSeatBooksByEventSectorId(ctx, tx, eventID, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to find seat books")
+		return
+	}
+
+	var (
+		statuses []string
+		grouped  = make(map[string][]seatmap.Seat)
+	)
+	for _, seat := range seats {
+		if _, ok := booked[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)]; ok {
+			continue
+		}
+		if _, ok := grouped[seat.Status]; !ok {
+			statuses = append(statuses, seat.Status)
+		}
+		grouped[seat.Status] = append(grouped[seat.Status], seatmap.Seat{Row: seat.SeatRow, Column: seat.SeatColumn})
+	}
+
+	for _, status := range statuses {
+		var outbox model.Outbox
+		outbox, err = s.SeatmapUseCase.SendSeatmapChange(ctx, tx, eventID, sectorID, status, grouped[status])
+		if err != nil {
+			log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to write seatmap change")
+			return
+		}
+		outboxes = append(outboxes, outbox)
+	}
+
+	return
+}
+
+// buildSectorSeats validate seats are unique and inside the grid, empty label is R{row}C{column}
+// and empty status is AVAILABLE
+func buildSectorSeats(rows, columns int, reqs []dto.SectorSeatmapSeatRequest) (seats []model.VenueSectorSeatmap, err error) {
+	selected := make(map[string]struct{}, len(reqs))
+	for _, req := range reqs {
+		if req.Row < 1 || req.Column < 1 || req.Row > rows || req.Column > columns {
+			return nil, &lib.ErrorSeatmapInvalid
+		}
+
+		key := helper.ConvertRowColumnKey(req.Row, req.Column)
+		if _, ok := selected[key]; ok {
+			return nil, &lib.ErrorSeatmapInvalid
+		}
+		selected[key] = struct{}{}
+
+		seat := model.VenueSectorSeatmap{
+			SeatRow:      req.Row,
+			SeatColumn:   req.Column,
+			SeatRowLabel: req.RowLabel,
+			Label:        req.Label,
+			Status:       req.Status,
+			Attributes:   req.Attributes,
+		}
+		if seat.SeatRowLabel == 0 {
+			seat.SeatRowLabel = req.Row
+		}
+		if seat.Label == "" {
+			seat.Label = fmt.Sprintf("R%dC%d", req.Row, req.Column)
+		}
+		if seat.Status == "" {
+			seat.Status = lib.SeatmapStatusAvailable
+		}
+		seats = append(seats, seat)
+	}
+
+	return
+}
+
+// parseSeatmapRecords map rows of seatmap file by its header, empty rows are skipped
+func parseSeatmapRecords(records [][]string) (reqs []dto.SectorSeatmapSeatRequest, err error) {
+	if len(records) < 2 {
+		return nil, &lib.ErrorSeatmapFileInvalid
+	}
+
+	index := make(map[string]int)
+	for i, header := range records[0] {
+		index[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	if _, ok := index["row"]; !ok {
+		return nil, &lib.ErrorSeatmapFileInvalid
+	}
+	if _, ok := index["column"]; !ok {
+		return nil, &lib.ErrorSeatmapFileInvalid
+	}
+
+	cell := func(record []string, name string) string {
+		i, ok := index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for _, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		var req dto.SectorSeatmapSeatRequest
+		req.Row, err = strconv.Atoi(cell(record, "row"))
+		if err != nil {
+			return nil, &lib.ErrorSeatmapFileInvalid
+		}
+		req.Column, err = strconv.Atoi(cell(record, "column"))
+		if err != nil {
+			return nil, &lib.ErrorSeatmapFileInvalid
+		}
+		if rowLabel := cell(record, "row_label"); rowLabel != "" {
+			req.RowLabel, err = strconv.Atoi(rowLabel)
+			if err != nil {
+				return nil, &lib.ErrorSeatmapFileInvalid
+			}
+		}
+		req.Label = cell(record, "label")
+		req.Status = strings.ToUpper(cell(record, "status"))
+
+		if len(req.Label) > 50 || (req.Status != "" && req.Status != lib.SeatmapStatusAvailable && req.Status != lib.SeatmapStatusDisable) {
+			return nil, &lib.ErrorSeatmapFileInvalid
+		}
+
+		// attributes are separated by | since comma is the delimiter of csv
+		if attributes := cell(record, "attributes"); attributes != "" {
+			for _, attribute := range strings.Split(attributes, "|") {
+				attribute = strings.ToUpper(strings.TrimSpace(attribute))
+				if !slices.Contains(seatAttributes, attribute) {
+					return nil, &lib.ErrorSeatmapFileInvalid
+				}
+				if !slices.Contains(req.Attributes, attribute) {
+					req.Attributes = append(req.Attributes, attribute)
+				}
+			}
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return nil, &lib.ErrorSeatmapFileInvalid
+	}
+
+	return reqs, nil
+}
+
+func countAvailableSeats(seats []model.VenueSectorSeatmap) (count int) {
+	for _, seat := range seats {
+		if seat.Status == lib.SeatmapStatusAvailable {
+			count++
+		}
+	}
+
+	return
+}
+
+func mapVenueSectorSeatmap(sectorID string, rows, columns, capacity int, seats []model.VenueSectorSeatmap) (res dto.VenueSectorSeatmapResponse) {
+	res = dto.VenueSectorSeatmapResponse{
+		SectorID: sectorID,
+		Rows:     rows,
+		Columns:  columns,
+		Capacity: capacity,
+		Seats:    make([]dto.SectorSeatmapRowColumnResponse, 0, len(seats)),
+	}
+	for _, seat := range seats {
+		res.Seats = append(res.Seats, dto.SectorSeatmapRowColumnResponse{
+			Row:        seat.SeatRow,
+			Column:     seat.SeatColumn,
+			RowLabel:   seat.SeatRowLabel,
+			Label:      seat.Label,
+			Status:     seat.Status,
+			Attributes: seat.Attributes,
+		})
+	}
+
+	return
+}
+
+func mapEventSeatmapOverrides(overrides []model.EventVenueSectorSeatmap) (res []dto.SectorSeatmapRowColumnResponse) {
+	res = make([]dto.SectorSeatmapRowColumnResponse, 0, len(overrides))
+	for _, seat := range overrides {
+		res = append(res, dto.SectorSeatmapRowColumnResponse{
+			Row:              seat.SeatRow,
+			Column:           seat.SeatColumn,
+			RowLabel:         seat.SeatRowLabel,
+			Label:            seat.Label,
+			Status:           seat.Status,
+			TicketCategoryID: seat.TicketCategoryID.String,
+			Attributes:       seat.Attributes,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"assist-tix/domain"
+	"assist-tix/internal/domain/seatmap"
+	"assist-tix/internal/usecase"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func toSeatmapSeats(params []domain.SeatmapParam) (seats []seatmap.Seat) {
+	seats = make([]seatmap.Seat, 0, len(params))
+	for _, param := range params {
+		seats = append(seats, seatmap.Seat{Row: param.SeatRow, Column: param.SeatColumn})
+	}
+	return
+}
+
+// sendReleasedSeats write AVAILABLE change of released seat books, grouped by their event sector
+func sendReleasedSeats(ctx context.Context, tx pgx.Tx, seatmapUseCase *usecase.SeatmapUsecase, released []model.EventSeatmapBook) (outboxes []model.Outbox, err error) {
+	type eventSector struct {
+		eventID  string
+		sectorID string
+	}
+
+	var (
+		order   []eventSector
+		grouped = make(map[eventSector][]seatmap.Seat)
+	)
+	for _, book := range released {
+		key := eventSector{eventID: book.EventID, sectorID: book.VenueSectorID}
+		if _, ok := grouped[key]; !ok {
+			order = append(order, key)
+		}
+		grouped[key] = append(grouped[key], seatmap.Seat{Row: book.SeatRow, Column: book.SeatColumn})
+	}
+
+	for _, key := range order {
+		var outbox model.Outbox
+		outbox, err = seatmapUseCase.SendSeatmapChange(ctx, tx, key.eventID, key.sectorID, lib.SeatmapStatusAvailable, grouped[key])
+		if err != nil {
+			return
+		}
+		outboxes = append(outboxes, outbox)
+	}
+
+	return
+}
+
+// relaySeatmapChanges publish seatmap changes right after their transaction is committed
+func relaySeatmapChanges(ctx context.Context, relay OutboxRelay, outboxes []model.Outbox) {
+	relayOutboxes(ctx, relay, outboxes)
+}
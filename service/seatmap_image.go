@@ -0,0 +1,229 @@
+package service
+
+import (
+	"assist-tix/dto"
+	"assist-tix/entity"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	seatmapImageDir    = "seatmap"
+	seatmapImageSVG    = "svg"
+	seatmapImagePNG    = "png"
+	seatmapImageDimmed = "#BDBDBD"
+)
+
+var seatmapImageColors = map[string]string{
+	lib.SeatmapStatusAvailable:   "#4CAF50",
+	lib.SeatmapStatusUnavailable: "#9E9E9E",
+	lib.SeatmapStatusDisable:     "#EEEEEE",
+}
+
+// seatmapImageFill color seat by its status, available seat uses color of sector when it is set
+func seatmapImageFill(status, sectorColor string) string {
+	if status == lib.SeatmapStatusAvailable && sectorColor != "" {
+		return sectorColor
+	}
+	if fill, ok := seatmapImageColors[status]; ok {
+		return fill
+	}
+	return seatmapImageColors[lib.SeatmapStatusUnavailable]
+}
+
+// RenderSectorSeatmap render seat layout of venue sector without any event state
+func (s *SeatmapServiceImpl) RenderSectorSeatmap(ctx context.Context, venueID, sectorID, format string) (res dto.SeatmapImageResponse, err error) {
+	sector, err := s.findVenueSector(ctx, venueID, sectorID)
+	if err != nil {
+		return
+	}
+
+	seats, err := s.VenueSectorSeatmapRepo.FindBySectorId(ctx, nil, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("sectorId", sectorID).Msg("failed to find seatmap of sector")
+		return
+	}
+
+	img := helper.SeatmapImage{
+		Title:    sector.Name,
+		Rows:     sector.SectorRow,
+		Columns:  sector.SectorColumn,
+		SeatSize: s.Env.SeatmapImage.SeatSize,
+		Seats:    make([]helper.SeatmapImageSeat, 0, len(seats)),
+	}
+	for _, seat := range seats {
+		img.Seats = append(img.Seats, helper.SeatmapImageSeat{
+			Row:      seat.SeatRow,
+			Column:   seat.SeatColumn,
+			RowLabel: seat.SeatRowLabel,
+			Label:    seat.Label,
+			Fill:     seatmapImageFill(seat.Status, sector.SectorColor),
+		})
+	}
+
+	return s.renderSeatmapImage(img, format, true)
+}
+
+// RenderEventSeatmap render seat status of ticket category sector in the event, booked seats are unavailable
+func (s *SeatmapServiceImpl) RenderEventSeatmap(ctx context.Context, eventID, ticketCategoryID, format string) (res dto.SeatmapImageResponse, err error) {
+	ticketCategory, err := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, nil, eventID, ticketCategoryID)
+	if err != nil {
+		return
+	}
+
+	img, seats, err := s.findEventSeatmapImage(ctx, eventID, ticketCategory.VenueSectorId)
+	if err != nil {
+		return
+	}
+
+	books, err := s.EventSeatmapBookRepo.FindSeatBooksByEventSectorId(ctx, nil, eventID, ticketCategory.VenueSectorId)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", ticketCategory.VenueSectorId).Msg("failed to find seat books of sector")
+		return
+	}
+
+	for i, seat := range seats {
+		status := seat.Status
+		if _, ok := books[helper.ConvertRowColumnKey(seat.SeatRow, seat.SeatColumn)]; ok {
+			status = lib.SeatmapStatusUnavailable
+		}
+		img.Seats[i].Fill = seatmapImageFill(status, img.Seats[i].Fill)
+	}
+
+	return s.renderSeatmapImage(img, format, false)
+}
+
+// RenderTicketSeatmap render sector of the ticket with its seat highlighted, other seats are dimmed
+// so the image doesn't leak availability
+func (s *SeatmapServiceImpl) RenderTicketSeatmap(ctx context.Context, transactionID, ticketNumber, format string) (res dto.SeatmapImageResponse, err error) {
+	ticket, err := s.EventTicketRepo.FindByTransactionIdAndTicketNumber(ctx, nil, transactionID, ticketNumber)
+	if err != nil {
+		log.Warn().Err(err).Str("transactionId", transactionID).Str("ticketNumber", ticketNumber).Msg("failed to find ticket")
+		return
+	}
+	if ticket.SeatRow == 0 || ticket.SeatColumn == 0 {
+		return res, &lib.ErrorVenueSectorDoesntHaveSeatmap
+	}
+
+	ticketCategory, err := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, nil, ticket.EventID, ticket.TicketCategoryID)
+	if err != nil {
+		return
+	}
+
+	img, seats, err := s.findEventSeatmapImage(ctx, ticket.EventID, ticketCategory.VenueSectorId)
+	if err != nil {
+		return
+	}
+
+	for i, seat := range seats {
+		if seat.SeatRow == ticket.SeatRow && seat.SeatColumn == ticket.SeatColumn {
+			img.Seats[i].Highlight = true
+			continue
+		}
+		if seat.Status == lib.SeatmapStatusDisable {
+			img.Seats[i].Fill = seatmapImageColors[lib.SeatmapStatusDisable]
+			continue
+		}
+		img.Seats[i].Fill = seatmapImageDimmed
+	}
+
+	return s.renderSeatmapImage(img, format, false)
+}
+
+// findEventSeatmapImage find seats of sector with event overrides. Fill of seat is set to the sector color,
+// caller decides the final color from the returned seats which have the same order
+func (s *SeatmapServiceImpl) findEventSeatmapImage(ctx context.Context, eventID, sectorID string) (img helper.SeatmapImage, seats []entity.EventVenueSector, err error) {
+	sector, err := s.VenueSectorRepo.FindById(ctx, nil, sectorID)
+	if err != nil {
+		log.Warn().Err(err).Str("sectorId", sectorID).Msg("failed to find venue sector")
+		return
+	}
+	if !sector.HasSeatmap {
+		return img, nil, &lib.ErrorVenueSectorDoesntHaveSeatmap
+	}
+
+	seats, err = s.EventTicketCategoryRepo.FindSeatmapByEventSectorId(ctx, nil, eventID, sectorID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("sectorId", sectorID).Msg("failed to find seatmap of event sector")
+		return
+	}
+
+	img = helper.SeatmapImage{
+		Title:    sector.Name,
+		Rows:     sector.SectorRow,
+		Columns:  sector.SectorColumn,
+		SeatSize: s.Env.SeatmapImage.SeatSize,
+		Seats:    make([]helper.SeatmapImageSeat, 0, len(seats)),
+	}
+	for _, seat := range seats {
+		img.Seats = append(img.Seats, helper.SeatmapImageSeat{
+			Row:      seat.SeatRow,
+			Column:   seat.SeatColumn,
+			RowLabel: seat.SeatRowLabel,
+			Label:    seat.Label,
+			Fill:     sector.SectorColor,
+		})
+	}
+
+	return
+}
+
+// renderSeatmapImage render image in the format. Image is named by hash of its drawing, so with gcs storage
+// and store set the same drawing is rendered once and read from storage afterwards. Renders with event state
+// change on every booking, they are not stored and rely on etag caching only
+func (s *SeatmapServiceImpl) renderSeatmapImage(img helper.SeatmapImage, format string, store bool) (res dto.SeatmapImageResponse, err error) {
+	if format == "" {
+		format = seatmapImageSVG
+	}
+
+	drawing, err := json.Marshal(img)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(append(drawing, format...))
+
+	res.ETag = hex.EncodeToString(sum[:])
+	res.ContentType = "image/svg+xml"
+	if format == seatmapImagePNG {
+		res.ContentType = "image/png"
+	}
+
+	useStorage := store && s.Env.Storage.Type == lib.StorageTypeGCS
+	fileName := path.Join(seatmapImageDir, res.ETag+"."+format)
+	if useStorage {
+		reader, errRead := s.GCSStorageRepo.ReadFile(fileName)
+		if errRead == nil {
+			defer reader.Close()
+			res.Body, err = io.ReadAll(reader)
+			return
+		}
+	}
+
+	if format == seatmapImagePNG {
+		res.Body, err = helper.RenderSeatmapPNG(img)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to render seatmap png")
+			return
+		}
+	} else {
+		res.Body = helper.RenderSeatmapSVG(img)
+	}
+
+	if useStorage {
+		errWrite := s.GCSStorageRepo.WriteFile(fileName, bytes.NewBuffer(res.Body))
+		if errWrite != nil {
+			log.Warn().Err(errWrite).Str("fileName", fileName).Msg("failed to store rendered seatmap")
+		}
+	}
+
+	return
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type TransactionTransition struct {
+	TransactionID string
+	From          string
+	To            string
+	Actor         string // lib.EventTransactionActor*
+	Reason        string
+
+	PaidAt    *time.Time
+	PGOrderID string
+}
+
+// TransactionLifecycle is the only way to change event transaction status.
+// It reject transition outside lib.EventTransactionStatusTransitions and record every transition on status history
+type TransactionLifecycle interface {
+	Transition(ctx context.Context, tx pgx.Tx, req TransactionTransition) (res model.EventTransaction, err error)
+	FindHistories(ctx context.Context, transactionID string) (res []model.EventTransactionStatusHistory, err error)
+}
+
+type TransactionLifecycleImpl struct {
+	DB                                *database.WrapDB
+	Env                               *config.EnvironmentVariable
+	EventTransactionRepo              repository.EventTransactionRepository
+	EventTransactionStatusHistoryRepo repository.EventTransactionStatusHistoryRepository
+}
+
+func NewTransactionLifecycle(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventTransactionRepo repository.EventTransactionRepository,
+	eventTransactionStatusHistoryRepo repository.EventTransactionStatusHistoryRepository,
+) TransactionLifecycle {
+	return &TransactionLifecycleImpl{
+		DB:                                db,
+		Env:                               env,
+		EventTransactionRepo:              eventTransactionRepo,
+		EventTransactionStatusHistoryRepo: eventTransactionStatusHistoryRepo,
+	}
+}
+
+// Transition must be called inside tx, so status and its history are committed together
+func (l *TransactionLifecycleImpl) Transition(ctx context.Context, tx pgx.Tx, req TransactionTransition) (res model.EventTransaction, err error) {
+	err = lib.ValidateEventTransactionStatusTransition(req.From, req.To)
+	if err != nil {
+		log.Warn().Str("transactionId", req.TransactionID).Str("from", req.From).Str("to", req.To).Str("actor", req.Actor).Msg("illegal transaction status transition")
+		return
+	}
+
+	res, err = l.EventTransactionRepo.UpdateStatus(ctx, tx, req.TransactionID, req.From, req.To, req.PaidAt, req.PGOrderID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", req.TransactionID).Str("from", req.From).Str("to", req.To).Msg("failed to update transaction status")
+		return
+	}
+
+	_, err = l.EventTransactionStatusHistoryRepo.Create(ctx, tx, model.EventTransactionStatusHistory{
+		TransactionID: req.TransactionID,
+		FromStatus:    req.From,
+		ToStatus:      req.To,
+		Actor:         req.Actor,
+		Reason:        helper.ToSQLString(req.Reason),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", req.TransactionID).Msg("failed to record transaction status history")
+		return
+	}
+
+	log.Info().Str("transactionId", req.TransactionID).Str("from", req.From).Str("to", req.To).Str("actor", req.Actor).Msg("transaction status changed")
+	return
+}
+
+func (l *TransactionLifecycleImpl) FindHistories(ctx context.Context, transactionID string) (res []model.EventTransactionStatusHistory, err error) {
+	return l.EventTransactionStatusHistoryRepo.FindByTransactionId(ctx, nil, transactionID)
+}
+
+// FindStatusHistories return status timeline of transaction, ordered from the oldest
+func (s *EventTransactionServiceImpl) FindStatusHistories(ctx context.Context, transactionID string) (res []dto.EventTransactionStatusHistoryResponse, err error) {
+	histories, err := s.TransactionLifecycle.FindHistories(ctx, transactionID)
+	if err != nil {
+		log.Error().Err(err).Str("transactionId", transactionID).Msg("failed to find transaction status histories")
+		return
+	}
+
+	res = make([]dto.EventTransactionStatusHistoryResponse, 0, len(histories))
+	for _, history := range histories {
+		res = append(res, dto.EventTransactionStatusHistoryResponse{
+			FromStatus: history.FromStatus,
+			ToStatus:   history.ToStatus,
+			Actor:      history.Actor,
+			Reason:     history.Reason.String,
+			CreatedAt:  history.CreatedAt,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"assist-tix/model"
+	"assist-tix/repository"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type VoucherService interface {
+	Create(ctx context.Context, eventID string, req dto.CreateVoucherRequest) (res dto.VoucherResponse, err error)
+	FindByEventId(ctx context.Context, eventID string) (res []dto.VoucherResponse, err error)
+	Delete(ctx context.Context, eventID, voucherID string) (err error)
+	Preview(ctx context.Context, eventID string, req dto.PreviewVoucherRequest) (res dto.PreviewVoucherResponse, err error)
+}
+
+type VoucherServiceImpl struct {
+	DB                      *database.WrapDB
+	Env                     *config.EnvironmentVariable
+	EventRepo               repository.EventRepository
+	EventTicketCategoryRepo repository.EventTicketCategoryRepository
+	VoucherRepo             repository.VoucherRepository
+	PriceTierRepo           repository.EventTicketCategoryPriceTierRepository
+}
+
+func NewVoucherService(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventRepo repository.EventRepository,
+	eventTicketCategoryRepo repository.EventTicketCategoryRepository,
+	voucherRepo repository.VoucherRepository,
+	priceTierRepo repository.EventTicketCategoryPriceTierRepository,
+) VoucherService {
+	return &VoucherServiceImpl{
+		DB:                      db,
+		Env:                     env,
+		EventRepo:               eventRepo,
+		EventTicketCategoryRepo: eventTicketCategoryRepo,
+		VoucherRepo:             voucherRepo,
+		PriceTierRepo:           priceTierRepo,
+	}
+}
+
+func (s *VoucherServiceImpl) Create(ctx context.Context, eventID string, req dto.CreateVoucherRequest) (res dto.VoucherResponse, err error) {
+	_, err = s.EventRepo.FindById(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event")
+		return
+	}
+
+	if req.TicketCategoryID != "" {
+		_, err = s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, nil, eventID, req.TicketCategoryID)
+		if err != nil {
+			log.Error().Err(err).Str("ticketCategoryId", req.TicketCategoryID).Msg("failed to find ticket category of voucher")
+			return
+		}
+	}
+
+	if !req.EndAt.After(req.StartAt) || (req.DiscountType == lib.VoucherDiscountTypePercentage && req.DiscountValue > 100) {
+		return res, &lib.ErrorVoucherInvalid
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	voucher, err := s.VoucherRepo.Create(ctx, nil, model.Voucher{
+		EventID:               eventID,
+		TicketCategoryID:      helper.ToSQLString(req.TicketCategoryID),
+		Code:                  strings.ToUpper(req.Code),
+		DiscountType:          req.DiscountType,
+		DiscountValue:         req.DiscountValue,
+		MaxDiscount:           toNullInt32(req.MaxDiscount),
+		UsageLimit:            toNullInt32(req.UsageLimit),
+		UsageLimitPerEmail:    toNullInt32(req.UsageLimitPerEmail),
+		UsageLimitPerGarudaID: toNullInt32(req.UsageLimitPerGarudaID),
+		IsActive:              isActive,
+		StartAt:               req.StartAt,
+		EndAt:                 req.EndAt,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Str("code", req.Code).Msg("failed to create voucher")
+		return
+	}
+
+	log.Info().Str("voucherId", voucher.ID).Str("code", voucher.Code).Msg("voucher created")
+	return toVoucherResponse(voucher), nil
+}
+
+func (s *VoucherServiceImpl) FindByEventId(ctx context.Context, eventID string) (res []dto.VoucherResponse, err error) {
+	vouchers, err := s.VoucherRepo.FindByEventId(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find vouchers")
+		return
+	}
+
+	res = make([]dto.VoucherResponse, 0, len(vouchers))
+	for _, voucher := range vouchers {
+		res = append(res, toVoucherResponse(voucher))
+	}
+
+	return
+}
+
+// Delete stop the voucher from being used, transactions which already use it keep their discount
+func (s *VoucherServiceImpl) Delete(ctx context.Context, eventID, voucherID string) (err error) {
+	err = s.VoucherRepo.SoftDelete(ctx, nil, eventID, voucherID)
+	if err != nil {
+		log.Error().Err(err).Str("voucherId", voucherID).Msg("failed to delete voucher")
+		return
+	}
+
+	return
+}
+
+// Preview calculate the discount of voucher on the items without using it, the order may still be rejected
+// when the voucher reach its limit before the order is created
+func (s *VoucherServiceImpl) Preview(ctx context.Context, eventID string, req dto.PreviewVoucherRequest) (res dto.PreviewVoucherResponse, err error) {
+	voucher, err := s.VoucherRepo.FindByEventIdAndCode(ctx, nil, eventID, req.Code)
+	if err != nil {
+		return
+	}
+
+	err = validateVoucherAvailability(voucher, time.Now())
+	if err != nil {
+		return
+	}
+
+	usage, err := s.VoucherRepo.CountUsages(ctx, nil, voucher.ID, req.Email, req.GarudaIDs)
+	if err != nil {
+		log.Error().Err(err).Str("voucherId", voucher.ID).Msg("failed to count voucher usages")
+		return
+	}
+
+	err = validateVoucherUsage(voucher, usage, req.Email != "", len(req.GarudaIDs) > 0)
+	if err != nil {
+		return
+	}
+
+	priceTiers, err := s.PriceTierRepo.FindByEventId(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find price tiers")
+		return
+	}
+
+	now := time.Now()
+	lines := make([]voucherLine, 0, len(req.Items))
+	for _, item := range req.Items {
+		ticketCategory, errCategory := s.EventTicketCategoryRepo.FindByIdAndEventId(ctx, nil, eventID, item.TicketCategoryID)
+		if errCategory != nil {
+			return res, errCategory
+		}
+
+		price := ticketCategory.Price
+		if tier, found := resolvePriceTier(priceTiers[ticketCategory.ID], now, item.Quantity); found {
+			price = tier.Price
+		}
+
+		lines = append(lines, voucherLine{
+			TicketCategoryID: item.TicketCategoryID,
+			TotalPrice:       price * item.Quantity,
+		})
+	}
+
+	discounts, err := calculateVoucherDiscounts(voucher, lines)
+	if err != nil {
+		return
+	}
+
+	res = dto.PreviewVoucherResponse{
+		Voucher: toVoucherResponse(voucher),
+		Lines:   make([]dto.PreviewVoucherLineResponse, 0, len(lines)),
+	}
+	for i, line := range lines {
+		res.TotalPrice += line.TotalPrice
+		res.DiscountAmount += discounts[i]
+		res.Lines = append(res.Lines, dto.PreviewVoucherLineResponse{
+			TicketCategoryID: line.TicketCategoryID,
+			Quantity:         req.Items[i].Quantity,
+			TotalPrice:       line.TotalPrice,
+			DiscountAmount:   discounts[i],
+		})
+	}
+	res.TotalAfterDiscount = res.TotalPrice - res.DiscountAmount
+
+	return
+}
+
+// voucherLine is ticket price of one category in an order
+type voucherLine struct {
+	TicketCategoryID string
+	TotalPrice       int
+}
+
+// voucherRedemption is voucher applied to an order, its usage is written once the transaction is created
+type voucherRedemption struct {
+	Voucher   model.Voucher
+	Discounts []int // discount of each line
+	Total     int
+}
+
+// redeemVoucher lock the voucher and check it against the order, must be called inside the order tx
+func (s *EventTransactionServiceImpl) redeemVoucher(ctx context.Context, tx pgx.Tx, eventID, code, email string, garudaIDs []string, lines []voucherLine) (redemption voucherRedemption, err error) {
+	voucher, err := s.VoucherRepo.FindByEventIdAndCodeForUpdate(ctx, tx, eventID, code)
+	if err != nil {
+		log.Error().Err(err).Str("code", code).Msg("failed to find voucher")
+		return
+	}
+
+	err = validateVoucherAvailability(voucher, time.Now())
+	if err != nil {
+		return
+	}
+
+	usage, err := s.VoucherRepo.CountUsages(ctx, tx, voucher.ID, email, garudaIDs)
+	if err != nil {
+		log.Error().Err(err).Str("voucherId", voucher.ID).Msg("failed to count voucher usages")
+		return
+	}
+
+	err = validateVoucherUsage(voucher, usage, true, len(garudaIDs) > 0)
+	if err != nil {
+		log.Warn().Err(err).Str("voucherId", voucher.ID).Str("email", email).Msg("voucher usage limit is reached")
+		return
+	}
+
+	discounts, err := calculateVoucherDiscounts(voucher, lines)
+	if err != nil {
+		return
+	}
+
+	redemption = voucherRedemption{Voucher: voucher, Discounts: discounts}
+	for _, discount := range discounts {
+		redemption.Total += discount
+	}
+
+	log.Info().Str("voucherId", voucher.ID).Str("code", voucher.Code).Int("discount", redemption.Total).Msg("voucher applied")
+	return
+}
+
+// discountedItemPrices split discount of a line evenly to its items, remainder goes to the first items,
+// so sum of item prices is the line price after discount and refund of an item pays back what was paid for it
+func discountedItemPrices(price, count, discount int) (prices []int) {
+	prices = make([]int, count)
+	if count == 0 {
+		return
+	}
+
+	share, remainder := discount/count, discount%count
+	for i := range prices {
+		prices[i] = price - share
+		if i < remainder {
+			prices[i]--
+		}
+	}
+
+	return
+}
+
+func (s *EventTransactionServiceImpl) createVoucherUsage(ctx context.Context, tx pgx.Tx, redemption voucherRedemption, transaction model.EventTransaction, garudaIDs []string) (err error) {
+	err = s.VoucherRepo.CreateUsage(ctx, tx, model.VoucherUsage{
+		VoucherID:      redemption.Voucher.ID,
+		TransactionID:  transaction.ID,
+		Email:          transaction.Email,
+		GarudaIDs:      garudaIDs,
+		DiscountAmount: redemption.Total,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("voucherId", redemption.Voucher.ID).Str("transactionId", transaction.ID).Msg("failed to create voucher usage")
+		return
+	}
+
+	return
+}
+
+func validateVoucherAvailability(voucher model.Voucher, now time.Time) (err error) {
+	if !voucher.IsActive || now.Before(voucher.StartAt) || now.After(voucher.EndAt) {
+		return &lib.ErrorVoucherInactive
+	}
+
+	return
+}
+
+// validateVoucherUsage check usage before the order is counted, per email and per garuda id limit only apply when they're known
+func validateVoucherUsage(voucher model.Voucher, usage model.VoucherUsageCount, checkEmail, checkGarudaID bool) (err error) {
+	if voucher.UsageLimit.Valid && usage.Total >= int(voucher.UsageLimit.Int32) {
+		return &lib.ErrorVoucherUsageLimitReached
+	}
+
+	if checkEmail && voucher.UsageLimitPerEmail.Valid && usage.Email >= int(voucher.UsageLimitPerEmail.Int32) {
+		return &lib.ErrorVoucherEmailLimitReached
+	}
+
+	if checkGarudaID && voucher.UsageLimitPerGarudaID.Valid && usage.GarudaID >= int(voucher.UsageLimitPerGarudaID.Int32) {
+		return &lib.ErrorVoucherGarudaIDLimitReached
+	}
+
+	return
+}
+
+// calculateVoucherDiscounts return discount of each line. Discount is counted on lines of the voucher category,
+// then split by their price so percentage fee of each line is counted after its own discount
+func calculateVoucherDiscounts(voucher model.Voucher, lines []voucherLine) (discounts []int, err error) {
+	discounts = make([]int, len(lines))
+
+	eligibleTotal := 0
+	lastEligible := -1
+	for i, line := range lines {
+		if voucher.TicketCategoryID.Valid && voucher.TicketCategoryID.String != line.TicketCategoryID {
+			continue
+		}
+		eligibleTotal += line.TotalPrice
+		lastEligible = i
+	}
+
+	if lastEligible < 0 || eligibleTotal <= 0 {
+		return nil, &lib.ErrorVoucherNotApplicable
+	}
+
+	var total int
+	switch voucher.DiscountType {
+	case lib.VoucherDiscountTypePercentage:
+		total = eligibleTotal * voucher.DiscountValue / 100
+		if voucher.MaxDiscount.Valid && total > int(voucher.MaxDiscount.Int32) {
+			total = int(voucher.MaxDiscount.Int32)
+		}
+	default:
+		total = min(voucher.DiscountValue, eligibleTotal)
+	}
+
+	remaining := total
+	for i, line := range lines {
+		if voucher.TicketCategoryID.Valid && voucher.TicketCategoryID.String != line.TicketCategoryID {
+			continue
+		}
+
+		if i == lastEligible {
+			discounts[i] = remaining
+			break
+		}
+
+		discounts[i] = total * line.TotalPrice / eligibleTotal
+		remaining -= discounts[i]
+	}
+
+	return
+}
+
+func toVoucherResponse(voucher model.Voucher) dto.VoucherResponse {
+	res := dto.VoucherResponse{
+		ID:               voucher.ID,
+		EventID:          voucher.EventID,
+		TicketCategoryID: voucher.TicketCategoryID.String,
+		Code:             voucher.Code,
+		DiscountType:     voucher.DiscountType,
+		DiscountValue:    voucher.DiscountValue,
+		IsActive:         voucher.IsActive,
+		StartAt:          voucher.StartAt,
+		EndAt:            voucher.EndAt,
+		CreatedAt:        voucher.CreatedAt,
+	}
+	if voucher.MaxDiscount.Valid {
+		res.MaxDiscount = intPtr(int(voucher.MaxDiscount.Int32))
+	}
+	if voucher.UsageLimit.Valid {
+		res.UsageLimit = intPtr(int(voucher.UsageLimit.Int32))
+	}
+	if voucher.UsageLimitPerEmail.Valid {
+		res.UsageLimitPerEmail = intPtr(int(voucher.UsageLimitPerEmail.Int32))
+	}
+	if voucher.UsageLimitPerGarudaID.Valid {
+		res.UsageLimitPerGarudaID = intPtr(int(voucher.UsageLimitPerGarudaID.Int32))
+	}
+
+	return res
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func toNullInt32(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
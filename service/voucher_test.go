@@ -0,0 +1,129 @@
+package service
+
+import (
+	"assist-tix/lib"
+	"assist-tix/model"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCalculateVoucherDiscounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		voucher model.Voucher
+		lines   []voucherLine
+		want    []int
+		wantErr error
+	}{
+		{
+			name:    "fixed discount on single line",
+			voucher: model.Voucher{DiscountType: lib.VoucherDiscountTypeFixed, DiscountValue: 20000},
+			lines:   []voucherLine{{TicketCategoryID: "a", TotalPrice: 100000}},
+			want:    []int{20000},
+		},
+		{
+			name:    "fixed discount is capped at eligible total",
+			voucher: model.Voucher{DiscountType: lib.VoucherDiscountTypeFixed, DiscountValue: 150000},
+			lines:   []voucherLine{{TicketCategoryID: "a", TotalPrice: 100000}},
+			want:    []int{100000},
+		},
+		{
+			name: "percentage discount is capped at max discount and split by line price",
+			voucher: model.Voucher{
+				DiscountType:  lib.VoucherDiscountTypePercentage,
+				DiscountValue: 10,
+				MaxDiscount:   sql.NullInt32{Int32: 25000, Valid: true},
+			},
+			lines: []voucherLine{{TicketCategoryID: "a", TotalPrice: 100000}, {TicketCategoryID: "b", TotalPrice: 200000}},
+			want:  []int{8333, 16667},
+		},
+		{
+			name: "category voucher only discounts lines of its category",
+			voucher: model.Voucher{
+				TicketCategoryID: sql.NullString{String: "b", Valid: true},
+				DiscountType:     lib.VoucherDiscountTypeFixed,
+				DiscountValue:    40000,
+			},
+			lines: []voucherLine{{TicketCategoryID: "a", TotalPrice: 100000}, {TicketCategoryID: "b", TotalPrice: 50000}, {TicketCategoryID: "b", TotalPrice: 150000}},
+			want:  []int{0, 10000, 30000},
+		},
+		{
+			name: "no line of voucher category",
+			voucher: model.Voucher{
+				TicketCategoryID: sql.NullString{String: "c", Valid: true},
+				DiscountType:     lib.VoucherDiscountTypeFixed,
+				DiscountValue:    40000,
+			},
+			lines:   []voucherLine{{TicketCategoryID: "a", TotalPrice: 100000}},
+			wantErr: &lib.ErrorVoucherNotApplicable,
+		},
+		{
+			name:    "free lines",
+			voucher: model.Voucher{DiscountType: lib.VoucherDiscountTypePercentage, DiscountValue: 50},
+			lines:   []voucherLine{{TicketCategoryID: "a", TotalPrice: 0}},
+			wantErr: &lib.ErrorVoucherNotApplicable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateVoucherDiscounts(tt.voucher, tt.lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("calculateVoucherDiscounts() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calculateVoucherDiscounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscountedItemPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    int
+		count    int
+		discount int
+		want     []int
+	}{
+		{
+			name:     "discount split evenly",
+			price:    50000,
+			count:    2,
+			discount: 10000,
+			want:     []int{45000, 45000},
+		},
+		{
+			name:     "remainder goes to the first items",
+			price:    100,
+			count:    4,
+			discount: 6,
+			want:     []int{98, 98, 99, 99},
+		},
+		{
+			name:     "no discount",
+			price:    50000,
+			count:    3,
+			discount: 0,
+			want:     []int{50000, 50000, 50000},
+		},
+		{
+			name:     "no item",
+			price:    50000,
+			count:    0,
+			discount: 10000,
+			want:     []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := discountedItemPrices(tt.price, tt.count, tt.discount)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discountedItemPrices() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
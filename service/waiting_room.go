@@ -0,0 +1,135 @@
+package service
+
+import (
+	"assist-tix/config"
+	"assist-tix/database"
+	"assist-tix/dto"
+	"assist-tix/helper"
+	"assist-tix/lib"
+	"assist-tix/repository"
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+type WaitingRoomService interface {
+	Join(ctx context.Context, eventID string) (res dto.WaitingRoomResponse, err error)
+	Status(ctx context.Context, eventID, queueToken string) (res dto.WaitingRoomResponse, err error)
+}
+
+type WaitingRoomServiceImpl struct {
+	DB               *database.WrapDB
+	Env              *config.EnvironmentVariable
+	EventRepo        repository.EventRepository
+	EventSettingRepo repository.EventSettingsRepository
+	WaitingRoomRepo  repository.WaitingRoomRepository
+}
+
+func NewWaitingRoomService(
+	db *database.WrapDB,
+	env *config.EnvironmentVariable,
+	eventRepo repository.EventRepository,
+	eventSettingRepo repository.EventSettingsRepository,
+	waitingRoomRepo repository.WaitingRoomRepository,
+) WaitingRoomService {
+	return &WaitingRoomServiceImpl{
+		DB:               db,
+		Env:              env,
+		EventRepo:        eventRepo,
+		EventSettingRepo: eventSettingRepo,
+		WaitingRoomRepo:  waitingRoomRepo,
+	}
+}
+
+// admissionPerMinute return admission rate of event, fails when waiting room isn't turned on
+func (s *WaitingRoomServiceImpl) admissionPerMinute(ctx context.Context, eventID string) (res int, err error) {
+	_, err = s.EventRepo.FindById(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event")
+		return
+	}
+
+	settings, err := s.EventSettingRepo.FindByEventId(ctx, nil, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to find event settings")
+		return
+	}
+
+	eventSettings := lib.MapEventSettings(settings)
+	if !eventSettings.WaitingRoomActive {
+		return 0, &lib.ErrorWaitingRoomNotActive
+	}
+
+	res = eventSettings.WaitingRoomAdmissionPerMinute
+	if res <= 0 {
+		res = lib.WaitingRoomDefaultAdmissionPerMinute
+	}
+
+	return
+}
+
+// Join put buyer at the end of the queue of event
+func (s *WaitingRoomServiceImpl) Join(ctx context.Context, eventID string) (res dto.WaitingRoomResponse, err error) {
+	perMinute, err := s.admissionPerMinute(ctx, eventID)
+	if err != nil {
+		return
+	}
+
+	number, err := s.WaitingRoomRepo.Join(ctx, eventID)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to join waiting room")
+		return res, &lib.ErrorWaitingRoomStore
+	}
+
+	queueToken, _, err := helper.GenerateWaitingRoomToken(s.Env, lib.WaitingRoomTokenTypeQueue, eventID, number, s.Env.WaitingRoom.QueueTokenTTL)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to generate queue token")
+		return
+	}
+
+	log.Info().Str("eventId", eventID).Int64("number", number).Msg("joined waiting room")
+	return s.status(ctx, eventID, queueToken, number, perMinute)
+}
+
+// Status give position of queue token, admission token is issued once its number is admitted
+func (s *WaitingRoomServiceImpl) Status(ctx context.Context, eventID, queueToken string) (res dto.WaitingRoomResponse, err error) {
+	claims, err := helper.VerifyWaitingRoomToken(s.Env, lib.WaitingRoomTokenTypeQueue, eventID, queueToken)
+	if err != nil {
+		log.Warn().Err(err).Str("eventId", eventID).Msg("invalid queue token")
+		return res, &lib.ErrorWaitingRoomTokenInvalid
+	}
+
+	perMinute, err := s.admissionPerMinute(ctx, eventID)
+	if err != nil {
+		return
+	}
+
+	return s.status(ctx, eventID, queueToken, claims.Number, perMinute)
+}
+
+func (s *WaitingRoomServiceImpl) status(ctx context.Context, eventID, queueToken string, number int64, perMinute int) (res dto.WaitingRoomResponse, err error) {
+	admitted, err := s.WaitingRoomRepo.Advance(ctx, eventID, perMinute)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to advance waiting room")
+		return res, &lib.ErrorWaitingRoomStore
+	}
+
+	res.QueueToken = queueToken
+	if number > admitted {
+		res.Status = lib.WaitingRoomStatusWaiting
+		res.Position = number - admitted
+		res.EstimatedWaitSeconds = (res.Position*60 + int64(perMinute) - 1) / int64(perMinute)
+		return
+	}
+
+	admissionToken, expiresAt, err := helper.GenerateWaitingRoomToken(s.Env, lib.WaitingRoomTokenTypeAdmission, eventID, number, s.Env.WaitingRoom.AdmissionTTL)
+	if err != nil {
+		log.Error().Err(err).Str("eventId", eventID).Msg("failed to generate admission token")
+		return
+	}
+
+	res.Status = lib.WaitingRoomStatusAdmitted
+	res.AdmissionToken = admissionToken
+	res.ExpiresAt = &expiresAt
+	return
+}